@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"podgen/internal/app/podgen"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/pkg/linediff"
+	"podgen/internal/pkg/progress"
+	"podgen/internal/storage"
+	"podgen/internal/storage/factory"
+)
+
+// dryRun holds the throwaway state of a --dry-run.
+// Operations run against a copy of the database and record object storage writes instead of performing them.
+type dryRun struct {
+	dir         string
+	store       storage.Store
+	recorders   []destinationRecorder
+	storagePath string
+	started     time.Time
+}
+
+// destinationRecorder records the writes to a destination, primary or a mirror.
+type destinationRecorder struct {
+	name     string
+	recorder *proc.RecordingStorage
+}
+
+// plannedAction is a recorded write and the destination it goes to.
+type plannedAction struct {
+	proc.ObjectAction
+	destination string
+}
+
+// newDryRun copies the database into a temporary directory. Object storage is wrapped in recorders with record.
+func newDryRun(storageType string, src storage.Store, storagePath string) (*dryRun, error) {
+	dir, err := os.MkdirTemp("", "podgen-dry-run-")
+	if err != nil {
+		return nil, fmt.Errorf("can't create temporary directory: %w", err)
+	}
+
+	store, err := factory.NewFromStrings(storageType, filepath.Join(dir, "podgen.db"))
+	if err == nil {
+		err = store.Open()
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("can't create database copy: %w", err)
+	}
+
+	d := &dryRun{
+		dir:         dir,
+		store:       store,
+		storagePath: storagePath,
+	}
+	if _, err := storage.Migrate(src, store); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("can't copy database: %w", err)
+	}
+	d.started = time.Now()
+	return d, nil
+}
+
+// record returns a recorder of the writes to the named destination, reading objects from objects.
+func (d *dryRun) record(name string, objects proc.ObjectStorage, baseURL string) proc.ObjectStorage {
+	r := &proc.RecordingStorage{Source: objects, BaseURL: baseURL}
+	d.recorders = append(d.recorders, destinationRecorder{name: name, recorder: r})
+	return r
+}
+
+// Close removes the database copy and generated files.
+func (d *dryRun) Close() {
+	if d == nil {
+		return
+	}
+	_ = d.store.Close()
+	_ = os.RemoveAll(d.dir)
+}
+
+// printPlan prints what the run would have changed: new episodes, object storage writes and feed changes.
+func (d *dryRun) printPlan(ctx context.Context, app *podgen.App, podcasts string) error {
+	fmt.Println("\nDry run, nothing was written to object storage or the database.")
+
+	history, err := app.EpisodeHistory(ctx, podcasts, storage.HistoryFilter{Since: d.started})
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(history))
+	for id := range history {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Println("\nNew episodes:")
+	found := 0
+	for _, id := range ids {
+		for _, e := range history[id] {
+			if e.Operation == storage.OperationScan {
+				fmt.Printf("  %s/%s\n", id, e.Filename)
+				found++
+			}
+		}
+	}
+	if found == 0 {
+		fmt.Println("  none")
+	}
+
+	// order actions as the run performs them: deletes, episodes, images, then feeds
+	rank := map[proc.ObjectKind]int{proc.KindEpisode: 0, proc.KindImage: 1, proc.KindFeed: 2}
+	var actions []plannedAction
+	for _, r := range d.recorders {
+		for _, a := range r.recorder.Actions() {
+			actions = append(actions, plannedAction{ObjectAction: a, destination: r.name})
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool {
+		a, b := actions[i], actions[j]
+		if a.Op != b.Op {
+			return a.Op == proc.ObjectDelete
+		}
+		if a.Kind != b.Kind {
+			return rank[a.Kind] < rank[b.Kind]
+		}
+		if a.Object != b.Object {
+			return a.Object < b.Object
+		}
+		return a.destination < b.destination
+	})
+
+	fmt.Println("\nObject storage:")
+	if len(actions) == 0 {
+		fmt.Println("  no changes")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	var uploadBytes, deleteBytes int64
+	var uploads, deletes int
+	for _, a := range actions {
+		size := "?"
+		if a.Size >= 0 {
+			size = progress.FormatBytes(a.Size)
+		}
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", a.Op, a.Kind, a.destination, a.Object, size)
+		switch a.Op {
+		case proc.ObjectUpload:
+			uploads++
+			uploadBytes += a.Size
+		case proc.ObjectDelete:
+			deletes++
+			deleteBytes += max(a.Size, 0)
+		}
+	}
+	_ = w.Flush()
+	if len(actions) > 0 {
+		fmt.Printf("  total: %d uploads (%s), %d deletes (%s)\n",
+			uploads, progress.FormatBytes(uploadBytes), deletes, progress.FormatBytes(deleteBytes))
+	}
+
+	// a feed mirrored to several destinations is the same file, its diff is printed once
+	diffed := map[string]bool{}
+	for _, a := range actions {
+		if a.Op != proc.ObjectUpload || a.Kind != proc.KindFeed || diffed[a.Object] {
+			continue
+		}
+		diffed[a.Object] = true
+		if err := d.printFeedDiff(a.ObjectAction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printFeedDiff prints changes between the feed last generated in the storage folder and the planned one.
+func (d *dryRun) printFeedDiff(a proc.ObjectAction) error {
+	planned, err := os.ReadFile(a.Path)
+	if err != nil {
+		return fmt.Errorf("can't read planned feed: %w", err)
+	}
+	current, err := os.ReadFile(filepath.Join(d.storagePath, a.Object)) //nolint:gosec // object names are built from config
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("can't read current feed: %w", err)
+	}
+
+	fmt.Printf("\nFeed %s:\n", a.Object)
+	diff := linediff.Unified(string(current), string(planned), 3)
+	if diff == "" {
+		fmt.Println("  no changes")
+		return nil
+	}
+	fmt.Printf("--- current\n+++ planned\n%s", diff)
+	return nil
+}
@@ -3,21 +3,31 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 	"unicode"
 
 	"github.com/jessevdk/go-flags"
 	"podgen/internal/app/podgen"
 	"podgen/internal/app/podgen/artwork"
+	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/scheduler"
+	"podgen/internal/app/podgen/server"
 	"podgen/internal/configs"
+	"podgen/internal/pkg/lockfile"
 	"podgen/internal/pkg/progress"
+	"podgen/internal/pkg/ratelimit"
 	"podgen/internal/storage"
 	"podgen/internal/storage/factory"
 )
@@ -31,8 +41,8 @@ var opts struct {
 	UpdateImage       bool   `short:"i" long:"image" description:"re upload cover of podcasts"`
 	Podcasts          string `short:"p" long:"podcast" description:"Podcasts name (separator quota)"`
 	AllPodcasts       bool   `short:"a" long:"all" description:"All podcasts"`
-	Rollback          bool   `short:"r" long:"rollback" description:"Rollback last episode"`
-	RollbackBySession string `long:"rollback-session" description:"Rollback by session name"`
+	Rollback          bool   `short:"r" long:"rollback" description:"Rollback last episode and its session"`
+	RollbackBySession string `long:"rollback-session" description:"Rollback by session: ID, last, last-N or date (YYYY-MM-DD)"`
 	ShowRSS           bool   `long:"rss" description:"Show RSS feed URL for podcasts"`
 	MigrateFrom       string `long:"migrate-from" description:"Migrate data from another database (format: type:path, e.g., bolt:/path/to/db)"`
 	AddPodcast        string `long:"add-podcast" description:"Add new podcast from folder name"`
@@ -41,11 +51,45 @@ var opts struct {
 	ForceDelete       bool   `long:"clear" description:"Force delete old episodes before upload (ignores delete_old_episodes setting)"`
 	GenerateArtwork   bool   `short:"g" long:"generate-artwork" description:"Force (re)generate podcast artwork"`
 	ArtworkStyle      string `long:"artwork-style" description:"Artwork style: solid, gradient, gradient-diagonal, radial, circles, blobs, noise, letter, aurora (default: aurora)"`
+	List              bool   `short:"l" long:"list" description:"List episodes of podcasts"`
+	ListStatus        string `long:"status" description:"Filter listed episodes by status: new, uploaded, deleted (separator comma)"`
+	ListSearch        string `long:"search" description:"Filter listed episodes by text in title or artist"`
+	ListSince         string `long:"since" description:"List episodes published or history recorded on or after date (YYYY-MM-DD)"`
+	ListUntil         string `long:"until" description:"List episodes published before date (YYYY-MM-DD)"`
+	ListSession       string `long:"session" description:"List episodes of upload session"`
+	ListMinSize       int64  `long:"min-size" description:"List episodes of at least this size in bytes"`
+	ListMaxSize       int64  `long:"max-size" description:"List episodes of at most this size in bytes"`
+	ListSort          string `long:"sort" description:"Sort listed episodes by filename, date, size or title; prefix with - for descending order"`
+	ListLimit         int    `long:"limit" description:"Maximum number of listed episodes, latest history entries per podcast, sessions or runs"`
+	ListOffset        int    `long:"offset" description:"Skip this many listed episodes per podcast"`
+	History           bool   `long:"history" description:"Show state history of episodes"`
+	HistoryEpisode    string `long:"episode" description:"Episode filename (used with --history)"`
+	Sessions          bool   `long:"sessions" description:"List upload sessions (use --limit to show only the latest)"`
+	ShowSession       string `long:"show-session" description:"Show upload session: ID, last, last-N or date (YYYY-MM-DD)"`
+	Reconcile         bool   `long:"reconcile" description:"Compare object storage with the database and podcast folders and report mismatches"`
+	Fix               bool   `long:"fix" description:"Fix mismatches found by --reconcile: upload again, delete orphan objects, correct episode status"`
+	DryRun            bool   `long:"dry-run" description:"Print what scan, upload, delete, image and feed steps would change without writing to object storage or the database"`
+	MaxBandwidth      string `long:"max-bandwidth" description:"Limit combined upload bandwidth of all workers, e.g. 2MB or 10Mbit, 0 for no limit (overrides config)"`
+	MarkDown          string `long:"mark-down" description:"Mark a publishing destination down, feeds link to mirrors until it is marked up (primary or mirror name)"`
+	MarkUp            string `long:"mark-up" description:"Mark a publishing destination up again (primary or mirror name)"`
+	RewriteLocations  bool   `long:"rewrite-locations" description:"Rewrite stored episode and image URLs after public_base_url or url_style changed, then regenerate feeds"`
+	Subscribers       bool   `long:"subscribers" description:"List subscribers of a private podcast with their feed URLs (used with -p)"`
+	AddSubscriber     string `long:"add-subscriber" description:"Add a subscriber to a private podcast, publish and print their feed URL (used with -p)"`
+	RotateSubscriber  string `long:"rotate-subscriber" description:"Replace the token of a subscriber, the old feed URL stops working, and print the new one (used with -p)"`
+	RevokeSubscriber  string `long:"revoke-subscriber" description:"Revoke a subscriber of a private podcast and delete their feed (used with -p)"`
+	Serve             bool   `long:"serve" description:"Serve feeds, artwork and episodes of podcasts over HTTP from the storage folder, and the API if configured"`
+	Listen            string `long:"listen" description:"Address the server listens at (used with --serve, overrides config)"`
+	BaseURL           string `long:"base-url" description:"URL feeds link episodes to, the address clients reach the server at (used with --serve, overrides config)"`
+	Daemon            bool   `long:"daemon" description:"Run scheduled scans and publishes of podcasts until interrupted"`
+	Runs              bool   `long:"runs" description:"List runs of scheduled jobs (filter with -p, use --limit to show only the latest)"`
 	// Dbg bool `long:"dbg" env:"DEBUG" description:"show debug info"`
 }
 
 var version string
 
+// daemonLockFile is the lock file of the daemon in the storage folder.
+const daemonLockFile = ".podgen.lock"
+
 // isTerminal returns true if the given file is a terminal device.
 func isTerminal(f *os.File) bool {
 	fi, err := f.Stat()
@@ -104,13 +148,89 @@ func main() {
 		return
 	}
 
-	app, store := setupApplication(conf)
+	app, store, plan := setupApplication(conf)
 	defer func() { _ = store.Close() }()
+	defer plan.Close()
+
+	if opts.MarkDown != "" || opts.MarkUp != "" {
+		if err := runMarkDestination(conf, store); err != nil {
+			log.Printf("[ERROR] %v", err)
+			plan.Close()
+			_ = store.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Session commands don't operate on podcasts, so they don't need -p or -a
+	if opts.Sessions || opts.ShowSession != "" {
+		if err := runSessions(ctx, app); err != nil {
+			log.Printf("[ERROR] %v", err)
+			plan.Close()
+			_ = store.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Runs {
+		if err := runRuns(ctx, app); err != nil {
+			log.Printf("[ERROR] %v", err)
+			plan.Close()
+			_ = store.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Daemon {
+		if err := runDaemon(ctx, conf, app); err != nil {
+			log.Printf("[ERROR] %v", err)
+			plan.Close()
+			_ = store.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Serve {
+		if err := runServe(ctx, conf, app); err != nil {
+			log.Printf("[ERROR] %v", err)
+			plan.Close()
+			_ = store.Close()
+			os.Exit(1)
+		}
+		return
+	}
 
-	podcasts := resolvePodcasts(app)
+	if opts.Subscribers || opts.AddSubscriber != "" || opts.RotateSubscriber != "" || opts.RevokeSubscriber != "" {
+		if err := runSubscribers(ctx, app); err != nil {
+			log.Printf("[ERROR] %v", err)
+			plan.Close()
+			_ = store.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	podcasts, err := resolvePodcasts(app)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		plan.Close()
+		_ = store.Close()
+		os.Exit(1)
+	}
 
 	exitCode := runOperations(ctx, app, podcasts)
+	if plan != nil {
+		if err := plan.printPlan(ctx, app, podcasts); err != nil {
+			log.Printf("[ERROR] can't print plan, %v", err)
+			exitCode = 1
+		}
+	}
 	if exitCode != 0 {
+		plan.Close()
+		_ = store.Close()
 		os.Exit(exitCode)
 	}
 }
@@ -233,7 +353,9 @@ func loadConfig(forMigration bool) *configs.Conf {
 	return conf
 }
 
-func setupApplication(conf *configs.Conf) (*podgen.App, storage.Store) {
+// setupApplication opens the database and creates the application.
+// With --dry-run the application works on a throwaway copy of the database, returned as the plan.
+func setupApplication(conf *configs.Conf) (*podgen.App, storage.Store, *dryRun) {
 	// Create storage using factory
 	storageType := conf.GetStorageType()
 	storageDSN := conf.GetStorageDSN()
@@ -264,38 +386,260 @@ func setupApplication(conf *configs.Conf) (*podgen.App, storage.Store) {
 		log.Fatalf("[ERROR] can't open storage: %v", err)
 	}
 
-	s3client, err := podgen.NewS3Client(
-		conf.CloudStorage.EndPointURL,
-		conf.CloudStorage.Secrets.Key,
-		conf.CloudStorage.Secrets.Secret,
-		true)
-	if err != nil {
-		log.Fatalf("[ERROR] can't create s3client instance, %v", err)
-	}
-
 	chunkSize := conf.Upload.ChunkSize
 	if chunkSize == 0 {
 		chunkSize = 3
 	}
 
+	maxBandwidth := conf.Upload.MaxBandwidth
+	if opts.MaxBandwidth != "" {
+		maxBandwidth = opts.MaxBandwidth
+	}
+	rate, err := ratelimit.ParseRate(maxBandwidth)
+	if err != nil {
+		log.Fatalf("[ERROR] can't parse max bandwidth: %v", err)
+	}
+	limiter := ratelimit.New(rate)
+	if limiter != nil {
+		log.Printf("[INFO] upload bandwidth limited to %s/s", progress.FormatBytes(rate))
+	}
+
+	retry := conf.GetUploadRetry()
+	var retryStorages []*proc.RetryStorage
+	newRetryStorage := func(published proc.ObjectStorage) *proc.RetryStorage {
+		r := &proc.RetryStorage{
+			Storage:  published,
+			Attempts: retry.Attempts,
+			Delay:    retry.Delay,
+			MaxDelay: retry.MaxDelay,
+		}
+		retryStorages = append(retryStorages, r)
+		return r
+	}
+
+	var procStore storage.Store = store
+	var plan *dryRun
+	if opts.DryRun {
+		plan, err = newDryRun(storageType, store, conf.GetStorageFolder())
+		if err != nil {
+			log.Fatalf("[ERROR] can't prepare dry run: %v", err)
+		}
+		procStore = plan.store
+	}
+	// wrap retries writes to a destination, a dry run records them instead
+	wrap := func(name string, published proc.ObjectStorage, baseURL string) proc.ObjectStorage {
+		var objects proc.ObjectStorage = newRetryStorage(published)
+		if plan != nil {
+			objects = plan.record(name, objects, baseURL)
+		}
+		return objects
+	}
+
+	// exit stops on errors once the dry run is prepared, log.Fatalf would leave its temporary directory behind
+	exit := func(err error) {
+		log.Printf("[ERROR] %v", err)
+		plan.Close()
+		_ = store.Close()
+		os.Exit(1)
+	}
+
+	// mirrors don't resume uploads, records of multipart uploads are kept by bucket and object name, not by endpoint
+	published, baseURL, err := newObjectStorage(conf, conf.CloudStorage, store, limiter)
+	if err != nil {
+		exit(err)
+	}
+	objects := wrap(configs.PrimaryDestination, published, baseURL)
+	podcastStorage, err := newPodcastStorages(conf, objects, procStore, limiter, wrap)
+	if err != nil {
+		exit(err)
+	}
+
+	keyTemplates := make(map[string]proc.KeyTemplate, len(conf.Podcasts))
+	feedNames := map[string]string{}
+	privateFeeds := map[string]configs.PrivateFeed{}
+	for id, p := range conf.Podcasts {
+		keyTemplates[id] = proc.KeyTemplate(conf.GetKeyTemplate(p))
+		if p.FeedName != "" {
+			feedNames[id] = p.FeedName
+		}
+		if p.Private.IsSet() {
+			privateFeeds[id] = p.Private
+		}
+	}
+
 	procEntity := &proc.Processor{
-		Storage:     store,
-		S3Client:    &proc.S3Store{Client: s3client, Location: conf.CloudStorage.Region, Bucket: conf.CloudStorage.Bucket},
-		Files:       &proc.Files{Storage: conf.GetStorageFolder()},
-		StoragePath: conf.GetStorageFolder(),
-		ChunkSize:   chunkSize,
+		Storage:        procStore,
+		Podcasts:       procStore,
+		History:        procStore,
+		Sessions:       procStore,
+		Subscribers:    procStore,
+		Runs:           procStore,
+		S3Client:       objects,
+		PodcastStorage: podcastStorage,
+		KeyTemplates:   keyTemplates,
+		FeedNames:      feedNames,
+		PrivateFeeds:   privateFeeds,
+		Files:          &proc.Files{Storage: conf.GetStorageFolder()},
+		StoragePath:    conf.GetStorageFolder(),
+		ChunkSize:      chunkSize,
+	}
+	if plan != nil {
+		procEntity.OutputPath = plan.dir
 	}
 
 	if isTerminal(os.Stdout) {
-		procEntity.Progress = progress.NewMulti(os.Stdout, chunkSize, 0)
+		multi := progress.NewMulti(os.Stdout, chunkSize, 0)
+		procEntity.Progress = multi
+		for _, r := range retryStorages {
+			r.Progress = multi
+		}
 	}
 
 	app, err := podgen.NewApplication(conf, procEntity)
 	if err != nil {
-		log.Fatalf("[ERROR] can't create app, %v", err)
+		exit(fmt.Errorf("can't create app, %w", err))
 	}
 
-	return app, store
+	return app, store, plan
+}
+
+// newObjectStorage creates the publishing backend chosen by the type of target, cloud_storage or a mirror,
+// and returns it with the base URL of its objects. S3 uploads are resumable if uploads is not nil.
+func newObjectStorage(conf *configs.Conf, target configs.CloudStorageConfig, uploads storage.UploadStore,
+	limiter *ratelimit.Bucket) (proc.ObjectStorage, string, error) {
+	publicURL := strings.TrimRight(target.PublicBaseURL, "/")
+	switch target.GetType() {
+	case configs.CloudStorageFilesystem:
+		if err := os.MkdirAll(target.Path, 0o755); err != nil { //nolint:gosec // published files are public
+			return nil, "", fmt.Errorf("can't create publishing directory %s: %w", target.Path, err)
+		}
+		return &proc.FilesystemStore{
+			Root:    target.Path,
+			BaseURL: target.PublicBaseURL,
+			Limiter: limiter,
+		}, publicURL, nil
+	case configs.CloudStorageWebDAV:
+		return &proc.WebDAVStore{
+			URL:      target.WebDAV.URL,
+			Username: target.WebDAV.Username,
+			Password: target.WebDAV.Password,
+			BaseURL:  target.PublicBaseURL,
+			Limiter:  limiter,
+		}, publicURL, nil
+	case configs.CloudStorageSFTP:
+		sshConfig, err := podgen.NewSSHClientConfig(target.SFTP)
+		if err != nil {
+			return nil, "", fmt.Errorf("can't configure sftp: %w", err)
+		}
+		return &proc.SFTPStore{
+			Address: podgen.SFTPAddress(target.SFTP.Address),
+			SSH:     sshConfig,
+			Root:    target.Path,
+			BaseURL: target.PublicBaseURL,
+			Limiter: limiter,
+		}, publicURL, nil
+	}
+
+	s3client, err := podgen.NewS3Client(
+		target.EndPointURL,
+		target.Secrets.Key,
+		target.Secrets.Secret,
+		true)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't create s3client instance, %w", err)
+	}
+	urls := podgen.NewURLTemplate(target)
+	urls.Endpoint = s3client.EndpointURL().String()
+	return &proc.S3Store{
+		Client:        s3client,
+		Location:      target.Region,
+		Bucket:        target.Bucket,
+		Uploads:       uploads,
+		PartSize:      conf.GetUploadPartSize(),
+		StaleAfter:    conf.Upload.AbortStaleAfter,
+		Limiter:       limiter,
+		PublicBaseURL: target.PublicBaseURL,
+		URLStyle:      target.URLStyle,
+		KeyPrefix:     target.KeyPrefix,
+		Policies:      conf.GetObjectPolicies(),
+	}, strings.TrimSuffix(urls.URL(""), "/"), nil
+}
+
+// newPodcastStorages creates the storage of every podcast published elsewhere than primary. Podcasts overriding
+// cloud_storage or public_base_url get a primary of their own, podcasts having mirrors publish to their primary
+// and the mirrors. Storages shared by several podcasts are created once, one per distinct target, and passed
+// to wrap with the name and base URL of their destination.
+func newPodcastStorages(conf *configs.Conf, primary proc.ObjectStorage, store storage.Store, limiter *ratelimit.Bucket,
+	wrap func(name string, published proc.ObjectStorage, baseURL string) proc.ObjectStorage) (map[string]proc.ObjectStorage, error) {
+	primaries := map[configs.CloudStorageConfig]proc.ObjectStorage{conf.CloudStorage: primary}
+	mirrors := map[string]proc.ObjectStorage{}
+	result := map[string]proc.ObjectStorage{}
+	for id, p := range conf.Podcasts {
+		target := conf.GetPodcastCloudStorage(p)
+		if _, ok := primaries[target]; !ok {
+			published, baseURL, err := newObjectStorage(conf, target, store, limiter)
+			if err != nil {
+				return nil, fmt.Errorf("podcast %s: %w", id, err)
+			}
+			primaries[target] = wrap(configs.PrimaryDestination, published, baseURL)
+		}
+		podcastPrimary := primaries[target]
+		if len(p.Mirrors) == 0 {
+			if podcastPrimary != primary {
+				result[id] = podcastPrimary
+			}
+			continue
+		}
+
+		mirrored := &proc.MirrorStorage{
+			Primary: proc.Destination{Name: configs.PrimaryDestination, Storage: podcastPrimary},
+			State:   store,
+		}
+		for _, name := range p.Mirrors {
+			if _, ok := mirrors[name]; !ok {
+				published, baseURL, err := newObjectStorage(conf, conf.Mirrors[name], nil, limiter)
+				if err != nil {
+					return nil, fmt.Errorf("mirror %s: %w", name, err)
+				}
+				mirrors[name] = wrap(name, published, baseURL)
+			}
+			mirrored.Mirrors = append(mirrored.Mirrors, proc.Destination{Name: name, Storage: mirrors[name]})
+		}
+		result[id] = mirrored
+	}
+	return result, nil
+}
+
+// runMarkDestination marks the destination of --mark-down or --mark-up and prints the status of all destinations.
+func runMarkDestination(conf *configs.Conf, store storage.Store) error {
+	if opts.DryRun {
+		return errors.New("--mark-down and --mark-up can't be used with --dry-run")
+	}
+	name, down := opts.MarkUp, false
+	if opts.MarkDown != "" {
+		name, down = opts.MarkDown, true
+	}
+	if _, ok := conf.Mirrors[name]; !ok && name != configs.PrimaryDestination {
+		return fmt.Errorf("unknown destination %q, expected %s or a mirror name", name, configs.PrimaryDestination)
+	}
+	if err := proc.SetDestinationDown(store, name, down, "marked down from the command line"); err != nil {
+		return err
+	}
+
+	statuses, err := store.ListDestinationStatuses()
+	if err != nil {
+		return fmt.Errorf("can't list destination statuses: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DESTINATION\tSTATUS\tSINCE\tREASON")
+	for _, s := range statuses {
+		status := "up"
+		if s.Down {
+			status = "down"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, status, s.UpdatedAt.Format("2006-01-02 15:04"), s.Reason)
+	}
+	return w.Flush()
 }
 
 // runMigration migrates data from a source database to the configured destination.
@@ -357,8 +701,9 @@ func runMigration(conf *configs.Conf) error {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
-	log.Printf("[INFO] Migration complete: %d podcasts (%d failed), %d episodes migrated, %d failed",
-		stats.PodcastsProcessed, stats.PodcastsFailed, stats.EpisodesMigrated, stats.EpisodesFailed)
+	log.Printf("[INFO] Migration complete: %d podcasts (%d failed), %d episodes migrated, %d failed, %d sessions, %d replicas",
+		stats.PodcastsProcessed, stats.PodcastsFailed, stats.EpisodesMigrated, stats.EpisodesFailed, stats.SessionsMigrated,
+		stats.ReplicasMigrated)
 
 	return nil
 }
@@ -458,7 +803,7 @@ func runAddPodcast() error {
 	return nil
 }
 
-func resolvePodcasts(app *podgen.App) string {
+func resolvePodcasts(app *podgen.App) (string, error) {
 	podcasts := opts.Podcasts
 	if podcasts == "" && opts.AllPodcasts {
 		podcastEntities := app.FindPodcasts()
@@ -471,9 +816,9 @@ func resolvePodcasts(app *podgen.App) string {
 	}
 
 	if podcasts == "" {
-		log.Fatalf("[ERROR] You didn't list podcasts")
+		return "", errors.New("you didn't list podcasts")
 	}
-	return podcasts
+	return podcasts, nil
 }
 
 func runOperations(ctx context.Context, app *podgen.App, podcasts string) int {
@@ -491,9 +836,29 @@ func runOperations(ctx context.Context, app *podgen.App, podcasts string) int {
 	}
 
 	if opts.Rollback {
-		app.RollbackEpisodes(ctx, podcasts)
+		if err := app.RollbackEpisodes(ctx, podcasts); err != nil {
+			log.Printf("[ERROR] %v", err)
+			hasError = true
+		}
 	} else if opts.RollbackBySession != "" {
-		app.RollbackEpisodesBySession(ctx, podcasts, opts.RollbackBySession)
+		if err := app.RollbackEpisodesBySession(ctx, podcasts, opts.RollbackBySession); err != nil {
+			log.Printf("[ERROR] %v", err)
+			hasError = true
+		}
+	}
+
+	if opts.Reconcile {
+		if err := runReconcile(ctx, app, podcasts); err != nil {
+			log.Printf("[ERROR] %v", err)
+			hasError = true
+		}
+	}
+
+	if opts.RewriteLocations {
+		if _, err := app.RewriteLocations(ctx, podcasts); err != nil {
+			log.Printf("[ERROR] %v", err)
+			hasError = true
+		}
 	}
 
 	if opts.Upload {
@@ -501,10 +866,7 @@ func runOperations(ctx context.Context, app *podgen.App, podcasts string) int {
 		if err := app.Update(ctx, podcasts); err != nil {
 			hasError = true
 		}
-		if err := app.DeleteOldEpisodes(ctx, podcasts, opts.ForceDelete); err != nil {
-			hasError = true
-		}
-		if err := app.UploadEpisodes(ctx, podcasts); err != nil {
+		if err := app.UploadEpisodes(ctx, podcasts, opts.ForceDelete); err != nil {
 			hasError = true
 		}
 		// Always auto-trigger feed update after upload phase, even if some podcasts failed
@@ -529,8 +891,401 @@ func runOperations(ctx context.Context, app *podgen.App, podcasts string) int {
 		}
 	}
 
+	if opts.List {
+		if err := runListEpisodes(ctx, app, podcasts); err != nil {
+			log.Printf("[ERROR] %v", err)
+			hasError = true
+		}
+	}
+
+	if opts.History {
+		if err := runHistory(ctx, app, podcasts); err != nil {
+			log.Printf("[ERROR] %v", err)
+			hasError = true
+		}
+	}
+
 	if hasError {
 		return 1
 	}
 	return 0
 }
+
+// buildEpisodeQuery converts listing flags to a storage query.
+func buildEpisodeQuery() (storage.EpisodeQuery, error) {
+	query := storage.EpisodeQuery{
+		Text:    opts.ListSearch,
+		MinSize: opts.ListMinSize,
+		MaxSize: opts.ListMaxSize,
+		Limit:   opts.ListLimit,
+		Offset:  opts.ListOffset,
+	}
+	query.Session = opts.ListSession
+
+	if opts.ListStatus != "" {
+		for _, s := range strings.Split(opts.ListStatus, ",") {
+			status, err := podcast.ParseStatus(s)
+			if err != nil {
+				return query, err
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	var err error
+	if query.SortBy, query.Desc, err = storage.ParseEpisodeSort(opts.ListSort); err != nil {
+		return query, err
+	}
+
+	if opts.ListSince != "" {
+		if query.PublishedFrom, err = time.ParseInLocation(time.DateOnly, opts.ListSince, time.Local); err != nil {
+			return query, fmt.Errorf("invalid --since date %q: %w", opts.ListSince, err)
+		}
+	}
+	if opts.ListUntil != "" {
+		if query.PublishedTo, err = time.ParseInLocation(time.DateOnly, opts.ListUntil, time.Local); err != nil {
+			return query, fmt.Errorf("invalid --until date %q: %w", opts.ListUntil, err)
+		}
+	}
+
+	return query, nil
+}
+
+// runListEpisodes prints episodes matching the listing flags as a table per podcast.
+func runListEpisodes(ctx context.Context, app *podgen.App, podcasts string) error {
+	query, err := buildEpisodeQuery()
+	if err != nil {
+		return err
+	}
+
+	result, err := app.ListEpisodes(ctx, podcasts, query)
+
+	ids := make([]string, 0, len(result))
+	for id := range result {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		fmt.Printf("\n%s (%d episodes)\n", id, len(result[id]))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "FILENAME\tSTATUS\tSIZE\tPUBLISHED\tTITLE")
+		for _, ep := range result[id] {
+			published := "-"
+			if t := ep.PublishedAt(); !t.IsZero() {
+				published = t.Local().Format(time.DateTime)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", ep.Filename, ep.Status, ep.Size, published, ep.Title)
+		}
+		_ = w.Flush()
+	}
+
+	return err
+}
+
+// runHistory prints recorded state transitions of episodes as a table per podcast.
+func runHistory(ctx context.Context, app *podgen.App, podcasts string) error {
+	filter := storage.HistoryFilter{Filename: opts.HistoryEpisode, Limit: opts.ListLimit}
+	if opts.ListSince != "" {
+		since, err := time.ParseInLocation(time.DateOnly, opts.ListSince, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --since date %q: %w", opts.ListSince, err)
+		}
+		filter.Since = since
+	}
+
+	result, err := app.EpisodeHistory(ctx, podcasts, filter)
+
+	ids := make([]string, 0, len(result))
+	for id := range result {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		fmt.Printf("\n%s (%d entries)\n", id, len(result[id]))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TIME\tEPISODE\tOPERATION\tSTATUS\tSESSION\tERROR")
+		for _, e := range result[id] {
+			status := e.NewStatus.String()
+			if e.OldStatus != e.NewStatus {
+				status = e.OldStatus.String() + " -> " + status
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.At.Local().Format(time.DateTime), e.Filename, e.Operation, status, e.Session, e.Error)
+		}
+		_ = w.Flush()
+	}
+
+	return err
+}
+
+// runReconcile prints mismatches between object storage, the database and podcast folders as a table per podcast,
+// fixing them first if --fix is set.
+func runReconcile(ctx context.Context, app *podgen.App, podcasts string) error {
+	result, err := app.Reconcile(ctx, podcasts, opts.Fix)
+
+	ids := make([]string, 0, len(result))
+	for id := range result {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	size := func(n int64) string {
+		if n < 0 {
+			return "-"
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	for _, id := range ids {
+		report := result[id]
+		fmt.Printf("\n%s (%d episodes, %d objects, %d mismatches)\n", id, report.Episodes, report.Objects, len(report.Findings))
+		if len(report.Findings) == 0 {
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		header := "ISSUE\tFILENAME\tSTATUS\tSTORED\tLOCAL\tREMOTE"
+		if opts.Fix {
+			header += "\tFIX"
+		}
+		_, _ = fmt.Fprintln(w, header)
+		for _, f := range report.Findings {
+			status := f.Status.String()
+			if f.Issue == proc.IssueOrphanObject {
+				status = "-"
+			}
+			line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", f.Issue, f.Filename, status,
+				size(f.StoredSize), size(f.LocalSize), size(f.RemoteSize))
+			if opts.Fix {
+				fix := f.Fix
+				switch {
+				case f.Error != "":
+					fix = "failed: " + f.Error
+				case fix == "":
+					fix = "-"
+				}
+				line += "\t" + fix
+			}
+			_, _ = fmt.Fprintln(w, line)
+		}
+		_ = w.Flush()
+	}
+
+	return err
+}
+
+// runSessions lists recorded upload sessions or prints details of the sessions matching --show-session.
+func runSessions(ctx context.Context, app *podgen.App) error {
+	if opts.ShowSession != "" {
+		sessions, err := app.FindSessions(ctx, opts.ShowSession)
+		if err != nil {
+			return err
+		}
+		for _, s := range sessions {
+			printSession(s)
+		}
+		return nil
+	}
+
+	sessions, err := app.ListSessions(ctx, opts.ListLimit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "REF\tSESSION\tOPERATION\tSTARTED\tDURATION\tOUTCOME\tUPLOADED\tDELETED\tFAILED\tSIZE\tPODCASTS")
+	uploads := 0
+	for _, s := range sessions {
+		// last-N references count upload sessions only
+		ref := "-"
+		if s.IsUpload() {
+			ref = "last"
+			if uploads > 0 {
+				ref = fmt.Sprintf("last-%d", uploads)
+			}
+			uploads++
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n", ref, s.ID, s.Operation,
+			s.StartedAt.Local().Format(time.DateTime), sessionDuration(s), s.Outcome, s.Uploaded, s.Deleted, s.Failed,
+			progress.FormatBytes(s.Bytes), strings.Join(s.PodcastIDs, ","))
+	}
+	return w.Flush()
+}
+
+// runServe serves the podcasts and the API over HTTP until ctx is done, the address and base URL of the config are overridden
+// by --listen and --base-url.
+func runServe(ctx context.Context, conf *configs.Conf, app *podgen.App) error {
+	if opts.Listen != "" {
+		conf.Serve.Listen = opts.Listen
+	}
+	if opts.BaseURL != "" {
+		conf.Serve.BaseURL = opts.BaseURL
+	}
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	serve := conf.GetServe()
+
+	feeds := app.LocalFeedURLs(serve.BaseURL)
+	ids := make([]string, 0, len(feeds))
+	for id := range feeds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Printf("%s: %s\n", id, feeds[id])
+	}
+	for id := range conf.Podcasts {
+		if _, ok := feeds[id]; !ok {
+			log.Printf("[WARN] private podcast %s is not served", id)
+		}
+	}
+	log.Printf("[INFO] serving %s at %s", conf.GetStorageFolder(), serve.Listen)
+	if len(serve.APITokens) > 0 {
+		log.Printf("[INFO] API enabled at %s/api/v1 for %d tokens", serve.BaseURL, len(serve.APITokens))
+		log.Printf("[INFO] dashboard at %s/dashboard", serve.BaseURL)
+	}
+	srv := &server.Server{App: app, Listen: serve.Listen, BaseURL: serve.BaseURL, Tokens: serve.APITokens}
+	return srv.Run(ctx)
+}
+
+// runDaemon runs the scheduled jobs of podcasts until ctx is done. The lock file in the storage folder keeps
+// a second daemon from running the jobs of the same podcasts.
+func runDaemon(ctx context.Context, conf *configs.Conf, app *podgen.App) error {
+	if opts.Serve || opts.DryRun {
+		return errors.New("--daemon can't be used with --serve or --dry-run")
+	}
+	sched, err := scheduler.New(app, conf.Podcasts)
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockfile.Acquire(filepath.Join(conf.GetStorageFolder(), daemonLockFile))
+	if err != nil {
+		if errors.Is(err, lockfile.ErrLocked) {
+			return fmt.Errorf("another daemon is running, %w", err)
+		}
+		return err
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("[WARN] can't release lock, %v", err)
+		}
+	}()
+
+	log.Printf("[INFO] daemon started for %s", conf.GetStorageFolder())
+	return sched.Run(ctx)
+}
+
+// runRuns lists recorded runs of scheduled jobs, of the podcast set by -p if any.
+func runRuns(ctx context.Context, app *podgen.App) error {
+	runs, err := app.ListRuns(ctx, strings.TrimSpace(opts.Podcasts), opts.ListLimit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RUN\tPODCAST\tJOB\tSTARTED\tDURATION\tOUTCOME\tERROR")
+	for _, r := range runs {
+		duration := "-"
+		if !r.FinishedAt.IsZero() {
+			duration = r.FinishedAt.Sub(r.StartedAt).Round(time.Second).String()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.PodcastID, r.Job,
+			r.StartedAt.Local().Format(time.DateTime), duration, r.Outcome, r.Error)
+	}
+	return w.Flush()
+}
+
+// runSubscribers adds, rotates or revokes a subscriber of the podcast set by -p, or lists its subscribers.
+func runSubscribers(ctx context.Context, app *podgen.App) error {
+	podcastID := strings.TrimSpace(opts.Podcasts)
+	if podcastID == "" || strings.Contains(podcastID, ",") {
+		return errors.New("subscriber commands need a single podcast, set by -p")
+	}
+
+	switch {
+	case opts.AddSubscriber != "":
+		url, err := app.AddSubscriber(ctx, podcastID, opts.AddSubscriber)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s/%s: %s\n", podcastID, opts.AddSubscriber, url)
+		return nil
+	case opts.RotateSubscriber != "":
+		url, err := app.RotateSubscriber(ctx, podcastID, opts.RotateSubscriber)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s/%s: %s\n", podcastID, opts.RotateSubscriber, url)
+		return nil
+	case opts.RevokeSubscriber != "":
+		if err := app.RevokeSubscriber(ctx, podcastID, opts.RevokeSubscriber); err != nil {
+			return err
+		}
+		fmt.Printf("%s/%s: revoked\n", podcastID, opts.RevokeSubscriber)
+		return nil
+	}
+
+	subscribers, err := app.ListSubscribers(podcastID)
+	if err != nil {
+		return err
+	}
+	urls := app.GetFeedURLs(podcastID)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SUBSCRIBER\tSTATUS\tADDED\tROTATED\tFEED")
+	for _, sub := range subscribers {
+		status, added, rotated := "active", "config", "-"
+		if !sub.Active() {
+			status = "revoked " + sub.RevokedAt.Local().Format(time.DateTime)
+		}
+		if !sub.CreatedAt.IsZero() {
+			added = sub.CreatedAt.Local().Format(time.DateTime)
+		}
+		if !sub.RotatedAt.IsZero() {
+			rotated = sub.RotatedAt.Local().Format(time.DateTime)
+		}
+		feed := urls[podcastID+"/"+sub.Name]
+		if feed == "" {
+			feed = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sub.Name, status, added, rotated, feed)
+	}
+	return w.Flush()
+}
+
+// printSession prints all recorded fields of the session.
+func printSession(s *storage.Session) {
+	if s.StartedAt.IsZero() {
+		fmt.Printf("\nSession %s: not recorded\n", s.ID)
+		return
+	}
+	fmt.Printf("\nSession %s\n", s.ID)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Started:\t%s\n", s.StartedAt.Local().Format(time.DateTime))
+	if !s.FinishedAt.IsZero() {
+		_, _ = fmt.Fprintf(w, "Finished:\t%s (%s)\n", s.FinishedAt.Local().Format(time.DateTime), sessionDuration(s))
+	}
+	_, _ = fmt.Fprintf(w, "Operation:\t%s\n", s.Operation)
+	if s.Target != "" {
+		_, _ = fmt.Fprintf(w, "Rolled back:\t%s\n", s.Target)
+	}
+	_, _ = fmt.Fprintf(w, "Host:\t%s\n", s.Host)
+	_, _ = fmt.Fprintf(w, "Podcasts:\t%s\n", strings.Join(s.PodcastIDs, ", "))
+	_, _ = fmt.Fprintf(w, "Outcome:\t%s\n", s.Outcome)
+	_, _ = fmt.Fprintf(w, "Uploaded:\t%d (%s)\n", s.Uploaded, progress.FormatBytes(s.Bytes))
+	_, _ = fmt.Fprintf(w, "Deleted:\t%d\n", s.Deleted)
+	_, _ = fmt.Fprintf(w, "Failed:\t%d\n", s.Failed)
+	if s.Error != "" {
+		_, _ = fmt.Fprintf(w, "Error:\t%s\n", s.Error)
+	}
+	_ = w.Flush()
+}
+
+// sessionDuration returns how long the session ran, or "-" if it hasn't finished.
+func sessionDuration(s *storage.Session) string {
+	if s.FinishedAt.IsZero() {
+		return "-"
+	}
+	return s.FinishedAt.Sub(s.StartedAt).Round(time.Second).String()
+}
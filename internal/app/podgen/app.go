@@ -6,14 +6,25 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	log "github.com/go-pkgz/lgr"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	"podgen/internal/app/podgen/artwork"
+	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/app/podgen/proc"
 	"podgen/internal/configs"
+	"podgen/internal/storage"
 )
 
 const podcastDefaultImage string = "podcast.png"
@@ -32,6 +43,8 @@ func NewApplication(conf *configs.Conf, p *proc.Processor) (*App, error) {
 
 // NewS3Client create s3 client instance
 func NewS3Client(endpoint, accessKeyID, secretAccessKey string, useSSL bool) (*minio.Client, error) {
+	// failed requests are retried by proc.RetryStorage, which reports them and honours the configured attempts
+	minio.MaxRetry = 1
 	client, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
 		Secure: useSSL,
@@ -43,6 +56,86 @@ func NewS3Client(endpoint, accessKeyID, secretAccessKey string, useSSL bool) (*m
 	return client, err
 }
 
+// NewURLTemplate returns the template of public URLs of objects published to target.
+func NewURLTemplate(target configs.CloudStorageConfig) proc.URLTemplate {
+	return proc.URLTemplate{
+		BaseURL:  target.PublicBaseURL,
+		Endpoint: target.EndPointURL,
+		Bucket:   target.Bucket,
+		Style:    target.URLStyle,
+		Prefix:   target.KeyPrefix,
+	}
+}
+
+// NewSSHClientConfig creates the ssh config of the sftp publishing backend.
+// The server is checked against the configured host key fingerprint, or known_hosts without it.
+func NewSSHClientConfig(conf configs.SFTPConfig) (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if conf.PrivateKey != "" {
+		keyPath := expandHome(conf.PrivateKey)
+		key, err := os.ReadFile(keyPath) //nolint:gosec // path comes from config
+		if err != nil {
+			return nil, fmt.Errorf("can't read private key %s: %w", keyPath, err)
+		}
+		var signer ssh.Signer
+		if conf.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(conf.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't parse private key %s: %w", keyPath, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if conf.Password != "" {
+		auth = append(auth, ssh.Password(conf.Password))
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	if conf.HostKey != "" {
+		hostKeyCallback = func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			if fingerprint := ssh.FingerprintSHA256(key); fingerprint != conf.HostKey {
+				return fmt.Errorf("host key mismatch, got %s, expected %s", fingerprint, conf.HostKey)
+			}
+			return nil
+		}
+	} else {
+		knownHostsPath := conf.KnownHosts
+		if knownHostsPath == "" {
+			knownHostsPath = "~/.ssh/known_hosts"
+		}
+		knownHostsPath = expandHome(knownHostsPath)
+		callback, err := knownhosts.New(knownHostsPath)
+		if err != nil {
+			return nil, fmt.Errorf("can't read known hosts %s: %w", knownHostsPath, err)
+		}
+		hostKeyCallback = callback
+	}
+
+	return &ssh.ClientConfig{User: conf.Username, Auth: auth, HostKeyCallback: hostKeyCallback, Timeout: 30 * time.Second}, nil
+}
+
+// SFTPAddress returns the address with the default ssh port if it has none.
+func SFTPAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(address, "22")
+	}
+	return address
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
 // Update find and add to db new episodes of podcast
 func (a *App) Update(ctx context.Context, podcastIDs string) error {
 	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
@@ -70,8 +163,10 @@ func (a *App) Update(ctx context.Context, podcastIDs string) error {
 	return errors.Join(errs...)
 }
 
-// UploadEpisodes by podcasts to s3 storage
-func (a *App) UploadEpisodes(ctx context.Context, podcastIDs string) error {
+// UploadEpisodes by podcasts to s3 storage.
+// Old episodes are deleted first for podcasts with delete_old_episodes set, or for all podcasts if forceDelete is true.
+// The whole run is recorded as an upload session.
+func (a *App) UploadEpisodes(ctx context.Context, podcastIDs string, forceDelete bool) error {
 	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
 
 	if len(podcasts) == 0 {
@@ -86,35 +181,42 @@ func (a *App) UploadEpisodes(ctx context.Context, podcastIDs string) error {
 	}
 
 	log.Printf("[INFO] Start session: %s", session)
+	record := a.processor.StartSession(session, storage.OperationUpload, "", slices.Sorted(maps.Keys(podcasts)))
 
-	var errs []error
-	for i, p := range podcasts {
-		log.Printf("[INFO] uploading podcast %s, folder: %s, maxSize: %d", i, p.Folder, p.MaxSize)
-		if err := a.processor.UploadNewEpisodes(ctx, session, i, p.Folder, p.MaxSize); err != nil {
-			log.Printf("[ERROR] can't upload new episodes for %s, %v", i, err)
-			errs = append(errs, fmt.Errorf("upload %s: %w", i, err))
-		}
+	if err := a.processor.AbortStaleUploads(ctx); err != nil {
+		log.Printf("[WARN] can't abort stale uploads, %v", err)
 	}
-	return errors.Join(errs...)
-}
-
-// DeleteOldEpisodes delete old episodes by podcasts
-// If force is true, deletes for all podcasts regardless of delete_old_episodes config
-func (a *App) DeleteOldEpisodes(ctx context.Context, podcastIDs string, force bool) error {
-	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
 
 	var errs []error
 	for i, p := range podcasts {
-		if !force && !p.DeleteOldEpisodes {
+		if !forceDelete && !p.DeleteOldEpisodes {
 			continue
 		}
-
-		err := a.processor.DeleteOldEpisodesByPodcast(ctx, i, p.Folder)
-		if err != nil {
+		if err := a.processor.DeleteOldEpisodesByPodcast(ctx, session, i, p.Folder); err != nil {
 			log.Printf("[ERROR] can't delete old episodes by podcast %s, %v", i, err)
 			errs = append(errs, fmt.Errorf("delete %s: %w", i, err))
 		}
 	}
+
+	for i, p := range podcasts {
+		log.Printf("[INFO] uploading podcast %s, folder: %s, maxSize: %d", i, p.Folder, p.MaxSize)
+		if err := a.processor.UploadNewEpisodes(ctx, session, i, p.Folder, p.MaxSize); err != nil {
+			log.Printf("[ERROR] can't upload new episodes for %s, %v", i, err)
+			errs = append(errs, fmt.Errorf("upload %s: %w", i, err))
+		}
+	}
+	err = errors.Join(errs...)
+	a.processor.FinishSession(ctx, record, err)
+	log.Printf("[INFO] Finish session: %s, outcome: %s, uploaded: %d, deleted: %d, failed: %d",
+		session, record.Outcome, record.Uploaded, record.Deleted, record.Failed)
+	return err
+}
+
+// Publish scans podcasts for new episodes, uploads them and regenerates feeds, like podgen -u.
+// Feeds are regenerated even if some uploads failed.
+func (a *App) Publish(ctx context.Context, podcastIDs string, forceDelete bool) error {
+	errs := []error{a.Update(ctx, podcastIDs), a.UploadEpisodes(ctx, podcastIDs, forceDelete)}
+	errs = append(errs, a.GenerateFeed(ctx, podcastIDs, a.GetPodcastImages(ctx, podcastIDs)))
 	return errors.Join(errs...)
 }
 
@@ -126,20 +228,32 @@ func (a *App) GenerateFeed(ctx context.Context, podcastIDs string, podcastImages
 	for i, p := range podcasts {
 		podcastImageURL := podcastImages[i]
 
-		feedFilename, err := a.processor.GenerateFeed(ctx, i, p, podcastImageURL)
+		feeds, err := a.processor.GenerateFeeds(ctx, i, p, podcastImageURL)
 		if err != nil {
 			log.Printf("[ERROR] can't generate feed for %s, %v", i, err)
 			errs = append(errs, fmt.Errorf("generate feed %s: %w", i, err))
 			continue
 		}
-		uploadInfo, err := a.processor.UploadFeed(ctx, p.Folder, feedFilename)
-		if err != nil {
-			log.Printf("[ERROR] can't upload feed for %s, %v", i, err)
-			errs = append(errs, fmt.Errorf("upload feed %s: %w", i, err))
-			continue
+		for _, feed := range feeds {
+			uploadInfo, err := a.processor.UploadFeed(ctx, i, p.Folder, feed.Filename)
+			if err != nil {
+				log.Printf("[ERROR] can't upload feed for %s, %v", i, err)
+				errs = append(errs, fmt.Errorf("upload feed %s: %w", i, err))
+				continue
+			}
+			switch {
+			case uploadInfo == nil:
+			case feed.Subscriber != "":
+				log.Printf("Feed of %s uploaded", feed.Subscriber) // URLs of private feeds are secrets, --rss shows them
+			default:
+				log.Printf("Feed url %s", uploadInfo.Location)
+			}
 		}
-		if uploadInfo != nil {
-			log.Printf("Feed url %s", uploadInfo.Location)
+		if a.processor.IsPrivate(i) {
+			if err := a.processor.DeletePublicFeed(ctx, i, p.Folder); err != nil {
+				log.Printf("[ERROR] can't delete public feed of private podcast %s, %v", i, err)
+				errs = append(errs, fmt.Errorf("delete public feed %s: %w", i, err))
+			}
 		}
 	}
 	return errors.Join(errs...)
@@ -164,13 +278,13 @@ func (a *App) UploadPodcastImage(ctx context.Context, podcastIDs string, forceRe
 	return result
 }
 
-// GetPodcastImages by podcast from s3 storage
+// GetPodcastImages returns image URLs of podcasts recorded on upload or found in s3 storage
 func (a *App) GetPodcastImages(ctx context.Context, podcastIDs string) map[string]string {
 	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
 
 	result := make(map[string]string, len(podcasts))
 	for i, p := range podcasts {
-		imageURL := a.processor.GetPodcastImage(ctx, p.Folder, podcastDefaultImage)
+		imageURL := a.processor.GetPodcastImage(ctx, i, p.Folder, podcastDefaultImage)
 		result[i] = imageURL
 	}
 
@@ -182,15 +296,30 @@ func (a *App) FindPodcasts() map[string]configs.Podcast {
 	return a.config.Podcasts
 }
 
-// GetFeedURLs returns RSS feed URLs for specified podcasts
+// GetFeedURLs returns RSS feed URLs for specified podcasts.
+// Private podcasts have a feed per subscriber, keyed by <podcast ID>/<subscriber>.
 func (a *App) GetFeedURLs(podcastIDs string) map[string]string {
 	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
 	result := make(map[string]string, len(podcasts))
 
 	for id, p := range podcasts {
-		url, err := a.processor.GetFeedURL(id, p.Folder,
-			a.config.CloudStorage.EndPointURL,
-			a.config.CloudStorage.Bucket)
+		// feeds of mirrored podcasts move to a mirror while cloud_storage is marked down
+		target := a.config.GetPodcastCloudStorage(p)
+		if mirror, ok := a.config.Mirrors[a.processor.FeedDestination(id)]; ok {
+			target = mirror
+		}
+		if a.processor.IsPrivate(id) {
+			subscribers, err := a.processor.GetSubscriberFeedURLs(id, p.Folder, NewURLTemplate(target))
+			if err != nil {
+				log.Printf("[ERROR] can't get feed URLs of subscribers of %s: %v", id, err)
+				continue
+			}
+			for name, url := range subscribers {
+				result[id+"/"+name] = url
+			}
+			continue
+		}
+		url, err := a.processor.GetFeedURL(id, p.Folder, NewURLTemplate(target))
 		if err != nil {
 			log.Printf("[ERROR] can't get feed URL for %s: %v", id, err)
 			continue
@@ -200,28 +329,349 @@ func (a *App) GetFeedURLs(podcastIDs string) map[string]string {
 	return result
 }
 
-// RollbackEpisodes rollback last episode by podcasts
-func (a *App) RollbackEpisodes(ctx context.Context, podcastIDs string) {
+// ServedPodcasts returns the podcasts served over HTTP from the storage folder by --serve.
+// Private podcasts are not served, it has no access control.
+func (a *App) ServedPodcasts() map[string]configs.Podcast {
+	result := make(map[string]configs.Podcast, len(a.config.Podcasts))
+	for id, p := range a.config.Podcasts {
+		if !a.processor.IsPrivate(id) {
+			result[id] = p
+		}
+	}
+	return result
+}
+
+// LocalFeed returns the file name and the content of the feed of a served podcast, linking episodes and artwork
+// to baseURL, the URL the storage folder is served at
+func (a *App) LocalFeed(podcastID, baseURL string) (name, feed string, err error) {
+	p, ok := a.ServedPodcasts()[podcastID]
+	if !ok {
+		return "", "", fs.ErrNotExist
+	}
+	return a.processor.LocalFeed(podcastID, p, baseURL, podcastDefaultImage)
+}
+
+// LocalFeedURLs returns the URLs of the feeds of served podcasts by podcast ID, see LocalFeed
+func (a *App) LocalFeedURLs(baseURL string) map[string]string {
+	podcasts := a.ServedPodcasts()
+	result := make(map[string]string, len(podcasts))
+	for id, p := range podcasts {
+		url, err := a.processor.GetFeedURL(id, p.Folder, proc.URLTemplate{BaseURL: baseURL})
+		if err != nil {
+			log.Printf("[ERROR] can't get feed URL for %s: %v", id, err)
+			continue
+		}
+		result[id] = url
+	}
+	return result
+}
+
+// LocalFile returns the path of an episode or the artwork of a served podcast in the storage folder,
+// fs.ErrNotExist for other files
+func (a *App) LocalFile(podcastID, filename string) (string, error) {
+	p, ok := a.ServedPodcasts()[podcastID]
+	if !ok {
+		return "", fs.ErrNotExist
+	}
+	return a.processor.LocalFile(podcastID, p.Folder, filename, podcastDefaultImage)
+}
+
+// RewriteLocations sets the stored locations of episodes and images of podcasts to the URLs of their storage
+// as currently configured, after public_base_url or url_style changed, then regenerates their feeds.
+// Returns the number of changed episodes by podcast.
+func (a *App) RewriteLocations(ctx context.Context, podcastIDs string) (map[string]int, error) {
 	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
+	result := make(map[string]int, len(podcasts))
 
-	for i := range podcasts {
-		err := a.processor.RollbackLastEpisodes(ctx, i)
+	var errs []error
+	for id, p := range podcasts {
+		urls := NewURLTemplate(a.config.GetPodcastCloudStorage(p))
+		changed, err := a.processor.RewriteLocations(ctx, id, p.Folder, podcastDefaultImage, urls)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rewrite locations of %s: %w", id, err))
+			continue
+		}
+		log.Printf("[INFO] rewritten locations of %d episodes of %s", changed, id)
+		result[id] = changed
+	}
+	if len(result) == 0 {
+		return result, errors.Join(errs...)
+	}
+
+	ids := strings.Join(slices.Sorted(maps.Keys(result)), ",")
+	if feedErr := a.GenerateFeed(ctx, ids, a.GetPodcastImages(ctx, ids)); feedErr != nil {
+		errs = append(errs, feedErr)
+	}
+	return result, errors.Join(errs...)
+}
+
+// RollbackEpisodes rollback last episode by podcasts, undoing its whole session if it has one.
+// The rollback is recorded as a session, and feeds are regenerated to drop reverted episodes.
+func (a *App) RollbackEpisodes(ctx context.Context, podcastIDs string) error {
+	return a.rollback(ctx, podcastIDs, "", func(session, podcastID, podcastFolder string) error {
+		return a.processor.RollbackLastEpisodes(ctx, session, podcastID, podcastFolder)
+	})
+}
+
+// RollbackEpisodesBySession rollback episodes by podcasts and session.
+// The session is a session ID, "last", "last-N" or a date (YYYY-MM-DD), see proc.Processor.FindSessions.
+// Each matching session is reverted in its own rollback session and marked as rolled back on success.
+func (a *App) RollbackEpisodesBySession(ctx context.Context, podcastIDs, session string) error {
+	sessions, err := a.processor.FindSessions(ctx, session)
+	if err != nil {
+		return fmt.Errorf("find session %s: %w", session, err)
+	}
+
+	var errs []error
+	for _, target := range sessions {
+		if !target.IsUpload() {
+			errs = append(errs, fmt.Errorf("session %s is a %s and can't be rolled back", target.ID, target.Operation))
+			continue
+		}
+		if target.Outcome == storage.SessionRolledBack {
+			log.Printf("[INFO] session %s is already rolled back", target.ID)
+			continue
+		}
+
+		log.Printf("[INFO] rolling back session %s", target.ID)
+		err := a.rollback(ctx, podcastIDs, target.ID, func(session, podcastID, podcastFolder string) error {
+			return a.processor.RollbackEpisodesOfSession(ctx, session, podcastID, podcastFolder, target.ID)
+		})
 		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		a.processor.MarkSessionRolledBack(target.ID)
+	}
+	return errors.Join(errs...)
+}
+
+// rollback runs fn for every podcast inside a new rollback session, then regenerates and uploads their feeds.
+// target is the ID of the reverted session, empty if there is no single one.
+func (a *App) rollback(ctx context.Context, podcastIDs, target string, fn func(session, podcastID, podcastFolder string) error) error {
+	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
+	if len(podcasts) == 0 {
+		log.Printf("[WARN] no podcasts found for IDs: %s", podcastIDs)
+		return nil
+	}
+
+	session, err := a.makeSessionString()
+	if err != nil {
+		return fmt.Errorf("make session string: %w", err)
+	}
+	record := a.processor.StartSession(session, storage.OperationRollback, target, slices.Sorted(maps.Keys(podcasts)))
+
+	var errs []error
+	for i, p := range podcasts {
+		if err := fn(session, i, p.Folder); err != nil {
 			log.Printf("[ERROR] can't rollback episode by podcast %s, %v", i, err)
+			errs = append(errs, fmt.Errorf("rollback %s: %w", i, err))
 		}
 	}
+	err = errors.Join(errs...)
+	a.processor.FinishSession(ctx, record, err)
+	log.Printf("[INFO] Finish rollback session: %s, outcome: %s, removed: %d, restored: %d, failed: %d",
+		session, record.Outcome, record.Deleted, record.Uploaded, record.Failed)
+
+	if feedErr := a.GenerateFeed(ctx, podcastIDs, a.GetPodcastImages(ctx, podcastIDs)); feedErr != nil {
+		errs = append(errs, feedErr)
+	}
+	return errors.Join(errs...)
 }
 
-// RollbackEpisodesBySession rollback episodes by podcasts and session
-func (a *App) RollbackEpisodesBySession(ctx context.Context, podcastIDs, session string) {
+// Reconcile compares object storage with the database and local files of podcasts, see proc.Processor.Reconcile.
+// With fix set the findings are fixed in a reconcile session, and feeds are regenerated to match corrected statuses.
+// Reports are returned even when fixing some findings fails.
+func (a *App) Reconcile(ctx context.Context, podcastIDs string, fix bool) (map[string]*proc.ReconcileReport, error) {
 	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
+	result := make(map[string]*proc.ReconcileReport, len(podcasts))
+
+	var errs []error
+	for i, p := range podcasts {
+		report, err := a.processor.Reconcile(ctx, i, p.Folder, podcastDefaultImage)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reconcile %s: %w", i, err))
+			continue
+		}
+		result[i] = report
+	}
+	if !fix || len(result) == 0 {
+		return result, errors.Join(errs...)
+	}
+
+	session, err := a.makeSessionString()
+	if err != nil {
+		return result, errors.Join(append(errs, fmt.Errorf("make session string: %w", err))...)
+	}
+	record := a.processor.StartSession(session, storage.OperationReconcile, "", slices.Sorted(maps.Keys(result)))
+
+	var fixErrs []error
+	for i, report := range result {
+		if err := a.processor.FixReconcile(ctx, session, i, podcasts[i].Folder, report); err != nil {
+			fixErrs = append(fixErrs, fmt.Errorf("fix %s: %w", i, err))
+		}
+	}
+	fixErr := errors.Join(fixErrs...)
+	a.processor.FinishSession(ctx, record, fixErr)
+	log.Printf("[INFO] Finish reconcile session: %s, outcome: %s, uploaded: %d, deleted: %d, failed: %d",
+		session, record.Outcome, record.Uploaded, record.Deleted, record.Failed)
+	errs = append(errs, fixErr)
+
+	ids := strings.Join(slices.Sorted(maps.Keys(result)), ",")
+	if feedErr := a.GenerateFeed(ctx, ids, a.GetPodcastImages(ctx, ids)); feedErr != nil {
+		errs = append(errs, feedErr)
+	}
+	return result, errors.Join(errs...)
+}
 
+// ListSessions returns recorded upload sessions, most recent first
+func (a *App) ListSessions(ctx context.Context, limit int) ([]*storage.Session, error) {
+	return a.processor.ListSessions(ctx, limit)
+}
+
+// RunScheduled runs the scheduled job of the podcast, configs.JobScan or configs.JobPublish, and records the run.
+// Jobs reuse Update and Publish, so their failures are logged the same way.
+func (a *App) RunScheduled(ctx context.Context, podcastID, job string) error {
+	var run func() error
+	switch job {
+	case configs.JobScan:
+		run = func() error { return a.Update(ctx, podcastID) }
+	case configs.JobPublish:
+		run = func() error { return a.Publish(ctx, podcastID, false) }
+	default:
+		return fmt.Errorf("unknown job %q", job)
+	}
+	if _, ok := a.config.Podcasts[podcastID]; !ok {
+		return fmt.Errorf("unknown podcast %s", podcastID)
+	}
+
+	id, err := a.makeSessionString()
+	if err != nil {
+		return fmt.Errorf("make run id: %w", err)
+	}
+	record := a.processor.StartRun(id, podcastID, job)
+	err = run()
+	a.processor.FinishRun(ctx, record, err)
+	log.Printf("[INFO] Finish %s of %s, run: %s, outcome: %s, took %s",
+		job, podcastID, id, record.Outcome, record.FinishedAt.Sub(record.StartedAt).Round(time.Millisecond))
+	return err
+}
+
+// ListRuns returns recorded runs of scheduled jobs of the podcast, or of all podcasts if podcastID is empty,
+// most recent first
+func (a *App) ListRuns(ctx context.Context, podcastID string, limit int) ([]*storage.Run, error) {
+	return a.processor.ListRuns(ctx, podcastID, limit)
+}
+
+// FindSessions resolves a session reference (ID, "last", "last-N" or YYYY-MM-DD) to recorded sessions
+func (a *App) FindSessions(ctx context.Context, ref string) ([]*storage.Session, error) {
+	return a.processor.FindSessions(ctx, ref)
+}
+
+// ListEpisodes returns episodes matching the query, grouped by podcast
+func (a *App) ListEpisodes(ctx context.Context, podcastIDs string, query storage.EpisodeQuery) (map[string][]*podcast.Episode, error) {
+	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
+	result := make(map[string][]*podcast.Episode, len(podcasts))
+
+	var errs []error
 	for i := range podcasts {
-		err := a.processor.RollbackEpisodesOfSession(ctx, i, session)
+		episodes, err := a.processor.ListEpisodes(ctx, i, query)
 		if err != nil {
-			log.Printf("[ERROR] can't rollback episode by podcast %s, %v", i, err)
+			errs = append(errs, fmt.Errorf("list episodes of podcast %s: %w", i, err))
+			continue
 		}
+		result[i] = episodes
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// GetEpisode returns the stored episode of a podcast, storage.ErrNotFound if there is none
+func (a *App) GetEpisode(podcastID, filename string) (*podcast.Episode, error) {
+	if _, ok := a.config.Podcasts[podcastID]; !ok {
+		return nil, fmt.Errorf("unknown podcast %s", podcastID)
+	}
+	return a.processor.GetEpisode(podcastID, filename)
+}
+
+// EditEpisode changes the stored metadata of an episode, see proc.Processor.EditEpisode
+func (a *App) EditEpisode(ctx context.Context, podcastID, filename string, edit proc.EpisodeEdit) (*podcast.Episode, error) {
+	if _, ok := a.config.Podcasts[podcastID]; !ok {
+		return nil, fmt.Errorf("unknown podcast %s", podcastID)
+	}
+	return a.processor.EditEpisode(ctx, podcastID, filename, edit)
+}
+
+// EpisodeHistory returns recorded state transitions of episodes, grouped by podcast
+func (a *App) EpisodeHistory(ctx context.Context, podcastIDs string, filter storage.HistoryFilter) (map[string][]storage.EpisodeEvent, error) {
+	podcasts := a.filterPodcastsByPodcastIDs(podcastIDs)
+	result := make(map[string][]storage.EpisodeEvent, len(podcasts))
+
+	var errs []error
+	for i := range podcasts {
+		events, err := a.processor.EpisodeHistory(ctx, i, filter)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("history of podcast %s: %w", i, err))
+			continue
+		}
+		result[i] = events
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// ListSubscribers returns the subscribers of a private podcast, revoked ones included
+func (a *App) ListSubscribers(podcastID string) ([]*storage.Subscriber, error) {
+	if _, ok := a.config.Podcasts[podcastID]; !ok {
+		return nil, fmt.Errorf("unknown podcast %s", podcastID)
+	}
+	return a.processor.ListSubscribers(podcastID)
+}
+
+// AddSubscriber adds a subscriber to a private podcast, publishes the feeds of the podcast and returns
+// the feed URL of the subscriber
+func (a *App) AddSubscriber(ctx context.Context, podcastID, name string) (string, error) {
+	if _, ok := a.config.Podcasts[podcastID]; !ok {
+		return "", fmt.Errorf("unknown podcast %s", podcastID)
+	}
+	if _, err := a.processor.AddSubscriber(ctx, podcastID, name); err != nil {
+		return "", err
+	}
+	return a.publishSubscriberFeed(ctx, podcastID, name)
+}
+
+// RotateSubscriber replaces the token of a subscriber, deleting their feed, publishes the feeds of the podcast
+// and returns the new feed URL of the subscriber
+func (a *App) RotateSubscriber(ctx context.Context, podcastID, name string) (string, error) {
+	p, ok := a.config.Podcasts[podcastID]
+	if !ok {
+		return "", fmt.Errorf("unknown podcast %s", podcastID)
+	}
+	if _, err := a.processor.RotateSubscriber(ctx, podcastID, p.Folder, name); err != nil {
+		return "", err
+	}
+	return a.publishSubscriberFeed(ctx, podcastID, name)
+}
+
+// RevokeSubscriber deletes the feed of a subscriber and marks them revoked
+func (a *App) RevokeSubscriber(ctx context.Context, podcastID, name string) error {
+	p, ok := a.config.Podcasts[podcastID]
+	if !ok {
+		return fmt.Errorf("unknown podcast %s", podcastID)
+	}
+	return a.processor.RevokeSubscriber(ctx, podcastID, p.Folder, name)
+}
+
+// publishSubscriberFeed regenerates the feeds of the podcast and returns the feed URL of the subscriber.
+func (a *App) publishSubscriberFeed(ctx context.Context, podcastID, name string) (string, error) {
+	if err := a.GenerateFeed(ctx, podcastID, a.GetPodcastImages(ctx, podcastID)); err != nil {
+		return "", err
+	}
+	url, ok := a.GetFeedURLs(podcastID)[podcastID+"/"+name]
+	if !ok {
+		return "", fmt.Errorf("no feed URL of subscriber %s of %s", name, podcastID)
 	}
+	return url, nil
 }
 
 func (a *App) filterPodcastsByPodcastIDs(podcastIDs string) map[string]configs.Podcast {
@@ -1,10 +1,18 @@
 package podgen
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 	"podgen/internal/app/podgen/proc"
 	"podgen/internal/configs"
 )
@@ -39,3 +47,72 @@ func TestApp_FindPodcasts(t *testing.T) {
 	assert.Contains(t, podcasts, "podcast1")
 	assert.Contains(t, podcasts, "podcast2")
 }
+
+func TestNewURLTemplate(t *testing.T) {
+	conf := &configs.Conf{}
+	conf.CloudStorage.EndPointURL, conf.CloudStorage.Bucket = "s3.example.com", "shows"
+	conf.CloudStorage.PublicBaseURL = "https://cdn.example.com"
+	assert.Equal(t, "https://cdn.example.com/news/ep.mp3", NewURLTemplate(conf.CloudStorage).URL("news/ep.mp3"))
+
+	client := configs.Podcast{Folder: "client", CloudStorage: configs.StorageOverride{Bucket: "client-bucket", KeyPrefix: "podcasts"}}
+	assert.Equal(t, "https://s3.example.com/client-bucket/podcasts/client/ep.mp3",
+		NewURLTemplate(conf.GetPodcastCloudStorage(client)).URL("client/ep.mp3"))
+}
+
+func TestNewSSHClientConfig(t *testing.T) {
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	fingerprint := ssh.FingerprintSHA256(hostSigner.PublicKey())
+
+	_, userKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(userKey, "", []byte("pass"))
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600))
+
+	t.Run("host key fingerprint", func(t *testing.T) {
+		conf, err := NewSSHClientConfig(configs.SFTPConfig{Username: "podgen", Password: "secret", HostKey: fingerprint})
+		require.NoError(t, err)
+		assert.Equal(t, "podgen", conf.User)
+		assert.Len(t, conf.Auth, 1)
+		require.NoError(t, conf.HostKeyCallback("host:22", nil, hostSigner.PublicKey()))
+
+		conf2, err := NewSSHClientConfig(configs.SFTPConfig{Username: "podgen", Password: "secret", HostKey: "SHA256:other"})
+		require.NoError(t, err)
+		assert.ErrorContains(t, conf2.HostKeyCallback("host:22", nil, hostSigner.PublicKey()), "host key mismatch")
+	})
+
+	t.Run("encrypted private key", func(t *testing.T) {
+		conf, err := NewSSHClientConfig(configs.SFTPConfig{Username: "podgen", PrivateKey: keyPath, Passphrase: "pass",
+			Password: "secret", HostKey: fingerprint})
+		require.NoError(t, err)
+		assert.Len(t, conf.Auth, 2)
+
+		_, err = NewSSHClientConfig(configs.SFTPConfig{Username: "podgen", PrivateKey: keyPath, Passphrase: "wrong", HostKey: fingerprint})
+		assert.ErrorContains(t, err, "can't parse private key")
+	})
+
+	t.Run("known hosts", func(t *testing.T) {
+		knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+		line := knownhosts.Line([]string{knownhosts.Normalize("host.example.com:22")}, hostSigner.PublicKey())
+		require.NoError(t, os.WriteFile(knownHosts, []byte(line+"\n"), 0o600))
+
+		conf, err := NewSSHClientConfig(configs.SFTPConfig{Username: "podgen", Password: "secret", KnownHosts: knownHosts})
+		require.NoError(t, err)
+		addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+		require.NoError(t, conf.HostKeyCallback("host.example.com:22", addr, hostSigner.PublicKey()))
+		assert.Error(t, conf.HostKeyCallback("other.example.com:22", addr, hostSigner.PublicKey()))
+
+		_, err = NewSSHClientConfig(configs.SFTPConfig{Username: "podgen", Password: "secret", KnownHosts: knownHosts + ".missing"})
+		assert.ErrorContains(t, err, "can't read known hosts")
+	})
+}
+
+func TestSFTPAddress(t *testing.T) {
+	assert.Equal(t, "host.example.com:22", SFTPAddress("host.example.com"))
+	assert.Equal(t, "host.example.com:2222", SFTPAddress("host.example.com:2222"))
+	assert.Equal(t, "[::1]:22", SFTPAddress("::1"))
+}
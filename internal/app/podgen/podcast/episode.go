@@ -1,6 +1,12 @@
 // Package podcast for work with podcast's episodes
 package podcast
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Status of episode
 type Status int
 
@@ -13,6 +19,34 @@ const (
 	Deleted
 )
 
+// String returns the lowercase name of the status
+func (s Status) String() string {
+	switch s {
+	case New:
+		return "new"
+	case Uploaded:
+		return "uploaded"
+	case Deleted:
+		return "deleted"
+	default:
+		return fmt.Sprintf("status(%d)", int(s))
+	}
+}
+
+// ParseStatus converts a status name (new, uploaded, deleted) to Status
+func ParseStatus(s string) (Status, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "new":
+		return New, nil
+	case "uploaded":
+		return Uploaded, nil
+	case "deleted":
+		return Deleted, nil
+	default:
+		return 0, fmt.Errorf("unknown episode status %q: valid statuses are new, uploaded, deleted", s)
+	}
+}
+
 // Episode of podcast
 type Episode struct {
 	Filename string
@@ -27,4 +61,21 @@ type Episode struct {
 	Year     string
 	Comment  string
 	Duration string
+	// Checksum is the hex SHA-256 of the file as uploaded, empty until the episode is uploaded.
+	Checksum string
+	// Key is the object name the episode was uploaded to, computed by the key template of the podcast.
+	// Empty for episodes not uploaded yet and for episodes uploaded to <folder>/<filename> before key templates.
+	Key string
+}
+
+// PublishedAt parses PubDate (RFC1123Z) and returns zero time if it's empty or malformed
+func (e *Episode) PublishedAt() time.Time {
+	if e.PubDate == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC1123Z, e.PubDate)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
@@ -0,0 +1,14 @@
+package podcast
+
+import "time"
+
+// Podcast is the state of a podcast kept between runs.
+// Settings such as title and folder live in config, this record tracks what podgen did.
+type Podcast struct {
+	ID            string
+	LastScanAt    time.Time
+	LastPublishAt time.Time
+	ImageURL      string
+	FeedURL       string
+	FeedHash      string
+}
@@ -0,0 +1,53 @@
+package proc
+
+import (
+	"crypto/md5" //nolint:gosec // MD5 is what S3 uses for ETags and Content-MD5, not a security measure
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// checksumMetadata is the user metadata key holding the hex SHA-256 of uploaded objects.
+const checksumMetadata = "Sha256"
+
+// Checksums holds digests of a local file, used to verify objects in object storage.
+type Checksums struct {
+	// SHA256 is the hex SHA-256, stored on episodes and in object metadata.
+	SHA256 string
+	// MD5 is the hex MD5, the ETag of objects uploaded in a single part.
+	MD5 string
+}
+
+// FileChecksums reads the file once and returns its digests.
+func FileChecksums(path string) (Checksums, error) {
+	f, err := os.Open(path) //nolint:gosec // paths are built from config
+	if err != nil {
+		return Checksums{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	sha, sum := sha256.New(), md5.New() //nolint:gosec // see import
+	if _, err := io.Copy(io.MultiWriter(sha, sum), f); err != nil {
+		return Checksums{}, err
+	}
+	return Checksums{SHA256: hex.EncodeToString(sha.Sum(nil)), MD5: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// Matches reports whether the object is known to hold the content with these digests.
+// The SHA-256 recorded in object metadata is compared first. Objects uploaded without it are compared by ETag,
+// which is the MD5 of the content only for single part uploads. Objects that can't be verified don't match.
+func (c Checksums) Matches(info *ObjectInfo) bool {
+	if info == nil {
+		return false
+	}
+	if info.SHA256 != "" {
+		return strings.EqualFold(info.SHA256, c.SHA256)
+	}
+	etag := strings.Trim(info.ETag, `"`)
+	if etag == "" || strings.Contains(etag, "-") {
+		return false
+	}
+	return strings.EqualFold(etag, c.MD5)
+}
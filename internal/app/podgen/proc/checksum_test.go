@@ -0,0 +1,48 @@
+package proc_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/proc"
+)
+
+func TestFileChecksums(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+
+	sums, err := proc.FileChecksums(path)
+	require.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", sums.SHA256)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", sums.MD5)
+
+	_, err = proc.FileChecksums(filepath.Join(t.TempDir(), "missing.mp3"))
+	require.Error(t, err)
+}
+
+func TestChecksums_Matches(t *testing.T) {
+	sums := proc.Checksums{SHA256: "abc123", MD5: "5d41402abc4b2a76b9719d911017c592"}
+
+	tests := []struct {
+		name string
+		info *proc.ObjectInfo
+		want bool
+	}{
+		{name: "no object", info: nil},
+		{name: "metadata checksum matches", info: &proc.ObjectInfo{SHA256: "ABC123", ETag: "whatever-2"}, want: true},
+		{name: "metadata checksum differs", info: &proc.ObjectInfo{SHA256: "def456", ETag: sums.MD5}},
+		{name: "single part etag matches", info: &proc.ObjectInfo{ETag: `"5d41402abc4b2a76b9719d911017c592"`}, want: true},
+		{name: "single part etag differs", info: &proc.ObjectInfo{ETag: "00000000000000000000000000000000"}},
+		{name: "multipart etag can't be verified", info: &proc.ObjectInfo{ETag: "5d41402abc4b2a76b9719d911017c592-3"}},
+		{name: "nothing to compare", info: &proc.ObjectInfo{Size: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sums.Matches(tt.info))
+		})
+	}
+}
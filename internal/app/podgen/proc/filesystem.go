@@ -0,0 +1,218 @@
+package proc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"podgen/internal/pkg/ratelimit"
+)
+
+// tempSuffix marks files being written by FilesystemStore, renamed into place once complete.
+const tempSuffix = ".podgen-tmp"
+
+// FilesystemStore publishes objects to a local or mounted directory served by a web server,
+// such as an nginx docroot or an NFS share. Object names are paths relative to Root.
+type FilesystemStore struct {
+	// Root is the directory objects are written to.
+	Root string
+	// BaseURL is the public URL Root is served at, object locations are built from it.
+	BaseURL string
+	// Limiter limits the combined bandwidth of all uploads. Nil doesn't limit.
+	Limiter *ratelimit.Bucket
+}
+
+// DeleteEpisode removes the file, missing files are ignored.
+func (s *FilesystemStore) DeleteEpisode(_ context.Context, objectName string) error {
+	target, err := s.path(objectName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("can't delete %s: %w", objectName, err)
+	}
+	return nil
+}
+
+// UploadEpisode copies the episode to the directory
+func (s *FilesystemStore) UploadEpisode(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.copyFile(ctx, objectName, filePath, nil)
+}
+
+// UploadEpisodeWithProgress copies the episode to the directory with progress callback
+func (s *FilesystemStore) UploadEpisodeWithProgress(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	return s.copyFile(ctx, objectName, filePath, progress)
+}
+
+// UploadImage copies the image to the directory
+func (s *FilesystemStore) UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.copyFile(ctx, objectName, filePath, nil)
+}
+
+// UploadFeed copies the feed to the directory
+func (s *FilesystemStore) UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.copyFile(ctx, objectName, filePath, nil)
+}
+
+// copyFile writes the file to a temporary file next to the target and renames it into place,
+// so the web server never serves a partial file.
+func (s *FilesystemStore) copyFile(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	target, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := os.Open(filePath) //nolint:gosec // filePath comes from internal code, not user input
+	if err != nil {
+		return nil, fmt.Errorf("can't open file %s: %w", filePath, err)
+	}
+	defer func() { _ = src.Close() }()
+
+	stat, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("can't stat file %s: %w", filePath, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil { //nolint:gosec // published files are public
+		return nil, fmt.Errorf("can't create directory for %s: %w", objectName, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*"+tempSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("can't create file for %s: %w", objectName, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // no-op after rename
+
+	sum := sha256.New()
+	reader := ratelimit.Reader(ctx, &progressReader{reader: src, total: stat.Size(), callback: progress}, s.Limiter)
+	if _, err := io.Copy(io.MultiWriter(tmp, sum), &contextReader{ctx: ctx, reader: reader}); err != nil {
+		_ = tmp.Close()
+		return nil, fmt.Errorf("can't write %s: %w", objectName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return nil, fmt.Errorf("can't write %s: %w", objectName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("can't write %s: %w", objectName, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil { //nolint:gosec // published files are public
+		return nil, fmt.Errorf("can't set mode of %s: %w", objectName, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, fmt.Errorf("can't move %s into place: %w", objectName, err)
+	}
+
+	return &UploadResult{Location: s.objectLocation(objectName), Checksum: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// GetObjectInfo of the file. SHA256 is computed from the content, as there is no metadata to record it in.
+func (s *FilesystemStore) GetObjectInfo(_ context.Context, objectName string) (*ObjectInfo, error) {
+	target, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", objectName)
+	}
+	sums, err := FileChecksums(target)
+	if err != nil {
+		return nil, fmt.Errorf("can't compute checksum of %s: %w", objectName, err)
+	}
+	return &ObjectInfo{Key: objectName, Location: s.objectLocation(objectName), Size: stat.Size(), SHA256: sums.SHA256}, nil
+}
+
+// List files whose names start with prefix, including nested ones
+func (s *FilesystemStore) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	// walk the deepest directory covering the prefix only
+	dir := path.Dir(prefix)
+	if strings.HasSuffix(prefix, "/") {
+		dir = strings.TrimSuffix(prefix, "/")
+	}
+	start, err := s.path(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ObjectInfo
+	err = filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(d.Name(), tempSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		result = append(result, ObjectInfo{Key: key, Location: s.objectLocation(key), Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't list files %s in %s: %w", prefix, s.Root, err)
+	}
+	return result, nil
+}
+
+// path returns the file of the object, rejecting names that escape Root.
+func (s *FilesystemStore) path(objectName string) (string, error) {
+	name, err := cleanObjectName(objectName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(name)), nil
+}
+
+func (s *FilesystemStore) objectLocation(key string) string {
+	return publicLocation(s.BaseURL, key)
+}
+
+// cleanObjectName returns the object name as a clean slash-separated relative path,
+// rejecting names that would escape the directory objects are published to.
+func cleanObjectName(objectName string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(objectName)) || strings.Contains(objectName, `\`) {
+		return "", fmt.Errorf("invalid object name %q", objectName)
+	}
+	return path.Clean(objectName), nil
+}
+
+// publicLocation returns the public URL of the object published under baseURL.
+func publicLocation(baseURL, key string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimRight(baseURL, "/"), key)
+}
+
+// contextReader stops reading once ctx is done, so a cancelled run doesn't finish copying large files.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,152 @@
+package proc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+	"podgen/internal/storage/factory"
+)
+
+func TestFilesystemStore(t *testing.T) {
+	root := t.TempDir()
+	s := &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com/"}
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	content := []byte("episode content")
+	require.NoError(t, os.WriteFile(src, content, 0o600))
+	sum := sha256.Sum256(content)
+
+	var reported []int64
+	result, err := s.UploadEpisodeWithProgress(ctx, "demo/ep.mp3", src, func(uploaded, total int64) {
+		reported = append(reported, uploaded)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://podcasts.example.com/demo/ep.mp3", result.Location)
+	assert.Equal(t, hex.EncodeToString(sum[:]), result.Checksum)
+	assert.Equal(t, []int64{int64(len(content))}, reported)
+
+	published, err := os.ReadFile(filepath.Join(root, "demo", "ep.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, content, published)
+	stat, err := os.Stat(filepath.Join(root, "demo", "ep.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), stat.Mode().Perm(), "published files are readable by the web server")
+
+	info, err := s.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), info.Size)
+	assert.Equal(t, result.Checksum, info.SHA256)
+	sums, err := proc.FileChecksums(src)
+	require.NoError(t, err)
+	assert.True(t, sums.Matches(info))
+
+	_, err = s.UploadFeed(ctx, "demo/feed.rss", src)
+	require.NoError(t, err)
+	_, err = s.UploadImage(ctx, "other/podcast.png", src)
+	require.NoError(t, err)
+	objects, err := s.List(ctx, "demo/")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	assert.ElementsMatch(t, []string{"demo/ep.mp3", "demo/feed.rss"}, keys)
+
+	objects, err = s.List(ctx, "missing/")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	require.NoError(t, s.DeleteEpisode(ctx, "demo/ep.mp3"))
+	require.NoError(t, s.DeleteEpisode(ctx, "demo/ep.mp3"), "missing files are ignored")
+	_, err = s.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFilesystemStore_InvalidName(t *testing.T) {
+	s := &proc.FilesystemStore{Root: t.TempDir(), BaseURL: "https://podcasts.example.com"}
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+
+	for _, name := range []string{"../ep.mp3", "/etc/ep.mp3", "demo/../../ep.mp3"} {
+		_, err := s.UploadEpisode(context.Background(), name, src)
+		assert.ErrorContains(t, err, "invalid object name", "name=%s", name)
+		assert.ErrorContains(t, s.DeleteEpisode(context.Background(), name), "invalid object name", "name=%s", name)
+	}
+}
+
+func TestFilesystemStore_Cancel(t *testing.T) {
+	root := t.TempDir()
+	s := &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"}
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.UploadEpisode(ctx, "demo/ep.mp3", src)
+	require.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(filepath.Join(root, "demo"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "temporary file is removed")
+}
+
+// TestFilesystemStore_EndToEnd scans, uploads and publishes the feed of a podcast with a real database
+// and the filesystem backend, the way a run with cloud_storage.type filesystem does.
+func TestFilesystemStore_EndToEnd(t *testing.T) {
+	storagePath, root := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	p := &proc.Processor{
+		Storage:     store,
+		Podcasts:    store,
+		History:     store,
+		Sessions:    store,
+		S3Client:    &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"},
+		Files:       &proc.Files{Storage: storagePath},
+		StoragePath: storagePath,
+		ChunkSize:   2,
+	}
+	ctx := context.Background()
+
+	added, err := p.Update(ctx, "demo", "demo")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), added)
+	require.NoError(t, p.UploadNewEpisodes(ctx, "sess", "demo", "demo", 0))
+
+	episode, err := store.GetEpisodeByFilename("demo", "2024-01-15-ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, podcast.Uploaded, episode.Status)
+	assert.Equal(t, "https://podcasts.example.com/demo/2024-01-15-ep.mp3", episode.Location)
+
+	feedName, err := p.GenerateFeed(ctx, "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.NoError(t, err)
+	result, err := p.UploadFeed(ctx, "demo", "demo", feedName)
+	require.NoError(t, err)
+
+	feedURL, err := p.GetFeedURL("demo", "demo", proc.URLTemplate{BaseURL: "https://podcasts.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, feedURL, result.Location)
+
+	feed, err := os.ReadFile(filepath.Join(root, "demo", feedName))
+	require.NoError(t, err)
+	assert.Contains(t, string(feed), `<enclosure url="https://podcasts.example.com/demo/2024-01-15-ep.mp3"`)
+	published, err := os.ReadFile(filepath.Join(root, "demo", "2024-01-15-ep.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, "episode content", string(published))
+}
@@ -1,6 +1,10 @@
 package proc
 
 //go:generate moq -out mocks/episode_store_mock.go -pkg mocks . EpisodeStore
+//go:generate moq -out mocks/podcast_store_mock.go -pkg mocks . PodcastStore
+//go:generate moq -out mocks/history_store_mock.go -pkg mocks . HistoryStore
+//go:generate moq -out mocks/session_store_mock.go -pkg mocks . SessionStore
+//go:generate moq -out mocks/upload_store_mock.go -pkg mocks . UploadStore
 //go:generate moq -out mocks/object_storage_mock.go -pkg mocks . ObjectStorage
 //go:generate moq -out mocks/file_scanner_mock.go -pkg mocks . FileScanner
 //go:generate moq -out mocks/progress_reporter_mock.go -pkg mocks . ProgressReporter
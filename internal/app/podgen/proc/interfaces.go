@@ -2,6 +2,7 @@ package proc
 
 import (
 	"context"
+	"time"
 
 	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/storage"
@@ -10,18 +11,45 @@ import (
 // UploadResult holds the result of an S3 upload operation.
 type UploadResult struct {
 	Location string
+	// Checksum is the hex SHA-256 of the uploaded file, empty if the storage doesn't compute it.
+	Checksum string
 }
 
 // ObjectInfo holds metadata about an S3 object.
 type ObjectInfo struct {
+	Key      string
 	Location string
 	Size     int64
+	ETag     string
+	// SHA256 is the hex SHA-256 recorded in object metadata on upload, empty for objects uploaded without it.
+	SHA256 string
 }
 
 // EpisodeStore is an alias for storage.EpisodeStore for backward compatibility.
 // New code should use storage.EpisodeStore directly.
 type EpisodeStore = storage.EpisodeStore
 
+// PodcastStore is an alias for storage.PodcastStore.
+type PodcastStore = storage.PodcastStore
+
+// HistoryStore is an alias for storage.HistoryStore.
+type HistoryStore = storage.HistoryStore
+
+// SessionStore is an alias for storage.SessionStore.
+type SessionStore = storage.SessionStore
+
+// UploadStore is an alias for storage.UploadStore.
+type UploadStore = storage.UploadStore
+
+// ReplicaStore is an alias for storage.ReplicaStore.
+type ReplicaStore = storage.ReplicaStore
+
+// SubscriberStore is an alias for storage.SubscriberStore.
+type SubscriberStore = storage.SubscriberStore
+
+// RunStore is an alias for storage.RunStore.
+type RunStore = storage.RunStore
+
 // ObjectStorage defines the interface for S3-compatible object storage operations.
 type ObjectStorage interface {
 	DeleteEpisode(ctx context.Context, objectName string) error
@@ -30,6 +58,29 @@ type ObjectStorage interface {
 	UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error)
 	UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error)
 	GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error)
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// UploadCleaner is implemented by object storages that resume interrupted uploads.
+// AbortStaleUploads aborts incomplete uploads that won't be resumed and returns their number.
+type UploadCleaner interface {
+	AbortStaleUploads(ctx context.Context) (int, error)
+}
+
+// LocationResolver is implemented by object storages publishing to several destinations.
+// Feeds link to the destination that serves, which changes when a destination is marked down.
+type LocationResolver interface {
+	// PublicLocation returns the URL feeds link the object at, location is the URL recorded on upload.
+	PublicLocation(objectName, location string) string
+	// ServingDestination returns the name of the destination feeds link to.
+	ServingDestination() string
+	// SetPrimaryLocation records the URL of the object at the primary destination, after its public URL changed.
+	SetPrimaryLocation(objectName, location string) error
+}
+
+// Presigner is implemented by object storages signing time-limited URLs of objects, used by private feeds.
+type Presigner interface {
+	PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error)
 }
 
 // FileScanner defines the interface for scanning podcast episode files.
@@ -42,6 +93,7 @@ type ProgressReporter interface {
 	StartFile(workerID int, filename string, totalSize int64)
 	UpdateProgress(workerID int, uploaded, total int64)
 	CompleteFile(workerID int, fileSize int64, err error)
+	RetryFile(workerID, attempt int, err error)
 	Finish()
 	Reset(totalTasks int)
 }
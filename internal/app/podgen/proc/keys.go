@@ -0,0 +1,82 @@
+package proc
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/configs"
+)
+
+// maxSlugLength caps the length of {slug} in runes, titles can be long.
+const maxSlugLength = 80
+
+// KeyTemplate is the layout of episode object names, such as {podcast}/{yyyy}/{mm}/{slug}-{hash8}.{ext},
+// see configs.KeyPlaceholders. The empty template is configs.DefaultKeyTemplate.
+type KeyTemplate string
+
+// Key returns the object name of the episode. sha256 is the hex SHA-256 of the file, used by {hash} and {hash8}.
+// The publication date falls back to the current date for episodes without one.
+func (t KeyTemplate) Key(podcastFolder string, episode *podcast.Episode, sha256 string) string {
+	template := string(t)
+	if template == "" {
+		template = configs.DefaultKeyTemplate
+	}
+
+	ext := path.Ext(episode.Filename)
+	name := strings.TrimSuffix(episode.Filename, ext)
+	date := episode.PublishedAt()
+	if date.IsZero() {
+		date = time.Now()
+	}
+	slug := Slugify(episode.Title)
+	if slug == "" {
+		slug = Slugify(name)
+	}
+
+	return strings.NewReplacer(
+		"{podcast}", podcastFolder,
+		"{filename}", episode.Filename,
+		"{name}", name,
+		"{ext}", strings.TrimPrefix(ext, "."),
+		"{yyyy}", fmt.Sprintf("%04d", date.Year()),
+		"{mm}", fmt.Sprintf("%02d", int(date.Month())),
+		"{dd}", fmt.Sprintf("%02d", date.Day()),
+		"{slug}", slug,
+		"{hash}", sha256,
+		"{hash8}", sha256[:min(8, len(sha256))],
+	).Replace(template)
+}
+
+// NeedsHash returns true if keys depend on the content of the file.
+func (t KeyTemplate) NeedsHash() bool {
+	return strings.Contains(string(t), "{hash")
+}
+
+// Slugify lowercases s and joins its runs of letters and digits with dashes, "Episode 1: Go!" becomes "episode-1-go".
+// Letters of any script are kept.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	n := 0
+	for _, r := range strings.ToLower(s) {
+		if n >= maxSlugLength {
+			break
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			n++
+			dash = false
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,128 @@
+package proc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+	"podgen/internal/storage/factory"
+)
+
+func TestKeyTemplate_Key(t *testing.T) {
+	episode := &podcast.Episode{Filename: "ep01.mp3", Title: "Episode 1: Hello, World!", PubDate: "Mon, 15 Jan 2024 10:00:00 +0000"}
+	sum := "d2a84f4b8b650937ec8f73cd8be2c74add5a911ba64df27458ed8229da804a26"
+
+	tests := []struct {
+		template proc.KeyTemplate
+		expected string
+	}{
+		{"", "news/ep01.mp3"},
+		{configs.DefaultKeyTemplate, "news/ep01.mp3"},
+		{"{podcast}/{yyyy}/{mm}/{slug}-{hash8}.{ext}", "news/2024/01/episode-1-hello-world-d2a84f4b.mp3"},
+		{"{podcast}/{yyyy}-{mm}-{dd}/{name}.{ext}", "news/2024-01-15/ep01.mp3"},
+		{"{podcast}/{hash}.{ext}", "news/" + sum + ".mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.template), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.template.Key("news", episode, sum))
+		})
+	}
+
+	untitled := &podcast.Episode{Filename: "Interview_Part 2.mp3"}
+	assert.Equal(t, "news/interview-part-2.mp3", proc.KeyTemplate("{podcast}/{slug}.{ext}").Key("news", untitled, ""),
+		"episodes without a title are slugified by file name")
+}
+
+func TestKeyTemplate_NeedsHash(t *testing.T) {
+	assert.False(t, proc.KeyTemplate(configs.DefaultKeyTemplate).NeedsHash())
+	assert.True(t, proc.KeyTemplate("{podcast}/{slug}-{hash8}.{ext}").NeedsHash())
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Episode 1: Go!":        "episode-1-go",
+		"  --Hello   World--  ": "hello-world",
+		"Выпуск №5":             "выпуск-5",
+		"!!!":                   "",
+	}
+	for title, expected := range tests {
+		assert.Equal(t, expected, proc.Slugify(title), title)
+	}
+	assert.Len(t, proc.Slugify(strings.Repeat("a", 200)), 80, "long titles are cut")
+}
+
+// TestProcessor_KeyTemplate uploads an episode under a key template, then changes the template
+// and checks the episode keeps its object.
+func TestProcessor_KeyTemplate(t *testing.T) {
+	storagePath, root := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+	sum := sha256.Sum256([]byte("episode content"))
+	key := "demo/2024/01/2024-01-15-ep-" + hex.EncodeToString(sum[:])[:8] + ".mp3"
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	p := &proc.Processor{
+		Storage:      store,
+		Podcasts:     store,
+		History:      store,
+		Sessions:     store,
+		S3Client:     &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"},
+		KeyTemplates: map[string]proc.KeyTemplate{"demo": "{podcast}/{yyyy}/{mm}/{slug}-{hash8}.{ext}"},
+		Files:        &proc.Files{Storage: storagePath},
+		StoragePath:  storagePath,
+		ChunkSize:    2,
+	}
+	ctx := context.Background()
+
+	_, err = p.Update(ctx, "demo", "demo")
+	require.NoError(t, err)
+	require.NoError(t, p.UploadNewEpisodes(ctx, "sess", "demo", "demo", 0))
+
+	episode, err := store.GetEpisodeByFilename("demo", "2024-01-15-ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, key, episode.Key)
+	assert.Equal(t, "https://podcasts.example.com/"+key, episode.Location)
+	_, err = os.Stat(filepath.Join(root, filepath.FromSlash(key)))
+	require.NoError(t, err)
+
+	// the stored key outlives the template
+	p.KeyTemplates = nil
+	feedName, err := p.GenerateFeed(ctx, "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.NoError(t, err)
+	feed, err := os.ReadFile(filepath.Join(storagePath, "demo", feedName))
+	require.NoError(t, err)
+	assert.Contains(t, string(feed), `<enclosure url="https://podcasts.example.com/`+key+`"`)
+
+	report, err := p.Reconcile(ctx, "demo", "demo", "podcast.png")
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings)
+
+	require.NoError(t, p.DeleteOldEpisodesByPodcast(ctx, "sess2", "demo", "demo"))
+	_, err = os.Stat(filepath.Join(root, filepath.FromSlash(key)))
+	assert.ErrorIs(t, err, os.ErrNotExist, "the object at the stored key is deleted")
+
+	// rollback restores the episode at the stored key, not at the one of the current template
+	require.NoError(t, p.RollbackEpisodesOfSession(ctx, "sess3", "demo", "demo", "sess2"))
+	episode, err = store.GetEpisodeByFilename("demo", "2024-01-15-ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, podcast.Uploaded, episode.Status)
+	assert.Equal(t, key, episode.Key)
+	assert.Equal(t, "https://podcasts.example.com/"+key, episode.Location)
+	_, err = os.Stat(filepath.Join(root, filepath.FromSlash(key)))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(root, "demo", "2024-01-15-ep.mp3"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,57 @@
+package proc
+
+import (
+	"fmt"
+	"strings"
+
+	"podgen/internal/configs"
+)
+
+// URLTemplate builds the public URLs of objects, episode enclosures, artwork and feeds, from their keys.
+type URLTemplate struct {
+	// BaseURL is the URL objects are served at, such as a CDN or a custom domain in front of the bucket.
+	// When set, the URL of an object is BaseURL/key and the other fields are ignored.
+	BaseURL string
+	// Endpoint is the URL of the storage, https is assumed if it has no scheme.
+	Endpoint string
+	// Bucket is left out of URLs if empty, for storages serving objects at Endpoint directly.
+	Bucket string
+	// Style is configs.URLStylePath (default) or configs.URLStyleVirtualHost.
+	Style string
+	// Prefix is the key prefix of the storage, prepended to keys.
+	Prefix string
+}
+
+// URL returns the public URL of the object.
+func (t URLTemplate) URL(key string) string {
+	key = prefixedKey(t.Prefix, key)
+	if t.BaseURL != "" {
+		return publicLocation(t.BaseURL, key)
+	}
+
+	// preserve the scheme of the endpoint, default to https if it has none
+	scheme, host := "https", t.Endpoint
+	if after, ok := strings.CutPrefix(host, "https://"); ok {
+		host = after
+	} else if after, ok := strings.CutPrefix(host, "http://"); ok {
+		scheme, host = "http", after
+	}
+	host = strings.TrimRight(host, "/")
+	switch {
+	case t.Bucket == "":
+	case t.Style == configs.URLStyleVirtualHost:
+		host = t.Bucket + "." + host
+	default:
+		host += "/" + t.Bucket
+	}
+	return fmt.Sprintf("%s://%s/%s", scheme, host, key)
+}
+
+// prefixedKey returns the key of the object under prefix, the prefix is separated by a slash.
+func prefixedKey(prefix, key string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
@@ -0,0 +1,285 @@
+package proc
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/storage"
+)
+
+// Destination is a named object storage objects are published to.
+type Destination struct {
+	Name    string
+	Storage ObjectStorage
+}
+
+// MirrorStorage is an ObjectStorage publishing every object to the primary destination and all mirrors.
+// Feeds link to the serving destination, the primary unless it is marked down, otherwise the first mirror that is up.
+// Writes return the result of the serving destination, failures at the other destinations are logged and recorded
+// in State, so they don't fail the episode.
+type MirrorStorage struct {
+	Primary Destination
+	Mirrors []Destination
+	// State records the replica of every written object at every destination and which destinations are down.
+	State ReplicaStore
+}
+
+// DeleteEpisode from all destinations
+func (m *MirrorStorage) DeleteEpisode(ctx context.Context, objectName string) error {
+	_, err := m.write(ctx, objectName, nil, func(d Destination, _ ProgressFunc) (*UploadResult, error) {
+		return nil, d.Storage.DeleteEpisode(ctx, objectName)
+	})
+	return err
+}
+
+// UploadEpisode to all destinations
+func (m *MirrorStorage) UploadEpisode(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return m.write(ctx, objectName, nil, func(d Destination, _ ProgressFunc) (*UploadResult, error) {
+		return d.Storage.UploadEpisode(ctx, objectName, filePath)
+	})
+}
+
+// UploadEpisodeWithProgress uploads to all destinations, progress tracks the upload to the serving destination
+func (m *MirrorStorage) UploadEpisodeWithProgress(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	return m.write(ctx, objectName, progress, func(d Destination, progress ProgressFunc) (*UploadResult, error) {
+		if progress == nil {
+			return d.Storage.UploadEpisode(ctx, objectName, filePath)
+		}
+		return d.Storage.UploadEpisodeWithProgress(ctx, objectName, filePath, progress)
+	})
+}
+
+// UploadImage to all destinations
+func (m *MirrorStorage) UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return m.write(ctx, objectName, nil, func(d Destination, _ ProgressFunc) (*UploadResult, error) {
+		return d.Storage.UploadImage(ctx, objectName, filePath)
+	})
+}
+
+// UploadFeed to all destinations
+func (m *MirrorStorage) UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return m.write(ctx, objectName, nil, func(d Destination, _ ProgressFunc) (*UploadResult, error) {
+		return d.Storage.UploadFeed(ctx, objectName, filePath)
+	})
+}
+
+// GetObjectInfo from the serving destination. An object that isn't recorded as uploaded to every destination
+// is reported missing, so the next upload copies it to the destinations lacking it.
+func (m *MirrorStorage) GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	destinations, err := m.destinations()
+	if err != nil {
+		return nil, err
+	}
+	info, err := destinations[0].Storage.GetObjectInfo(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas, err := m.State.GetReplicas(objectName)
+	if err != nil {
+		return nil, fmt.Errorf("can't get replicas of %s: %w", objectName, err)
+	}
+	uploaded := make(map[string]bool, len(replicas))
+	for _, r := range replicas {
+		uploaded[r.Destination] = r.Status == storage.ReplicaUploaded
+	}
+	for _, d := range destinations[1:] {
+		if !uploaded[d.Name] {
+			return nil, fmt.Errorf("%s is missing at %s: %w", objectName, d.Name, fs.ErrNotExist)
+		}
+	}
+	return info, nil
+}
+
+// List objects of the serving destination
+func (m *MirrorStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	destinations, err := m.destinations()
+	if err != nil {
+		return nil, err
+	}
+	return destinations[0].Storage.List(ctx, prefix)
+}
+
+// AbortStaleUploads of every destination resuming uploads
+func (m *MirrorStorage) AbortStaleUploads(ctx context.Context) (int, error) {
+	aborted := 0
+	for _, d := range append([]Destination{m.Primary}, m.Mirrors...) {
+		cleaner, ok := d.Storage.(UploadCleaner)
+		if !ok {
+			continue
+		}
+		n, err := cleaner.AbortStaleUploads(ctx)
+		aborted += n
+		if err != nil {
+			return aborted, fmt.Errorf("can't abort stale uploads at %s: %w", d.Name, err)
+		}
+	}
+	return aborted, nil
+}
+
+// PresignedURL of the object at the serving destination
+func (m *MirrorStorage) PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	destinations, err := m.destinations()
+	if err != nil {
+		return "", err
+	}
+	presigner, ok := destinations[0].Storage.(Presigner)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrPresignUnsupported, destinations[0].Name)
+	}
+	return presigner.PresignedURL(ctx, objectName, expiry)
+}
+
+// PublicLocation returns the URL of the object at the serving destination, or at the next one holding it.
+// Objects uploaded while the primary was down link to the primary again once it is up.
+// Location is returned for objects no destination is recorded to hold.
+func (m *MirrorStorage) PublicLocation(objectName, location string) string {
+	destinations, err := m.destinations()
+	if err != nil {
+		log.Printf("[WARN] can't get destination statuses, %v", err)
+		return location
+	}
+	replicas, err := m.State.GetReplicas(objectName)
+	if err != nil {
+		log.Printf("[WARN] can't get replicas of %s, %v", objectName, err)
+		return location
+	}
+	for _, d := range destinations {
+		for _, r := range replicas {
+			if r.Destination == d.Name && r.Status == storage.ReplicaUploaded && r.Location != "" {
+				return r.Location
+			}
+		}
+	}
+	return location
+}
+
+// ServingDestination returns the name of the destination feeds link to.
+func (m *MirrorStorage) ServingDestination() string {
+	destinations, err := m.destinations()
+	if err != nil {
+		log.Printf("[WARN] can't get destination statuses, %v", err)
+		return m.Primary.Name
+	}
+	return destinations[0].Name
+}
+
+// SetPrimaryLocation records the URL of the object at the primary destination, objects it doesn't hold are skipped.
+func (m *MirrorStorage) SetPrimaryLocation(objectName, location string) error {
+	replicas, err := m.State.GetReplicas(objectName)
+	if err != nil {
+		return fmt.Errorf("can't get replicas of %s: %w", objectName, err)
+	}
+	for _, r := range replicas {
+		if r.Destination != m.Primary.Name || r.Location == location {
+			continue
+		}
+		r.Location, r.UpdatedAt = location, time.Now()
+		if err := m.State.SaveReplica(r); err != nil {
+			return fmt.Errorf("can't save replica of %s: %w", objectName, err)
+		}
+	}
+	return nil
+}
+
+// write runs fn against every destination, the serving one first, and records the replicas.
+// Progress is reported for the serving destination only.
+func (m *MirrorStorage) write(ctx context.Context, objectName string, progress ProgressFunc,
+	fn func(d Destination, progress ProgressFunc) (*UploadResult, error)) (*UploadResult, error) {
+	destinations, err := m.destinations()
+	if err != nil {
+		return nil, err
+	}
+
+	var result *UploadResult
+	var resultErr error
+	for i, d := range destinations {
+		if i > 0 {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			progress = nil
+		}
+		res, err := fn(d, progress)
+		m.record(objectName, d.Name, res, err)
+		if i == 0 {
+			result, resultErr = res, err
+			continue
+		}
+		if err != nil {
+			log.Printf("[WARN] can't write %s to %s, %v", objectName, d.Name, err)
+		}
+	}
+	return result, resultErr
+}
+
+// record saves the state of the object at the destination after a write, res is nil for deletes.
+func (m *MirrorStorage) record(objectName, destination string, res *UploadResult, err error) {
+	replica := &storage.Replica{Object: objectName, Destination: destination, UpdatedAt: time.Now()}
+	switch {
+	case err != nil:
+		replica.Status, replica.Error = storage.ReplicaFailed, err.Error()
+	case res == nil:
+		replica.Status = storage.ReplicaDeleted
+	default:
+		replica.Status, replica.Location, replica.Checksum = storage.ReplicaUploaded, res.Location, res.Checksum
+	}
+	if err != nil || res == nil {
+		// keep the location of the last upload, the object may still be served from caches
+		if replicas, getErr := m.State.GetReplicas(objectName); getErr == nil {
+			for _, r := range replicas {
+				if r.Destination == destination {
+					replica.Location = r.Location
+				}
+			}
+		}
+	}
+	if saveErr := m.State.SaveReplica(replica); saveErr != nil {
+		log.Printf("[WARN] can't record replica of %s at %s, %v", objectName, destination, saveErr)
+	}
+}
+
+// destinations returns all destinations, the serving one first followed by the others in configured order.
+// Mirrors that are down go last, if every destination is down the primary serves.
+func (m *MirrorStorage) destinations() ([]Destination, error) {
+	statuses, err := m.State.ListDestinationStatuses()
+	if err != nil {
+		return nil, fmt.Errorf("can't get destination statuses: %w", err)
+	}
+	down := make(map[string]bool, len(statuses))
+	for _, s := range statuses {
+		down[s.Name] = s.Down
+	}
+
+	all := append([]Destination{m.Primary}, m.Mirrors...)
+	result := make([]Destination, 0, len(all))
+	for _, d := range all {
+		if !down[d.Name] {
+			result = append(result, d)
+		}
+	}
+	if len(result) == 0 || result[0].Name == m.Primary.Name {
+		return all, nil
+	}
+	for _, d := range all {
+		if down[d.Name] {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// SetDestinationDown marks the destination down or up, feeds link to another destination while it is down.
+func SetDestinationDown(state ReplicaStore, name string, down bool, reason string) error {
+	status := &storage.DestinationStatus{Name: name, Down: down, UpdatedAt: time.Now()}
+	if down {
+		status.Reason = reason
+	}
+	if err := state.SaveDestinationStatus(status); err != nil {
+		return fmt.Errorf("can't save status of %s: %w", name, err)
+	}
+	return nil
+}
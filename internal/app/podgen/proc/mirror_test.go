@@ -0,0 +1,209 @@
+package proc_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/proc/mocks"
+	"podgen/internal/configs"
+	"podgen/internal/storage"
+	"podgen/internal/storage/factory"
+)
+
+// newMirrorStorage returns a storage publishing to the directories primary and backup, recording state in a real database.
+func newMirrorStorage(t *testing.T) (m *proc.MirrorStorage, store storage.Store, primary, backup string) {
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	primary, backup = t.TempDir(), t.TempDir()
+	m = &proc.MirrorStorage{
+		Primary: proc.Destination{Name: configs.PrimaryDestination,
+			Storage: &proc.FilesystemStore{Root: primary, BaseURL: "https://cdn.example.com"}},
+		Mirrors: []proc.Destination{{Name: "backup",
+			Storage: &proc.FilesystemStore{Root: backup, BaseURL: "https://backup.example.com"}}},
+		State: store,
+	}
+	return m, store, primary, backup
+}
+
+func TestMirrorStorage(t *testing.T) {
+	m, store, primary, backup := newMirrorStorage(t)
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+
+	var reported []int64
+	result, err := m.UploadEpisodeWithProgress(ctx, "demo/ep.mp3", src, func(uploaded, total int64) {
+		reported = append(reported, uploaded)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/demo/ep.mp3", result.Location, "the primary serves")
+	assert.Equal(t, []int64{int64(len("episode content"))}, reported, "progress of the primary upload only")
+	for _, dir := range []string{primary, backup} {
+		published, err := os.ReadFile(filepath.Join(dir, "demo", "ep.mp3"))
+		require.NoError(t, err)
+		assert.Equal(t, "episode content", string(published))
+	}
+
+	replicas, err := store.GetReplicas("demo/ep.mp3")
+	require.NoError(t, err)
+	require.Len(t, replicas, 2)
+	assert.Equal(t, "backup", replicas[0].Destination)
+	assert.Equal(t, storage.ReplicaUploaded, replicas[0].Status)
+	assert.Equal(t, "https://backup.example.com/demo/ep.mp3", replicas[0].Location)
+	assert.Equal(t, configs.PrimaryDestination, replicas[1].Destination)
+	assert.Equal(t, result.Checksum, replicas[1].Checksum)
+
+	info, err := m.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/demo/ep.mp3", info.Location)
+	assert.Equal(t, "https://cdn.example.com/demo/ep.mp3", m.PublicLocation("demo/ep.mp3", result.Location))
+	assert.Equal(t, configs.PrimaryDestination, m.ServingDestination())
+
+	require.NoError(t, m.DeleteEpisode(ctx, "demo/ep.mp3"))
+	for _, dir := range []string{primary, backup} {
+		_, err := os.Stat(filepath.Join(dir, "demo", "ep.mp3"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	}
+	replicas, err = store.GetReplicas("demo/ep.mp3")
+	require.NoError(t, err)
+	for _, r := range replicas {
+		assert.Equal(t, storage.ReplicaDeleted, r.Status, r.Destination)
+		assert.NotEmpty(t, r.Location, "location of the deleted object is kept")
+	}
+}
+
+func TestMirrorStorage_MirrorFails(t *testing.T) {
+	m, store, primary, _ := newMirrorStorage(t)
+	m.Mirrors = append(m.Mirrors, proc.Destination{Name: "broken", Storage: &mocks.ObjectStorageMock{
+		UploadEpisodeFunc: func(context.Context, string, string) (*proc.UploadResult, error) {
+			return nil, assert.AnError
+		},
+	}})
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+
+	result, err := m.UploadEpisode(ctx, "demo/ep.mp3", src)
+	require.NoError(t, err, "a failing mirror doesn't fail the upload")
+	assert.Equal(t, "https://cdn.example.com/demo/ep.mp3", result.Location)
+	_, err = os.Stat(filepath.Join(primary, "demo", "ep.mp3"))
+	require.NoError(t, err)
+
+	replicas, err := store.GetReplicas("demo/ep.mp3")
+	require.NoError(t, err)
+	require.Len(t, replicas, 3)
+	assert.Equal(t, "broken", replicas[1].Destination)
+	assert.Equal(t, storage.ReplicaFailed, replicas[1].Status)
+	assert.Equal(t, assert.AnError.Error(), replicas[1].Error)
+
+	// the object is reported missing until every destination holds it, so the next run uploads it again
+	_, err = m.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	assert.ErrorContains(t, err, "broken")
+}
+
+func TestMirrorStorage_PrimaryDown(t *testing.T) {
+	m, store, primary, _ := newMirrorStorage(t)
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+	result, err := m.UploadEpisode(ctx, "demo/ep1.mp3", src)
+	require.NoError(t, err)
+
+	require.NoError(t, proc.SetDestinationDown(store, configs.PrimaryDestination, true, "cdn outage"))
+	assert.Equal(t, "backup", m.ServingDestination())
+	assert.Equal(t, "https://backup.example.com/demo/ep1.mp3", m.PublicLocation("demo/ep1.mp3", result.Location))
+	assert.Equal(t, "https://cdn.example.com/other.mp3", m.PublicLocation("other.mp3", "https://cdn.example.com/other.mp3"),
+		"objects without replicas keep their location")
+
+	// writes still reach the primary, but return the result of the serving mirror
+	result, err = m.UploadEpisode(ctx, "demo/ep2.mp3", src)
+	require.NoError(t, err)
+	assert.Equal(t, "https://backup.example.com/demo/ep2.mp3", result.Location)
+	_, err = os.Stat(filepath.Join(primary, "demo", "ep2.mp3"))
+	require.NoError(t, err)
+
+	objects, err := m.List(ctx, "demo/")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "https://backup.example.com/demo/ep1.mp3", objects[0].Location, "listed from the mirror")
+
+	// once up, the primary serves objects uploaded during the outage too
+	require.NoError(t, proc.SetDestinationDown(store, configs.PrimaryDestination, false, ""))
+	assert.Equal(t, configs.PrimaryDestination, m.ServingDestination())
+	assert.Equal(t, "https://cdn.example.com/demo/ep2.mp3", m.PublicLocation("demo/ep2.mp3", result.Location))
+
+	// with every destination down the primary serves
+	require.NoError(t, proc.SetDestinationDown(store, configs.PrimaryDestination, true, "cdn outage"))
+	require.NoError(t, proc.SetDestinationDown(store, "backup", true, "disk full"))
+	assert.Equal(t, configs.PrimaryDestination, m.ServingDestination())
+}
+
+func TestMirrorStorage_SetPrimaryLocation(t *testing.T) {
+	m, store, _, _ := newMirrorStorage(t)
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+	_, err := m.UploadEpisode(context.Background(), "demo/ep.mp3", src)
+	require.NoError(t, err)
+
+	require.NoError(t, m.SetPrimaryLocation("demo/ep.mp3", "https://new-cdn.example.com/demo/ep.mp3"))
+	require.NoError(t, m.SetPrimaryLocation("demo/unknown.mp3", "https://new-cdn.example.com/demo/unknown.mp3"))
+	assert.Equal(t, "https://new-cdn.example.com/demo/ep.mp3", m.PublicLocation("demo/ep.mp3", ""))
+
+	replicas, err := store.GetReplicas("demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "https://backup.example.com/demo/ep.mp3", replicas[0].Location, "mirrors keep their locations")
+	replicas, err = store.GetReplicas("demo/unknown.mp3")
+	require.NoError(t, err)
+	assert.Empty(t, replicas)
+}
+
+// TestMirrorStorage_Feed publishes a podcast to a mirrored storage and checks the feed links to the mirror
+// while the primary is down.
+func TestMirrorStorage_Feed(t *testing.T) {
+	m, store, _, backup := newMirrorStorage(t)
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+
+	p := &proc.Processor{
+		Storage:        store,
+		Podcasts:       store,
+		History:        store,
+		Sessions:       store,
+		S3Client:       &mocks.ObjectStorageMock{},
+		PodcastStorage: map[string]proc.ObjectStorage{"demo": m},
+		Files:          &proc.Files{Storage: storagePath},
+		StoragePath:    storagePath,
+		ChunkSize:      2,
+	}
+	ctx := context.Background()
+
+	_, err := p.Update(ctx, "demo", "demo")
+	require.NoError(t, err)
+	require.NoError(t, p.UploadNewEpisodes(ctx, "sess", "demo", "demo", 0))
+	assert.Equal(t, configs.PrimaryDestination, p.FeedDestination("demo"))
+
+	require.NoError(t, proc.SetDestinationDown(store, configs.PrimaryDestination, true, "cdn outage"))
+	assert.Equal(t, "backup", p.FeedDestination("demo"))
+	feedName, err := p.GenerateFeed(ctx, "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.NoError(t, err)
+	_, err = p.UploadFeed(ctx, "demo", "demo", feedName)
+	require.NoError(t, err)
+
+	feed, err := os.ReadFile(filepath.Join(backup, "demo", feedName))
+	require.NoError(t, err)
+	assert.Contains(t, string(feed), `<enclosure url="https://backup.example.com/demo/2024-01-15-ep.mp3"`)
+}
@@ -6,6 +6,7 @@ package mocks
 import (
 	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/app/podgen/proc"
+	"podgen/internal/storage"
 	"sync"
 )
 
@@ -19,6 +20,15 @@ var _ proc.EpisodeStore = &EpisodeStoreMock{}
 //
 //		// make and configure a mocked proc.EpisodeStore
 //		mockedEpisodeStore := &EpisodeStoreMock{
+//			ChangeStatusEpisodesFunc: func(podcastID string, fromStatus podcast.Status, toStatus podcast.Status) error {
+//				panic("mock out the ChangeStatusEpisodes method")
+//			},
+//			ChangeStatusEpisodesByFilterFunc: func(podcastID string, filter storage.EpisodeFilter, toStatus podcast.Status) (int, error) {
+//				panic("mock out the ChangeStatusEpisodesByFilter method")
+//			},
+//			DeleteEpisodesFunc: func(podcastID string, filenames []string) (int, error) {
+//				panic("mock out the DeleteEpisodes method")
+//			},
 //			FindEpisodesBySessionFunc: func(podcastID string, session string) ([]*podcast.Episode, error) {
 //				panic("mock out the FindEpisodesBySession method")
 //			},
@@ -34,9 +44,15 @@ var _ proc.EpisodeStore = &EpisodeStoreMock{}
 //			GetLastEpisodeByNotStatusFunc: func(podcastID string, status podcast.Status) (*podcast.Episode, error) {
 //				panic("mock out the GetLastEpisodeByNotStatus method")
 //			},
+//			QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+//				panic("mock out the QueryEpisodes method")
+//			},
 //			SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
 //				panic("mock out the SaveEpisode method")
 //			},
+//			SaveEpisodesFunc: func(podcastID string, episodes []*podcast.Episode) error {
+//				panic("mock out the SaveEpisodes method")
+//			},
 //		}
 //
 //		// use mockedEpisodeStore in code that requires proc.EpisodeStore
@@ -44,6 +60,15 @@ var _ proc.EpisodeStore = &EpisodeStoreMock{}
 //
 //	}
 type EpisodeStoreMock struct {
+	// ChangeStatusEpisodesFunc mocks the ChangeStatusEpisodes method.
+	ChangeStatusEpisodesFunc func(podcastID string, fromStatus podcast.Status, toStatus podcast.Status) error
+
+	// ChangeStatusEpisodesByFilterFunc mocks the ChangeStatusEpisodesByFilter method.
+	ChangeStatusEpisodesByFilterFunc func(podcastID string, filter storage.EpisodeFilter, toStatus podcast.Status) (int, error)
+
+	// DeleteEpisodesFunc mocks the DeleteEpisodes method.
+	DeleteEpisodesFunc func(podcastID string, filenames []string) (int, error)
+
 	// FindEpisodesBySessionFunc mocks the FindEpisodesBySession method.
 	FindEpisodesBySessionFunc func(podcastID string, session string) ([]*podcast.Episode, error)
 
@@ -59,11 +84,42 @@ type EpisodeStoreMock struct {
 	// GetLastEpisodeByNotStatusFunc mocks the GetLastEpisodeByNotStatus method.
 	GetLastEpisodeByNotStatusFunc func(podcastID string, status podcast.Status) (*podcast.Episode, error)
 
+	// QueryEpisodesFunc mocks the QueryEpisodes method.
+	QueryEpisodesFunc func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error)
+
 	// SaveEpisodeFunc mocks the SaveEpisode method.
 	SaveEpisodeFunc func(podcastID string, episode *podcast.Episode) error
 
+	// SaveEpisodesFunc mocks the SaveEpisodes method.
+	SaveEpisodesFunc func(podcastID string, episodes []*podcast.Episode) error
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// ChangeStatusEpisodes holds details about calls to the ChangeStatusEpisodes method.
+		ChangeStatusEpisodes []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// FromStatus is the fromStatus argument value.
+			FromStatus podcast.Status
+			// ToStatus is the toStatus argument value.
+			ToStatus podcast.Status
+		}
+		// ChangeStatusEpisodesByFilter holds details about calls to the ChangeStatusEpisodesByFilter method.
+		ChangeStatusEpisodesByFilter []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// Filter is the filter argument value.
+			Filter storage.EpisodeFilter
+			// ToStatus is the toStatus argument value.
+			ToStatus podcast.Status
+		}
+		// DeleteEpisodes holds details about calls to the DeleteEpisodes method.
+		DeleteEpisodes []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// Filenames is the filenames argument value.
+			Filenames []string
+		}
 		// FindEpisodesBySession holds details about calls to the FindEpisodesBySession method.
 		FindEpisodesBySession []struct {
 			// PodcastID is the podcastID argument value.
@@ -101,6 +157,13 @@ type EpisodeStoreMock struct {
 			// Status is the status argument value.
 			Status podcast.Status
 		}
+		// QueryEpisodes holds details about calls to the QueryEpisodes method.
+		QueryEpisodes []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// Q is the q argument value.
+			Q storage.EpisodeQuery
+		}
 		// SaveEpisode holds details about calls to the SaveEpisode method.
 		SaveEpisode []struct {
 			// PodcastID is the podcastID argument value.
@@ -108,13 +171,141 @@ type EpisodeStoreMock struct {
 			// Episode is the episode argument value.
 			Episode *podcast.Episode
 		}
+		// SaveEpisodes holds details about calls to the SaveEpisodes method.
+		SaveEpisodes []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// Episodes is the episodes argument value.
+			Episodes []*podcast.Episode
+		}
+	}
+	lockChangeStatusEpisodes         sync.RWMutex
+	lockChangeStatusEpisodesByFilter sync.RWMutex
+	lockDeleteEpisodes               sync.RWMutex
+	lockFindEpisodesBySession        sync.RWMutex
+	lockFindEpisodesBySizeLimit      sync.RWMutex
+	lockFindEpisodesByStatus         sync.RWMutex
+	lockGetEpisodeByFilename         sync.RWMutex
+	lockGetLastEpisodeByNotStatus    sync.RWMutex
+	lockQueryEpisodes                sync.RWMutex
+	lockSaveEpisode                  sync.RWMutex
+	lockSaveEpisodes                 sync.RWMutex
+}
+
+// ChangeStatusEpisodes calls ChangeStatusEpisodesFunc.
+func (mock *EpisodeStoreMock) ChangeStatusEpisodes(podcastID string, fromStatus podcast.Status, toStatus podcast.Status) error {
+	if mock.ChangeStatusEpisodesFunc == nil {
+		panic("EpisodeStoreMock.ChangeStatusEpisodesFunc: method is nil but EpisodeStore.ChangeStatusEpisodes was just called")
 	}
-	lockFindEpisodesBySession     sync.RWMutex
-	lockFindEpisodesBySizeLimit   sync.RWMutex
-	lockFindEpisodesByStatus      sync.RWMutex
-	lockGetEpisodeByFilename      sync.RWMutex
-	lockGetLastEpisodeByNotStatus sync.RWMutex
-	lockSaveEpisode               sync.RWMutex
+	callInfo := struct {
+		PodcastID  string
+		FromStatus podcast.Status
+		ToStatus   podcast.Status
+	}{
+		PodcastID:  podcastID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+	}
+	mock.lockChangeStatusEpisodes.Lock()
+	mock.calls.ChangeStatusEpisodes = append(mock.calls.ChangeStatusEpisodes, callInfo)
+	mock.lockChangeStatusEpisodes.Unlock()
+	return mock.ChangeStatusEpisodesFunc(podcastID, fromStatus, toStatus)
+}
+
+// ChangeStatusEpisodesCalls gets all the calls that were made to ChangeStatusEpisodes.
+// Check the length with:
+//
+//	len(mockedEpisodeStore.ChangeStatusEpisodesCalls())
+func (mock *EpisodeStoreMock) ChangeStatusEpisodesCalls() []struct {
+	PodcastID  string
+	FromStatus podcast.Status
+	ToStatus   podcast.Status
+} {
+	var calls []struct {
+		PodcastID  string
+		FromStatus podcast.Status
+		ToStatus   podcast.Status
+	}
+	mock.lockChangeStatusEpisodes.RLock()
+	calls = mock.calls.ChangeStatusEpisodes
+	mock.lockChangeStatusEpisodes.RUnlock()
+	return calls
+}
+
+// ChangeStatusEpisodesByFilter calls ChangeStatusEpisodesByFilterFunc.
+func (mock *EpisodeStoreMock) ChangeStatusEpisodesByFilter(podcastID string, filter storage.EpisodeFilter, toStatus podcast.Status) (int, error) {
+	if mock.ChangeStatusEpisodesByFilterFunc == nil {
+		panic("EpisodeStoreMock.ChangeStatusEpisodesByFilterFunc: method is nil but EpisodeStore.ChangeStatusEpisodesByFilter was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+		Filter    storage.EpisodeFilter
+		ToStatus  podcast.Status
+	}{
+		PodcastID: podcastID,
+		Filter:    filter,
+		ToStatus:  toStatus,
+	}
+	mock.lockChangeStatusEpisodesByFilter.Lock()
+	mock.calls.ChangeStatusEpisodesByFilter = append(mock.calls.ChangeStatusEpisodesByFilter, callInfo)
+	mock.lockChangeStatusEpisodesByFilter.Unlock()
+	return mock.ChangeStatusEpisodesByFilterFunc(podcastID, filter, toStatus)
+}
+
+// ChangeStatusEpisodesByFilterCalls gets all the calls that were made to ChangeStatusEpisodesByFilter.
+// Check the length with:
+//
+//	len(mockedEpisodeStore.ChangeStatusEpisodesByFilterCalls())
+func (mock *EpisodeStoreMock) ChangeStatusEpisodesByFilterCalls() []struct {
+	PodcastID string
+	Filter    storage.EpisodeFilter
+	ToStatus  podcast.Status
+} {
+	var calls []struct {
+		PodcastID string
+		Filter    storage.EpisodeFilter
+		ToStatus  podcast.Status
+	}
+	mock.lockChangeStatusEpisodesByFilter.RLock()
+	calls = mock.calls.ChangeStatusEpisodesByFilter
+	mock.lockChangeStatusEpisodesByFilter.RUnlock()
+	return calls
+}
+
+// DeleteEpisodes calls DeleteEpisodesFunc.
+func (mock *EpisodeStoreMock) DeleteEpisodes(podcastID string, filenames []string) (int, error) {
+	if mock.DeleteEpisodesFunc == nil {
+		panic("EpisodeStoreMock.DeleteEpisodesFunc: method is nil but EpisodeStore.DeleteEpisodes was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+		Filenames []string
+	}{
+		PodcastID: podcastID,
+		Filenames: filenames,
+	}
+	mock.lockDeleteEpisodes.Lock()
+	mock.calls.DeleteEpisodes = append(mock.calls.DeleteEpisodes, callInfo)
+	mock.lockDeleteEpisodes.Unlock()
+	return mock.DeleteEpisodesFunc(podcastID, filenames)
+}
+
+// DeleteEpisodesCalls gets all the calls that were made to DeleteEpisodes.
+// Check the length with:
+//
+//	len(mockedEpisodeStore.DeleteEpisodesCalls())
+func (mock *EpisodeStoreMock) DeleteEpisodesCalls() []struct {
+	PodcastID string
+	Filenames []string
+} {
+	var calls []struct {
+		PodcastID string
+		Filenames []string
+	}
+	mock.lockDeleteEpisodes.RLock()
+	calls = mock.calls.DeleteEpisodes
+	mock.lockDeleteEpisodes.RUnlock()
+	return calls
 }
 
 // FindEpisodesBySession calls FindEpisodesBySessionFunc.
@@ -301,6 +492,42 @@ func (mock *EpisodeStoreMock) GetLastEpisodeByNotStatusCalls() []struct {
 	return calls
 }
 
+// QueryEpisodes calls QueryEpisodesFunc.
+func (mock *EpisodeStoreMock) QueryEpisodes(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+	if mock.QueryEpisodesFunc == nil {
+		panic("EpisodeStoreMock.QueryEpisodesFunc: method is nil but EpisodeStore.QueryEpisodes was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+		Q         storage.EpisodeQuery
+	}{
+		PodcastID: podcastID,
+		Q:         q,
+	}
+	mock.lockQueryEpisodes.Lock()
+	mock.calls.QueryEpisodes = append(mock.calls.QueryEpisodes, callInfo)
+	mock.lockQueryEpisodes.Unlock()
+	return mock.QueryEpisodesFunc(podcastID, q)
+}
+
+// QueryEpisodesCalls gets all the calls that were made to QueryEpisodes.
+// Check the length with:
+//
+//	len(mockedEpisodeStore.QueryEpisodesCalls())
+func (mock *EpisodeStoreMock) QueryEpisodesCalls() []struct {
+	PodcastID string
+	Q         storage.EpisodeQuery
+} {
+	var calls []struct {
+		PodcastID string
+		Q         storage.EpisodeQuery
+	}
+	mock.lockQueryEpisodes.RLock()
+	calls = mock.calls.QueryEpisodes
+	mock.lockQueryEpisodes.RUnlock()
+	return calls
+}
+
 // SaveEpisode calls SaveEpisodeFunc.
 func (mock *EpisodeStoreMock) SaveEpisode(podcastID string, episode *podcast.Episode) error {
 	if mock.SaveEpisodeFunc == nil {
@@ -336,3 +563,39 @@ func (mock *EpisodeStoreMock) SaveEpisodeCalls() []struct {
 	mock.lockSaveEpisode.RUnlock()
 	return calls
 }
+
+// SaveEpisodes calls SaveEpisodesFunc.
+func (mock *EpisodeStoreMock) SaveEpisodes(podcastID string, episodes []*podcast.Episode) error {
+	if mock.SaveEpisodesFunc == nil {
+		panic("EpisodeStoreMock.SaveEpisodesFunc: method is nil but EpisodeStore.SaveEpisodes was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+		Episodes  []*podcast.Episode
+	}{
+		PodcastID: podcastID,
+		Episodes:  episodes,
+	}
+	mock.lockSaveEpisodes.Lock()
+	mock.calls.SaveEpisodes = append(mock.calls.SaveEpisodes, callInfo)
+	mock.lockSaveEpisodes.Unlock()
+	return mock.SaveEpisodesFunc(podcastID, episodes)
+}
+
+// SaveEpisodesCalls gets all the calls that were made to SaveEpisodes.
+// Check the length with:
+//
+//	len(mockedEpisodeStore.SaveEpisodesCalls())
+func (mock *EpisodeStoreMock) SaveEpisodesCalls() []struct {
+	PodcastID string
+	Episodes  []*podcast.Episode
+} {
+	var calls []struct {
+		PodcastID string
+		Episodes  []*podcast.Episode
+	}
+	mock.lockSaveEpisodes.RLock()
+	calls = mock.calls.SaveEpisodes
+	mock.lockSaveEpisodes.RUnlock()
+	return calls
+}
@@ -0,0 +1,132 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/storage"
+	"sync"
+)
+
+// Ensure, that HistoryStoreMock does implement proc.HistoryStore.
+// If this is not the case, regenerate this file with moq.
+var _ proc.HistoryStore = &HistoryStoreMock{}
+
+// HistoryStoreMock is a mock implementation of proc.HistoryStore.
+//
+//	func TestSomethingThatUsesHistoryStore(t *testing.T) {
+//
+//		// make and configure a mocked proc.HistoryStore
+//		mockedHistoryStore := &HistoryStoreMock{
+//			AppendHistoryFunc: func(podcastID string, events []storage.EpisodeEvent) error {
+//				panic("mock out the AppendHistory method")
+//			},
+//			ListHistoryFunc: func(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+//				panic("mock out the ListHistory method")
+//			},
+//		}
+//
+//		// use mockedHistoryStore in code that requires proc.HistoryStore
+//		// and then make assertions.
+//
+//	}
+type HistoryStoreMock struct {
+	// AppendHistoryFunc mocks the AppendHistory method.
+	AppendHistoryFunc func(podcastID string, events []storage.EpisodeEvent) error
+
+	// ListHistoryFunc mocks the ListHistory method.
+	ListHistoryFunc func(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// AppendHistory holds details about calls to the AppendHistory method.
+		AppendHistory []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// Events is the events argument value.
+			Events []storage.EpisodeEvent
+		}
+		// ListHistory holds details about calls to the ListHistory method.
+		ListHistory []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+			// Filter is the filter argument value.
+			Filter storage.HistoryFilter
+		}
+	}
+	lockAppendHistory sync.RWMutex
+	lockListHistory   sync.RWMutex
+}
+
+// AppendHistory calls AppendHistoryFunc.
+func (mock *HistoryStoreMock) AppendHistory(podcastID string, events []storage.EpisodeEvent) error {
+	if mock.AppendHistoryFunc == nil {
+		panic("HistoryStoreMock.AppendHistoryFunc: method is nil but HistoryStore.AppendHistory was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+		Events    []storage.EpisodeEvent
+	}{
+		PodcastID: podcastID,
+		Events:    events,
+	}
+	mock.lockAppendHistory.Lock()
+	mock.calls.AppendHistory = append(mock.calls.AppendHistory, callInfo)
+	mock.lockAppendHistory.Unlock()
+	return mock.AppendHistoryFunc(podcastID, events)
+}
+
+// AppendHistoryCalls gets all the calls that were made to AppendHistory.
+// Check the length with:
+//
+//	len(mockedHistoryStore.AppendHistoryCalls())
+func (mock *HistoryStoreMock) AppendHistoryCalls() []struct {
+	PodcastID string
+	Events    []storage.EpisodeEvent
+} {
+	var calls []struct {
+		PodcastID string
+		Events    []storage.EpisodeEvent
+	}
+	mock.lockAppendHistory.RLock()
+	calls = mock.calls.AppendHistory
+	mock.lockAppendHistory.RUnlock()
+	return calls
+}
+
+// ListHistory calls ListHistoryFunc.
+func (mock *HistoryStoreMock) ListHistory(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+	if mock.ListHistoryFunc == nil {
+		panic("HistoryStoreMock.ListHistoryFunc: method is nil but HistoryStore.ListHistory was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+		Filter    storage.HistoryFilter
+	}{
+		PodcastID: podcastID,
+		Filter:    filter,
+	}
+	mock.lockListHistory.Lock()
+	mock.calls.ListHistory = append(mock.calls.ListHistory, callInfo)
+	mock.lockListHistory.Unlock()
+	return mock.ListHistoryFunc(podcastID, filter)
+}
+
+// ListHistoryCalls gets all the calls that were made to ListHistory.
+// Check the length with:
+//
+//	len(mockedHistoryStore.ListHistoryCalls())
+func (mock *HistoryStoreMock) ListHistoryCalls() []struct {
+	PodcastID string
+	Filter    storage.HistoryFilter
+} {
+	var calls []struct {
+		PodcastID string
+		Filter    storage.HistoryFilter
+	}
+	mock.lockListHistory.RLock()
+	calls = mock.calls.ListHistory
+	mock.lockListHistory.RUnlock()
+	return calls
+}
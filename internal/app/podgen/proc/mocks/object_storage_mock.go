@@ -25,6 +25,9 @@ var _ proc.ObjectStorage = &ObjectStorageMock{}
 //			GetObjectInfoFunc: func(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
 //				panic("mock out the GetObjectInfo method")
 //			},
+//			ListFunc: func(ctx context.Context, prefix string) ([]proc.ObjectInfo, error) {
+//				panic("mock out the List method")
+//			},
 //			UploadEpisodeFunc: func(ctx context.Context, objectName string, filePath string) (*proc.UploadResult, error) {
 //				panic("mock out the UploadEpisode method")
 //			},
@@ -47,6 +50,9 @@ type ObjectStorageMock struct {
 	// GetObjectInfoFunc mocks the GetObjectInfo method.
 	GetObjectInfoFunc func(ctx context.Context, objectName string) (*proc.ObjectInfo, error)
 
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, prefix string) ([]proc.ObjectInfo, error)
+
 	// UploadEpisodeFunc mocks the UploadEpisode method.
 	UploadEpisodeFunc func(ctx context.Context, objectName string, filePath string) (*proc.UploadResult, error)
 
@@ -75,6 +81,13 @@ type ObjectStorageMock struct {
 			// ObjectName is the objectName argument value.
 			ObjectName string
 		}
+		// List holds details about calls to the List method.
+		List []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Prefix is the prefix argument value.
+			Prefix string
+		}
 		// UploadEpisode holds details about calls to the UploadEpisode method.
 		UploadEpisode []struct {
 			// Ctx is the ctx argument value.
@@ -105,6 +118,7 @@ type ObjectStorageMock struct {
 	}
 	lockDeleteEpisode sync.RWMutex
 	lockGetObjectInfo sync.RWMutex
+	lockList          sync.RWMutex
 	lockUploadEpisode sync.RWMutex
 	lockUploadFeed    sync.RWMutex
 	lockUploadImage   sync.RWMutex
@@ -182,6 +196,42 @@ func (mock *ObjectStorageMock) GetObjectInfoCalls() []struct {
 	return calls
 }
 
+// List calls ListFunc.
+func (mock *ObjectStorageMock) List(ctx context.Context, prefix string) ([]proc.ObjectInfo, error) {
+	if mock.ListFunc == nil {
+		panic("ObjectStorageMock.ListFunc: method is nil but ObjectStorage.List was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Prefix string
+	}{
+		Ctx:    ctx,
+		Prefix: prefix,
+	}
+	mock.lockList.Lock()
+	mock.calls.List = append(mock.calls.List, callInfo)
+	mock.lockList.Unlock()
+	return mock.ListFunc(ctx, prefix)
+}
+
+// ListCalls gets all the calls that were made to List.
+// Check the length with:
+//
+//	len(mockedObjectStorage.ListCalls())
+func (mock *ObjectStorageMock) ListCalls() []struct {
+	Ctx    context.Context
+	Prefix string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Prefix string
+	}
+	mock.lockList.RLock()
+	calls = mock.calls.List
+	mock.lockList.RUnlock()
+	return calls
+}
+
 // UploadEpisode calls UploadEpisodeFunc.
 func (mock *ObjectStorageMock) UploadEpisode(ctx context.Context, objectName string, filePath string) (*proc.UploadResult, error) {
 	if mock.UploadEpisodeFunc == nil {
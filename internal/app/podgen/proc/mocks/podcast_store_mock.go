@@ -0,0 +1,120 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"sync"
+)
+
+// Ensure, that PodcastStoreMock does implement proc.PodcastStore.
+// If this is not the case, regenerate this file with moq.
+var _ proc.PodcastStore = &PodcastStoreMock{}
+
+// PodcastStoreMock is a mock implementation of proc.PodcastStore.
+//
+//	func TestSomethingThatUsesPodcastStore(t *testing.T) {
+//
+//		// make and configure a mocked proc.PodcastStore
+//		mockedPodcastStore := &PodcastStoreMock{
+//			GetPodcastFunc: func(podcastID string) (*podcast.Podcast, error) {
+//				panic("mock out the GetPodcast method")
+//			},
+//			SavePodcastFunc: func(p *podcast.Podcast) error {
+//				panic("mock out the SavePodcast method")
+//			},
+//		}
+//
+//		// use mockedPodcastStore in code that requires proc.PodcastStore
+//		// and then make assertions.
+//
+//	}
+type PodcastStoreMock struct {
+	// GetPodcastFunc mocks the GetPodcast method.
+	GetPodcastFunc func(podcastID string) (*podcast.Podcast, error)
+
+	// SavePodcastFunc mocks the SavePodcast method.
+	SavePodcastFunc func(p *podcast.Podcast) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetPodcast holds details about calls to the GetPodcast method.
+		GetPodcast []struct {
+			// PodcastID is the podcastID argument value.
+			PodcastID string
+		}
+		// SavePodcast holds details about calls to the SavePodcast method.
+		SavePodcast []struct {
+			// P is the p argument value.
+			P *podcast.Podcast
+		}
+	}
+	lockGetPodcast  sync.RWMutex
+	lockSavePodcast sync.RWMutex
+}
+
+// GetPodcast calls GetPodcastFunc.
+func (mock *PodcastStoreMock) GetPodcast(podcastID string) (*podcast.Podcast, error) {
+	if mock.GetPodcastFunc == nil {
+		panic("PodcastStoreMock.GetPodcastFunc: method is nil but PodcastStore.GetPodcast was just called")
+	}
+	callInfo := struct {
+		PodcastID string
+	}{
+		PodcastID: podcastID,
+	}
+	mock.lockGetPodcast.Lock()
+	mock.calls.GetPodcast = append(mock.calls.GetPodcast, callInfo)
+	mock.lockGetPodcast.Unlock()
+	return mock.GetPodcastFunc(podcastID)
+}
+
+// GetPodcastCalls gets all the calls that were made to GetPodcast.
+// Check the length with:
+//
+//	len(mockedPodcastStore.GetPodcastCalls())
+func (mock *PodcastStoreMock) GetPodcastCalls() []struct {
+	PodcastID string
+} {
+	var calls []struct {
+		PodcastID string
+	}
+	mock.lockGetPodcast.RLock()
+	calls = mock.calls.GetPodcast
+	mock.lockGetPodcast.RUnlock()
+	return calls
+}
+
+// SavePodcast calls SavePodcastFunc.
+func (mock *PodcastStoreMock) SavePodcast(p *podcast.Podcast) error {
+	if mock.SavePodcastFunc == nil {
+		panic("PodcastStoreMock.SavePodcastFunc: method is nil but PodcastStore.SavePodcast was just called")
+	}
+	callInfo := struct {
+		P *podcast.Podcast
+	}{
+		P: p,
+	}
+	mock.lockSavePodcast.Lock()
+	mock.calls.SavePodcast = append(mock.calls.SavePodcast, callInfo)
+	mock.lockSavePodcast.Unlock()
+	return mock.SavePodcastFunc(p)
+}
+
+// SavePodcastCalls gets all the calls that were made to SavePodcast.
+// Check the length with:
+//
+//	len(mockedPodcastStore.SavePodcastCalls())
+func (mock *PodcastStoreMock) SavePodcastCalls() []struct {
+	P *podcast.Podcast
+} {
+	var calls []struct {
+		P *podcast.Podcast
+	}
+	mock.lockSavePodcast.RLock()
+	calls = mock.calls.SavePodcast
+	mock.lockSavePodcast.RUnlock()
+	return calls
+}
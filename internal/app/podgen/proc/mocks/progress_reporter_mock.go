@@ -21,6 +21,7 @@ var _ proc.ProgressReporter = &ProgressReporterMock{}
 //			StartFileFunc: func(workerID int, filename string, totalSize int64) {},
 //			UpdateProgressFunc: func(workerID int, uploaded int64, total int64) {},
 //			CompleteFileFunc: func(workerID int, fileSize int64, err error) {},
+//			RetryFileFunc: func(workerID int, attempt int, err error) {},
 //			FinishFunc: func() {},
 //		}
 //
@@ -38,6 +39,9 @@ type ProgressReporterMock struct {
 	// CompleteFileFunc mocks the CompleteFile method.
 	CompleteFileFunc func(workerID int, fileSize int64, err error)
 
+	// RetryFileFunc mocks the RetryFile method.
+	RetryFileFunc func(workerID int, attempt int, err error)
+
 	// FinishFunc mocks the Finish method.
 	FinishFunc func()
 
@@ -73,6 +77,15 @@ type ProgressReporterMock struct {
 			// Err is the err argument value.
 			Err error
 		}
+		// RetryFile holds details about calls to the RetryFile method.
+		RetryFile []struct {
+			// WorkerID is the workerID argument value.
+			WorkerID int
+			// Attempt is the attempt argument value.
+			Attempt int
+			// Err is the err argument value.
+			Err error
+		}
 		// Finish holds details about calls to the Finish method.
 		Finish []struct{}
 		// Reset holds details about calls to the Reset method.
@@ -84,6 +97,7 @@ type ProgressReporterMock struct {
 	lockStartFile      sync.RWMutex
 	lockUpdateProgress sync.RWMutex
 	lockCompleteFile   sync.RWMutex
+	lockRetryFile      sync.RWMutex
 	lockFinish         sync.RWMutex
 	lockReset          sync.RWMutex
 }
@@ -199,6 +213,43 @@ func (mock *ProgressReporterMock) CompleteFileCalls() []struct {
 	return calls
 }
 
+// RetryFile calls RetryFileFunc.
+func (mock *ProgressReporterMock) RetryFile(workerID int, attempt int, err error) {
+	if mock.RetryFileFunc == nil {
+		panic("ProgressReporterMock.RetryFileFunc: method is nil but ProgressReporter.RetryFile was just called")
+	}
+	callInfo := struct {
+		WorkerID int
+		Attempt  int
+		Err      error
+	}{
+		WorkerID: workerID,
+		Attempt:  attempt,
+		Err:      err,
+	}
+	mock.lockRetryFile.Lock()
+	mock.calls.RetryFile = append(mock.calls.RetryFile, callInfo)
+	mock.lockRetryFile.Unlock()
+	mock.RetryFileFunc(workerID, attempt, err)
+}
+
+// RetryFileCalls gets all the calls that were made to RetryFile.
+func (mock *ProgressReporterMock) RetryFileCalls() []struct {
+	WorkerID int
+	Attempt  int
+	Err      error
+} {
+	var calls []struct {
+		WorkerID int
+		Attempt  int
+		Err      error
+	}
+	mock.lockRetryFile.RLock()
+	calls = mock.calls.RetryFile
+	mock.lockRetryFile.RUnlock()
+	return calls
+}
+
 // Finish calls FinishFunc.
 func (mock *ProgressReporterMock) Finish() {
 	if mock.FinishFunc == nil {
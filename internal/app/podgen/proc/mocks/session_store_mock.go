@@ -0,0 +1,164 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/storage"
+	"sync"
+)
+
+// Ensure, that SessionStoreMock does implement proc.SessionStore.
+// If this is not the case, regenerate this file with moq.
+var _ proc.SessionStore = &SessionStoreMock{}
+
+// SessionStoreMock is a mock implementation of proc.SessionStore.
+//
+//	func TestSomethingThatUsesSessionStore(t *testing.T) {
+//
+//		// make and configure a mocked proc.SessionStore
+//		mockedSessionStore := &SessionStoreMock{
+//			GetSessionFunc: func(id string) (*storage.Session, error) {
+//				panic("mock out the GetSession method")
+//			},
+//			ListSessionsFunc: func(limit int) ([]*storage.Session, error) {
+//				panic("mock out the ListSessions method")
+//			},
+//			SaveSessionFunc: func(session *storage.Session) error {
+//				panic("mock out the SaveSession method")
+//			},
+//		}
+//
+//		// use mockedSessionStore in code that requires proc.SessionStore
+//		// and then make assertions.
+//
+//	}
+type SessionStoreMock struct {
+	// GetSessionFunc mocks the GetSession method.
+	GetSessionFunc func(id string) (*storage.Session, error)
+
+	// ListSessionsFunc mocks the ListSessions method.
+	ListSessionsFunc func(limit int) ([]*storage.Session, error)
+
+	// SaveSessionFunc mocks the SaveSession method.
+	SaveSessionFunc func(session *storage.Session) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetSession holds details about calls to the GetSession method.
+		GetSession []struct {
+			// ID is the id argument value.
+			ID string
+		}
+		// ListSessions holds details about calls to the ListSessions method.
+		ListSessions []struct {
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// SaveSession holds details about calls to the SaveSession method.
+		SaveSession []struct {
+			// Session is the session argument value.
+			Session *storage.Session
+		}
+	}
+	lockGetSession   sync.RWMutex
+	lockListSessions sync.RWMutex
+	lockSaveSession  sync.RWMutex
+}
+
+// GetSession calls GetSessionFunc.
+func (mock *SessionStoreMock) GetSession(id string) (*storage.Session, error) {
+	if mock.GetSessionFunc == nil {
+		panic("SessionStoreMock.GetSessionFunc: method is nil but SessionStore.GetSession was just called")
+	}
+	callInfo := struct {
+		ID string
+	}{
+		ID: id,
+	}
+	mock.lockGetSession.Lock()
+	mock.calls.GetSession = append(mock.calls.GetSession, callInfo)
+	mock.lockGetSession.Unlock()
+	return mock.GetSessionFunc(id)
+}
+
+// GetSessionCalls gets all the calls that were made to GetSession.
+// Check the length with:
+//
+//	len(mockedSessionStore.GetSessionCalls())
+func (mock *SessionStoreMock) GetSessionCalls() []struct {
+	ID string
+} {
+	var calls []struct {
+		ID string
+	}
+	mock.lockGetSession.RLock()
+	calls = mock.calls.GetSession
+	mock.lockGetSession.RUnlock()
+	return calls
+}
+
+// ListSessions calls ListSessionsFunc.
+func (mock *SessionStoreMock) ListSessions(limit int) ([]*storage.Session, error) {
+	if mock.ListSessionsFunc == nil {
+		panic("SessionStoreMock.ListSessionsFunc: method is nil but SessionStore.ListSessions was just called")
+	}
+	callInfo := struct {
+		Limit int
+	}{
+		Limit: limit,
+	}
+	mock.lockListSessions.Lock()
+	mock.calls.ListSessions = append(mock.calls.ListSessions, callInfo)
+	mock.lockListSessions.Unlock()
+	return mock.ListSessionsFunc(limit)
+}
+
+// ListSessionsCalls gets all the calls that were made to ListSessions.
+// Check the length with:
+//
+//	len(mockedSessionStore.ListSessionsCalls())
+func (mock *SessionStoreMock) ListSessionsCalls() []struct {
+	Limit int
+} {
+	var calls []struct {
+		Limit int
+	}
+	mock.lockListSessions.RLock()
+	calls = mock.calls.ListSessions
+	mock.lockListSessions.RUnlock()
+	return calls
+}
+
+// SaveSession calls SaveSessionFunc.
+func (mock *SessionStoreMock) SaveSession(session *storage.Session) error {
+	if mock.SaveSessionFunc == nil {
+		panic("SessionStoreMock.SaveSessionFunc: method is nil but SessionStore.SaveSession was just called")
+	}
+	callInfo := struct {
+		Session *storage.Session
+	}{
+		Session: session,
+	}
+	mock.lockSaveSession.Lock()
+	mock.calls.SaveSession = append(mock.calls.SaveSession, callInfo)
+	mock.lockSaveSession.Unlock()
+	return mock.SaveSessionFunc(session)
+}
+
+// SaveSessionCalls gets all the calls that were made to SaveSession.
+// Check the length with:
+//
+//	len(mockedSessionStore.SaveSessionCalls())
+func (mock *SessionStoreMock) SaveSessionCalls() []struct {
+	Session *storage.Session
+} {
+	var calls []struct {
+		Session *storage.Session
+	}
+	mock.lockSaveSession.RLock()
+	calls = mock.calls.SaveSession
+	mock.lockSaveSession.RUnlock()
+	return calls
+}
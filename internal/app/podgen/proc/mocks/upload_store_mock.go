@@ -0,0 +1,213 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mocks
+
+import (
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/storage"
+	"sync"
+)
+
+// Ensure, that UploadStoreMock does implement proc.UploadStore.
+// If this is not the case, regenerate this file with moq.
+var _ proc.UploadStore = &UploadStoreMock{}
+
+// UploadStoreMock is a mock implementation of proc.UploadStore.
+//
+//	func TestSomethingThatUsesUploadStore(t *testing.T) {
+//
+//		// make and configure a mocked proc.UploadStore
+//		mockedUploadStore := &UploadStoreMock{
+//			DeleteMultipartUploadFunc: func(bucket string, object string) error {
+//				panic("mock out the DeleteMultipartUpload method")
+//			},
+//			GetMultipartUploadFunc: func(bucket string, object string) (*storage.MultipartUpload, error) {
+//				panic("mock out the GetMultipartUpload method")
+//			},
+//			ListMultipartUploadsFunc: func() ([]*storage.MultipartUpload, error) {
+//				panic("mock out the ListMultipartUploads method")
+//			},
+//			SaveMultipartUploadFunc: func(upload *storage.MultipartUpload) error {
+//				panic("mock out the SaveMultipartUpload method")
+//			},
+//		}
+//
+//		// use mockedUploadStore in code that requires proc.UploadStore
+//		// and then make assertions.
+//
+//	}
+type UploadStoreMock struct {
+	// DeleteMultipartUploadFunc mocks the DeleteMultipartUpload method.
+	DeleteMultipartUploadFunc func(bucket string, object string) error
+
+	// GetMultipartUploadFunc mocks the GetMultipartUpload method.
+	GetMultipartUploadFunc func(bucket string, object string) (*storage.MultipartUpload, error)
+
+	// ListMultipartUploadsFunc mocks the ListMultipartUploads method.
+	ListMultipartUploadsFunc func() ([]*storage.MultipartUpload, error)
+
+	// SaveMultipartUploadFunc mocks the SaveMultipartUpload method.
+	SaveMultipartUploadFunc func(upload *storage.MultipartUpload) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// DeleteMultipartUpload holds details about calls to the DeleteMultipartUpload method.
+		DeleteMultipartUpload []struct {
+			// Bucket is the bucket argument value.
+			Bucket string
+			// Object is the object argument value.
+			Object string
+		}
+		// GetMultipartUpload holds details about calls to the GetMultipartUpload method.
+		GetMultipartUpload []struct {
+			// Bucket is the bucket argument value.
+			Bucket string
+			// Object is the object argument value.
+			Object string
+		}
+		// ListMultipartUploads holds details about calls to the ListMultipartUploads method.
+		ListMultipartUploads []struct {
+		}
+		// SaveMultipartUpload holds details about calls to the SaveMultipartUpload method.
+		SaveMultipartUpload []struct {
+			// Upload is the upload argument value.
+			Upload *storage.MultipartUpload
+		}
+	}
+	lockDeleteMultipartUpload sync.RWMutex
+	lockGetMultipartUpload    sync.RWMutex
+	lockListMultipartUploads  sync.RWMutex
+	lockSaveMultipartUpload   sync.RWMutex
+}
+
+// DeleteMultipartUpload calls DeleteMultipartUploadFunc.
+func (mock *UploadStoreMock) DeleteMultipartUpload(bucket string, object string) error {
+	if mock.DeleteMultipartUploadFunc == nil {
+		panic("UploadStoreMock.DeleteMultipartUploadFunc: method is nil but UploadStore.DeleteMultipartUpload was just called")
+	}
+	callInfo := struct {
+		Bucket string
+		Object string
+	}{
+		Bucket: bucket,
+		Object: object,
+	}
+	mock.lockDeleteMultipartUpload.Lock()
+	mock.calls.DeleteMultipartUpload = append(mock.calls.DeleteMultipartUpload, callInfo)
+	mock.lockDeleteMultipartUpload.Unlock()
+	return mock.DeleteMultipartUploadFunc(bucket, object)
+}
+
+// DeleteMultipartUploadCalls gets all the calls that were made to DeleteMultipartUpload.
+// Check the length with:
+//
+//	len(mockedUploadStore.DeleteMultipartUploadCalls())
+func (mock *UploadStoreMock) DeleteMultipartUploadCalls() []struct {
+	Bucket string
+	Object string
+} {
+	var calls []struct {
+		Bucket string
+		Object string
+	}
+	mock.lockDeleteMultipartUpload.RLock()
+	calls = mock.calls.DeleteMultipartUpload
+	mock.lockDeleteMultipartUpload.RUnlock()
+	return calls
+}
+
+// GetMultipartUpload calls GetMultipartUploadFunc.
+func (mock *UploadStoreMock) GetMultipartUpload(bucket string, object string) (*storage.MultipartUpload, error) {
+	if mock.GetMultipartUploadFunc == nil {
+		panic("UploadStoreMock.GetMultipartUploadFunc: method is nil but UploadStore.GetMultipartUpload was just called")
+	}
+	callInfo := struct {
+		Bucket string
+		Object string
+	}{
+		Bucket: bucket,
+		Object: object,
+	}
+	mock.lockGetMultipartUpload.Lock()
+	mock.calls.GetMultipartUpload = append(mock.calls.GetMultipartUpload, callInfo)
+	mock.lockGetMultipartUpload.Unlock()
+	return mock.GetMultipartUploadFunc(bucket, object)
+}
+
+// GetMultipartUploadCalls gets all the calls that were made to GetMultipartUpload.
+// Check the length with:
+//
+//	len(mockedUploadStore.GetMultipartUploadCalls())
+func (mock *UploadStoreMock) GetMultipartUploadCalls() []struct {
+	Bucket string
+	Object string
+} {
+	var calls []struct {
+		Bucket string
+		Object string
+	}
+	mock.lockGetMultipartUpload.RLock()
+	calls = mock.calls.GetMultipartUpload
+	mock.lockGetMultipartUpload.RUnlock()
+	return calls
+}
+
+// ListMultipartUploads calls ListMultipartUploadsFunc.
+func (mock *UploadStoreMock) ListMultipartUploads() ([]*storage.MultipartUpload, error) {
+	if mock.ListMultipartUploadsFunc == nil {
+		panic("UploadStoreMock.ListMultipartUploadsFunc: method is nil but UploadStore.ListMultipartUploads was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListMultipartUploads.Lock()
+	mock.calls.ListMultipartUploads = append(mock.calls.ListMultipartUploads, callInfo)
+	mock.lockListMultipartUploads.Unlock()
+	return mock.ListMultipartUploadsFunc()
+}
+
+// ListMultipartUploadsCalls gets all the calls that were made to ListMultipartUploads.
+// Check the length with:
+//
+//	len(mockedUploadStore.ListMultipartUploadsCalls())
+func (mock *UploadStoreMock) ListMultipartUploadsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListMultipartUploads.RLock()
+	calls = mock.calls.ListMultipartUploads
+	mock.lockListMultipartUploads.RUnlock()
+	return calls
+}
+
+// SaveMultipartUpload calls SaveMultipartUploadFunc.
+func (mock *UploadStoreMock) SaveMultipartUpload(upload *storage.MultipartUpload) error {
+	if mock.SaveMultipartUploadFunc == nil {
+		panic("UploadStoreMock.SaveMultipartUploadFunc: method is nil but UploadStore.SaveMultipartUpload was just called")
+	}
+	callInfo := struct {
+		Upload *storage.MultipartUpload
+	}{
+		Upload: upload,
+	}
+	mock.lockSaveMultipartUpload.Lock()
+	mock.calls.SaveMultipartUpload = append(mock.calls.SaveMultipartUpload, callInfo)
+	mock.lockSaveMultipartUpload.Unlock()
+	return mock.SaveMultipartUploadFunc(upload)
+}
+
+// SaveMultipartUploadCalls gets all the calls that were made to SaveMultipartUpload.
+// Check the length with:
+//
+//	len(mockedUploadStore.SaveMultipartUploadCalls())
+func (mock *UploadStoreMock) SaveMultipartUploadCalls() []struct {
+	Upload *storage.MultipartUpload
+} {
+	var calls []struct {
+		Upload *storage.MultipartUpload
+	}
+	mock.lockSaveMultipartUpload.RLock()
+	calls = mock.calls.SaveMultipartUpload
+	mock.lockSaveMultipartUpload.RUnlock()
+	return calls
+}
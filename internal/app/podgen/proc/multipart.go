@@ -0,0 +1,275 @@
+package proc
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // Content-MD5 of parts, not a security measure
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"github.com/minio/minio-go/v7"
+	"podgen/internal/pkg/ratelimit"
+	"podgen/internal/storage"
+)
+
+const (
+	// minPartSize is the smallest part S3 accepts, except for the last one.
+	minPartSize = 5 << 20
+	// defaultStaleAfter is the age after which incomplete uploads are aborted if StaleAfter is not set.
+	defaultStaleAfter = 7 * 24 * time.Hour
+)
+
+// resumable reports whether the file is uploaded in recorded parts instead of a single request.
+func (s *S3Store) resumable(size int64) bool {
+	return s.Uploads != nil && size > s.partSize()
+}
+
+func (s *S3Store) partSize() int64 {
+	return max(s.PartSize, minPartSize)
+}
+
+// uploadResumable uploads the file in parts, recording every uploaded part in Uploads.
+// An upload of the same file interrupted earlier continues from its last recorded part.
+func (s *S3Store) uploadResumable(ctx context.Context, objectName, filePath string, sums Checksums,
+	opts minio.PutObjectOptions, progress ProgressFunc) error {
+	file, err := os.Open(filePath) //nolint:gosec // filePath comes from internal code, not user input
+	if err != nil {
+		return fmt.Errorf("can't open file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("can't stat file %s: %w", filePath, err)
+	}
+
+	core := minio.Core{Client: s.Client}
+	upload, err := s.resumeUpload(ctx, core, objectName, filePath, stat, sums)
+	if err != nil {
+		return err
+	}
+	if upload == nil {
+		uploadID, err := core.NewMultipartUpload(ctx, s.Bucket, objectName, opts)
+		if err != nil {
+			return fmt.Errorf("can't start multipart upload %s: %w", objectName, err)
+		}
+		now := time.Now()
+		upload = &storage.MultipartUpload{
+			Object:    objectName,
+			Bucket:    s.Bucket,
+			UploadID:  uploadID,
+			FilePath:  filePath,
+			Size:      stat.Size(),
+			ModTime:   stat.ModTime(),
+			SHA256:    sums.SHA256,
+			PartSize:  s.partSize(),
+			StartedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.Uploads.SaveMultipartUpload(upload); err != nil {
+			return fmt.Errorf("can't record multipart upload %s: %w", objectName, err)
+		}
+	}
+
+	done := make(map[int]bool, len(upload.Parts))
+	var uploaded int64
+	for _, part := range upload.Parts {
+		done[part.Number] = true
+		uploaded += part.Size
+	}
+	if progress != nil && uploaded > 0 {
+		progress(uploaded, upload.Size)
+	}
+
+	for offset, number := int64(0), 1; offset < upload.Size; offset, number = offset+upload.PartSize, number+1 {
+		if done[number] {
+			continue
+		}
+		length := min(upload.PartSize, upload.Size-offset)
+		section := io.NewSectionReader(file, offset, length)
+		sum := md5.New() //nolint:gosec // see import
+		if _, err := io.Copy(sum, section); err != nil {
+			return fmt.Errorf("can't read part %d of %s: %w", number, filePath, err)
+		}
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("can't read part %d of %s: %w", number, filePath, err)
+		}
+
+		reader := ratelimit.Reader(ctx, &progressReader{reader: section, total: upload.Size, uploaded: uploaded, callback: progress},
+			s.Limiter)
+		part, err := core.PutObjectPart(ctx, s.Bucket, objectName, upload.UploadID, number, reader, length,
+			base64.StdEncoding.EncodeToString(sum.Sum(nil)), "", nil)
+		if err != nil {
+			s.forgetIfGone(s.Bucket, objectName, err)
+			return fmt.Errorf("can't upload part %d of %s: %w", number, objectName, err)
+		}
+
+		uploaded += length
+		upload.Parts = append(upload.Parts, storage.UploadPart{Number: number, ETag: part.ETag, Size: length})
+		upload.UpdatedAt = time.Now()
+		if err := s.Uploads.SaveMultipartUpload(upload); err != nil {
+			return fmt.Errorf("can't record part %d of %s: %w", number, objectName, err)
+		}
+	}
+
+	slices.SortFunc(upload.Parts, func(a, b storage.UploadPart) int { return a.Number - b.Number })
+	complete := make([]minio.CompletePart, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		complete = append(complete, minio.CompletePart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	if _, err := core.CompleteMultipartUpload(ctx, s.Bucket, objectName, upload.UploadID, complete, opts); err != nil {
+		s.forgetIfGone(s.Bucket, objectName, err)
+		return fmt.Errorf("can't complete multipart upload %s: %w", objectName, err)
+	}
+	s.forgetUpload(s.Bucket, objectName)
+	return nil
+}
+
+// resumeUpload returns the recorded upload of the object if it can continue, nil if a new one has to start.
+// An upload continues only if the file hasn't changed and the bucket still has it; its parts are
+// narrowed to the ones the bucket holds with the recorded ETag. Uploads of a changed file are aborted.
+func (s *S3Store) resumeUpload(ctx context.Context, core minio.Core, objectName, filePath string,
+	stat os.FileInfo, sums Checksums) (*storage.MultipartUpload, error) {
+	upload, err := s.Uploads.GetMultipartUpload(s.Bucket, objectName)
+	if errors.Is(err, storage.ErrUploadNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get multipart upload %s: %w", objectName, err)
+	}
+
+	if upload.FilePath != filePath || upload.Size != stat.Size() || !upload.ModTime.Equal(stat.ModTime()) ||
+		upload.SHA256 != sums.SHA256 {
+		log.Printf("[INFO] %s changed since its upload started, starting over", filePath)
+		if err := s.abortUpload(ctx, upload); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	remote := map[int]string{}
+	for marker := 0; ; {
+		result, err := core.ListObjectParts(ctx, s.Bucket, objectName, upload.UploadID, marker, 1000)
+		if err != nil {
+			if s.forgetIfGone(s.Bucket, objectName, err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("can't list parts of %s: %w", objectName, err)
+		}
+		for _, part := range result.ObjectParts {
+			remote[part.PartNumber] = strings.Trim(part.ETag, `"`)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	parts := upload.Parts[:0]
+	for _, part := range upload.Parts {
+		if etag, ok := remote[part.Number]; ok && etag == strings.Trim(part.ETag, `"`) {
+			parts = append(parts, part)
+		}
+	}
+	upload.Parts = parts
+	log.Printf("[INFO] resuming upload of %s, %d parts already uploaded", objectName, len(parts))
+	return upload, nil
+}
+
+// AbortStaleUploads aborts incomplete multipart uploads that won't be resumed: recorded uploads whose file
+// is gone or changed, and any upload under KeyPrefix in the bucket started more than StaleAfter ago.
+// Uploads recorded for other buckets are left to the stores of those buckets.
+func (s *S3Store) AbortStaleUploads(ctx context.Context) (int, error) {
+	if s.Uploads == nil {
+		return 0, nil
+	}
+	staleAfter := s.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleAfter
+	}
+	cutoff := time.Now().Add(-staleAfter)
+
+	uploads, err := s.Uploads.ListMultipartUploads()
+	if err != nil {
+		return 0, fmt.Errorf("can't list multipart uploads: %w", err)
+	}
+	aborted := 0
+	kept := map[string]bool{}
+	for _, upload := range uploads {
+		if upload.Bucket != s.Bucket || !strings.HasPrefix(upload.Object, s.key("")) {
+			continue
+		}
+		stat, statErr := os.Stat(upload.FilePath)
+		if statErr == nil && stat.Size() == upload.Size && stat.ModTime().Equal(upload.ModTime) && upload.StartedAt.After(cutoff) {
+			kept[upload.UploadID] = true
+			continue
+		}
+		if err := s.abortUpload(ctx, upload); err != nil {
+			return aborted, err
+		}
+		aborted++
+	}
+
+	exists, err := s.Client.BucketExists(ctx, s.Bucket)
+	if err != nil {
+		return aborted, fmt.Errorf("can't check exists bucket %s: %w", s.Bucket, err)
+	}
+	if !exists {
+		return aborted, nil
+	}
+	core := minio.Core{Client: s.Client}
+	for info := range s.Client.ListIncompleteUploads(ctx, s.Bucket, s.key(""), true) {
+		if info.Err != nil {
+			return aborted, fmt.Errorf("can't list incomplete uploads in bucket %s: %w", s.Bucket, info.Err)
+		}
+		if kept[info.UploadID] || info.Initiated.After(cutoff) {
+			continue
+		}
+		if err := core.AbortMultipartUpload(ctx, s.Bucket, info.Key, info.UploadID); err != nil {
+			return aborted, fmt.Errorf("can't abort upload of %s: %w", info.Key, err)
+		}
+		kept[info.UploadID] = true // recorded uploads are listed again by the bucket
+		aborted++
+	}
+	if aborted > 0 {
+		log.Printf("[INFO] aborted %d stale multipart uploads in bucket %s", aborted, s.Bucket)
+	}
+	return aborted, nil
+}
+
+// abortUpload aborts the upload in its bucket and forgets it. An upload the bucket no longer has is just forgotten.
+func (s *S3Store) abortUpload(ctx context.Context, upload *storage.MultipartUpload) error {
+	core := minio.Core{Client: s.Client}
+	if err := core.AbortMultipartUpload(ctx, upload.Bucket, upload.Object, upload.UploadID); err != nil && !isNoSuchUpload(err) {
+		log.Printf("[WARN] can't abort upload of %s, %v", upload.Object, err)
+		return fmt.Errorf("can't abort upload of %s: %w", upload.Object, err)
+	}
+	s.forgetUpload(upload.Bucket, upload.Object)
+	return nil
+}
+
+// forgetIfGone forgets the upload of the object if err tells the bucket doesn't have it anymore.
+func (s *S3Store) forgetIfGone(bucket, objectName string, err error) bool {
+	if !isNoSuchUpload(err) {
+		return false
+	}
+	s.forgetUpload(bucket, objectName)
+	return true
+}
+
+func (s *S3Store) forgetUpload(bucket, objectName string) {
+	if err := s.Uploads.DeleteMultipartUpload(bucket, objectName); err != nil {
+		log.Printf("[WARN] can't delete multipart upload record %s, %v", objectName, err)
+	}
+}
+
+func isNoSuchUpload(err error) bool {
+	var resp minio.ErrorResponse
+	return errors.As(err, &resp) && resp.Code == "NoSuchUpload"
+}
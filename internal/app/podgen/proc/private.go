@@ -0,0 +1,309 @@
+package proc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"podgen/internal/configs"
+	"podgen/internal/storage"
+)
+
+// ErrPresignUnsupported is returned by object storages that can't presign URLs.
+var ErrPresignUnsupported = errors.New("storage can't presign URLs")
+
+// tokenBytes is the number of random bytes of subscriber tokens.
+const tokenBytes = 32
+
+// SubscriberFeed is the feed file of a subscriber of a private podcast.
+type SubscriberFeed struct {
+	Subscriber string
+	Filename   string
+}
+
+// GenerateFeeds writes the feeds of the podcast and returns their file names:
+// the public feed, or a feed per active subscriber if the podcast is private, see PrivateFeeds and Subscribers.
+func (p *Processor) GenerateFeeds(ctx context.Context, podcastID string, podcastEntity configs.Podcast,
+	podcastImageURL string) ([]SubscriberFeed, error) {
+	private := p.PrivateFeeds[podcastID]
+	if !private.IsSet() {
+		feedName, err := p.GenerateFeed(ctx, podcastID, podcastEntity, podcastImageURL)
+		if err != nil {
+			return nil, err
+		}
+		return []SubscriberFeed{{Filename: feedName}}, nil
+	}
+
+	subscribers, err := p.subscribers(podcastID)
+	if err != nil {
+		return nil, err
+	}
+	feeds := make([]SubscriberFeed, 0, len(subscribers))
+	for _, sub := range subscribers {
+		enclosure := tokenEnclosure(private.GetTokenParam(), sub.Token)
+		if private.Mode == configs.PrivatePresigned {
+			enclosure = p.presignedEnclosure(podcastID, private.GetExpiry())
+		}
+		feedName, err := p.writeFeed(ctx, podcastID, podcastEntity, podcastImageURL,
+			subscriberFeedKey(podcastID, sub.Token), enclosure)
+		if err != nil {
+			return nil, fmt.Errorf("feed of subscriber %s: %w", sub.Name, err)
+		}
+		feeds = append(feeds, SubscriberFeed{Subscriber: sub.Name, Filename: feedName})
+	}
+	return feeds, nil
+}
+
+// IsPrivate returns true if the podcast publishes feeds of subscribers instead of the public feed.
+func (p *Processor) IsPrivate(podcastID string) bool {
+	return p.PrivateFeeds[podcastID].IsSet()
+}
+
+// GetSubscriberFeedURLs returns the feed URLs of the active subscribers of a private podcast by subscriber name,
+// none for public podcasts.
+func (p *Processor) GetSubscriberFeedURLs(podcastID, podcastFolder string, urls URLTemplate) (map[string]string, error) {
+	result := map[string]string{}
+	if !p.IsPrivate(podcastID) {
+		return result, nil
+	}
+	subscribers, err := p.subscribers(podcastID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subscribers {
+		result[sub.Name] = urls.URL(fmt.Sprintf("%s/%s.rss", podcastFolder, subscriberFeedKey(podcastID, sub.Token)))
+	}
+	return result, nil
+}
+
+// DeletePublicFeed deletes the public feed of a private podcast, so its well-known URL stops working.
+// The local file is removed too, missing feeds are skipped.
+func (p *Processor) DeletePublicFeed(ctx context.Context, podcastID, podcastFolder string) error {
+	feedKey, err := p.getFeedKey(podcastID)
+	if err != nil {
+		return err
+	}
+	return p.deleteFeed(ctx, podcastID, podcastFolder, feedKey)
+}
+
+// ListSubscribers returns the subscribers of a private podcast, those of the config first, then the stored ones
+// ordered by name, revoked ones included. Subscribers of the config have no times.
+func (p *Processor) ListSubscribers(podcastID string) ([]*storage.Subscriber, error) {
+	var result []*storage.Subscriber
+	for _, sub := range p.PrivateFeeds[podcastID].Subscribers {
+		result = append(result, &storage.Subscriber{PodcastID: podcastID, Name: sub.Name, Token: sub.Token})
+	}
+	if p.Subscribers == nil {
+		return result, nil
+	}
+	stored, err := p.Subscribers.ListSubscribers(podcastID)
+	if err != nil {
+		return nil, fmt.Errorf("can't list subscribers of %s: %w", podcastID, err)
+	}
+	for _, sub := range stored {
+		if !p.inConfig(podcastID, sub.Name) {
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
+
+// AddSubscriber adds a subscriber with a new token to a private podcast. Revoked subscribers can be added again.
+// The feed of the subscriber is published by the next feed run.
+func (p *Processor) AddSubscriber(_ context.Context, podcastID, name string) (*storage.Subscriber, error) {
+	if err := p.checkSubscribers(podcastID, name); err != nil {
+		return nil, err
+	}
+	existing, err := p.Subscribers.GetSubscriber(podcastID, name)
+	switch {
+	case err == nil && existing.Active():
+		return nil, fmt.Errorf("subscriber %s of %s already exists", name, podcastID)
+	case err != nil && !errors.Is(err, storage.ErrSubscriberNotFound):
+		return nil, fmt.Errorf("can't get subscriber %s of %s: %w", name, podcastID, err)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	sub := &storage.Subscriber{PodcastID: podcastID, Name: name, Token: token, CreatedAt: time.Now()}
+	if err := p.Subscribers.SaveSubscriber(sub); err != nil {
+		return nil, fmt.Errorf("can't save subscriber %s of %s: %w", name, podcastID, err)
+	}
+	return sub, nil
+}
+
+// RotateSubscriber replaces the token of the subscriber and deletes their feed, the old feed URL stops working.
+// The feed at the new URL is published by the next feed run.
+func (p *Processor) RotateSubscriber(ctx context.Context, podcastID, podcastFolder, name string) (*storage.Subscriber, error) {
+	sub, err := p.activeSubscriber(podcastID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.deleteFeed(ctx, podcastID, podcastFolder, subscriberFeedKey(podcastID, sub.Token)); err != nil {
+		return nil, err
+	}
+	if sub.Token, err = newToken(); err != nil {
+		return nil, err
+	}
+	sub.RotatedAt = time.Now()
+	if err := p.Subscribers.SaveSubscriber(sub); err != nil {
+		return nil, fmt.Errorf("can't save subscriber %s of %s: %w", name, podcastID, err)
+	}
+	return sub, nil
+}
+
+// RevokeSubscriber deletes the feed of the subscriber and marks them revoked.
+// The subscriber stays active if deleting the feed fails, so revoking can be retried.
+func (p *Processor) RevokeSubscriber(ctx context.Context, podcastID, podcastFolder, name string) error {
+	sub, err := p.activeSubscriber(podcastID, name)
+	if err != nil {
+		return err
+	}
+	if err := p.deleteFeed(ctx, podcastID, podcastFolder, subscriberFeedKey(podcastID, sub.Token)); err != nil {
+		return err
+	}
+	sub.Token, sub.RevokedAt = "", time.Now()
+	if err := p.Subscribers.SaveSubscriber(sub); err != nil {
+		return fmt.Errorf("can't save subscriber %s of %s: %w", name, podcastID, err)
+	}
+	return nil
+}
+
+// subscribers returns the active subscribers of a private podcast, see ListSubscribers.
+func (p *Processor) subscribers(podcastID string) ([]configs.Subscriber, error) {
+	all, err := p.ListSubscribers(podcastID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]configs.Subscriber, 0, len(all))
+	for _, sub := range all {
+		if sub.Active() {
+			result = append(result, configs.Subscriber{Name: sub.Name, Token: sub.Token})
+		}
+	}
+	return result, nil
+}
+
+// activeSubscriber returns the stored subscriber of the podcast, an error if there is none or they are revoked.
+func (p *Processor) activeSubscriber(podcastID, name string) (*storage.Subscriber, error) {
+	if err := p.checkSubscribers(podcastID, name); err != nil {
+		return nil, err
+	}
+	sub, err := p.Subscribers.GetSubscriber(podcastID, name)
+	if err != nil {
+		return nil, fmt.Errorf("can't get subscriber %s of %s: %w", name, podcastID, err)
+	}
+	if !sub.Active() {
+		return nil, fmt.Errorf("subscriber %s of %s is revoked", name, podcastID)
+	}
+	return sub, nil
+}
+
+// checkSubscribers returns an error if stored subscribers named name can't be managed for the podcast.
+func (p *Processor) checkSubscribers(podcastID, name string) error {
+	switch {
+	case p.Subscribers == nil:
+		return errors.New("subscriber store is not set")
+	case !p.IsPrivate(podcastID):
+		return fmt.Errorf("podcast %s is not private, set its private.mode", podcastID)
+	case name == "":
+		return errors.New("subscriber name is required")
+	case p.inConfig(podcastID, name):
+		return fmt.Errorf("subscriber %s of %s is listed in the config, edit it there", name, podcastID)
+	}
+	return nil
+}
+
+// inConfig returns true if the subscriber is listed in the config of the podcast.
+func (p *Processor) inConfig(podcastID, name string) bool {
+	return slices.ContainsFunc(p.PrivateFeeds[podcastID].Subscribers, func(sub configs.Subscriber) bool {
+		return sub.Name == name
+	})
+}
+
+// deleteFeed deletes the feed <feedKey>.rss of the podcast from the storage and the output folder,
+// missing feeds are skipped. Other failures of the lookup are returned, the feed may still be published.
+func (p *Processor) deleteFeed(ctx context.Context, podcastID, podcastFolder, feedKey string) error {
+	feedName := feedKey + ".rss"
+	objectName := podcastFolder + "/" + feedName
+	_, err := p.objects(podcastID).GetObjectInfo(ctx, objectName)
+	switch {
+	case err == nil:
+		if err := p.objects(podcastID).DeleteEpisode(ctx, objectName); err != nil {
+			return fmt.Errorf("can't delete feed %s: %w", objectName, err)
+		}
+	case !IsNotFound(err):
+		return fmt.Errorf("can't get info of feed %s: %w", objectName, err)
+	}
+	feedPath, err := p.outputPath(podcastFolder, feedName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(feedPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("can't remove feed %s: %w", feedPath, err)
+	}
+	return nil
+}
+
+// feedKeys returns the names of the feeds of the podcast without extension, the public feed first.
+func (p *Processor) feedKeys(podcastID string) ([]string, error) {
+	feedKey, err := p.getFeedKey(podcastID)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{feedKey}
+	subscribers, err := p.subscribers(podcastID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subscribers {
+		keys = append(keys, subscriberFeedKey(podcastID, sub.Token))
+	}
+	return keys, nil
+}
+
+// presignedEnclosure links episodes with URLs presigned by the storage of the podcast.
+func (p *Processor) presignedEnclosure(podcastID string, expiry time.Duration) enclosureFunc {
+	return func(ctx context.Context, key, _ string) (string, error) {
+		presigner, ok := p.objects(podcastID).(Presigner)
+		if !ok {
+			return "", ErrPresignUnsupported
+		}
+		return presigner.PresignedURL(ctx, key, expiry)
+	}
+}
+
+// tokenEnclosure links episodes with the token appended to their location as the query parameter param.
+func tokenEnclosure(param, token string) enclosureFunc {
+	return func(_ context.Context, _, location string) (string, error) {
+		sep := "?"
+		if strings.Contains(location, "?") {
+			sep = "&"
+		}
+		return location + sep + url.QueryEscape(param) + "=" + url.QueryEscape(token), nil
+	}
+}
+
+// newToken returns a random subscriber token.
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("can't generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// subscriberFeedKey returns the name of the feed of a subscriber without extension. It is derived from the token,
+// so it can't be guessed from the podcast ID like the public feed.
+func subscriberFeedKey(podcastID, token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(podcastID+"/"+token)))
+}
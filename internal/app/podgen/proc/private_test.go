@@ -0,0 +1,271 @@
+package proc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+	"podgen/internal/storage/factory"
+)
+
+// newPrivateProcessor returns a processor publishing the uploaded podcast demo with one episode to objects.
+func newPrivateProcessor(t *testing.T, objects proc.ObjectStorage, private configs.PrivateFeed) (*proc.Processor, string) {
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	p := &proc.Processor{
+		Storage:      store,
+		Podcasts:     store,
+		History:      store,
+		Sessions:     store,
+		Subscribers:  store,
+		S3Client:     objects,
+		PrivateFeeds: map[string]configs.PrivateFeed{"demo": private},
+		Files:        &proc.Files{Storage: storagePath},
+		StoragePath:  storagePath,
+		ChunkSize:    2,
+	}
+	ctx := context.Background()
+	_, err = p.Update(ctx, "demo", "demo")
+	require.NoError(t, err)
+	require.NoError(t, p.UploadNewEpisodes(ctx, "sess", "demo", "demo", 0))
+	return p, storagePath
+}
+
+func TestProcessor_GenerateFeeds_Token(t *testing.T) {
+	root := t.TempDir()
+	objects := &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"}
+	p, storagePath := newPrivateProcessor(t, objects, configs.PrivateFeed{Mode: configs.PrivateToken, Subscribers: []configs.Subscriber{
+		{Name: "alice", Token: "alice-secret-token"},
+		{Name: "bob", Token: "bob+secret/token!"},
+	}})
+	ctx := context.Background()
+	entity := configs.Podcast{Title: "Demo", Folder: "demo"}
+
+	// a public feed published before the podcast went private
+	publicName, err := p.GenerateFeed(ctx, "demo", entity, "")
+	require.NoError(t, err)
+	_, err = p.UploadFeed(ctx, "demo", "demo", publicName)
+	require.NoError(t, err)
+
+	feeds, err := p.GenerateFeeds(ctx, "demo", entity, "")
+	require.NoError(t, err)
+	require.Len(t, feeds, 2)
+	assert.Equal(t, "alice", feeds[0].Subscriber)
+	assert.Equal(t, "bob", feeds[1].Subscriber)
+	assert.NotEqual(t, feeds[0].Filename, feeds[1].Filename)
+	assert.NotEqual(t, publicName, feeds[0].Filename)
+
+	alice, err := os.ReadFile(filepath.Join(storagePath, "demo", feeds[0].Filename))
+	require.NoError(t, err)
+	assert.Contains(t, string(alice), `<enclosure url="https://podcasts.example.com/demo/2024-01-15-ep.mp3?token=alice-secret-token"`)
+	bob, err := os.ReadFile(filepath.Join(storagePath, "demo", feeds[1].Filename))
+	require.NoError(t, err)
+	assert.Contains(t, string(bob), `?token=bob%2Bsecret%2Ftoken%21"`, "tokens are escaped")
+
+	for _, feed := range feeds {
+		_, err = p.UploadFeed(ctx, "demo", "demo", feed.Filename)
+		require.NoError(t, err)
+	}
+	record, err := p.Podcasts.GetPodcast("demo")
+	require.NoError(t, err)
+	assert.Empty(t, record.FeedURL, "feed URLs of subscribers are not recorded")
+
+	urls, err := p.GetSubscriberFeedURLs("demo", "demo", proc.URLTemplate{BaseURL: "https://podcasts.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"alice": "https://podcasts.example.com/demo/" + feeds[0].Filename,
+		"bob":   "https://podcasts.example.com/demo/" + feeds[1].Filename,
+	}, urls)
+
+	require.NoError(t, p.DeletePublicFeed(ctx, "demo", "demo"))
+	_, err = os.Stat(filepath.Join(root, "demo", publicName))
+	require.ErrorIs(t, err, os.ErrNotExist, "the public feed is deleted")
+	_, err = os.Stat(filepath.Join(storagePath, "demo", publicName))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, p.DeletePublicFeed(ctx, "demo", "demo"), "missing public feed is skipped")
+
+	report, err := p.Reconcile(ctx, "demo", "demo", "podcast.png")
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings, "feeds of subscribers are not orphans")
+
+	// feeds of removed subscribers are orphans, reconcile --fix deletes them
+	p.PrivateFeeds["demo"] = configs.PrivateFeed{Mode: configs.PrivateToken, Subscribers: []configs.Subscriber{
+		{Name: "alice", Token: "alice-secret-token"},
+	}}
+	report, err = p.Reconcile(ctx, "demo", "demo", "podcast.png")
+	require.NoError(t, err)
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, proc.IssueOrphanObject, report.Findings[0].Issue)
+	assert.Equal(t, "demo/"+feeds[1].Filename, report.Findings[0].Key)
+}
+
+func TestProcessor_GenerateFeeds_Presigned(t *testing.T) {
+	_, s3 := newFakeS3(t)
+	p, storagePath := newPrivateProcessor(t, s3, configs.PrivateFeed{Mode: configs.PrivatePresigned, Expiry: time.Hour,
+		Subscribers: []configs.Subscriber{{Name: "alice", Token: "alice-secret-token"}}})
+
+	feeds, err := p.GenerateFeeds(context.Background(), "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	feed, err := os.ReadFile(filepath.Join(storagePath, "demo", feeds[0].Filename))
+	require.NoError(t, err)
+	endpoint := strings.TrimRight(s3.Client.EndpointURL().String(), "/")
+	assert.Contains(t, string(feed), `<enclosure url="`+endpoint+`/bucket/demo/2024-01-15-ep.mp3?X-Amz-Algorithm=AWS4-HMAC-SHA256`)
+	assert.Contains(t, string(feed), "X-Amz-Expires=3600")
+	assert.Contains(t, string(feed), "X-Amz-Signature=")
+}
+
+func TestProcessor_GenerateFeeds_PresignUnsupported(t *testing.T) {
+	objects := &proc.FilesystemStore{Root: t.TempDir(), BaseURL: "https://podcasts.example.com"}
+	p, _ := newPrivateProcessor(t, objects, configs.PrivateFeed{Mode: configs.PrivatePresigned,
+		Subscribers: []configs.Subscriber{{Name: "alice", Token: "alice-secret-token"}}})
+
+	_, err := p.GenerateFeeds(context.Background(), "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.ErrorIs(t, err, proc.ErrPresignUnsupported)
+}
+
+func TestProcessor_Subscribers(t *testing.T) {
+	root := t.TempDir()
+	objects := &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"}
+	p, _ := newPrivateProcessor(t, objects, configs.PrivateFeed{Mode: configs.PrivateToken, Subscribers: []configs.Subscriber{
+		{Name: "alice", Token: "alice-secret-token"},
+	}})
+	ctx := context.Background()
+	entity := configs.Podcast{Title: "Demo", Folder: "demo"}
+	urls := proc.URLTemplate{BaseURL: "https://podcasts.example.com"}
+
+	_, err := p.AddSubscriber(ctx, "demo", "alice")
+	require.ErrorContains(t, err, "listed in the config")
+	_, err = p.AddSubscriber(ctx, "other", "bob")
+	require.ErrorContains(t, err, "podcast other is not private")
+
+	bob, err := p.AddSubscriber(ctx, "demo", "bob")
+	require.NoError(t, err)
+	assert.Len(t, bob.Token, 64)
+	_, err = p.AddSubscriber(ctx, "demo", "bob")
+	require.ErrorContains(t, err, "already exists")
+
+	publish := func() map[string]string {
+		feeds, err := p.GenerateFeeds(ctx, "demo", entity, "")
+		require.NoError(t, err)
+		names := map[string]string{}
+		for _, feed := range feeds {
+			_, err = p.UploadFeed(ctx, "demo", "demo", feed.Filename)
+			require.NoError(t, err)
+			names[feed.Subscriber] = feed.Filename
+		}
+		return names
+	}
+	feeds := publish()
+	require.Len(t, feeds, 2, "feeds of subscribers of the config and of the database")
+	published, err := os.ReadFile(filepath.Join(root, "demo", feeds["bob"]))
+	require.NoError(t, err)
+	assert.Contains(t, string(published), "?token="+bob.Token)
+	feedURLs, err := p.GetSubscriberFeedURLs("demo", "demo", urls)
+	require.NoError(t, err)
+	assert.Equal(t, "https://podcasts.example.com/demo/"+feeds["bob"], feedURLs["bob"])
+
+	// rotation deletes the feed at the old URL
+	rotated, err := p.RotateSubscriber(ctx, "demo", "demo", "bob")
+	require.NoError(t, err)
+	assert.NotEqual(t, bob.Token, rotated.Token)
+	assert.False(t, rotated.RotatedAt.IsZero())
+	_, err = os.Stat(filepath.Join(root, "demo", feeds["bob"]))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	rotatedFeeds := publish()
+	assert.NotEqual(t, feeds["bob"], rotatedFeeds["bob"])
+	assert.Equal(t, feeds["alice"], rotatedFeeds["alice"])
+
+	// revocation deletes the feed and keeps the subscriber listed
+	require.NoError(t, p.RevokeSubscriber(ctx, "demo", "demo", "bob"))
+	_, err = os.Stat(filepath.Join(root, "demo", rotatedFeeds["bob"]))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.ErrorContains(t, p.RevokeSubscriber(ctx, "demo", "demo", "bob"), "is revoked")
+	_, err = p.RotateSubscriber(ctx, "demo", "demo", "bob")
+	require.ErrorContains(t, err, "is revoked")
+	assert.Len(t, publish(), 1)
+
+	subs, err := p.ListSubscribers("demo")
+	require.NoError(t, err)
+	require.Len(t, subs, 2)
+	assert.Equal(t, "alice", subs[0].Name)
+	assert.True(t, subs[0].CreatedAt.IsZero(), "subscribers of the config have no times")
+	assert.Equal(t, "bob", subs[1].Name)
+	assert.False(t, subs[1].Active())
+	assert.Empty(t, subs[1].Token)
+
+	// revoked subscribers can be added again, with a new feed
+	again, err := p.AddSubscriber(ctx, "demo", "bob")
+	require.NoError(t, err)
+	assert.True(t, again.Active())
+	assert.Len(t, publish(), 2)
+
+	report, err := p.Reconcile(ctx, "demo", "demo", "podcast.png")
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings)
+}
+
+// lookupFailing fails lookups of objects with err, if set.
+type lookupFailing struct {
+	*proc.FilesystemStore
+	err error
+}
+
+func (s *lookupFailing) GetObjectInfo(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.FilesystemStore.GetObjectInfo(ctx, objectName)
+}
+
+func TestProcessor_Subscribers_FeedLookupFails(t *testing.T) {
+	root := t.TempDir()
+	objects := &lookupFailing{FilesystemStore: &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"}}
+	p, _ := newPrivateProcessor(t, objects, configs.PrivateFeed{Mode: configs.PrivateToken})
+	ctx := context.Background()
+	bob, err := p.AddSubscriber(ctx, "demo", "bob")
+	require.NoError(t, err)
+	feeds, err := p.GenerateFeeds(ctx, "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	_, err = p.UploadFeed(ctx, "demo", "demo", feeds[0].Filename)
+	require.NoError(t, err)
+	feedPath := filepath.Join(root, "demo", feeds[0].Filename)
+
+	// a failed lookup keeps the subscriber and the feed, so revoking and rotating can be retried
+	objects.err = errors.New("connection reset by peer")
+	require.ErrorContains(t, p.RevokeSubscriber(ctx, "demo", "demo", "bob"), "connection reset by peer")
+	_, err = p.RotateSubscriber(ctx, "demo", "demo", "bob")
+	require.ErrorContains(t, err, "connection reset by peer")
+	_, err = os.Stat(feedPath)
+	require.NoError(t, err)
+	subs, err := p.ListSubscribers("demo")
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, bob.Token, subs[0].Token)
+
+	objects.err = nil
+	require.NoError(t, p.RevokeSubscriber(ctx, "demo", "demo", "bob"))
+	_, err = os.Stat(feedPath)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// feeds that were never published are skipped
+	_, err = p.AddSubscriber(ctx, "demo", "carol")
+	require.NoError(t, err)
+	require.NoError(t, p.RevokeSubscriber(ctx, "demo", "demo", "carol"))
+}
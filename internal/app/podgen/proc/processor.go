@@ -6,8 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"html"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	log "github.com/go-pkgz/lgr"
 	"podgen/internal/app/podgen/artwork"
@@ -18,12 +23,29 @@ import (
 
 // Processor is searcher of episode files and writer to store
 type Processor struct {
-	Storage     EpisodeStore
-	Files       FileScanner
-	S3Client    ObjectStorage
-	Progress    ProgressReporter
-	StoragePath string
-	ChunkSize   int
+	Storage  EpisodeStore
+	Podcasts PodcastStore
+	History  HistoryStore
+	Sessions SessionStore
+	// Subscribers holds the subscribers of private podcasts added besides those of the config, optional.
+	Subscribers SubscriberStore
+	// Runs holds the run history of scheduled jobs, optional.
+	Runs     RunStore
+	Files    FileScanner
+	S3Client ObjectStorage
+	// PodcastStorage holds the object storage of podcasts published elsewhere than S3Client, by podcast ID.
+	PodcastStorage map[string]ObjectStorage
+	// KeyTemplates holds the object key layout of episodes by podcast ID, configs.DefaultKeyTemplate if missing.
+	KeyTemplates map[string]KeyTemplate
+	// FeedNames holds the names of public feeds without .rss by podcast ID, the SHA-256 of the ID if missing.
+	FeedNames map[string]string
+	// PrivateFeeds holds the subscribers of private podcasts by podcast ID, podcasts missing are public.
+	PrivateFeeds map[string]configs.PrivateFeed
+	Progress     ProgressReporter
+	StoragePath  string
+	// OutputPath is where generated feeds and artwork are written, StoragePath if empty.
+	OutputPath string
+	ChunkSize  int
 }
 
 // UploadedEpisode struct for result of upload
@@ -31,6 +53,8 @@ type UploadedEpisode struct {
 	PodcastID string
 	Filename  string
 	Location  string
+	Checksum  string
+	Key       string
 }
 
 // DeletedEpisode struct for result of delete
@@ -71,14 +95,25 @@ func (p *Processor) Update(ctx context.Context, folderName, podcastID string) (i
 			return 0, fmt.Errorf("can't add episode %s to %s, %w", episode.Filename, podcastID, e)
 		}
 		log.Printf("[INFO] added new episode: %s", episode.Filename)
+		p.recordHistory(podcastID, storage.EpisodeEvent{
+			Filename:  episode.Filename,
+			Operation: storage.OperationScan,
+			OldStatus: podcast.New,
+			NewStatus: podcast.New,
+		})
 		countNew++
 	}
 
+	p.updatePodcast(podcastID, func(record *podcast.Podcast) {
+		record.LastScanAt = time.Now()
+	})
+
 	return countNew, nil
 }
 
-// DeleteOldEpisodesByPodcast from s3 storage
-func (p *Processor) DeleteOldEpisodesByPodcast(ctx context.Context, podcastID, podcastFolder string) error {
+// DeleteOldEpisodesByPodcast from s3 storage.
+// session is the ID of the run the deletion is part of, recorded in episode history.
+func (p *Processor) DeleteOldEpisodesByPodcast(ctx context.Context, session, podcastID, podcastFolder string) error {
 	episodes, err := p.Storage.FindEpisodesByStatus(podcastID, podcast.Uploaded)
 	if err != nil {
 		return fmt.Errorf("can't find episodes %s, %w", podcastID, err)
@@ -135,11 +170,12 @@ func (p *Processor) DeleteOldEpisodesByPodcast(ctx context.Context, podcastID, p
 			j := j             // capture loop variable
 			episode := episode // capture loop variable
 			tasks[j] = func(ctx context.Context) error {
+				ctx = withWorkerID(ctx, j)
 				// Note: verbose logging removed to avoid interfering with progress bar display
 				if p.Progress != nil {
 					p.Progress.StartFile(j, episode.Filename, 0)
 				}
-				delErr := p.S3Client.DeleteEpisode(ctx, fmt.Sprintf("%s/%s", podcastFolder, episode.Filename))
+				delErr := p.objects(podcastID).DeleteEpisode(ctx, p.episodeKey(podcastFolder, episode))
 				if p.Progress != nil {
 					p.Progress.CompleteFile(j, 0, delErr)
 				}
@@ -155,6 +191,15 @@ func (p *Processor) DeleteOldEpisodesByPodcast(ctx context.Context, podcastID, p
 			if err != nil {
 				log.Printf("[ERROR] can't delete episode %s, %v", results[resultIdx].filename, err)
 				deleteErrs = append(deleteErrs, fmt.Errorf("delete %s: %w", results[resultIdx].filename, err))
+				p.recordHistory(podcastID, storage.EpisodeEvent{
+					Filename:  episodes[resultIdx].Filename,
+					Operation: storage.OperationDelete,
+					OldStatus: episodes[resultIdx].Status,
+					NewStatus: episodes[resultIdx].Status,
+					Session:   session,
+					Location:  episodes[resultIdx].Location,
+					Error:     err.Error(),
+				})
 				continue
 			}
 			results[resultIdx].ok = true
@@ -175,19 +220,31 @@ func (p *Processor) DeleteOldEpisodesByPodcast(ctx context.Context, podcastID, p
 				log.Printf("[WARN] episode not found after delete: %s - %s", podcastID, results[j].filename)
 				continue
 			}
+			event := storage.EpisodeEvent{
+				Filename:  episode.Filename,
+				Operation: storage.OperationDelete,
+				OldStatus: episode.Status,
+				NewStatus: podcast.Deleted,
+				Session:   session,
+				Location:  episode.Location,
+			}
 			episode.Status = podcast.Deleted
 			if err = p.Storage.SaveEpisode(podcastID, episode); err != nil {
 				log.Printf("[ERROR] can't change status episode %s, %v", episode.Filename, err)
 				deleteErrs = append(deleteErrs, fmt.Errorf("save episode %s: %w", episode.Filename, err))
+				event.NewStatus, event.Error = event.OldStatus, err.Error()
 			}
+			p.recordHistory(podcastID, event)
 		}
 	}
 
 	return errors.Join(deleteErrs...)
 }
 
-// RollbackLastEpisodes last deleted episode
-func (p *Processor) RollbackLastEpisodes(ctx context.Context, podcastID string) error {
+// RollbackLastEpisodes reverts the last uploaded or deleted episode of the podcast.
+// If the episode was uploaded in a session, the whole session is rolled back, see RollbackEpisodesOfSession.
+// session is the ID of the rollback run.
+func (p *Processor) RollbackLastEpisodes(ctx context.Context, session, podcastID, podcastFolder string) error {
 	episode, err := p.Storage.GetLastEpisodeByNotStatus(podcastID, podcast.New)
 	if err != nil {
 		log.Printf("[ERROR] can't find episodes %s, %v", podcastID, err)
@@ -200,52 +257,231 @@ func (p *Processor) RollbackLastEpisodes(ctx context.Context, podcastID string)
 	}
 
 	if episode.Session != "" {
-		return p.RollbackEpisodesOfSession(ctx, podcastID, episode.Session)
+		return p.RollbackEpisodesOfSession(ctx, session, podcastID, podcastFolder, episode.Session)
 	}
 
-	episode.Status = podcast.New
-	if err = p.Storage.SaveEpisode(podcastID, episode); err != nil {
-		log.Printf("[ERROR] can't change status episode %s, %v", episode.Filename, err)
+	return p.revertEpisode(ctx, session, podcastID, podcastFolder, episode)
+}
+
+// RollbackEpisodesOfSession reverts what the target session did to the podcast.
+// Episodes uploaded in the target session are removed from object storage and set back to New,
+// episodes it deleted from object storage are uploaded again from local files.
+// session is the ID of the rollback run.
+func (p *Processor) RollbackEpisodesOfSession(ctx context.Context, session, podcastID, podcastFolder, target string) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	return nil
-}
+	log.Printf("[INFO] Started rollback episodes of session %s - %s", target, podcastID)
 
-// RollbackEpisodesOfSession last deleted episode of session
-func (p *Processor) RollbackEpisodesOfSession(ctx context.Context, podcastID, session string) error {
-	episodes, err := p.Storage.FindEpisodesBySession(podcastID, session)
+	episodes, err := p.Storage.QueryEpisodes(podcastID, storage.EpisodeQuery{EpisodeFilter: storage.EpisodeFilter{
+		Session:  target,
+		Statuses: []podcast.Status{podcast.Uploaded, podcast.Deleted},
+	}})
 	if err != nil {
-		log.Printf("[ERROR] can't find episodes %s, %v", podcastID, err)
+		log.Printf("[ERROR] can't find episodes of session %s - %s, %v", target, podcastID, err)
 		return err
 	}
 
-	if len(episodes) == 0 {
+	var errs []error
+	reverted := 0
+	for _, episode := range episodes {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := p.revertEpisode(ctx, session, podcastID, podcastFolder, episode); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reverted++
+	}
+
+	restored, err := p.restoreDeletedEpisodes(ctx, session, podcastID, podcastFolder, target)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if reverted == 0 && restored == 0 && len(errs) == 0 {
 		log.Printf("[INFO] Episodes for rollback not found %s", podcastID)
 		return nil
 	}
 
-	log.Printf("[INFO] Started rollback episodes %s", podcastID)
+	log.Printf("[INFO] Rolled back session %s - %s: %d episodes reverted, %d restored", target, podcastID, reverted, restored)
+	return errors.Join(errs...)
+}
 
-	for _, episode := range episodes {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+// revertEpisode removes the uploaded episode from object storage and sets it back to New.
+// Deleted episodes are not in object storage anymore, so only their status is reset.
+func (p *Processor) revertEpisode(ctx context.Context, session, podcastID, podcastFolder string, episode *podcast.Episode) error {
+	event := storage.EpisodeEvent{
+		Filename:  episode.Filename,
+		Operation: storage.OperationRollback,
+		OldStatus: episode.Status,
+		NewStatus: podcast.New,
+		Session:   session,
+		Location:  episode.Location,
+	}
 
-		episode.Status = podcast.New
-		if err = p.Storage.SaveEpisode(podcastID, episode); err != nil {
-			log.Printf("[ERROR] can't change status episode %s, %v", episode.Filename, err)
-			return err
+	if episode.Status == podcast.Uploaded {
+		if err := p.objects(podcastID).DeleteEpisode(ctx, p.episodeKey(podcastFolder, episode)); err != nil {
+			log.Printf("[ERROR] can't delete episode %s, %v", episode.Filename, err)
+			event.NewStatus, event.Error = event.OldStatus, err.Error()
+			p.recordHistory(podcastID, event)
+			return fmt.Errorf("delete %s: %w", episode.Filename, err)
 		}
+	}
 
-		log.Printf("[INFO] Episode rollback %s - %s", episode.Filename, podcastID)
+	episode.Status = podcast.New
+	if err := p.Storage.SaveEpisode(podcastID, episode); err != nil {
+		log.Printf("[ERROR] can't change status episode %s, %v", episode.Filename, err)
+		event.NewStatus, event.Error = event.OldStatus, err.Error()
+		p.recordHistory(podcastID, event)
+		return fmt.Errorf("save episode %s: %w", episode.Filename, err)
 	}
 
+	p.recordHistory(podcastID, event)
 	return nil
 }
 
+// restoreDeletedEpisodes uploads again the episodes the target session deleted from object storage.
+// Deletions are looked up in the episode history, so nothing is restored without it.
+// Episodes removed or uploaded again since then are left alone.
+func (p *Processor) restoreDeletedEpisodes(ctx context.Context, session, podcastID, podcastFolder, target string) (int, error) {
+	if p.History == nil {
+		log.Printf("[WARN] episode history is not available, episodes deleted by session %s - %s are not restored", target, podcastID)
+		return 0, nil
+	}
+
+	events, err := p.History.ListHistory(podcastID, storage.HistoryFilter{})
+	if err != nil {
+		log.Printf("[ERROR] can't get history %s, %v", podcastID, err)
+		return 0, fmt.Errorf("get history: %w", err)
+	}
+
+	var filenames []string
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if e.Operation != storage.OperationDelete || e.Session != target || e.Error != "" || seen[e.Filename] {
+			continue
+		}
+		seen[e.Filename] = true
+		filenames = append(filenames, e.Filename)
+	}
+
+	var errs []error
+	restored := 0
+	for _, filename := range filenames {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		episode, err := p.Storage.GetEpisodeByFilename(podcastID, filename)
+		if err != nil {
+			log.Printf("[ERROR] can't get episode by filename %s - %s, %v", podcastID, filename, err)
+			errs = append(errs, fmt.Errorf("get episode %s: %w", filename, err))
+			continue
+		}
+		if episode == nil || episode.Status != podcast.Deleted {
+			continue
+		}
+
+		event := storage.EpisodeEvent{
+			Filename:  episode.Filename,
+			Operation: storage.OperationRollback,
+			OldStatus: podcast.Deleted,
+			NewStatus: podcast.Uploaded,
+			Session:   session,
+		}
+		result, err := p.uploadSingleEpisode(ctx, podcastID, podcastFolder, episode, nil)
+		if err != nil {
+			log.Printf("[ERROR] can't upload episode %s, %v", episode.Filename, err)
+			event.NewStatus, event.Error = event.OldStatus, err.Error()
+			p.recordHistory(podcastID, event)
+			errs = append(errs, fmt.Errorf("upload %s: %w", episode.Filename, err))
+			continue
+		}
+
+		event.Location = result.Location
+		episode.Status = podcast.Uploaded
+		episode.Location = result.Location
+		episode.Checksum = result.Checksum
+		episode.Key = result.Key
+		if err := p.Storage.SaveEpisode(podcastID, episode); err != nil {
+			log.Printf("[ERROR] can't save episode %s, %v", episode.Filename, err)
+			event.NewStatus, event.Error = event.OldStatus, err.Error()
+			p.recordHistory(podcastID, event)
+			errs = append(errs, fmt.Errorf("save episode %s: %w", episode.Filename, err))
+			continue
+		}
+		p.recordHistory(podcastID, event)
+		restored++
+	}
+
+	return restored, errors.Join(errs...)
+}
+
+// ListEpisodes returns episodes of podcast matching the query
+func (p *Processor) ListEpisodes(ctx context.Context, podcastID string, query storage.EpisodeQuery) ([]*podcast.Episode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	episodes, err := p.Storage.QueryEpisodes(podcastID, query)
+	if err != nil {
+		log.Printf("[ERROR] can't query episodes %s, %v", podcastID, err)
+		return nil, err
+	}
+
+	return episodes, nil
+}
+
+// GetEpisode returns the stored episode of podcast, storage.ErrNotFound if there is none
+func (p *Processor) GetEpisode(podcastID, filename string) (*podcast.Episode, error) {
+	return p.Storage.GetEpisodeByFilename(podcastID, filename)
+}
+
+// EpisodeEdit holds changes to the metadata of an episode, nil fields are left as they are.
+type EpisodeEdit struct {
+	Title   *string
+	Artist  *string
+	Album   *string
+	Year    *string
+	Comment *string
+}
+
+// EditEpisode changes the stored metadata of an episode, feeds show it once they are regenerated.
+// The ID3 tags of the file are not changed. storage.ErrNotFound is returned for unknown episodes.
+func (p *Processor) EditEpisode(ctx context.Context, podcastID, filename string, edit EpisodeEdit) (*podcast.Episode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	episode, err := p.Storage.GetEpisodeByFilename(podcastID, filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{edit.Title, &episode.Title},
+		{edit.Artist, &episode.Artist},
+		{edit.Album, &episode.Album},
+		{edit.Year, &episode.Year},
+		{edit.Comment, &episode.Comment},
+	} {
+		if field.value != nil {
+			*field.target = strings.TrimSpace(*field.value)
+		}
+	}
+	if err := p.Storage.SaveEpisode(podcastID, episode); err != nil {
+		return nil, fmt.Errorf("can't save episode %s: %w", filename, err)
+	}
+	return episode, nil
+}
+
 // UploadPodcastImage to s3 storage.
 // If the image file is not found and autoGenerate is true, artwork is generated using podcastTitle as the label.
 // If forceRegenerate is true, artwork is always regenerated regardless of existing images.
@@ -257,6 +493,10 @@ func (p *Processor) UploadPodcastImage(ctx context.Context, podcastID, podcastFo
 
 	// Force regenerate artwork — always generate, no file checks
 	if forceRegenerate {
+		var err error
+		if podcastImagePath, err = p.outputPath(podcastFolder, podcastImageFilename); err != nil {
+			return "", err
+		}
 		log.Printf("[INFO] Force generating artwork for %s at %s (style: %s)", podcastID, podcastImagePath, artworkStyle)
 		if err := artwork.GenerateWithStyle(podcastID, podcastTitle, podcastImagePath, artworkStyle); err != nil {
 			return "", fmt.Errorf("artwork generation for %s: %w", podcastID, err)
@@ -272,7 +512,10 @@ func (p *Processor) UploadPodcastImage(ctx context.Context, podcastID, podcastFo
 				return "", errors.New("podcast image not found")
 			}
 
-			podcastImagePath = fmt.Sprintf("%s/%s/%s", p.StoragePath, podcastFolder, podcastImageFilename)
+			var err error
+			if podcastImagePath, err = p.outputPath(podcastFolder, podcastImageFilename); err != nil {
+				return "", err
+			}
 			log.Printf("[INFO] Generating artwork for %s at %s (style: %s)", podcastID, podcastImagePath, artworkStyle)
 			if err := artwork.GenerateWithStyle(podcastID, podcastTitle, podcastImagePath, artworkStyle); err != nil {
 				return "", fmt.Errorf("artwork generation for %s: %w", podcastID, err)
@@ -280,7 +523,7 @@ func (p *Processor) UploadPodcastImage(ctx context.Context, podcastID, podcastFo
 		}
 	}
 
-	uploadInfo, err := p.S3Client.UploadImage(ctx,
+	uploadInfo, err := p.objects(podcastID).UploadImage(withObjectMetadata(ctx, map[string]string{"podcast": podcastID}),
 		fmt.Sprintf("%s/%s", podcastFolder, podcastImageFilename),
 		podcastImagePath)
 
@@ -290,17 +533,98 @@ func (p *Processor) UploadPodcastImage(ctx context.Context, podcastID, podcastFo
 
 	log.Printf("[INFO] Image of podcast uploaded %s - %s", podcastImageFilename, uploadInfo.Location)
 
+	p.updatePodcast(podcastID, func(record *podcast.Podcast) {
+		record.ImageURL = uploadInfo.Location
+	})
+
 	return uploadInfo.Location, nil
 }
 
-// GetPodcastImage from s3 storage
-func (p *Processor) GetPodcastImage(ctx context.Context, podcastFolder, podcastImageFilename string) string {
-	imageInfo, err := p.S3Client.GetObjectInfo(ctx, fmt.Sprintf("%s/%s", podcastFolder, podcastImageFilename))
+// GetPodcastImage returns image URL recorded on upload, falling back to s3 storage lookup
+func (p *Processor) GetPodcastImage(ctx context.Context, podcastID, podcastFolder, podcastImageFilename string) string {
+	objectName := fmt.Sprintf("%s/%s", podcastFolder, podcastImageFilename)
+	if record := p.getPodcast(podcastID); record != nil && record.ImageURL != "" {
+		return p.publicLocation(podcastID, objectName, record.ImageURL)
+	}
+
+	imageInfo, err := p.objects(podcastID).GetObjectInfo(ctx, objectName)
 	if err != nil {
 		log.Printf("[ERROR] can't get image info %s, %v", podcastImageFilename, err)
 		return ""
 	}
-	return imageInfo.Location
+
+	p.updatePodcast(podcastID, func(record *podcast.Podcast) {
+		record.ImageURL = imageInfo.Location
+	})
+
+	return p.publicLocation(podcastID, objectName, imageInfo.Location)
+}
+
+// RewriteLocations sets the locations of stored episodes and the podcast image to the URLs built by urls,
+// after the public URL of the storage changed. Objects aren't touched, feeds need to be regenerated afterwards.
+// Returns the number of episodes changed.
+func (p *Processor) RewriteLocations(ctx context.Context, podcastID, podcastFolder, podcastImageFilename string, urls URLTemplate) (int, error) {
+	episodes, err := p.Storage.QueryEpisodes(podcastID, storage.EpisodeQuery{})
+	if err != nil {
+		return 0, fmt.Errorf("can't list episodes of %s: %w", podcastID, err)
+	}
+
+	resolver, mirrored := p.objects(podcastID).(LocationResolver)
+	rewrite := func(objectName, location string) string {
+		if location == "" {
+			return ""
+		}
+		location = urls.URL(objectName)
+		if mirrored {
+			if err := resolver.SetPrimaryLocation(objectName, location); err != nil {
+				log.Printf("[WARN] can't rewrite primary location of %s, %v", objectName, err)
+			}
+		}
+		return location
+	}
+
+	var changed []*podcast.Episode
+	for _, episode := range episodes {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		location := rewrite(p.episodeKey(podcastFolder, episode), episode.Location)
+		if location == episode.Location {
+			continue
+		}
+		episode.Location = location
+		changed = append(changed, episode)
+	}
+	if len(changed) > 0 {
+		if err := p.Storage.SaveEpisodes(podcastID, changed); err != nil {
+			return 0, fmt.Errorf("can't save episodes of %s: %w", podcastID, err)
+		}
+	}
+
+	p.updatePodcast(podcastID, func(record *podcast.Podcast) {
+		record.ImageURL = rewrite(fmt.Sprintf("%s/%s", podcastFolder, podcastImageFilename), record.ImageURL)
+	})
+	return len(changed), nil
+}
+
+// AbortStaleUploads aborts incomplete uploads that won't be resumed, in every object storage resuming uploads.
+func (p *Processor) AbortStaleUploads(ctx context.Context) error {
+	storages := []ObjectStorage{p.S3Client}
+	for _, id := range slices.Sorted(maps.Keys(p.PodcastStorage)) {
+		if !slices.Contains(storages, p.PodcastStorage[id]) {
+			storages = append(storages, p.PodcastStorage[id])
+		}
+	}
+
+	var errs []error
+	for _, objects := range storages {
+		if cleaner, ok := objects.(UploadCleaner); ok {
+			if _, err := cleaner.AbortStaleUploads(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // UploadNewEpisodes get new episodes by total limit of size and upload to s3 storage
@@ -335,6 +659,7 @@ func (p *Processor) UploadNewEpisodes(ctx context.Context, session, podcastID, p
 
 	// uploadFn uses stable workerID for progress reporting
 	uploadFn := func(ctx context.Context, workerID int, task UploadTask) UploadTaskResult {
+		ctx = withWorkerID(ctx, workerID)
 		if p.Progress != nil {
 			p.Progress.StartFile(workerID, task.Episode.Filename, task.Episode.Size)
 		}
@@ -352,6 +677,8 @@ func (p *Processor) UploadNewEpisodes(ctx context.Context, session, podcastID, p
 			Index:    task.Index,
 			Episode:  task.Episode,
 			Location: result.Location,
+			Checksum: result.Checksum,
+			Key:      result.Key,
 			Err:      uploadErr,
 		}
 	}
@@ -365,6 +692,14 @@ func (p *Processor) UploadNewEpisodes(ctx context.Context, session, podcastID, p
 		if result.Err != nil {
 			log.Printf("[ERROR] can't upload episode %s, %v", result.Episode.Filename, result.Err)
 			uploadErrs = append(uploadErrs, fmt.Errorf("upload %s: %w", result.Episode.Filename, result.Err))
+			p.recordHistory(podcastID, storage.EpisodeEvent{
+				Filename:  result.Episode.Filename,
+				Operation: storage.OperationUpload,
+				OldStatus: result.Episode.Status,
+				NewStatus: result.Episode.Status,
+				Session:   session,
+				Error:     result.Err.Error(),
+			})
 			continue
 		}
 		episode, err := p.Storage.GetEpisodeByFilename(podcastID, result.Episode.Filename)
@@ -378,14 +713,25 @@ func (p *Processor) UploadNewEpisodes(ctx context.Context, session, podcastID, p
 			uploadErrs = append(uploadErrs, fmt.Errorf("episode not found after upload: %s", result.Episode.Filename))
 			continue
 		}
+		event := storage.EpisodeEvent{
+			Filename:  episode.Filename,
+			Operation: storage.OperationUpload,
+			OldStatus: episode.Status,
+			NewStatus: podcast.Uploaded,
+			Session:   session,
+			Location:  result.Location,
+		}
 		episode.Session = session
 		episode.Status = podcast.Uploaded
 		episode.Location = result.Location
+		episode.Checksum = result.Checksum
+		episode.Key = result.Key
 		if err = p.Storage.SaveEpisode(podcastID, episode); err != nil {
 			log.Printf("[ERROR] can't save episode %s, %v", episode.Filename, err)
 			uploadErrs = append(uploadErrs, fmt.Errorf("save episode %s: %w", episode.Filename, err))
-			continue
+			event.NewStatus, event.Error = event.OldStatus, err.Error()
 		}
+		p.recordHistory(podcastID, event)
 	}
 
 	// Include context error if canceled to signal incomplete work
@@ -395,13 +741,64 @@ func (p *Processor) UploadNewEpisodes(ctx context.Context, session, podcastID, p
 	return errors.Join(uploadErrs...)
 }
 
-// GenerateFeed to podcast
-func (p *Processor) GenerateFeed(_ context.Context, podcastID string, podcastEntity configs.Podcast, podcastImageURL string) (string, error) {
+// GenerateFeed writes the public feed of the podcast and returns its file name
+func (p *Processor) GenerateFeed(ctx context.Context, podcastID string, podcastEntity configs.Podcast, podcastImageURL string) (string, error) {
+	feedKey, err := p.getFeedKey(podcastID)
+	if err != nil {
+		return "", fmt.Errorf("can't generate feed key for %s, %w", podcastID, err)
+	}
+	return p.writeFeed(ctx, podcastID, podcastEntity, podcastImageURL, feedKey, nil)
+}
+
+// enclosureFunc returns the enclosure URL of the episode stored at key, location is its public URL.
+type enclosureFunc func(ctx context.Context, key, location string) (string, error)
+
+// writeFeed writes the feed of uploaded episodes to <feedKey>.rss and returns the file name.
+// Enclosures link to the public location of episodes, or to the URL returned by enclosure if set.
+func (p *Processor) writeFeed(ctx context.Context, podcastID string, podcastEntity configs.Podcast, podcastImageURL,
+	feedKey string, enclosure enclosureFunc) (string, error) {
 	episodes, err := p.Storage.FindEpisodesByStatus(podcastID, podcast.Uploaded)
 	if err != nil {
 		return "", fmt.Errorf("can't find episodes %s, %w", podcastID, err)
 	}
 
+	feed, err := renderFeed(podcastEntity, podcastImageURL, episodes, func(episode *podcast.Episode) (string, error) {
+		key := p.episodeKey(podcastEntity.Folder, episode)
+		location := p.publicLocation(podcastID, key, episode.Location)
+		if enclosure == nil {
+			return location, nil
+		}
+		return enclosure(ctx, key, location)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	feedFilename := fmt.Sprintf("%s.rss", feedKey)
+	feedPath, err := p.outputPath(podcastEntity.Folder, feedFilename)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Create(feedPath) // nolint
+	if err != nil {
+		return "", fmt.Errorf("can't create file %s, %w", feedPath, err)
+	}
+	defer func(f *os.File) {
+		if err = f.Close(); err != nil {
+			log.Printf("[ERROR] can't close file %s, %v", feedPath, err)
+		}
+	}(f)
+
+	if _, err = f.WriteString(feed); err != nil {
+		return "", fmt.Errorf("can't write to file %s: %w", feedPath, err)
+	}
+
+	return feedFilename, nil
+}
+
+// renderFeed returns the RSS document of the episodes, link returns the enclosure URL of an episode.
+func renderFeed(podcastEntity configs.Podcast, podcastImageURL string, episodes []*podcast.Episode,
+	link func(episode *podcast.Episode) (string, error)) (string, error) {
 	var header, body, footer string
 
 	info := map[string]string{
@@ -463,14 +860,18 @@ func (p *Processor) GenerateFeed(_ context.Context, podcastID string, podcastEnt
 			title = episode.Filename
 		}
 		desc := BuildItemDescription(episode)
+		location, err := link(episode)
+		if err != nil {
+			return "", fmt.Errorf("can't link episode %s: %w", episode.Filename, err)
+		}
 		item := "<item>\n" +
 			fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)) +
 			fmt.Sprintf("<description><![CDATA[%s]]></description>\n", desc) +
 			fmt.Sprintf("<itunes:summary><![CDATA[%s]]></itunes:summary>\n", desc) +
 			fmt.Sprintf("<pubDate>%s</pubDate>\n", episode.PubDate) +
 			fmt.Sprintf("<itunes:image href=%q />\n", podcastImageURL) +
-			fmt.Sprintf("<enclosure url=%q type=\"audio/mp3\" length=\"%d\" />\n", episode.Location, episode.Size) +
-			fmt.Sprintf("<media:content url=%q fileSize=\"%d\" type=\"audio/mp3\" />\n", episode.Location, episode.Size) +
+			fmt.Sprintf("<enclosure url=%q type=\"audio/mp3\" length=\"%d\" />\n", location, episode.Size) +
+			fmt.Sprintf("<media:content url=%q fileSize=\"%d\" type=\"audio/mp3\" />\n", location, episode.Size) +
 			"<itunes:explicit>No</itunes:explicit>\n"
 		if episode.Duration != "" {
 			item += fmt.Sprintf("<itunes:duration>%s</itunes:duration>\n", episode.Duration)
@@ -479,44 +880,166 @@ func (p *Processor) GenerateFeed(_ context.Context, podcastID string, podcastEnt
 		body += item
 	}
 
-	feedKey, err := p.getFeedKey(podcastID)
+	return fmt.Sprintf("%s\n%s\n%s", header, body, footer), nil
+}
+
+// UploadFeed of podcast to s3 storage
+func (p *Processor) UploadFeed(ctx context.Context, podcastID, podcastFolder, feedName string) (*UploadResult, error) {
+	feedPath, err := p.outputPath(podcastFolder, feedName)
 	if err != nil {
-		return "", fmt.Errorf("can't generate feed key for %s, %w", podcastID, err)
+		return nil, err
 	}
-	feedFilename := fmt.Sprintf("%s.rss", feedKey)
-	feedPath := fmt.Sprintf("%s/%s/%s", p.StoragePath, podcastEntity.Folder, feedFilename)
-	f, err := os.Create(feedPath) // nolint
+	uploadInfo, err := p.objects(podcastID).UploadFeed(withObjectMetadata(ctx, map[string]string{"podcast": podcastID}),
+		fmt.Sprintf("%s/%s", podcastFolder, feedName), feedPath)
+
 	if err != nil {
-		return "", fmt.Errorf("can't create file %s, %w", feedPath, err)
+		log.Printf("[ERROR] can't upload feed %s, %v", feedName, err)
+		return nil, fmt.Errorf("upload feed %s: %w", feedName, err)
 	}
-	defer func(f *os.File) {
-		if err = f.Close(); err != nil {
-			log.Printf("[ERROR] can't close file %s, %v", feedPath, err)
+
+	// feeds of subscribers are not recorded, the record would give their URLs away
+	private := p.PrivateFeeds[podcastID].IsSet()
+	p.updatePodcast(podcastID, func(record *podcast.Podcast) {
+		record.LastPublishAt = time.Now()
+		if private {
+			record.FeedURL, record.FeedHash = "", ""
+			return
 		}
-	}(f)
+		record.FeedURL = uploadInfo.Location
+		record.FeedHash = fileHash(feedPath)
+	})
 
-	if _, err = fmt.Fprintf(f, "%s\n%s\n%s", header, body, footer); err != nil {
-		return "", fmt.Errorf("can't write to file %s: %w", feedPath, err)
+	return uploadInfo, nil
+}
+
+// EpisodeHistory returns recorded state transitions of podcast episodes, oldest first
+func (p *Processor) EpisodeHistory(ctx context.Context, podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.History == nil {
+		return nil, errors.New("episode history is not available")
 	}
 
-	return feedFilename, nil
+	events, err := p.History.ListHistory(podcastID, filter)
+	if err != nil {
+		log.Printf("[ERROR] can't get history %s, %v", podcastID, err)
+		return nil, err
+	}
+
+	return events, nil
 }
 
-// UploadFeed of podcast to s3 storage
-func (p *Processor) UploadFeed(ctx context.Context, podcastFolder, feedName string) (*UploadResult, error) {
-	uploadInfo, err := p.S3Client.UploadFeed(ctx,
-		fmt.Sprintf("%s/%s", podcastFolder, feedName),
-		fmt.Sprintf("%s/%s/%s", p.StoragePath, podcastFolder, feedName))
+// recordHistory appends episode state transitions to the history, stamping them with the current time.
+// History is informational, so failures are logged and don't fail the operation.
+func (p *Processor) recordHistory(podcastID string, events ...storage.EpisodeEvent) {
+	if p.History == nil || len(events) == 0 {
+		return
+	}
+	now := time.Now()
+	for i := range events {
+		if events[i].At.IsZero() {
+			events[i].At = now
+		}
+	}
+	if err := p.History.AppendHistory(podcastID, events); err != nil {
+		log.Printf("[WARN] can't record history of %s, %v", podcastID, err)
+	}
+}
 
+// getPodcast returns the stored podcast record, or nil if there is none
+func (p *Processor) getPodcast(podcastID string) *podcast.Podcast {
+	if p.Podcasts == nil {
+		return nil
+	}
+	record, err := p.Podcasts.GetPodcast(podcastID)
 	if err != nil {
-		log.Printf("[ERROR] can't upload feed %s, %v", feedName, err)
-		return nil, fmt.Errorf("upload feed %s: %w", feedName, err)
+		if !errors.Is(err, storage.ErrPodcastNotFound) {
+			log.Printf("[WARN] can't get podcast record %s, %v", podcastID, err)
+		}
+		return nil
 	}
+	return record
+}
 
-	return uploadInfo, nil
+// updatePodcast applies fn to the podcast record and saves it, creating the record if needed.
+// Records only cache what was done, so failures are logged and don't fail the operation.
+func (p *Processor) updatePodcast(podcastID string, fn func(record *podcast.Podcast)) {
+	if p.Podcasts == nil {
+		return
+	}
+	record := p.getPodcast(podcastID)
+	if record == nil {
+		record = &podcast.Podcast{ID: podcastID}
+	}
+	fn(record)
+	if err := p.Podcasts.SavePodcast(record); err != nil {
+		log.Printf("[WARN] can't save podcast record %s, %v", podcastID, err)
+	}
+}
+
+// objects returns the object storage the podcast is published to.
+func (p *Processor) objects(podcastID string) ObjectStorage {
+	if objects, ok := p.PodcastStorage[podcastID]; ok {
+		return objects
+	}
+	return p.S3Client
+}
+
+// publicLocation returns the URL feeds link the object at, location unless the podcast is mirrored
+// and its primary destination is down.
+func (p *Processor) publicLocation(podcastID, objectName, location string) string {
+	if resolver, ok := p.objects(podcastID).(LocationResolver); ok {
+		return resolver.PublicLocation(objectName, location)
+	}
+	return location
+}
+
+// FeedDestination returns the name of the destination feeds of the podcast link to,
+// configs.PrimaryDestination unless the podcast is mirrored and its primary destination is down.
+func (p *Processor) FeedDestination(podcastID string) string {
+	if resolver, ok := p.objects(podcastID).(LocationResolver); ok {
+		return resolver.ServingDestination()
+	}
+	return configs.PrimaryDestination
+}
+
+// outputPath returns the path of a generated file of the podcast folder.
+// Generated files go next to episodes unless OutputPath is set.
+func (p *Processor) outputPath(podcastFolder, filename string) (string, error) {
+	if p.OutputPath == "" {
+		return fmt.Sprintf("%s/%s/%s", p.StoragePath, podcastFolder, filename), nil
+	}
+	dir := filepath.Join(p.OutputPath, podcastFolder)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("can't create output folder %s: %w", dir, err)
+	}
+	return filepath.Join(dir, filename), nil
 }
 
+// fileHash returns hex encoded sha256 of the file content, or empty string if it can't be read
+func fileHash(path string) string {
+	f, err := os.Open(path) // nolint
+	if err != nil {
+		log.Printf("[WARN] can't open file %s for hashing, %v", path, err)
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		log.Printf("[WARN] can't hash file %s, %v", path, err)
+		return ""
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// getFeedKey returns the name of the public feed of the podcast without .rss: the configured feed name,
+// or the SHA-256 of the podcast ID.
 func (p *Processor) getFeedKey(podcastID string) (string, error) {
+	if name := p.FeedNames[podcastID]; name != "" {
+		return name, nil
+	}
 	h := sha256.New()
 	if _, err := h.Write([]byte(podcastID)); err != nil {
 		return "", err
@@ -524,22 +1047,13 @@ func (p *Processor) getFeedKey(podcastID string) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
-// GetFeedURL returns the RSS feed URL for a podcast
-func (p *Processor) GetFeedURL(podcastID, podcastFolder, baseURL, bucket string) (string, error) {
+// GetFeedURL returns the RSS feed URL for a podcast, built by the URL template of its storage.
+func (p *Processor) GetFeedURL(podcastID, podcastFolder string, urls URLTemplate) (string, error) {
 	feedKey, err := p.getFeedKey(podcastID)
 	if err != nil {
 		return "", err
 	}
-	// Preserve original scheme, default to https if no scheme present
-	scheme := "https"
-	cleanURL := baseURL
-	if strings.HasPrefix(baseURL, "https://") {
-		cleanURL = strings.TrimPrefix(baseURL, "https://")
-	} else if strings.HasPrefix(baseURL, "http://") {
-		scheme = "http"
-		cleanURL = strings.TrimPrefix(baseURL, "http://")
-	}
-	return fmt.Sprintf("%s://%s/%s/%s/%s.rss", scheme, cleanURL, bucket, podcastFolder, feedKey), nil
+	return urls.URL(fmt.Sprintf("%s/%s.rss", podcastFolder, feedKey)), nil
 }
 
 // BuildItemDescription builds an RSS item description from episode metadata.
@@ -582,36 +1096,83 @@ func SanitizeCDATA(s string) string {
 	return strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>")
 }
 
+// uploadSingleEpisode uploads the episode file unless object storage already holds the same content.
+// An object of the same size is reused only if its checksum matches the local file, so a replaced file is uploaded again.
 func (p *Processor) uploadSingleEpisode(ctx context.Context, podcastID, podcastFolder string, episodeItem *podcast.Episode, progress ProgressFunc) (UploadedEpisode, error) {
-	// Check if file already exists on S3 with same size
-	objectInfo, err := p.S3Client.GetObjectInfo(ctx, fmt.Sprintf("%s/%s", podcastFolder, episodeItem.Filename))
+	filePath := fmt.Sprintf("%s/%s/%s", p.StoragePath, podcastFolder, episodeItem.Filename)
+	objectName, sums, err := p.uploadKey(podcastID, podcastFolder, episodeItem)
+	if err != nil {
+		return UploadedEpisode{}, err
+	}
+
+	objectInfo, err := p.objects(podcastID).GetObjectInfo(ctx, objectName)
 	if err != nil {
 		log.Printf("[DEBUG] GetObjectInfo for %s: %v", episodeItem.Filename, err)
 	}
-	var location string
+	var location, checksum string
 	if objectInfo != nil && episodeItem.Size == objectInfo.Size {
-		location = objectInfo.Location
-		// Report 100% progress for cached files
-		if progress != nil {
-			progress(episodeItem.Size, episodeItem.Size)
+		if sums == nil {
+			var fileSums Checksums
+			fileSums, err = FileChecksums(filePath)
+			sums = &fileSums
+		}
+		switch {
+		case err != nil:
+			log.Printf("[WARN] can't compute checksum of %s, %v", filePath, err)
+		case sums.Matches(objectInfo):
+			location, checksum = objectInfo.Location, sums.SHA256
+			// Report 100% progress for cached files
+			if progress != nil {
+				progress(episodeItem.Size, episodeItem.Size)
+			}
+		default:
+			log.Printf("[INFO] object %s can't be verified against the local file, uploading again", objectName)
 		}
 	}
 
 	if location == "" {
 		// Upload with progress tracking
-		uploadInfo, err := p.S3Client.UploadEpisodeWithProgress(ctx,
-			fmt.Sprintf("%s/%s", podcastFolder, episodeItem.Filename),
-			fmt.Sprintf("%s/%s/%s", p.StoragePath, podcastFolder, episodeItem.Filename),
-			progress)
+		uploadCtx := withObjectMetadata(ctx, map[string]string{"podcast": podcastID, "episode": episodeItem.Filename})
+		uploadInfo, err := p.objects(podcastID).UploadEpisodeWithProgress(uploadCtx, objectName, filePath, progress)
 		if err != nil {
 			return UploadedEpisode{}, err
 		}
-		location = uploadInfo.Location
+		location, checksum = uploadInfo.Location, uploadInfo.Checksum
 	}
 
 	return UploadedEpisode{
 		PodcastID: podcastID,
 		Filename:  episodeItem.Filename,
 		Location:  location,
+		Checksum:  checksum,
+		Key:       objectName,
 	}, nil
 }
+
+// episodeKey returns the object name the episode was uploaded to, <folder>/<filename> for episodes uploaded
+// before key templates.
+func (p *Processor) episodeKey(podcastFolder string, episode *podcast.Episode) string {
+	if episode.Key != "" {
+		return episode.Key
+	}
+	return fmt.Sprintf("%s/%s", podcastFolder, episode.Filename)
+}
+
+// uploadKey returns the object name the episode is uploaded to. Episodes with a stored key keep it, so enclosures
+// published before a template change keep working when they are uploaded again, for example restored by rollback.
+// Episodes uploaded or deleted before key templates keep <folder>/<filename>, others get the name computed
+// by the key template of the podcast. Checksums of the file are returned if the template needed them.
+func (p *Processor) uploadKey(podcastID, podcastFolder string, episode *podcast.Episode) (string, *Checksums, error) {
+	if episode.Key != "" || episode.Status == podcast.Uploaded || episode.Status == podcast.Deleted {
+		return p.episodeKey(podcastFolder, episode), nil, nil
+	}
+	template := p.KeyTemplates[podcastID]
+	if !template.NeedsHash() {
+		return template.Key(podcastFolder, episode, ""), nil, nil
+	}
+	sums, err := FileChecksums(p.localPath(podcastFolder, episode.Filename))
+	if err != nil {
+		return "", nil, fmt.Errorf("can't compute checksum of %s: %w", episode.Filename, err)
+	}
+	return template.Key(podcastFolder, episode, sums.SHA256), &sums, nil
+}
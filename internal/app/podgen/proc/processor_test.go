@@ -2,13 +2,18 @@ package proc_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"image"
 	_ "image/png"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -121,9 +126,20 @@ func TestProcessor_Update(t *testing.T) {
 				},
 			}
 
+			podcasts := &mocks.PodcastStoreMock{
+				GetPodcastFunc: func(podcastID string) (*podcast.Podcast, error) {
+					return nil, storage.ErrPodcastNotFound
+				},
+				SavePodcastFunc: func(p *podcast.Podcast) error { return nil },
+			}
+
+			history := newHistoryMock()
+
 			p := &proc.Processor{
-				Storage: store,
-				Files:   scanner,
+				Storage:  store,
+				Podcasts: podcasts,
+				History:  history,
+				Files:    scanner,
 			}
 
 			count, err := p.Update(context.Background(), tt.folderName, tt.podcastID)
@@ -132,10 +148,22 @@ func TestProcessor_Update(t *testing.T) {
 				if tt.wantErrContain != "" {
 					assert.Contains(t, err.Error(), tt.wantErrContain)
 				}
+				assert.Empty(t, podcasts.SavePodcastCalls(), "failed scan must not be recorded")
 				return
 			}
 			require.NoError(t, err)
 			assert.Equal(t, tt.wantCount, count)
+
+			calls := podcasts.SavePodcastCalls()
+			require.Len(t, calls, 1)
+			assert.Equal(t, tt.podcastID, calls[0].P.ID)
+			assert.False(t, calls[0].P.LastScanAt.IsZero())
+
+			events := recordedEvents(t, history)
+			assert.Len(t, events, int(tt.wantCount), "each added episode is recorded")
+			for _, e := range events {
+				assert.Equal(t, storage.OperationScan, e.Operation)
+			}
 		})
 	}
 }
@@ -214,7 +242,7 @@ func TestProcessor_DeleteOldEpisodesByPodcast(t *testing.T) {
 				ChunkSize: 2,
 			}
 
-			err := p.DeleteOldEpisodesByPodcast(context.Background(), tt.podcastID, tt.podcastFolder)
+			err := p.DeleteOldEpisodesByPodcast(context.Background(), "sess1", tt.podcastID, tt.podcastFolder)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -231,12 +259,14 @@ func TestProcessor_DeleteOldEpisodesByPodcast(t *testing.T) {
 
 func TestProcessor_RollbackLastEpisodes(t *testing.T) {
 	tests := []struct {
-		name      string
-		podcastID string
-		episode   *podcast.Episode
-		findErr   error
-		saveErr   error
-		wantErr   bool
+		name       string
+		podcastID  string
+		episode    *podcast.Episode
+		findErr    error
+		deleteErr  error
+		saveErr    error
+		wantDelete bool
+		wantErr    bool
 	}{
 		{
 			name:      "no episode found for rollback",
@@ -244,9 +274,15 @@ func TestProcessor_RollbackLastEpisodes(t *testing.T) {
 			episode:   nil,
 		},
 		{
-			name:      "rollback single episode",
+			name:       "rollback uploaded episode",
+			podcastID:  "pod1",
+			episode:    &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded},
+			wantDelete: true,
+		},
+		{
+			name:      "rollback deleted episode",
 			podcastID: "pod1",
-			episode:   &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded},
+			episode:   &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Deleted},
 		},
 		{
 			name:      "find error",
@@ -255,11 +291,20 @@ func TestProcessor_RollbackLastEpisodes(t *testing.T) {
 			wantErr:   true,
 		},
 		{
-			name:      "save error",
-			podcastID: "pod1",
-			episode:   &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded},
-			saveErr:   errors.New("save failed"),
-			wantErr:   true,
+			name:       "delete error",
+			podcastID:  "pod1",
+			episode:    &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded},
+			deleteErr:  errors.New("access denied"),
+			wantDelete: true,
+			wantErr:    true,
+		},
+		{
+			name:       "save error",
+			podcastID:  "pod1",
+			episode:    &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded},
+			saveErr:    errors.New("save failed"),
+			wantDelete: true,
+			wantErr:    true,
 		},
 	}
 
@@ -272,21 +317,33 @@ func TestProcessor_RollbackLastEpisodes(t *testing.T) {
 				SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
 					return tt.saveErr
 				},
-				FindEpisodesBySessionFunc: func(podcastID string, session string) ([]*podcast.Episode, error) {
-					return nil, nil
+			}
+			s3 := &mocks.ObjectStorageMock{
+				DeleteEpisodeFunc: func(ctx context.Context, objectName string) error {
+					return tt.deleteErr
 				},
 			}
 
-			p := &proc.Processor{Storage: store}
+			p := &proc.Processor{Storage: store, S3Client: s3}
 
-			err := p.RollbackLastEpisodes(context.Background(), tt.podcastID)
+			err := p.RollbackLastEpisodes(context.Background(), "rb1", tt.podcastID, "folder1")
+
+			if tt.wantDelete {
+				require.Len(t, s3.DeleteEpisodeCalls(), 1)
+				assert.Equal(t, "folder1/ep1.mp3", s3.DeleteEpisodeCalls()[0].ObjectName)
+			} else {
+				assert.Empty(t, s3.DeleteEpisodeCalls())
+			}
+			if tt.deleteErr != nil {
+				assert.Empty(t, store.SaveEpisodeCalls(), "episode must stay uploaded if its object wasn't deleted")
+			}
 			if tt.wantErr {
 				require.Error(t, err)
 				return
 			}
 			require.NoError(t, err)
 
-			if tt.episode != nil && tt.saveErr == nil {
+			if tt.episode != nil {
 				saveCalls := store.SaveEpisodeCalls()
 				require.NotEmpty(t, saveCalls)
 				assert.Equal(t, podcast.New, saveCalls[0].Episode.Status)
@@ -297,71 +354,210 @@ func TestProcessor_RollbackLastEpisodes(t *testing.T) {
 
 func TestProcessor_RollbackEpisodesOfSession(t *testing.T) {
 	tests := []struct {
-		name      string
-		podcastID string
-		session   string
-		episodes  []*podcast.Episode
-		findErr   error
-		saveErr   error
-		wantErr   bool
+		name        string
+		episodes    []*podcast.Episode
+		queryErr    error
+		deleteErr   error
+		wantDeletes []string
+		wantSaved   int
+		wantErr     bool
 	}{
 		{
-			name:      "no episodes in session",
-			podcastID: "pod1",
-			session:   "sess1",
-			episodes:  []*podcast.Episode{},
+			name: "no episodes in session",
 		},
 		{
-			name:      "rollback session episodes",
-			podcastID: "pod1",
-			session:   "sess1",
+			name: "rollback session episodes",
 			episodes: []*podcast.Episode{
 				{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "sess1"},
-				{Filename: "ep2.mp3", Status: podcast.Uploaded, Session: "sess1"},
+				{Filename: "ep2.mp3", Status: podcast.Deleted, Session: "sess1"},
 			},
+			wantDeletes: []string{"folder1/ep1.mp3"},
+			wantSaved:   2,
 		},
 		{
-			name:      "find error",
-			podcastID: "pod1",
-			session:   "sess1",
-			findErr:   errors.New("find failed"),
-			wantErr:   true,
+			name:     "query error",
+			queryErr: errors.New("query failed"),
+			wantErr:  true,
 		},
 		{
-			name:      "save error stops rollback",
-			podcastID: "pod1",
-			session:   "sess1",
+			name: "delete error keeps episode uploaded",
 			episodes: []*podcast.Episode{
 				{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "sess1"},
+				{Filename: "ep2.mp3", Status: podcast.Deleted, Session: "sess1"},
 			},
-			saveErr: errors.New("save failed"),
-			wantErr: true,
+			deleteErr:   errors.New("access denied"),
+			wantDeletes: []string{"folder1/ep1.mp3"},
+			wantSaved:   1,
+			wantErr:     true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			store := &mocks.EpisodeStoreMock{
-				FindEpisodesBySessionFunc: func(podcastID string, session string) ([]*podcast.Episode, error) {
-					return tt.episodes, tt.findErr
+				QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+					return tt.episodes, tt.queryErr
 				},
 				SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
-					return tt.saveErr
+					return nil
+				},
+			}
+			s3 := &mocks.ObjectStorageMock{
+				DeleteEpisodeFunc: func(ctx context.Context, objectName string) error {
+					return tt.deleteErr
 				},
 			}
 
-			p := &proc.Processor{Storage: store}
+			p := &proc.Processor{Storage: store, S3Client: s3}
 
-			err := p.RollbackEpisodesOfSession(context.Background(), tt.podcastID, tt.session)
+			err := p.RollbackEpisodesOfSession(context.Background(), "rb1", "pod1", "folder1", "sess1")
 			if tt.wantErr {
 				require.Error(t, err)
-				return
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tt.queryErr == nil {
+				require.Len(t, store.QueryEpisodesCalls(), 1)
+				assert.Equal(t, storage.EpisodeFilter{Session: "sess1", Statuses: []podcast.Status{podcast.Uploaded, podcast.Deleted}},
+					store.QueryEpisodesCalls()[0].Q.EpisodeFilter)
+			}
+			var deleted []string
+			for _, call := range s3.DeleteEpisodeCalls() {
+				deleted = append(deleted, call.ObjectName)
+			}
+			assert.Equal(t, tt.wantDeletes, deleted)
+			require.Len(t, store.SaveEpisodeCalls(), tt.wantSaved)
+			for _, call := range store.SaveEpisodeCalls() {
+				assert.Equal(t, podcast.New, call.Episode.Status)
 			}
-			require.NoError(t, err)
 		})
 	}
 }
 
+func TestProcessor_RollbackEpisodesOfSession_RestoresDeletedEpisodes(t *testing.T) {
+	episodes := map[string]*podcast.Episode{
+		"old1.mp3": {Filename: "old1.mp3", Size: 100, Status: podcast.Deleted, Session: "sess0"},
+		"old2.mp3": {Filename: "old2.mp3", Size: 200, Status: podcast.Uploaded, Session: "sess2"},
+		"old3.mp3": {Filename: "old3.mp3", Size: 300, Status: podcast.Deleted, Session: "sess0"},
+	}
+	store := &mocks.EpisodeStoreMock{
+		QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+			return nil, nil
+		},
+		GetEpisodeByFilenameFunc: func(podcastID string, fileName string) (*podcast.Episode, error) {
+			return episodes[fileName], nil
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
+			return nil
+		},
+	}
+	history := &mocks.HistoryStoreMock{
+		ListHistoryFunc: func(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+			return []storage.EpisodeEvent{
+				{Filename: "old1.mp3", Operation: storage.OperationDelete, OldStatus: podcast.Uploaded, NewStatus: podcast.Deleted, Session: "sess1"},
+				// uploaded again by a later session
+				{Filename: "old2.mp3", Operation: storage.OperationDelete, OldStatus: podcast.Uploaded, NewStatus: podcast.Deleted, Session: "sess1"},
+				// failed delete left the object in place
+				{Filename: "old3.mp3", Operation: storage.OperationDelete, OldStatus: podcast.Uploaded, NewStatus: podcast.Uploaded, Session: "sess1", Error: "access denied"},
+				// deleted by another session
+				{Filename: "old3.mp3", Operation: storage.OperationDelete, OldStatus: podcast.Uploaded, NewStatus: podcast.Deleted, Session: "sess9"},
+			}, nil
+		},
+		AppendHistoryFunc: func(podcastID string, events []storage.EpisodeEvent) error { return nil },
+	}
+	var uploadedFiles []string
+	s3 := &mocks.ObjectStorageMock{
+		GetObjectInfoFunc: func(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
+			return nil, errors.New("not found")
+		},
+		UploadEpisodeWithProgressFunc: func(ctx context.Context, objectName, filePath string, progress proc.ProgressFunc) (*proc.UploadResult, error) {
+			uploadedFiles = append(uploadedFiles, filePath)
+			return &proc.UploadResult{Location: "https://s3/bucket/" + objectName}, nil
+		},
+	}
+
+	p := &proc.Processor{Storage: store, History: history, S3Client: s3, StoragePath: "/storage"}
+
+	require.NoError(t, p.RollbackEpisodesOfSession(context.Background(), "rb1", "pod1", "folder1", "sess1"))
+
+	assert.Equal(t, []string{"/storage/folder1/old1.mp3"}, uploadedFiles)
+
+	require.Len(t, store.SaveEpisodeCalls(), 1)
+	saved := store.SaveEpisodeCalls()[0].Episode
+	assert.Equal(t, podcast.Uploaded, saved.Status)
+	assert.Equal(t, "https://s3/bucket/folder1/old1.mp3", saved.Location)
+	assert.Equal(t, "sess0", saved.Session, "restored episode keeps the session it was uploaded in")
+
+	events := recordedEvents(t, history)
+	require.Len(t, events, 1)
+	assert.Equal(t, storage.OperationRollback, events["old1.mp3"].Operation)
+	assert.Equal(t, podcast.Deleted, events["old1.mp3"].OldStatus)
+	assert.Equal(t, podcast.Uploaded, events["old1.mp3"].NewStatus)
+	assert.Equal(t, "rb1", events["old1.mp3"].Session)
+}
+
+func TestProcessor_ListEpisodes(t *testing.T) {
+	query := storage.EpisodeQuery{Text: "go", SortBy: storage.SortByPubDate, Desc: true, Limit: 10}
+	episodes := []*podcast.Episode{{Filename: "ep1.mp3"}}
+
+	store := &mocks.EpisodeStoreMock{
+		QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+			return episodes, nil
+		},
+	}
+	p := &proc.Processor{Storage: store}
+
+	got, err := p.ListEpisodes(context.Background(), "pod1", query)
+	require.NoError(t, err)
+	assert.Equal(t, episodes, got)
+
+	calls := store.QueryEpisodesCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "pod1", calls[0].PodcastID)
+	assert.Equal(t, query, calls[0].Q)
+
+	store.QueryEpisodesFunc = func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+		return nil, errors.New("query failed")
+	}
+	_, err = p.ListEpisodes(context.Background(), "pod1", query)
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.ListEpisodes(ctx, "pod1", query)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, store.QueryEpisodesCalls(), 2, "canceled context must not query storage")
+}
+
+func TestProcessor_EditEpisode(t *testing.T) {
+	var saved *podcast.Episode
+	store := &mocks.EpisodeStoreMock{
+		GetEpisodeByFilenameFunc: func(podcastID, fileName string) (*podcast.Episode, error) {
+			if fileName != "ep1.mp3" {
+				return nil, storage.ErrNotFound
+			}
+			return &podcast.Episode{Filename: "ep1.mp3", Title: "Old", Artist: "Host", Status: podcast.Uploaded}, nil
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
+			saved = episode
+			return nil
+		},
+	}
+	p := &proc.Processor{Storage: store}
+
+	title, comment := " New title ", ""
+	episode, err := p.EditEpisode(context.Background(), "pod1", "ep1.mp3", proc.EpisodeEdit{Title: &title, Comment: &comment})
+	require.NoError(t, err)
+	assert.Equal(t, "New title", episode.Title)
+	assert.Equal(t, "Host", episode.Artist, "fields not set are kept")
+	assert.Equal(t, podcast.Uploaded, episode.Status)
+	assert.Equal(t, episode, saved)
+
+	_, err = p.EditEpisode(context.Background(), "pod1", "missing.mp3", proc.EpisodeEdit{Title: &title})
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
+
 func TestProcessor_UploadNewEpisodes(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -451,6 +647,84 @@ func TestProcessor_UploadNewEpisodes(t *testing.T) {
 	}
 }
 
+func TestProcessor_UploadNewEpisodes_VerifiesChecksum(t *testing.T) {
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "pod"), 0o750))
+	episodes := map[string]*podcast.Episode{}
+	for _, name := range []string{"same.mp3", "replaced.mp3", "legacy.mp3", "multipart.mp3"} {
+		require.NoError(t, os.WriteFile(filepath.Join(storagePath, "pod", name), []byte("content of "+name), 0o600))
+		episodes[name] = &podcast.Episode{Filename: name, Size: int64(len("content of " + name)), Status: podcast.New}
+	}
+	checksums := func(name string) proc.Checksums {
+		sums, err := proc.FileChecksums(filepath.Join(storagePath, "pod", name))
+		require.NoError(t, err)
+		return sums
+	}
+
+	// every object has the size of the local file, only some hold the same content
+	objects := map[string]*proc.ObjectInfo{
+		"pod/same.mp3":      {SHA256: checksums("same.mp3").SHA256},
+		"pod/replaced.mp3":  {SHA256: checksums("same.mp3").SHA256},
+		"pod/legacy.mp3":    {ETag: checksums("legacy.mp3").MD5},
+		"pod/multipart.mp3": {ETag: checksums("multipart.mp3").MD5 + "-2"},
+	}
+
+	var mu sync.Mutex // guards episodes and uploaded, the mocks are called by parallel workers
+	store := &mocks.EpisodeStoreMock{
+		FindEpisodesBySizeLimitFunc: func(podcastID string, status podcast.Status, sizeLimit int64) ([]*podcast.Episode, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			result := make([]*podcast.Episode, 0, len(episodes))
+			for _, e := range episodes {
+				epCopy := *e
+				result = append(result, &epCopy)
+			}
+			return result, nil
+		},
+		GetEpisodeByFilenameFunc: func(podcastID, fileName string) (*podcast.Episode, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			epCopy := *episodes[fileName]
+			return &epCopy, nil
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
+			mu.Lock()
+			defer mu.Unlock()
+			episodes[episode.Filename] = episode
+			return nil
+		},
+	}
+	var uploaded []string
+	s3 := &mocks.ObjectStorageMock{
+		GetObjectInfoFunc: func(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			info := *objects[objectName]
+			info.Location = "https://s3/bucket/" + objectName
+			info.Size = episodes[strings.TrimPrefix(objectName, "pod/")].Size
+			return &info, nil
+		},
+		UploadEpisodeWithProgressFunc: func(ctx context.Context, objectName, filePath string, progress proc.ProgressFunc) (*proc.UploadResult, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			uploaded = append(uploaded, objectName)
+			return &proc.UploadResult{Location: "https://s3/bucket/" + objectName, Checksum: "uploaded-checksum"}, nil
+		},
+	}
+	p := &proc.Processor{Storage: store, S3Client: s3, StoragePath: storagePath, ChunkSize: 2}
+
+	require.NoError(t, p.UploadNewEpisodes(context.Background(), "sess", "pod", "pod", 0))
+
+	assert.ElementsMatch(t, []string{"pod/replaced.mp3", "pod/multipart.mp3"}, uploaded,
+		"objects of the same size are uploaded again unless their checksum matches")
+	for name, e := range episodes {
+		assert.Equal(t, podcast.Uploaded, e.Status, name)
+	}
+	assert.Equal(t, checksums("same.mp3").SHA256, episodes["same.mp3"].Checksum)
+	assert.Equal(t, checksums("legacy.mp3").SHA256, episodes["legacy.mp3"].Checksum)
+	assert.Equal(t, "uploaded-checksum", episodes["replaced.mp3"].Checksum)
+}
+
 func TestProcessor_GetPodcastImage(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -485,12 +759,78 @@ func TestProcessor_GetPodcastImage(t *testing.T) {
 			}
 
 			p := &proc.Processor{S3Client: s3}
-			result := p.GetPodcastImage(context.Background(), tt.folder, tt.imageFile)
+			result := p.GetPodcastImage(context.Background(), "pod1", tt.folder, tt.imageFile)
 			assert.Equal(t, tt.wantLocation, result)
 		})
 	}
 }
 
+func TestProcessor_GetPodcastImage_UsesPodcastRecord(t *testing.T) {
+	s3 := &mocks.ObjectStorageMock{
+		GetObjectInfoFunc: func(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
+			return &proc.ObjectInfo{Location: "https://s3/bucket/" + objectName}, nil
+		},
+	}
+	records := map[string]*podcast.Podcast{}
+	podcasts := &mocks.PodcastStoreMock{
+		GetPodcastFunc: func(podcastID string) (*podcast.Podcast, error) {
+			if r, ok := records[podcastID]; ok {
+				return r, nil
+			}
+			return nil, storage.ErrPodcastNotFound
+		},
+		SavePodcastFunc: func(p *podcast.Podcast) error {
+			records[p.ID] = p
+			return nil
+		},
+	}
+
+	p := &proc.Processor{S3Client: s3, Podcasts: podcasts}
+
+	// First lookup asks s3 storage and records the URL
+	assert.Equal(t, "https://s3/bucket/folder1/cover.png", p.GetPodcastImage(context.Background(), "pod1", "folder1", "cover.png"))
+	require.Len(t, s3.GetObjectInfoCalls(), 1)
+	require.Contains(t, records, "pod1")
+	assert.Equal(t, "https://s3/bucket/folder1/cover.png", records["pod1"].ImageURL)
+
+	// Next lookups are served from the record
+	assert.Equal(t, "https://s3/bucket/folder1/cover.png", p.GetPodcastImage(context.Background(), "pod1", "folder1", "cover.png"))
+	assert.Len(t, s3.GetObjectInfoCalls(), 1)
+}
+
+func TestProcessor_UploadFeed_RecordsPodcast(t *testing.T) {
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "folder1"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "folder1", "feed.rss"), []byte("<rss/>"), 0o600))
+
+	s3 := &mocks.ObjectStorageMock{
+		UploadFeedFunc: func(ctx context.Context, objectName string, filePath string) (*proc.UploadResult, error) {
+			return &proc.UploadResult{Location: "https://s3/bucket/" + objectName}, nil
+		},
+	}
+	podcasts := &mocks.PodcastStoreMock{
+		GetPodcastFunc: func(podcastID string) (*podcast.Podcast, error) {
+			return &podcast.Podcast{ID: podcastID, ImageURL: "https://s3/bucket/folder1/podcast.png"}, nil
+		},
+		SavePodcastFunc: func(p *podcast.Podcast) error { return nil },
+	}
+
+	p := &proc.Processor{S3Client: s3, Podcasts: podcasts, StoragePath: storagePath}
+
+	before := time.Now()
+	_, err := p.UploadFeed(context.Background(), "pod1", "folder1", "feed.rss")
+	require.NoError(t, err)
+
+	calls := podcasts.SavePodcastCalls()
+	require.Len(t, calls, 1)
+	record := calls[0].P
+	assert.Equal(t, "pod1", record.ID)
+	assert.Equal(t, "https://s3/bucket/folder1/feed.rss", record.FeedURL)
+	assert.Equal(t, "https://s3/bucket/folder1/podcast.png", record.ImageURL, "other fields must be kept")
+	assert.Equal(t, "b8a3805e669decf7180d8c7f4af5d4706d615d0678c424bd1c6b853def3bf3d2", record.FeedHash)
+	assert.False(t, record.LastPublishAt.Before(before))
+}
+
 func TestProcessor_UploadFeed(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -528,7 +868,7 @@ func TestProcessor_UploadFeed(t *testing.T) {
 				StoragePath: "/tmp/storage",
 			}
 
-			result, err := p.UploadFeed(context.Background(), tt.folder, tt.feedName)
+			result, err := p.UploadFeed(context.Background(), "pod1", tt.folder, tt.feedName)
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Nil(t, result)
@@ -650,7 +990,7 @@ func TestProcessor_DeleteOldEpisodesByPodcast_WithProgress(t *testing.T) {
 		ChunkSize: 2,
 	}
 
-	err := p.DeleteOldEpisodesByPodcast(context.Background(), "pod1", "folder1")
+	err := p.DeleteOldEpisodesByPodcast(context.Background(), "sess2", "pod1", "folder1")
 	require.NoError(t, err)
 
 	assert.Len(t, progress.StartFileCalls(), 1)
@@ -698,7 +1038,7 @@ func TestProcessor_DeleteOldEpisodesByPodcast_ZeroChunkSize(t *testing.T) {
 		ChunkSize: 0, // should not panic, defaults to 1
 	}
 
-	err := p.DeleteOldEpisodesByPodcast(context.Background(), "pod1", "folder1")
+	err := p.DeleteOldEpisodesByPodcast(context.Background(), "sess2", "pod1", "folder1")
 	require.NoError(t, err)
 
 	// verify both episodes were processed
@@ -839,8 +1179,11 @@ func TestProcessor_UploadNewEpisodes_WorkerPool_PartialUploadError(t *testing.T)
 		},
 	}
 
+	history := newHistoryMock()
+
 	p := &proc.Processor{
 		Storage:     store,
+		History:     history,
 		S3Client:    s3,
 		StoragePath: "/tmp/storage",
 		ChunkSize:   3,
@@ -853,6 +1196,144 @@ func TestProcessor_UploadNewEpisodes_WorkerPool_PartialUploadError(t *testing.T)
 	// ep1 and ep3 must still be saved
 	saveCalls := store.SaveEpisodeCalls()
 	assert.Len(t, saveCalls, 2)
+
+	// every attempt is recorded, including the failed one
+	events := recordedEvents(t, history)
+	require.Len(t, events, 3)
+	assert.Equal(t, storage.OperationUpload, events["ep1.mp3"].Operation)
+	assert.Equal(t, podcast.New, events["ep1.mp3"].OldStatus)
+	assert.Equal(t, podcast.Uploaded, events["ep1.mp3"].NewStatus)
+	assert.Equal(t, "sess1", events["ep1.mp3"].Session)
+	assert.Equal(t, "https://s3/bucket/folder1/ep1.mp3", events["ep1.mp3"].Location)
+	assert.Empty(t, events["ep1.mp3"].Error)
+	assert.Equal(t, podcast.New, events["ep2.mp3"].NewStatus)
+	assert.Contains(t, events["ep2.mp3"].Error, "s3 upload failed")
+	assert.False(t, events["ep2.mp3"].At.IsZero())
+}
+
+func TestProcessor_DeleteOldEpisodesByPodcast_RecordsHistory(t *testing.T) {
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "s1", Location: "https://s3/bucket/folder1/ep1.mp3"},
+		{Filename: "ep2.mp3", Status: podcast.Uploaded, Session: "s1"},
+	}
+
+	store := &mocks.EpisodeStoreMock{
+		FindEpisodesByStatusFunc: func(podcastID string, status podcast.Status) ([]*podcast.Episode, error) {
+			return episodes, nil
+		},
+		GetEpisodeByFilenameFunc: func(podcastID string, fileName string) (*podcast.Episode, error) {
+			for _, ep := range episodes {
+				if ep.Filename == fileName {
+					epCopy := *ep
+					return &epCopy, nil
+				}
+			}
+			return nil, errors.New("not found")
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
+			return nil
+		},
+	}
+
+	s3 := &mocks.ObjectStorageMock{
+		DeleteEpisodeFunc: func(ctx context.Context, objectName string) error {
+			if objectName == "folder1/ep2.mp3" {
+				return errors.New("access denied")
+			}
+			return nil
+		},
+	}
+
+	history := newHistoryMock()
+	p := &proc.Processor{Storage: store, History: history, S3Client: s3, ChunkSize: 2}
+
+	err := p.DeleteOldEpisodesByPodcast(context.Background(), "sess2", "pod1", "folder1")
+	require.Error(t, err)
+
+	events := recordedEvents(t, history)
+	require.Len(t, events, 2)
+	assert.Equal(t, storage.OperationDelete, events["ep1.mp3"].Operation)
+	assert.Equal(t, podcast.Uploaded, events["ep1.mp3"].OldStatus)
+	assert.Equal(t, podcast.Deleted, events["ep1.mp3"].NewStatus)
+	assert.Equal(t, "https://s3/bucket/folder1/ep1.mp3", events["ep1.mp3"].Location)
+	assert.Equal(t, "sess2", events["ep1.mp3"].Session, "deletion is recorded in the session that ran it")
+	assert.Equal(t, podcast.Uploaded, events["ep2.mp3"].NewStatus)
+	assert.Equal(t, "access denied", events["ep2.mp3"].Error)
+}
+
+func TestProcessor_RollbackEpisodesOfSession_RecordsHistory(t *testing.T) {
+	store := &mocks.EpisodeStoreMock{
+		QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+			return []*podcast.Episode{
+				{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "sess1", Location: "https://s3/bucket/folder1/ep1.mp3"},
+				{Filename: "ep2.mp3", Status: podcast.Deleted, Session: "sess1"},
+			}, nil
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error {
+			return nil
+		},
+	}
+	history := newHistoryMock()
+	history.ListHistoryFunc = func(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+		return nil, nil
+	}
+	s3 := &mocks.ObjectStorageMock{
+		DeleteEpisodeFunc: func(ctx context.Context, objectName string) error { return nil },
+	}
+	p := &proc.Processor{Storage: store, History: history, S3Client: s3}
+
+	require.NoError(t, p.RollbackEpisodesOfSession(context.Background(), "rb1", "pod1", "folder1", "sess1"))
+
+	events := recordedEvents(t, history)
+	require.Len(t, events, 2)
+	assert.Equal(t, storage.OperationRollback, events["ep1.mp3"].Operation)
+	assert.Equal(t, podcast.Uploaded, events["ep1.mp3"].OldStatus)
+	assert.Equal(t, podcast.New, events["ep1.mp3"].NewStatus)
+	assert.Equal(t, "https://s3/bucket/folder1/ep1.mp3", events["ep1.mp3"].Location)
+	assert.Equal(t, storage.OperationRollback, events["ep2.mp3"].Operation)
+	assert.Equal(t, podcast.Deleted, events["ep2.mp3"].OldStatus)
+	assert.Equal(t, podcast.New, events["ep2.mp3"].NewStatus)
+	assert.Equal(t, "rb1", events["ep2.mp3"].Session)
+}
+
+func TestProcessor_EpisodeHistory(t *testing.T) {
+	filter := storage.HistoryFilter{Filename: "ep1.mp3", Limit: 5}
+	history := &mocks.HistoryStoreMock{
+		ListHistoryFunc: func(podcastID string, f storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+			return []storage.EpisodeEvent{{Filename: "ep1.mp3", Operation: storage.OperationScan}}, nil
+		},
+	}
+	p := &proc.Processor{History: history}
+
+	events, err := p.EpisodeHistory(context.Background(), "pod1", filter)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	require.Len(t, history.ListHistoryCalls(), 1)
+	assert.Equal(t, "pod1", history.ListHistoryCalls()[0].PodcastID)
+	assert.Equal(t, filter, history.ListHistoryCalls()[0].Filter)
+
+	_, err = (&proc.Processor{}).EpisodeHistory(context.Background(), "pod1", filter)
+	require.Error(t, err, "processor without history store must fail")
+}
+
+func newHistoryMock() *mocks.HistoryStoreMock {
+	return &mocks.HistoryStoreMock{
+		AppendHistoryFunc: func(podcastID string, events []storage.EpisodeEvent) error { return nil },
+	}
+}
+
+// recordedEvents returns appended history events by filename.
+func recordedEvents(t *testing.T, history *mocks.HistoryStoreMock) map[string]storage.EpisodeEvent {
+	t.Helper()
+	result := map[string]storage.EpisodeEvent{}
+	for _, call := range history.AppendHistoryCalls() {
+		for _, e := range call.Events {
+			_, dup := result[e.Filename]
+			require.False(t, dup, "more than one event recorded for %s", e.Filename)
+			result[e.Filename] = e
+		}
+	}
+	return result
 }
 
 func TestProcessor_UploadNewEpisodes_WorkerPool_ContextCancellation(t *testing.T) {
@@ -1149,46 +1630,66 @@ func TestProcessor_GetFeedURL(t *testing.T) {
 		name          string
 		podcastID     string
 		podcastFolder string
-		baseURL       string
-		bucket        string
+		urls          proc.URLTemplate
 		wantContains  string
 		wantNotDouble bool
 	}{
 		{
-			name:          "baseURL without scheme",
+			name:          "endpoint without scheme",
 			podcastID:     "pod1",
 			podcastFolder: "folder1",
-			baseURL:       "s3.example.com",
-			bucket:        "bucket1",
+			urls:          proc.URLTemplate{Endpoint: "s3.example.com", Bucket: "bucket1"},
 			wantContains:  "https://s3.example.com/bucket1/folder1/",
 			wantNotDouble: true,
 		},
 		{
-			name:          "baseURL with https scheme is stripped",
+			name:          "endpoint with https scheme is stripped",
 			podcastID:     "pod1",
 			podcastFolder: "folder1",
-			baseURL:       "https://s3.example.com",
-			bucket:        "bucket1",
+			urls:          proc.URLTemplate{Endpoint: "https://s3.example.com", Bucket: "bucket1"},
 			wantContains:  "https://s3.example.com/bucket1/folder1/",
 			wantNotDouble: true,
 		},
 		{
-			name:          "baseURL with http scheme is preserved",
+			name:          "endpoint with http scheme is preserved",
 			podcastID:     "pod1",
 			podcastFolder: "folder1",
-			baseURL:       "http://s3.example.com",
-			bucket:        "bucket1",
+			urls:          proc.URLTemplate{Endpoint: "http://s3.example.com", Bucket: "bucket1"},
 			wantContains:  "http://s3.example.com/bucket1/folder1/",
 			wantNotDouble: true,
 		},
+		{
+			name:          "empty bucket is left out",
+			podcastID:     "pod1",
+			podcastFolder: "folder1",
+			urls:          proc.URLTemplate{Endpoint: "https://podcasts.example.com/"},
+			wantContains:  "https://podcasts.example.com/folder1/",
+			wantNotDouble: true,
+		},
+		{
+			name:          "virtual host style",
+			podcastID:     "pod1",
+			podcastFolder: "folder1",
+			urls:          proc.URLTemplate{Endpoint: "https://s3.example.com", Bucket: "bucket1", Style: configs.URLStyleVirtualHost},
+			wantContains:  "https://bucket1.s3.example.com/folder1/",
+			wantNotDouble: true,
+		},
+		{
+			name:          "public base url replaces endpoint and bucket",
+			podcastID:     "pod1",
+			podcastFolder: "folder1",
+			urls:          proc.URLTemplate{BaseURL: "https://cdn.example.com/", Endpoint: "s3.example.com", Bucket: "bucket1"},
+			wantContains:  "https://cdn.example.com/folder1/",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &proc.Processor{}
-			url, err := p.GetFeedURL(tt.podcastID, tt.podcastFolder, tt.baseURL, tt.bucket)
+			url, err := p.GetFeedURL(tt.podcastID, tt.podcastFolder, tt.urls)
 			require.NoError(t, err)
 			assert.Contains(t, url, tt.wantContains)
+			assert.True(t, strings.HasSuffix(url, ".rss"))
 			if tt.wantNotDouble {
 				assert.NotContains(t, url, "https://https://")
 				assert.NotContains(t, url, "https://http://")
@@ -1197,6 +1698,80 @@ func TestProcessor_GetFeedURL(t *testing.T) {
 	}
 }
 
+func TestProcessor_GetFeedURL_FeedName(t *testing.T) {
+	p := &proc.Processor{FeedNames: map[string]string{"pod1": "feed"}}
+	urls := proc.URLTemplate{BaseURL: "https://cdn.example.com"}
+	url, err := p.GetFeedURL("pod1", "folder1", urls)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/folder1/feed.rss", url)
+
+	url, err = p.GetFeedURL("pod2", "folder2", urls)
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte("pod2"))
+	assert.Equal(t, "https://cdn.example.com/folder2/"+hex.EncodeToString(sum[:])+".rss", url, "podcasts without a name")
+}
+
+func TestProcessor_RewriteLocations(t *testing.T) {
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Location: "https://s3.example.com/bucket/news/ep1.mp3"},
+		{Filename: "ep2.mp3", Status: podcast.Deleted, Location: "https://s3.example.com/bucket/news/ep2.mp3"},
+		{Filename: "ep3.mp3", Status: podcast.New},
+		{Filename: "ep4.mp3", Status: podcast.Uploaded, Location: "https://cdn.example.com/news/ep4.mp3"},
+	}
+	var saved []*podcast.Episode
+	store := &mocks.EpisodeStoreMock{
+		QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+			return episodes, nil
+		},
+		SaveEpisodesFunc: func(podcastID string, e []*podcast.Episode) error {
+			saved = e
+			return nil
+		},
+	}
+	records := map[string]*podcast.Podcast{"news": {ID: "news", ImageURL: "https://s3.example.com/bucket/news/podcast.png"}}
+	podcasts := &mocks.PodcastStoreMock{
+		GetPodcastFunc: func(podcastID string) (*podcast.Podcast, error) {
+			return records[podcastID], nil
+		},
+		SavePodcastFunc: func(p *podcast.Podcast) error {
+			records[p.ID] = p
+			return nil
+		},
+	}
+	p := &proc.Processor{Storage: store, Podcasts: podcasts}
+
+	changed, err := p.RewriteLocations(context.Background(), "news", "news", "podcast.png", proc.URLTemplate{BaseURL: "https://cdn.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	require.Len(t, saved, 2)
+	assert.Equal(t, "https://cdn.example.com/news/ep1.mp3", saved[0].Location)
+	assert.Equal(t, "https://cdn.example.com/news/ep2.mp3", saved[1].Location)
+	assert.Empty(t, episodes[2].Location, "episodes never uploaded get no location")
+	assert.Equal(t, "https://cdn.example.com/news/podcast.png", records["news"].ImageURL)
+}
+
+// cleanerStorage counts calls of AbortStaleUploads
+type cleanerStorage struct {
+	mocks.ObjectStorageMock
+	calls int
+}
+
+func (c *cleanerStorage) AbortStaleUploads(context.Context) (int, error) {
+	c.calls++
+	return 0, nil
+}
+
+func TestProcessor_AbortStaleUploads(t *testing.T) {
+	primary, client := &cleanerStorage{}, &cleanerStorage{}
+	p := &proc.Processor{
+		S3Client:       primary,
+		PodcastStorage: map[string]proc.ObjectStorage{"p1": client, "p2": client, "p3": &mocks.ObjectStorageMock{}},
+	}
+	require.NoError(t, p.AbortStaleUploads(context.Background()))
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 1, client.calls, "storages shared by podcasts are cleaned once")
+}
+
 func TestProcessor_UploadPodcastImage(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -0,0 +1,295 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/storage"
+)
+
+// ReconcileIssue names a mismatch between the database, the podcast folder and object storage.
+type ReconcileIssue string
+
+const (
+	// IssueOrphanObject is an object under the podcast prefix that belongs to no episode.
+	IssueOrphanObject ReconcileIssue = "orphan"
+	// IssueMissingRemote is an episode marked Uploaded without an object.
+	IssueMissingRemote ReconcileIssue = "missing_remote"
+	// IssueSizeMismatch is an uploaded episode whose object size differs from the stored size.
+	IssueSizeMismatch ReconcileIssue = "size_mismatch"
+	// IssueNotMarkedUploaded is an object of an episode that is not marked Uploaded.
+	IssueNotMarkedUploaded ReconcileIssue = "not_uploaded"
+	// IssueMissingLocal is an episode whose file is gone from the podcast folder.
+	// It can't be uploaded or restored, so it is only reported.
+	IssueMissingLocal ReconcileIssue = "missing_local"
+)
+
+// ReconcileFinding is a mismatch found by Reconcile.
+type ReconcileFinding struct {
+	Issue ReconcileIssue
+	// Filename is the episode filename, or the object name relative to the podcast folder for orphans.
+	Filename string
+	// Key is the object name compared with the episode, or the name of the orphan object.
+	Key string
+	// Status is the stored episode status. Orphans have no episode, so it is unset for them.
+	Status podcast.Status
+	// StoredSize, LocalSize and RemoteSize are the sizes in the database,
+	// the podcast folder and object storage, -1 where the episode is absent.
+	StoredSize int64
+	LocalSize  int64
+	RemoteSize int64
+	// Fix describes what FixReconcile did, empty if the finding was left alone.
+	Fix string
+	// Error is set if the fix failed.
+	Error string
+}
+
+// ReconcileReport is the result of comparing a podcast across the database, the podcast folder and object storage.
+type ReconcileReport struct {
+	PodcastID string
+	// Episodes and Objects are the numbers of stored episodes and listed objects that were compared.
+	Episodes int
+	Objects  int
+	Findings []ReconcileFinding
+}
+
+// Reconcile lists objects under the podcast folder in object storage and compares them with stored episodes and local files.
+// It reports orphan objects, episodes marked Uploaded but missing remotely, objects of episodes not marked Uploaded,
+// size mismatches and episodes whose local file is gone. The feed and the podcast image are not episodes and are skipped.
+// Nothing is changed, see FixReconcile.
+func (p *Processor) Reconcile(ctx context.Context, podcastID, podcastFolder, podcastImageFilename string) (*ReconcileReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	episodes, err := p.Storage.QueryEpisodes(podcastID, storage.EpisodeQuery{})
+	if err != nil {
+		log.Printf("[ERROR] can't query episodes %s, %v", podcastID, err)
+		return nil, fmt.Errorf("query episodes: %w", err)
+	}
+
+	prefix := podcastFolder + "/"
+	listed, err := p.objects(podcastID).List(ctx, prefix)
+	if err != nil {
+		log.Printf("[ERROR] can't list objects %s, %v", prefix, err)
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+
+	feedKeys, err := p.feedKeys(podcastID)
+	if err != nil {
+		return nil, err
+	}
+	skip := map[string]bool{podcastImageFilename: true}
+	for _, key := range feedKeys {
+		skip[key+".rss"] = true
+	}
+
+	objects := make(map[string]ObjectInfo, len(listed))
+	for _, obj := range listed {
+		name := strings.TrimPrefix(obj.Key, prefix)
+		if !skip[name] {
+			objects[name] = obj
+		}
+	}
+
+	report := &ReconcileReport{PodcastID: podcastID, Episodes: len(episodes), Objects: len(objects)}
+	for _, episode := range episodes {
+		finding := ReconcileFinding{
+			Filename:   episode.Filename,
+			Key:        p.episodeKey(podcastFolder, episode),
+			Status:     episode.Status,
+			StoredSize: episode.Size,
+			LocalSize:  p.localSize(podcastFolder, episode.Filename),
+			RemoteSize: -1,
+		}
+		// episodes not uploaded are looked up where the next upload puts them, their stored key if they have one
+		if episode.Status != podcast.Uploaded && finding.LocalSize >= 0 {
+			if key, _, err := p.uploadKey(podcastID, podcastFolder, episode); err == nil {
+				finding.Key = key
+			}
+		}
+		name := strings.TrimPrefix(finding.Key, prefix)
+		obj, remote := objects[name]
+		if remote {
+			finding.RemoteSize = obj.Size
+			delete(objects, name)
+		}
+
+		switch {
+		case episode.Status == podcast.Uploaded && !remote:
+			finding.Issue = IssueMissingRemote
+		case episode.Status == podcast.Uploaded && obj.Size != episode.Size:
+			finding.Issue = IssueSizeMismatch
+		case episode.Status != podcast.Uploaded && remote:
+			finding.Issue = IssueNotMarkedUploaded
+		case episode.Status != podcast.Deleted && finding.LocalSize < 0:
+			finding.Issue = IssueMissingLocal
+		default:
+			continue
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+
+	for name, obj := range objects {
+		report.Findings = append(report.Findings, ReconcileFinding{
+			Issue:      IssueOrphanObject,
+			Filename:   name,
+			Key:        obj.Key,
+			StoredSize: -1,
+			LocalSize:  p.localSize(podcastFolder, name),
+			RemoteSize: obj.Size,
+		})
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Filename < report.Findings[j].Filename
+	})
+
+	log.Printf("[INFO] Reconciled %s: %d episodes, %d objects, %d findings",
+		podcastID, report.Episodes, report.Objects, len(report.Findings))
+	return report, nil
+}
+
+// FixReconcile brings object storage and the database in line with each other for the findings of the report:
+//   - missing or resized objects are uploaded again if the local file matches the stored size,
+//     otherwise a missing episode is marked Deleted and a stored size matching both copies is corrected;
+//   - objects of New episodes holding the local file mark them Uploaded, other objects of not uploaded episodes
+//     are deleted unless the local file is gone too;
+//   - orphan objects are deleted.
+//
+// Each finding gets Fix or Error set. session is the ID of the reconcile run, recorded in the episode history.
+func (p *Processor) FixReconcile(ctx context.Context, session, podcastID, podcastFolder string, report *ReconcileReport) error {
+	var errs []error
+	for i := range report.Findings {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		finding := &report.Findings[i]
+		if err := p.fixFinding(ctx, session, podcastID, podcastFolder, finding); err != nil {
+			log.Printf("[ERROR] can't fix %s %s - %s, %v", finding.Issue, finding.Filename, podcastID, err)
+			finding.Error = err.Error()
+			errs = append(errs, fmt.Errorf("fix %s: %w", finding.Filename, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Processor) fixFinding(ctx context.Context, session, podcastID, podcastFolder string, finding *ReconcileFinding) error {
+	objectName := finding.Key
+
+	if finding.Issue == IssueOrphanObject {
+		if err := p.objects(podcastID).DeleteEpisode(ctx, objectName); err != nil {
+			return fmt.Errorf("delete object: %w", err)
+		}
+		finding.Fix = "deleted object"
+		return nil
+	}
+	if finding.Issue == IssueMissingLocal {
+		return nil
+	}
+
+	episode, err := p.Storage.GetEpisodeByFilename(podcastID, finding.Filename)
+	if err != nil {
+		return fmt.Errorf("get episode: %w", err)
+	}
+	if episode.Status != finding.Status {
+		return fmt.Errorf("episode status changed to %s since reconcile", episode.Status)
+	}
+
+	event := storage.EpisodeEvent{
+		Filename:  episode.Filename,
+		Operation: storage.OperationReconcile,
+		OldStatus: episode.Status,
+		NewStatus: episode.Status,
+		Session:   session,
+		Location:  episode.Location,
+	}
+	fail := func(err error) error {
+		event.NewStatus, event.Error = event.OldStatus, err.Error()
+		p.recordHistory(podcastID, event)
+		return err
+	}
+
+	// an object of a New episode is taken as its upload only if it holds the local file
+	verified := false
+	var info *ObjectInfo
+	var sums Checksums
+	if finding.Issue == IssueNotMarkedUploaded && episode.Status == podcast.New && finding.LocalSize == episode.Size {
+		if info, err = p.objects(podcastID).GetObjectInfo(ctx, objectName); err != nil {
+			return fail(fmt.Errorf("get object info: %w", err))
+		}
+		if sums, err = FileChecksums(p.localPath(podcastFolder, finding.Filename)); err != nil {
+			return fail(fmt.Errorf("compute checksum: %w", err))
+		}
+		verified = sums.Matches(info)
+	}
+
+	switch {
+	case (finding.Issue == IssueMissingRemote || finding.Issue == IssueSizeMismatch) && finding.LocalSize == episode.Size:
+		event.Operation = storage.OperationUpload
+		result, err := p.uploadSingleEpisode(ctx, podcastID, podcastFolder, episode, nil)
+		if err != nil {
+			return fail(fmt.Errorf("upload: %w", err))
+		}
+		episode.Location, event.Location = result.Location, result.Location
+		episode.Checksum, episode.Key = result.Checksum, result.Key
+		finding.Fix = "uploaded again"
+
+	case finding.Issue == IssueMissingRemote:
+		episode.Status, event.NewStatus = podcast.Deleted, podcast.Deleted
+		finding.Fix = "marked deleted"
+
+	case finding.Issue == IssueSizeMismatch && finding.LocalSize == finding.RemoteSize:
+		episode.Size = finding.RemoteSize
+		finding.Fix = "corrected size"
+
+	case finding.Issue == IssueSizeMismatch:
+		// neither copy matches the stored size; a rescan has to decide which is right
+		return nil
+
+	case finding.Issue == IssueNotMarkedUploaded && episode.Status == podcast.New && finding.LocalSize < 0:
+		// the object may be the only copy of the episode left
+		return nil
+
+	case finding.Issue == IssueNotMarkedUploaded && verified:
+		episode.Status, event.NewStatus = podcast.Uploaded, podcast.Uploaded
+		episode.Location, event.Location = info.Location, info.Location
+		episode.Checksum, episode.Key = sums.SHA256, objectName
+		finding.Fix = "marked uploaded"
+
+	case finding.Issue == IssueNotMarkedUploaded:
+		event.Operation = storage.OperationDelete
+		if err := p.objects(podcastID).DeleteEpisode(ctx, objectName); err != nil {
+			return fail(fmt.Errorf("delete object: %w", err))
+		}
+		p.recordHistory(podcastID, event)
+		finding.Fix = "deleted object"
+		return nil
+	}
+
+	if err := p.Storage.SaveEpisode(podcastID, episode); err != nil {
+		return fail(fmt.Errorf("save episode: %w", err))
+	}
+	p.recordHistory(podcastID, event)
+	return nil
+}
+
+// localSize returns the size of the file in the podcast folder, -1 if there is none.
+func (p *Processor) localSize(podcastFolder, filename string) int64 {
+	stat, err := os.Stat(p.localPath(podcastFolder, filename))
+	if err != nil || stat.IsDir() {
+		return -1
+	}
+	return stat.Size()
+}
+
+func (p *Processor) localPath(podcastFolder, filename string) string {
+	return filepath.Join(p.StoragePath, podcastFolder, filename)
+}
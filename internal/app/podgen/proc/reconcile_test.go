@@ -0,0 +1,190 @@
+package proc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/proc/mocks"
+	"podgen/internal/storage"
+)
+
+func TestProcessor_Reconcile(t *testing.T) {
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	for name, size := range map[string]int{"a.mp3": 100, "c.mp3": 300, "d.mp3": 400, "g.mp3": 700} {
+		require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", name), make([]byte, size), 0o600))
+	}
+
+	episodes := map[string]*podcast.Episode{
+		"a.mp3": {Filename: "a.mp3", Size: 100, Status: podcast.Uploaded}, // object missing, local file present
+		"b.mp3": {Filename: "b.mp3", Size: 200, Status: podcast.Uploaded}, // object and local file missing
+		"c.mp3": {Filename: "c.mp3", Size: 300, Status: podcast.Uploaded}, // object truncated
+		"d.mp3": {Filename: "d.mp3", Size: 400, Status: podcast.New},      // uploaded, status not saved
+		"e.mp3": {Filename: "e.mp3", Size: 500, Status: podcast.Deleted},  // delete didn't remove the object
+		"f.mp3": {Filename: "f.mp3", Size: 600, Status: podcast.New},      // local file removed
+		"g.mp3": {Filename: "g.mp3", Size: 700, Status: podcast.Uploaded}, // in sync
+	}
+	feedKey := fmt.Sprintf("%x", sha256.Sum256([]byte("demo")))
+	objects := map[string]int64{
+		"demo/c.mp3":               250,
+		"demo/d.mp3":               400,
+		"demo/e.mp3":               500,
+		"demo/g.mp3":               700,
+		"demo/old/x.mp3":           10,
+		"demo/podcast.png":         5,
+		"demo/" + feedKey + ".rss": 20,
+	}
+	sums, err := proc.FileChecksums(filepath.Join(storagePath, "demo", "d.mp3"))
+	require.NoError(t, err)
+
+	store := &mocks.EpisodeStoreMock{
+		QueryEpisodesFunc: func(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+			result := make([]*podcast.Episode, 0, len(episodes))
+			for _, e := range episodes {
+				result = append(result, e)
+			}
+			return result, nil
+		},
+		GetEpisodeByFilenameFunc: func(podcastID, fileName string) (*podcast.Episode, error) {
+			return episodes[fileName], nil
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error { return nil },
+	}
+	var uploaded []string
+	s3 := &mocks.ObjectStorageMock{
+		ListFunc: func(ctx context.Context, prefix string) ([]proc.ObjectInfo, error) {
+			assert.Equal(t, "demo/", prefix)
+			var result []proc.ObjectInfo
+			for key, size := range objects {
+				result = append(result, proc.ObjectInfo{Key: key, Size: size})
+			}
+			return result, nil
+		},
+		GetObjectInfoFunc: func(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
+			size, ok := objects[objectName]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			info := &proc.ObjectInfo{Key: objectName, Location: "https://s3/" + objectName, Size: size}
+			if objectName == "demo/d.mp3" {
+				info.SHA256 = sums.SHA256
+			}
+			return info, nil
+		},
+		UploadEpisodeWithProgressFunc: func(ctx context.Context, objectName, filePath string, progress proc.ProgressFunc) (*proc.UploadResult, error) {
+			uploaded = append(uploaded, objectName)
+			return &proc.UploadResult{Location: "https://s3/" + objectName, Checksum: "sum-" + objectName}, nil
+		},
+		DeleteEpisodeFunc: func(ctx context.Context, objectName string) error { return nil },
+	}
+	history := newHistoryMock()
+	p := &proc.Processor{Storage: store, History: history, S3Client: s3, StoragePath: storagePath}
+
+	report, err := p.Reconcile(context.Background(), "demo", "demo", "podcast.png")
+	require.NoError(t, err)
+	assert.Equal(t, 7, report.Episodes)
+	assert.Equal(t, 5, report.Objects, "feed and image are not compared")
+	assert.Equal(t, []proc.ReconcileFinding{
+		{Issue: proc.IssueMissingRemote, Filename: "a.mp3", Key: "demo/a.mp3", Status: podcast.Uploaded, StoredSize: 100, LocalSize: 100, RemoteSize: -1},
+		{Issue: proc.IssueMissingRemote, Filename: "b.mp3", Key: "demo/b.mp3", Status: podcast.Uploaded, StoredSize: 200, LocalSize: -1, RemoteSize: -1},
+		{Issue: proc.IssueSizeMismatch, Filename: "c.mp3", Key: "demo/c.mp3", Status: podcast.Uploaded, StoredSize: 300, LocalSize: 300, RemoteSize: 250},
+		{Issue: proc.IssueNotMarkedUploaded, Filename: "d.mp3", Key: "demo/d.mp3", Status: podcast.New, StoredSize: 400, LocalSize: 400, RemoteSize: 400},
+		{Issue: proc.IssueNotMarkedUploaded, Filename: "e.mp3", Key: "demo/e.mp3", Status: podcast.Deleted, StoredSize: 500, LocalSize: -1, RemoteSize: 500},
+		{Issue: proc.IssueMissingLocal, Filename: "f.mp3", Key: "demo/f.mp3", Status: podcast.New, StoredSize: 600, LocalSize: -1, RemoteSize: -1},
+		{Issue: proc.IssueOrphanObject, Filename: "old/x.mp3", Key: "demo/old/x.mp3", StoredSize: -1, LocalSize: -1, RemoteSize: 10},
+	}, report.Findings)
+	assert.Empty(t, store.SaveEpisodeCalls(), "reconcile without fix must not change anything")
+	assert.Empty(t, s3.DeleteEpisodeCalls())
+	assert.Empty(t, uploaded)
+
+	require.NoError(t, p.FixReconcile(context.Background(), "fix-session", "demo", "demo", report))
+
+	fixes := map[string]string{}
+	for _, f := range report.Findings {
+		assert.Empty(t, f.Error, f.Filename)
+		fixes[f.Filename] = f.Fix
+	}
+	assert.Equal(t, map[string]string{
+		"a.mp3":     "uploaded again",
+		"b.mp3":     "marked deleted",
+		"c.mp3":     "uploaded again",
+		"d.mp3":     "marked uploaded",
+		"e.mp3":     "deleted object",
+		"f.mp3":     "",
+		"old/x.mp3": "deleted object",
+	}, fixes)
+
+	assert.ElementsMatch(t, []string{"demo/a.mp3", "demo/c.mp3"}, uploaded)
+	var deleted []string
+	for _, call := range s3.DeleteEpisodeCalls() {
+		deleted = append(deleted, call.ObjectName)
+	}
+	assert.ElementsMatch(t, []string{"demo/e.mp3", "demo/old/x.mp3"}, deleted)
+
+	assert.Equal(t, podcast.Deleted, episodes["b.mp3"].Status)
+	assert.Equal(t, podcast.Uploaded, episodes["d.mp3"].Status)
+	assert.Equal(t, "https://s3/demo/d.mp3", episodes["d.mp3"].Location)
+	assert.Equal(t, sums.SHA256, episodes["d.mp3"].Checksum)
+	assert.Equal(t, "https://s3/demo/a.mp3", episodes["a.mp3"].Location)
+	assert.Equal(t, "sum-demo/a.mp3", episodes["a.mp3"].Checksum)
+
+	events := recordedEvents(t, history)
+	require.Len(t, events, 5)
+	for _, e := range events {
+		assert.Equal(t, "fix-session", e.Session)
+		assert.Empty(t, e.Error)
+	}
+	assert.Equal(t, storage.OperationUpload, events["a.mp3"].Operation)
+	assert.Equal(t, storage.OperationReconcile, events["b.mp3"].Operation)
+	assert.Equal(t, podcast.Deleted, events["b.mp3"].NewStatus)
+	assert.Equal(t, storage.OperationReconcile, events["d.mp3"].Operation)
+	assert.Equal(t, podcast.Uploaded, events["d.mp3"].NewStatus)
+	assert.Equal(t, storage.OperationDelete, events["e.mp3"].Operation)
+}
+
+func TestProcessor_FixReconcile_Errors(t *testing.T) {
+	episodes := map[string]*podcast.Episode{
+		"a.mp3": {Filename: "a.mp3", Size: 100, Status: podcast.Uploaded},
+		"b.mp3": {Filename: "b.mp3", Size: 200, Status: podcast.Deleted},
+	}
+	store := &mocks.EpisodeStoreMock{
+		GetEpisodeByFilenameFunc: func(podcastID, fileName string) (*podcast.Episode, error) {
+			return episodes[fileName], nil
+		},
+	}
+	s3 := &mocks.ObjectStorageMock{
+		DeleteEpisodeFunc: func(ctx context.Context, objectName string) error { return errors.New("access denied") },
+	}
+	history := newHistoryMock()
+	p := &proc.Processor{Storage: store, History: history, S3Client: s3}
+
+	report := &proc.ReconcileReport{PodcastID: "demo", Findings: []proc.ReconcileFinding{
+		// status changed by another run after the report was made
+		{Issue: proc.IssueMissingRemote, Filename: "a.mp3", Key: "demo/a.mp3", Status: podcast.New, StoredSize: 100, LocalSize: -1, RemoteSize: -1},
+		{Issue: proc.IssueNotMarkedUploaded, Filename: "b.mp3", Key: "demo/b.mp3", Status: podcast.Deleted, StoredSize: 200, LocalSize: -1, RemoteSize: 150},
+		{Issue: proc.IssueOrphanObject, Filename: "x.mp3", Key: "demo/x.mp3", StoredSize: -1, LocalSize: -1, RemoteSize: 10},
+	}}
+
+	err := p.FixReconcile(context.Background(), "fix-session", "demo", "demo", report)
+	require.Error(t, err)
+	for _, f := range report.Findings {
+		assert.Empty(t, f.Fix, f.Filename)
+		assert.NotEmpty(t, f.Error, f.Filename)
+	}
+	assert.Equal(t, podcast.Uploaded, episodes["a.mp3"].Status)
+	assert.Empty(t, store.SaveEpisodeCalls())
+
+	events := recordedEvents(t, history)
+	require.Len(t, events, 1)
+	assert.Equal(t, storage.OperationDelete, events["b.mp3"].Operation)
+	assert.Contains(t, events["b.mp3"].Error, "access denied")
+}
@@ -0,0 +1,138 @@
+package proc
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectOp names a write to object storage.
+type ObjectOp string
+
+const (
+	// ObjectUpload is an upload of a local file.
+	ObjectUpload ObjectOp = "upload"
+	// ObjectDelete is a removal of an object.
+	ObjectDelete ObjectOp = "delete"
+)
+
+// ObjectKind tells what an uploaded object is.
+type ObjectKind string
+
+const (
+	// KindEpisode is an episode file.
+	KindEpisode ObjectKind = "episode"
+	// KindImage is a podcast image.
+	KindImage ObjectKind = "image"
+	// KindFeed is a podcast feed.
+	KindFeed ObjectKind = "feed"
+)
+
+// ObjectAction is a write recorded by RecordingStorage.
+type ObjectAction struct {
+	Op     ObjectOp
+	Kind   ObjectKind
+	Object string
+	// Path is the local file of an upload.
+	Path string
+	// Size is the size of the uploaded file or the deleted object, -1 if unknown.
+	Size int64
+}
+
+// RecordingStorage is an ObjectStorage that records writes instead of performing them.
+// Reads are passed to Source, so uploads of objects already in place are skipped as usual.
+// It is used to plan operations without touching the bucket.
+type RecordingStorage struct {
+	// Source answers object lookups; nil means no object exists.
+	Source ObjectStorage
+	// BaseURL is prepended to object names to build locations of recorded uploads.
+	BaseURL string
+
+	mu      sync.Mutex
+	actions []ObjectAction
+}
+
+// Actions returns recorded writes in the order they were made.
+func (r *RecordingStorage) Actions() []ObjectAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]ObjectAction, len(r.actions))
+	copy(result, r.actions)
+	return result
+}
+
+// DeleteEpisode records removal of the object.
+func (r *RecordingStorage) DeleteEpisode(ctx context.Context, objectName string) error {
+	size := int64(-1)
+	if info, err := r.GetObjectInfo(ctx, objectName); err == nil && info != nil {
+		size = info.Size
+	}
+	r.record(ObjectAction{Op: ObjectDelete, Kind: KindEpisode, Object: objectName, Size: size})
+	return nil
+}
+
+// UploadEpisode records upload of the episode file.
+func (r *RecordingStorage) UploadEpisode(_ context.Context, objectName, filePath string) (*UploadResult, error) {
+	return r.upload(KindEpisode, objectName, filePath, nil)
+}
+
+// UploadEpisodeWithProgress records upload of the episode file, reporting it as complete.
+func (r *RecordingStorage) UploadEpisodeWithProgress(_ context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	return r.upload(KindEpisode, objectName, filePath, progress)
+}
+
+// UploadImage records upload of the podcast image.
+func (r *RecordingStorage) UploadImage(_ context.Context, objectName, filePath string) (*UploadResult, error) {
+	return r.upload(KindImage, objectName, filePath, nil)
+}
+
+// UploadFeed records upload of the feed.
+func (r *RecordingStorage) UploadFeed(_ context.Context, objectName, filePath string) (*UploadResult, error) {
+	return r.upload(KindFeed, objectName, filePath, nil)
+}
+
+// GetObjectInfo looks the object up in Source.
+func (r *RecordingStorage) GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	if r.Source == nil {
+		return nil, os.ErrNotExist
+	}
+	return r.Source.GetObjectInfo(ctx, objectName)
+}
+
+// List lists objects in Source. Recorded writes are not reflected.
+func (r *RecordingStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	if r.Source == nil {
+		return nil, nil
+	}
+	return r.Source.List(ctx, prefix)
+}
+
+// PresignedURL presigns with Source, presigning doesn't write.
+func (r *RecordingStorage) PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	presigner, ok := r.Source.(Presigner)
+	if !ok {
+		return "", ErrPresignUnsupported
+	}
+	return presigner.PresignedURL(ctx, objectName, expiry)
+}
+
+// upload records an upload. Like a real upload it fails if the local file can't be read.
+func (r *RecordingStorage) upload(kind ObjectKind, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(stat.Size(), stat.Size())
+	}
+	r.record(ObjectAction{Op: ObjectUpload, Kind: kind, Object: objectName, Path: filePath, Size: stat.Size()})
+	return &UploadResult{Location: strings.TrimRight(r.BaseURL, "/") + "/" + objectName}, nil
+}
+
+func (r *RecordingStorage) record(action ObjectAction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = append(r.actions, action)
+}
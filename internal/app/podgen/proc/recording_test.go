@@ -0,0 +1,85 @@
+package proc_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/proc/mocks"
+	"podgen/internal/configs"
+)
+
+func TestRecordingStorage(t *testing.T) {
+	dir := t.TempDir()
+	episodePath := filepath.Join(dir, "ep1.mp3")
+	require.NoError(t, os.WriteFile(episodePath, make([]byte, 1234), 0o600))
+	feedPath := filepath.Join(dir, "feed.rss")
+	require.NoError(t, os.WriteFile(feedPath, []byte("<rss/>"), 0o600))
+
+	source := &mocks.ObjectStorageMock{
+		GetObjectInfoFunc: func(ctx context.Context, objectName string) (*proc.ObjectInfo, error) {
+			if objectName == "demo/old.mp3" {
+				return &proc.ObjectInfo{Location: "https://s3/bucket/demo/old.mp3", Size: 500}, nil
+			}
+			return nil, errors.New("not found")
+		},
+	}
+	r := &proc.RecordingStorage{Source: source, BaseURL: "https://s3/bucket/"}
+	ctx := context.Background()
+
+	var reported int64
+	result, err := r.UploadEpisodeWithProgress(ctx, "demo/ep1.mp3", episodePath, func(uploaded, total int64) { reported = uploaded })
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3/bucket/demo/ep1.mp3", result.Location)
+	assert.Equal(t, int64(1234), reported)
+
+	_, err = r.UploadFeed(ctx, "demo/feed.rss", feedPath)
+	require.NoError(t, err)
+	require.NoError(t, r.DeleteEpisode(ctx, "demo/old.mp3"))
+	require.NoError(t, r.DeleteEpisode(ctx, "demo/gone.mp3"))
+
+	_, err = r.UploadImage(ctx, "demo/podcast.png", filepath.Join(dir, "missing.png"))
+	require.Error(t, err, "upload of a missing file must fail like a real one")
+
+	info, err := r.GetObjectInfo(ctx, "demo/old.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), info.Size)
+
+	assert.Equal(t, []proc.ObjectAction{
+		{Op: proc.ObjectUpload, Kind: proc.KindEpisode, Object: "demo/ep1.mp3", Path: episodePath, Size: 1234},
+		{Op: proc.ObjectUpload, Kind: proc.KindFeed, Object: "demo/feed.rss", Path: feedPath, Size: 6},
+		{Op: proc.ObjectDelete, Kind: proc.KindEpisode, Object: "demo/old.mp3", Size: 500},
+		{Op: proc.ObjectDelete, Kind: proc.KindEpisode, Object: "demo/gone.mp3", Size: -1},
+	}, r.Actions())
+}
+
+func TestProcessor_GenerateFeed_OutputPath(t *testing.T) {
+	storagePath := t.TempDir()
+	outputPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+
+	store := &mocks.EpisodeStoreMock{
+		FindEpisodesByStatusFunc: func(podcastID string, status podcast.Status) ([]*podcast.Episode, error) {
+			return nil, nil
+		},
+	}
+	recorder := &proc.RecordingStorage{BaseURL: "https://s3/bucket"}
+	p := &proc.Processor{Storage: store, S3Client: recorder, StoragePath: storagePath, OutputPath: outputPath}
+
+	feedName, err := p.GenerateFeed(context.Background(), "demo", configs.Podcast{Title: "Demo", Folder: "demo"}, "")
+	require.NoError(t, err)
+	_, err = p.UploadFeed(context.Background(), "demo", "demo", feedName)
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(outputPath, "demo", feedName))
+	assert.NoFileExists(t, filepath.Join(storagePath, "demo", feedName), "generated feed must not be written to storage folder")
+	actions := recorder.Actions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, filepath.Join(outputPath, "demo", feedName), actions[0].Path)
+}
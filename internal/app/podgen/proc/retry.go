@@ -0,0 +1,217 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/sftp"
+)
+
+// RetryStorage is an ObjectStorage that retries failed requests of Storage with jittered exponential backoff.
+// Only errors that may go away on their own are retried, see IsRetryable.
+type RetryStorage struct {
+	Storage ObjectStorage
+	// Attempts is the total number of tries of a request, values below 2 disable retries.
+	Attempts int
+	// Delay is the backoff before the first retry, doubled for every next one up to MaxDelay.
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// Progress is told about retries of uploads and deletes run by workers, optional.
+	Progress ProgressReporter
+}
+
+// DeleteEpisode from storage with retries
+func (r *RetryStorage) DeleteEpisode(ctx context.Context, objectName string) error {
+	_, err := retry(ctx, r, "delete "+objectName, func() (struct{}, error) {
+		return struct{}{}, r.Storage.DeleteEpisode(ctx, objectName)
+	})
+	return err
+}
+
+// UploadEpisode to storage with retries
+func (r *RetryStorage) UploadEpisode(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return retry(ctx, r, "upload "+objectName, func() (*UploadResult, error) {
+		return r.Storage.UploadEpisode(ctx, objectName, filePath)
+	})
+}
+
+// UploadEpisodeWithProgress uploads to storage with retries, progress starts over on every retry
+func (r *RetryStorage) UploadEpisodeWithProgress(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	return retry(ctx, r, "upload "+objectName, func() (*UploadResult, error) {
+		return r.Storage.UploadEpisodeWithProgress(ctx, objectName, filePath, progress)
+	})
+}
+
+// UploadImage to storage with retries
+func (r *RetryStorage) UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return retry(ctx, r, "upload "+objectName, func() (*UploadResult, error) {
+		return r.Storage.UploadImage(ctx, objectName, filePath)
+	})
+}
+
+// UploadFeed to storage with retries
+func (r *RetryStorage) UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return retry(ctx, r, "upload "+objectName, func() (*UploadResult, error) {
+		return r.Storage.UploadFeed(ctx, objectName, filePath)
+	})
+}
+
+// GetObjectInfo from storage with retries
+func (r *RetryStorage) GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	return retry(ctx, r, "get info "+objectName, func() (*ObjectInfo, error) {
+		return r.Storage.GetObjectInfo(ctx, objectName)
+	})
+}
+
+// List objects in storage with retries
+func (r *RetryStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return retry(ctx, r, "list "+prefix, func() ([]ObjectInfo, error) {
+		return r.Storage.List(ctx, prefix)
+	})
+}
+
+// AbortStaleUploads of Storage with retries, if it resumes uploads
+func (r *RetryStorage) AbortStaleUploads(ctx context.Context) (int, error) {
+	cleaner, ok := r.Storage.(UploadCleaner)
+	if !ok {
+		return 0, nil
+	}
+	return retry(ctx, r, "abort stale uploads", func() (int, error) {
+		return cleaner.AbortStaleUploads(ctx)
+	})
+}
+
+// PresignedURL of Storage with retries, presigning may look up the region of the bucket
+func (r *RetryStorage) PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	presigner, ok := r.Storage.(Presigner)
+	if !ok {
+		return "", ErrPresignUnsupported
+	}
+	return retry(ctx, r, "presign "+objectName, func() (string, error) {
+		return presigner.PresignedURL(ctx, objectName, expiry)
+	})
+}
+
+// retry calls fn until it succeeds, fails with an error that is not retryable, attempts run out or ctx is done.
+func retry[T any](ctx context.Context, r *RetryStorage, name string, fn func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= r.Attempts || !IsRetryable(err) {
+			return result, err
+		}
+
+		delay := r.backoff(attempt)
+		log.Printf("[WARN] %s failed on attempt %d of %d, retry in %v: %v", name, attempt, r.Attempts, delay, err)
+		if r.Progress != nil {
+			if workerID := workerIDFrom(ctx); workerID >= 0 {
+				r.Progress.RetryFile(workerID, attempt, err)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, fmt.Errorf("%s: %w, last error: %w", name, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the retry following the attempt: half of Delay*2^(attempt-1),
+// capped at MaxDelay, plus a random part of the same size, so concurrent workers don't retry in lockstep.
+func (r *RetryStorage) backoff(attempt int) time.Duration {
+	delay := r.Delay
+	for i := 1; i < attempt && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1) //nolint:gosec // jitter doesn't need a secure source
+}
+
+// IsNotFound reports whether the request failed because the object doesn't exist. Files of the filesystem,
+// WebDAV and SFTP backends match fs.ErrNotExist, objects of S3 are reported as NoSuchKey.
+func IsNotFound(err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	var resp minio.ErrorResponse
+	return errors.As(err, &resp) && (resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound)
+}
+
+// IsRetryable reports whether the request failed for a reason that may go away on its own:
+// throttling, server errors, timeouts, dropped connections and uploads that arrived corrupted.
+// Client errors such as denied access or missing files are not retried, neither is a cancelled context.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrChecksumMismatch) {
+		return true
+	}
+
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) {
+		switch resp.Code {
+		case "SlowDown", "InternalError", "RequestTimeout", "ServiceUnavailable":
+			return true
+		}
+		return resp.StatusCode >= http.StatusInternalServerError ||
+			resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusRequestTimeout
+	}
+	if errors.Is(err, sftp.ErrSSHFxConnectionLost) {
+		return true
+	}
+
+	// requests that got no response at all; minio replaces the error of a dropped connection with a message
+	var urlErr *url.Error
+	var opErr *net.OpError
+	if errors.As(err, &urlErr) || errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+type workerIDKey struct{}
+
+// withWorkerID marks requests made with ctx as made by the worker, so retries are shown on its progress line.
+func withWorkerID(ctx context.Context, workerID int) context.Context {
+	return context.WithValue(ctx, workerIDKey{}, workerID)
+}
+
+// workerIDFrom returns the worker set by withWorkerID, -1 if there is none.
+func workerIDFrom(ctx context.Context) int {
+	if id, ok := ctx.Value(workerIDKey{}).(int); ok {
+		return id
+	}
+	return -1
+}
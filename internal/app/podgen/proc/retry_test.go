@@ -0,0 +1,292 @@
+package proc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/proc/mocks"
+)
+
+func TestRetryStorage(t *testing.T) {
+	dir := t.TempDir()
+	episodePath := filepath.Join(dir, "ep.mp3")
+	require.NoError(t, os.WriteFile(episodePath, []byte("episode content"), 0o600))
+
+	tests := []struct {
+		name     string
+		attempts int
+		fail     func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool
+		wantErr  string
+		wantPuts int
+	}{
+		{
+			name:     "no failures",
+			attempts: 3,
+			wantPuts: 1,
+		},
+		{
+			name:     "throttled then uploaded",
+			attempts: 3,
+			fail: func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+				if r.Method == http.MethodPut && n == 1 {
+					s3Error(w, http.StatusServiceUnavailable, "SlowDown")
+					return true
+				}
+				return false
+			},
+			wantPuts: 2,
+		},
+		{
+			name:     "connection reset then uploaded",
+			attempts: 3,
+			fail: func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+				if r.Method == http.MethodPut && n == 1 {
+					resetConnection(w)
+					return true
+				}
+				return false
+			},
+			wantPuts: 2,
+		},
+		{
+			name:     "corrupted upload is uploaded again",
+			attempts: 3,
+			fail: func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+				if r.Method == http.MethodPut && n == 1 {
+					_, _ = io.Copy(io.Discard, r.Body)
+					f.mu.Lock()
+					f.objects["demo/ep.mp3"] = []byte("garbage")
+					f.mu.Unlock()
+					w.Header().Set("ETag", etag([]byte("garbage")))
+					w.WriteHeader(http.StatusOK)
+					return true
+				}
+				return false
+			},
+			wantPuts: 2,
+		},
+		{
+			name:     "access denied is not retried",
+			attempts: 3,
+			fail: func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+				if r.Method == http.MethodPut {
+					s3Error(w, http.StatusForbidden, "AccessDenied")
+					return true
+				}
+				return false
+			},
+			wantErr:  "Access",
+			wantPuts: 1,
+		},
+		{
+			name:     "attempts run out",
+			attempts: 3,
+			fail: func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+				if r.Method == http.MethodPut {
+					s3Error(w, http.StatusInternalServerError, "InternalError")
+					return true
+				}
+				return false
+			},
+			wantErr:  "InternalError",
+			wantPuts: 3,
+		},
+		{
+			name:     "single attempt disables retries",
+			attempts: 1,
+			fail: func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+				if r.Method == http.MethodPut {
+					s3Error(w, http.StatusServiceUnavailable, "SlowDown")
+					return true
+				}
+				return false
+			},
+			wantErr:  "SlowDown",
+			wantPuts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, s3 := newFakeS3(t)
+			fake.fail = tt.fail
+			r := &proc.RetryStorage{Storage: s3, Attempts: tt.attempts, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+			result, err := r.UploadEpisode(context.Background(), "demo/ep.mp3", episodePath)
+			assert.Equal(t, tt.wantPuts, fake.count("PUT demo/ep.mp3"))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, result.Checksum, 64)
+			assert.Equal(t, []byte("episode content"), fake.objects["demo/ep.mp3"])
+		})
+	}
+}
+
+func TestRetryStorage_Requests(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	fake.objects["demo/ep.mp3"] = []byte("episode content")
+	fake.fail = func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+		if n == 1 {
+			s3Error(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+			return true
+		}
+		return false
+	}
+	r := &proc.RetryStorage{Storage: s3, Attempts: 2, Delay: time.Millisecond}
+	ctx := context.Background()
+
+	info, err := r.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("episode content")), info.Size)
+	assert.Equal(t, 2, fake.count("HEAD demo/ep.mp3"))
+
+	require.NoError(t, r.DeleteEpisode(ctx, "demo/ep.mp3"))
+	assert.Equal(t, 2, fake.count("DELETE demo/ep.mp3"))
+	assert.Empty(t, fake.objects)
+
+	_, err = r.GetObjectInfo(ctx, "demo/missing.mp3")
+	require.Error(t, err)
+	assert.Equal(t, 2, fake.count("HEAD demo/missing.mp3"), "503 is retried, 404 is not")
+}
+
+func TestRetryStorage_Cancel(t *testing.T) {
+	dir := t.TempDir()
+	episodePath := filepath.Join(dir, "ep.mp3")
+	require.NoError(t, os.WriteFile(episodePath, []byte("episode content"), 0o600))
+
+	fake, s3 := newFakeS3(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := make(chan struct{})
+	fake.fail = func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+		s3Error(w, http.StatusServiceUnavailable, "SlowDown")
+		close(failed)
+		return true
+	}
+	go func() {
+		<-failed
+		time.Sleep(500 * time.Millisecond) // cancel while waiting to retry, after minio gave up on the request
+		cancel()
+	}()
+	r := &proc.RetryStorage{Storage: s3, Attempts: 5, Delay: time.Hour, MaxDelay: time.Hour}
+
+	start := time.Now()
+	_, err := r.UploadEpisode(ctx, "demo/ep.mp3", episodePath)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "SlowDown", "last error is kept")
+	assert.Less(t, time.Since(start), time.Minute, "backoff must not wait after cancel")
+	assert.Equal(t, 1, fake.count("PUT demo/ep.mp3"))
+}
+
+func TestRetryStorage_ReportsRetries(t *testing.T) {
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "ep.mp3"), []byte("episode content"), 0o600))
+	episode := &podcast.Episode{Filename: "ep.mp3", Size: int64(len("episode content")), Status: podcast.New}
+
+	fake, s3 := newFakeS3(t)
+	fake.fail = func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+		if r.Method == http.MethodPut && n < 3 {
+			resetConnection(w)
+			return true
+		}
+		return false
+	}
+	reporter := &mocks.ProgressReporterMock{
+		StartFileFunc:      func(workerID int, filename string, totalSize int64) {},
+		UpdateProgressFunc: func(workerID int, uploaded, total int64) {},
+		CompleteFileFunc:   func(workerID int, fileSize int64, err error) {},
+		RetryFileFunc:      func(workerID, attempt int, err error) {},
+		ResetFunc:          func(totalTasks int) {},
+		FinishFunc:         func() {},
+	}
+	store := &mocks.EpisodeStoreMock{
+		FindEpisodesBySizeLimitFunc: func(podcastID string, status podcast.Status, sizeLimit int64) ([]*podcast.Episode, error) {
+			return []*podcast.Episode{episode}, nil
+		},
+		GetEpisodeByFilenameFunc: func(podcastID, fileName string) (*podcast.Episode, error) {
+			return episode, nil
+		},
+		SaveEpisodeFunc: func(podcastID string, episode *podcast.Episode) error { return nil },
+	}
+	p := &proc.Processor{
+		Storage:     store,
+		S3Client:    &proc.RetryStorage{Storage: s3, Attempts: 3, Delay: time.Millisecond, Progress: reporter},
+		Progress:    reporter,
+		StoragePath: storagePath,
+		ChunkSize:   1,
+	}
+
+	require.NoError(t, p.UploadNewEpisodes(context.Background(), "sess", "demo", "demo", 0))
+	assert.Equal(t, podcast.Uploaded, episode.Status)
+	assert.Equal(t, 3, fake.count("PUT demo/ep.mp3"))
+
+	retries := reporter.RetryFileCalls()
+	require.Len(t, retries, 2)
+	workerID := reporter.StartFileCalls()[0].WorkerID
+	for i, call := range retries {
+		assert.Equal(t, workerID, call.WorkerID)
+		assert.Equal(t, i+1, call.Attempt)
+		assert.Error(t, call.Err)
+	}
+	require.Len(t, reporter.CompleteFileCalls(), 1)
+	assert.NoError(t, reporter.CompleteFileCalls()[0].Err)
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("open ep.mp3: no such file or directory"), false},
+		{context.Canceled, false},
+		{fmt.Errorf("upload: %w", context.DeadlineExceeded), false},
+		{minio.ErrorResponse{StatusCode: http.StatusForbidden, Code: "AccessDenied"}, false},
+		{minio.ErrorResponse{StatusCode: http.StatusNotFound, Code: "NoSuchKey"}, false},
+		{minio.ErrorResponse{StatusCode: http.StatusServiceUnavailable, Code: "SlowDown"}, true},
+		{minio.ErrorResponse{StatusCode: http.StatusBadGateway}, true},
+		{minio.ErrorResponse{StatusCode: http.StatusTooManyRequests}, true},
+		{minio.ErrorResponse{StatusCode: http.StatusBadRequest, Code: "RequestTimeout"}, true},
+		{fmt.Errorf("verify: %w", proc.ErrChecksumMismatch), true},
+		{fmt.Errorf("put: %w", io.ErrUnexpectedEOF), true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, proc.IsRetryable(tt.err), "%v", tt.err)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{fmt.Errorf("stat: %w", os.ErrNotExist), true},
+		{&proc.HTTPStatusError{Method: "PROPFIND", Key: "demo/feed.rss", StatusCode: http.StatusNotFound}, true},
+		{&proc.HTTPStatusError{Method: "PROPFIND", Key: "demo/feed.rss", StatusCode: http.StatusBadGateway}, false},
+		{minio.ErrorResponse{StatusCode: http.StatusNotFound, Code: "NoSuchKey"}, true},
+		{minio.ErrorResponse{StatusCode: http.StatusForbidden, Code: "AccessDenied"}, false},
+		{minio.ErrorResponse{StatusCode: http.StatusServiceUnavailable, Code: "SlowDown"}, false},
+		{fmt.Errorf("put: %w", io.ErrUnexpectedEOF), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, proc.IsNotFound(tt.err), "%v", tt.err)
+	}
+}
@@ -0,0 +1,70 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/storage"
+)
+
+// StartRun records the start of a scheduled job of the podcast and returns it.
+// The returned run is usable even when runs are not persisted.
+func (p *Processor) StartRun(id, podcastID, job string) *storage.Run {
+	run := &storage.Run{
+		ID:        id,
+		PodcastID: podcastID,
+		Job:       job,
+		StartedAt: time.Now(),
+		Outcome:   storage.SessionRunning,
+	}
+	p.saveRun(run)
+	return run
+}
+
+// FinishRun records the outcome of the run.
+// runErr is the error the job ended with, nil on success. A failed job is canceled if ctx is done,
+// a job that finished before shutdown succeeded.
+func (p *Processor) FinishRun(ctx context.Context, run *storage.Run, runErr error) {
+	run.FinishedAt = time.Now()
+	switch {
+	case runErr == nil:
+		run.Outcome = storage.SessionSucceeded
+	case ctx.Err() != nil:
+		run.Outcome = storage.SessionCanceled
+	default:
+		run.Outcome = storage.SessionFailed
+	}
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	p.saveRun(run)
+}
+
+// ListRuns returns recorded runs of scheduled jobs, most recent first.
+// An empty podcastID lists runs of all podcasts, a positive limit caps the number of runs.
+func (p *Processor) ListRuns(ctx context.Context, podcastID string, limit int) ([]*storage.Run, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.Runs == nil {
+		return nil, errors.New("run history is not available")
+	}
+
+	runs, err := p.Runs.ListRuns(podcastID, limit)
+	if err != nil {
+		log.Printf("[ERROR] can't list runs, %v", err)
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (p *Processor) saveRun(run *storage.Run) {
+	if p.Runs == nil {
+		return
+	}
+	if err := p.Runs.SaveRun(run); err != nil {
+		log.Printf("[WARN] can't save run %s, %v", run.ID, err)
+	}
+}
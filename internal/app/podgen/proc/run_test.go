@@ -0,0 +1,92 @@
+package proc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/storage"
+)
+
+// runStore keeps every save of runs in memory, the last save of a run is its record.
+type runStore struct {
+	saved []storage.Run
+}
+
+func (s *runStore) SaveRun(run *storage.Run) error {
+	s.saved = append(s.saved, *run)
+	return nil
+}
+
+func (s *runStore) ListRuns(podcastID string, limit int) ([]*storage.Run, error) {
+	var runs []*storage.Run
+	seen := map[string]bool{}
+	for i := len(s.saved) - 1; i >= 0 && (limit <= 0 || len(runs) < limit); i-- {
+		if !seen[s.saved[i].ID] && (podcastID == "" || s.saved[i].PodcastID == podcastID) {
+			runs = append(runs, &s.saved[i])
+		}
+		seen[s.saved[i].ID] = true
+	}
+	return runs, nil
+}
+
+func TestProcessor_FinishRun(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		runErr      error
+		wantOutcome storage.SessionOutcome
+		wantError   string
+	}{
+		{name: "success", ctx: context.Background(), wantOutcome: storage.SessionSucceeded},
+		{name: "failed", ctx: context.Background(), runErr: errors.New("boom"), wantOutcome: storage.SessionFailed,
+			wantError: "boom"},
+		{name: "canceled", ctx: canceled, runErr: context.Canceled, wantOutcome: storage.SessionCanceled,
+			wantError: context.Canceled.Error()},
+		{name: "finished before shutdown", ctx: canceled, wantOutcome: storage.SessionSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &runStore{}
+			p := &proc.Processor{Runs: store}
+
+			run := p.StartRun("r1", "demo", "scan")
+			require.Len(t, store.saved, 1)
+			assert.Equal(t, storage.SessionRunning, store.saved[0].Outcome)
+			assert.False(t, run.StartedAt.IsZero())
+
+			p.FinishRun(tt.ctx, run, tt.runErr)
+			require.Len(t, store.saved, 2)
+			assert.Equal(t, tt.wantOutcome, store.saved[1].Outcome)
+			assert.Equal(t, tt.wantError, store.saved[1].Error)
+			assert.False(t, store.saved[1].FinishedAt.Before(run.StartedAt))
+		})
+	}
+}
+
+func TestProcessor_ListRuns(t *testing.T) {
+	store := &runStore{}
+	p := &proc.Processor{Runs: store}
+	for _, r := range []struct{ id, podcast string }{{"r1", "demo"}, {"r2", "other"}, {"r3", "demo"}} {
+		p.FinishRun(context.Background(), p.StartRun(r.id, r.podcast, "publish"), nil)
+	}
+
+	runs, err := p.ListRuns(context.Background(), "demo", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "r3", runs[0].ID)
+	assert.Equal(t, storage.SessionSucceeded, runs[0].Outcome)
+
+	_, err = (&proc.Processor{}).ListRuns(context.Background(), "", 0)
+	assert.Error(t, err, "without a run store")
+
+	// runs are not recorded without a store
+	run := (&proc.Processor{}).StartRun("r4", "demo", "scan")
+	assert.Equal(t, "r4", run.ID)
+}
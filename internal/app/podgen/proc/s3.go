@@ -2,24 +2,52 @@ package proc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"podgen/internal/configs"
+	"podgen/internal/pkg/ratelimit"
 )
 
 // ProgressFunc is a callback for upload progress reporting.
 type ProgressFunc func(uploaded, total int64)
 
+// ErrChecksumMismatch is returned when the stored object doesn't hold the uploaded file.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // S3Store store
 type S3Store struct {
 	Client   *minio.Client
 	Location string
 	Bucket   string
+	// Uploads records multipart uploads of files larger than PartSize, so they resume after a restart.
+	// Without it files are uploaded by the client in one go.
+	Uploads UploadStore
+	// PartSize is the part size of recorded uploads, at least 5MB.
+	PartSize int64
+	// StaleAfter is the age of incomplete uploads aborted by AbortStaleUploads, 7 days if not set.
+	StaleAfter time.Duration
+	// Limiter limits the combined bandwidth of all uploads, shared by the workers uploading in parallel.
+	// Nil doesn't limit.
+	Limiter *ratelimit.Bucket
+	// PublicBaseURL is the URL objects are served at, such as a CDN in front of the bucket.
+	// Without it locations point at the endpoint.
+	PublicBaseURL string
+	// URLStyle is the style of locations pointing at the endpoint, see URLTemplate.
+	URLStyle string
+	// KeyPrefix is prepended to object names to get the keys of objects in the bucket.
+	// Object names returned by GetObjectInfo and List don't include it.
+	KeyPrefix string
+	// Policies are the headers of uploaded episodes, images and feeds, such as Cache-Control and ACL.
+	Policies configs.ObjectPolicies
 }
 
 // DeleteEpisode from s3 storage
@@ -31,31 +59,31 @@ func (s *S3Store) DeleteEpisode(ctx context.Context, objectName string) error {
 	if !exists {
 		return nil
 	}
-	return s.Client.RemoveObject(ctx, s.Bucket, objectName, minio.RemoveObjectOptions{})
+	return s.Client.RemoveObject(ctx, s.Bucket, s.key(objectName), minio.RemoveObjectOptions{})
 }
 
 // UploadEpisode to s3 storage
 func (s *S3Store) UploadEpisode(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
-	return s.uploadFile(ctx, objectName, filePath, nil)
+	return s.uploadFile(ctx, objectName, filePath, s.Policies.Episode, nil)
 }
 
 // UploadEpisodeWithProgress uploads to s3 storage with progress callback
 func (s *S3Store) UploadEpisodeWithProgress(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
-	return s.uploadFile(ctx, objectName, filePath, progress)
+	return s.uploadFile(ctx, objectName, filePath, s.Policies.Episode, progress)
 }
 
 // UploadImage to s3 storage
 func (s *S3Store) UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
-	return s.uploadFile(ctx, objectName, filePath, nil)
+	return s.uploadFile(ctx, objectName, filePath, s.Policies.Image, nil)
 }
 
 // UploadFeed to s3 storage
 func (s *S3Store) UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
-	return s.uploadFile(ctx, objectName, filePath, nil)
+	return s.uploadFile(ctx, objectName, filePath, s.Policies.Feed, nil)
 }
 
-// detectContentType determines MIME type from file extension.
-func detectContentType(filename string) string {
+// DetectContentType determines the MIME type of a file from its extension.
+func DetectContentType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
 
 	// Custom mappings for common types
@@ -89,7 +117,13 @@ func detectContentType(filename string) string {
 	return "application/octet-stream"
 }
 
-func (s *S3Store) uploadFile(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+// uploadFile uploads the file and verifies what arrived.
+// The request carries Content-MD5, so the server rejects corrupted payloads, and the SHA-256 of the file
+// is stored in object metadata and compared with the stored object after upload.
+// Files larger than PartSize are uploaded in recorded parts if Uploads is set, see uploadResumable.
+// The headers of the object are set by policy, see putOptions.
+func (s *S3Store) uploadFile(ctx context.Context, objectName, filePath string, policy configs.ObjectPolicy,
+	progress ProgressFunc) (*UploadResult, error) {
 	exists, errBucketExists := s.Client.BucketExists(ctx, s.Bucket)
 	if errBucketExists != nil {
 		return nil, fmt.Errorf("can't check exists bucket %s: %w", s.Bucket, errBucketExists)
@@ -101,74 +135,182 @@ func (s *S3Store) uploadFile(ctx context.Context, objectName, filePath string, p
 		}
 	}
 
-	var uploadInfo minio.UploadInfo
-	var err error
+	sums, err := FileChecksums(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("can't compute checksum of %s: %w", filePath, err)
+	}
 
-	contentType := detectContentType(filePath)
-	opts := minio.PutObjectOptions{
-		ContentType:        contentType,
-		ContentDisposition: "inline",
+	opts, err := putOptions(ctx, DetectContentType(filePath), sums, policy)
+	if err != nil {
+		return nil, fmt.Errorf("can't upload %s: %w", objectName, err)
+	}
+
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("can't stat file %s: %w", filePath, err)
 	}
 
-	if progress != nil {
-		// Use PutObject with progress tracking
+	switch {
+	case s.resumable(stat.Size()):
+		if err := s.uploadResumable(ctx, s.key(objectName), filePath, sums, opts, progress); err != nil {
+			return nil, err
+		}
+	case progress != nil || s.Limiter != nil:
+		// Use PutObject with progress tracking and bandwidth limit
 		file, err := os.Open(filePath) //nolint:gosec // filePath comes from internal code, not user input
 		if err != nil {
 			return nil, fmt.Errorf("can't open file %s: %w", filePath, err)
 		}
 		defer func() { _ = file.Close() }()
 
-		stat, err := file.Stat()
-		if err != nil {
-			return nil, fmt.Errorf("can't stat file %s: %w", filePath, err)
-		}
-
-		reader := &progressReader{
+		reader := ratelimit.Reader(ctx, &progressReader{
 			reader:   file,
 			total:    stat.Size(),
 			callback: progress,
-		}
+		}, s.Limiter)
 
-		uploadInfo, err = s.Client.PutObject(ctx, s.Bucket, objectName, reader, stat.Size(), opts)
-		if err != nil {
+		if _, err = s.Client.PutObject(ctx, s.Bucket, s.key(objectName), reader, stat.Size(), opts); err != nil {
 			return nil, err
 		}
-	} else {
+	default:
 		// Use FPutObject for simple upload
-		uploadInfo, err = s.Client.FPutObject(ctx, s.Bucket, objectName, filePath, opts)
-		if err != nil {
+		if _, err = s.Client.FPutObject(ctx, s.Bucket, s.key(objectName), filePath, opts); err != nil {
 			return nil, err
 		}
 	}
 
-	location := uploadInfo.Location
-	if location == "" {
-		objectInfo, err := s.GetObjectInfo(ctx, objectName)
+	objectInfo, err := s.GetObjectInfo(ctx, objectName)
+	if err != nil {
+		return nil, fmt.Errorf("can't verify upload %s in bucket %s: %w", objectName, s.Bucket, err)
+	}
+	if !sums.Matches(objectInfo) {
+		return nil, fmt.Errorf("%w after upload %s in bucket %s", ErrChecksumMismatch, objectName, s.Bucket)
+	}
+
+	return &UploadResult{Location: objectInfo.Location, Checksum: sums.SHA256}, nil
+}
+
+// putOptions returns the options of the upload of a file with the content type and checksums.
+// User metadata holds the metadata of the policy and of ctx, see withObjectMetadata, metadata of ctx wins.
+func putOptions(ctx context.Context, contentType string, sums Checksums, policy configs.ObjectPolicy) (minio.PutObjectOptions, error) {
+	opts := minio.PutObjectOptions{
+		ContentType:        contentType,
+		ContentDisposition: "inline",
+		CacheControl:       policy.CacheControl,
+		StorageClass:       policy.StorageClass,
+		SendContentMd5:     true,
+		UserMetadata:       map[string]string{},
+	}
+	// headers are ASCII, other values are sent encoded as RFC 2047 words, the way S3 returns them
+	for k, v := range policy.Metadata {
+		opts.UserMetadata[k] = mime.QEncoding.Encode("utf-8", v)
+	}
+	for k, v := range objectMetadataFrom(ctx) {
+		opts.UserMetadata[k] = mime.QEncoding.Encode("utf-8", v)
+	}
+	opts.UserMetadata[checksumMetadata] = sums.SHA256
+	if policy.ACL != "" {
+		opts.UserMetadata["x-amz-acl"] = policy.ACL
+	}
+
+	switch policy.Encryption {
+	case configs.EncryptionS3:
+		opts.ServerSideEncryption = encrypt.NewSSE()
+	case configs.EncryptionKMS:
+		sse, err := encrypt.NewSSEKMS(policy.KMSKeyID, nil)
 		if err != nil {
-			return nil, fmt.Errorf("can't get file location %s in bucket %s: %w", objectName, s.Bucket, err)
+			return opts, fmt.Errorf("can't set encryption: %w", err)
 		}
-		location = objectInfo.Location
+		opts.ServerSideEncryption = sse
+	}
+	return opts, nil
+}
+
+type objectMetadataKey struct{}
+
+// withObjectMetadata adds user metadata to the objects uploaded with ctx, such as the podcast they belong to.
+// Storages without user metadata ignore it.
+func withObjectMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	merged := map[string]string{}
+	for k, v := range objectMetadataFrom(ctx) {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[k] = v
 	}
-	return &UploadResult{Location: location}, nil
+	return context.WithValue(ctx, objectMetadataKey{}, merged)
+}
+
+// objectMetadataFrom returns the metadata set by withObjectMetadata, nil if there is none.
+func objectMetadataFrom(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(objectMetadataKey{}).(map[string]string)
+	return metadata
+}
+
+// PresignedURL returns a URL of the object valid for expiry, at the endpoint even if PublicBaseURL is set.
+func (s *S3Store) PresignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	u, err := s.Client.PresignedGetObject(ctx, s.Bucket, s.key(objectName), expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("can't presign %s in bucket %s: %w", objectName, s.Bucket, err)
+	}
+	return u.String(), nil
 }
 
 // GetObjectInfo from object on s3 storage
 func (s *S3Store) GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error) {
-	statInfo, err := s.Client.StatObject(ctx, s.Bucket, objectName, minio.StatObjectOptions{})
+	statInfo, err := s.Client.StatObject(ctx, s.Bucket, s.key(objectName), minio.StatObjectOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	endpoint := s.Client.EndpointURL()
-
 	objectInfo := ObjectInfo{
-		Location: fmt.Sprintf("%s/%s/%s", strings.TrimRight(endpoint.String(), "/"), s.Bucket, statInfo.Key),
+		Key:      objectName,
+		Location: s.objectLocation(objectName),
 		Size:     statInfo.Size,
+		ETag:     statInfo.ETag,
+	}
+	for k, v := range statInfo.UserMetadata {
+		if strings.EqualFold(k, checksumMetadata) {
+			objectInfo.SHA256 = v
+		}
 	}
 
 	return &objectInfo, nil
 }
 
+// List objects on s3 storage whose names start with prefix, including nested ones
+func (s *S3Store) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	exists, errBucketExists := s.Client.BucketExists(ctx, s.Bucket)
+	if errBucketExists != nil {
+		return nil, fmt.Errorf("can't check exists bucket %s: %w", s.Bucket, errBucketExists)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	var result []ObjectInfo
+	keyPrefix := s.key("")
+	for obj := range s.Client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{Prefix: keyPrefix + prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("can't list objects %s in bucket %s: %w", prefix, s.Bucket, obj.Err)
+		}
+		name := strings.TrimPrefix(obj.Key, keyPrefix)
+		result = append(result, ObjectInfo{Key: name, Location: s.objectLocation(name), Size: obj.Size, ETag: obj.ETag})
+	}
+	return result, nil
+}
+
+// objectLocation returns the public URL of the object.
+func (s *S3Store) objectLocation(objectName string) string {
+	return URLTemplate{BaseURL: s.PublicBaseURL, Endpoint: s.Client.EndpointURL().String(), Bucket: s.Bucket,
+		Style: s.URLStyle, Prefix: s.KeyPrefix}.URL(objectName)
+}
+
+// key returns the key of the object in the bucket.
+func (s *S3Store) key(objectName string) string {
+	return prefixedKey(s.KeyPrefix, objectName)
+}
+
 // progressReader wraps an io.Reader to track upload progress.
 type progressReader struct {
 	reader   io.Reader
@@ -0,0 +1,650 @@
+package proc_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // S3 ETags are MD5
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/proc/mocks"
+	"podgen/internal/configs"
+	"podgen/internal/pkg/ratelimit"
+	"podgen/internal/storage"
+	"podgen/internal/storage/factory"
+)
+
+// fakeS3 is a minimal S3 server keeping objects and multipart uploads of a single bucket in memory.
+// fail is asked before every request to an object and may answer it instead, to inject failures.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	metadata map[string]string
+	headers  map[string]http.Header // of the request creating the object, by key
+	uploads  map[string]*fakeUpload // by upload ID
+	requests map[string]int         // by request name, see requestName
+	fail     func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool
+	nextID   int
+}
+
+type fakeUpload struct {
+	key       string
+	metadata  string
+	parts     map[int][]byte
+	initiated time.Time
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *proc.S3Store) {
+	f := &fakeS3{
+		objects:  map[string][]byte{},
+		metadata: map[string]string{},
+		headers:  map[string]http.Header{},
+		uploads:  map[string]*fakeUpload{},
+		requests: map[string]int{},
+	}
+	srv := httptest.NewTLSServer(f)
+	t.Cleanup(srv.Close)
+
+	// requests are retried by RetryStorage only
+	maxRetry := minio.MaxRetry
+	minio.MaxRetry = 1
+	t.Cleanup(func() { minio.MaxRetry = maxRetry })
+
+	client, err := minio.New(strings.TrimPrefix(srv.URL, "https://"), &minio.Options{
+		Creds:     credentials.NewStaticV4("key", "secret", ""),
+		Secure:    true,
+		Region:    "us-east-1",
+		Transport: srv.Client().Transport,
+	})
+	require.NoError(t, err)
+	return f, &proc.S3Store{Client: client, Bucket: "bucket"}
+}
+
+func (f *fakeS3) count(request string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[request]
+}
+
+// requestName is the method and the object, followed by the multipart operation,
+// e.g. "PUT demo/ep.mp3" or "PUT demo/ep.mp3 part".
+func requestName(r *http.Request, key string) string {
+	name := r.Method + " " + key
+	q := r.URL.Query()
+	switch {
+	case q.Has("uploads"):
+		return name + " uploads"
+	case !q.Has("uploadId"):
+		return name
+	case r.Method == http.MethodPut:
+		return name + " part"
+	case r.Method == http.MethodGet:
+		return name + " parts"
+	case r.Method == http.MethodPost:
+		return name + " complete"
+	default:
+		return name + " abort"
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bucket"), "/")
+	if key == "" { // bucket requests
+		if r.Method == http.MethodGet && r.URL.Query().Has("uploads") {
+			f.listUploads(w)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	name := requestName(r, key)
+	f.mu.Lock()
+	f.requests[name]++
+	n := f.requests[name]
+	f.mu.Unlock()
+	if f.fail != nil && f.fail(f, w, r, n) {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if q := r.URL.Query(); q.Has("uploads") || q.Has("uploadId") {
+		f.multipart(w, r, key)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		f.objects[key] = body
+		f.metadata[key] = r.Header.Get("X-Amz-Meta-Sha256")
+		f.headers[key] = r.Header.Clone()
+		w.Header().Set("ETag", etag(body))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.Header().Set("ETag", etag(body))
+		w.Header().Set("X-Amz-Meta-Sha256", f.metadata[key])
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// multipart serves requests of multipart uploads, called with the lock held.
+func (f *fakeS3) multipart(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	if q.Has("uploads") {
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = &fakeUpload{key: key, metadata: r.Header.Get("X-Amz-Meta-Sha256"), parts: map[int][]byte{}, initiated: time.Now()}
+		f.headers[key] = r.Header.Clone()
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadID string `xml:"UploadId"`
+		}{Bucket: "bucket", Key: key, UploadID: id})
+		return
+	}
+
+	upload, ok := f.uploads[q.Get("uploadId")]
+	if !ok || upload.key != key {
+		s3Error(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		number, _ := strconv.Atoi(q.Get("partNumber"))
+		upload.parts[number] = body
+		w.Header().Set("ETag", etag(body))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		type part struct {
+			PartNumber   int
+			ETag         string
+			Size         int
+			LastModified time.Time
+		}
+		result := struct {
+			XMLName     xml.Name `xml:"ListPartsResult"`
+			Bucket      string
+			Key         string
+			UploadID    string `xml:"UploadId"`
+			IsTruncated bool
+			Parts       []part `xml:"Part"`
+		}{Bucket: "bucket", Key: key, UploadID: q.Get("uploadId")}
+		for number, body := range upload.parts {
+			result.Parts = append(result.Parts, part{PartNumber: number, ETag: etag(body), Size: len(body), LastModified: time.Now().UTC()})
+		}
+		sort.Slice(result.Parts, func(i, j int) bool { return result.Parts[i].PartNumber < result.Parts[j].PartNumber })
+		writeXML(w, result)
+	case http.MethodPost:
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			s3Error(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var content, sums []byte
+		for _, p := range complete.Parts {
+			body, ok := upload.parts[p.PartNumber]
+			if !ok || strings.Trim(etag(body), `"`) != strings.Trim(p.ETag, `"`) {
+				s3Error(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			content = append(content, body...)
+			sum := md5.Sum(body) //nolint:gosec // see import
+			sums = append(sums, sum[:]...)
+		}
+		sum := md5.Sum(sums) //nolint:gosec // see import
+		objectETag := fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(complete.Parts))
+		f.objects[key] = content
+		f.metadata[key] = upload.metadata
+		delete(f.uploads, q.Get("uploadId"))
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: "bucket", Key: key, ETag: objectETag})
+	case http.MethodDelete:
+		delete(f.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) listUploads(w http.ResponseWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	type upload struct {
+		Key       string
+		UploadID  string `xml:"UploadId"`
+		Initiated time.Time
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListMultipartUploadsResult"`
+		Bucket      string
+		IsTruncated bool
+		Uploads     []upload `xml:"Upload"`
+	}{Bucket: "bucket"}
+	for id, u := range f.uploads {
+		result.Uploads = append(result.Uploads, upload{Key: u.key, UploadID: id, Initiated: u.initiated.UTC()})
+	}
+	sort.Slice(result.Uploads, func(i, j int) bool { return result.Uploads[i].UploadID < result.Uploads[j].UploadID })
+	writeXML(w, result)
+}
+
+// readBody reads the request body and checks its Content-MD5, answering with an error if it doesn't match.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	if want := r.Header.Get("Content-Md5"); want != "" {
+		sum := md5.Sum(body) //nolint:gosec // see import
+		if base64.StdEncoding.EncodeToString(sum[:]) != want {
+			s3Error(w, http.StatusBadRequest, "BadDigest")
+			return nil, false
+		}
+	}
+	return body, true
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func etag(body []byte) string {
+	sum := md5.Sum(body) //nolint:gosec // see import
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// s3Error answers with an S3 error document.
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+// resetConnection drops the connection without an answer.
+func resetConnection(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		_ = conn.Close()
+	}
+}
+
+// newUploadStore returns an UploadStore mock keeping records in the map, keyed by "bucket:object".
+func newUploadStore(records map[string]*storage.MultipartUpload) *mocks.UploadStoreMock {
+	var mu sync.Mutex
+	return &mocks.UploadStoreMock{
+		SaveMultipartUploadFunc: func(upload *storage.MultipartUpload) error {
+			mu.Lock()
+			defer mu.Unlock()
+			record := *upload
+			record.Parts = append([]storage.UploadPart(nil), upload.Parts...)
+			records[upload.Bucket+":"+upload.Object] = &record
+			return nil
+		},
+		GetMultipartUploadFunc: func(bucket, object string) (*storage.MultipartUpload, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			upload, ok := records[bucket+":"+object]
+			if !ok {
+				return nil, storage.ErrUploadNotFound
+			}
+			record := *upload
+			record.Parts = append([]storage.UploadPart(nil), upload.Parts...)
+			return &record, nil
+		},
+		DeleteMultipartUploadFunc: func(bucket, object string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(records, bucket+":"+object)
+			return nil
+		},
+		ListMultipartUploadsFunc: func() ([]*storage.MultipartUpload, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			var result []*storage.MultipartUpload
+			for _, upload := range records {
+				result = append(result, upload)
+			}
+			return result, nil
+		},
+	}
+}
+
+// writeEpisode writes a file of 12MB, uploaded in three parts of 5MB, 5MB and 2MB.
+func writeEpisode(t *testing.T, path string, fill byte) []byte {
+	content := bytes.Repeat([]byte{fill}, 12<<20)
+	copy(content, path) // parts differ from each other
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return content
+}
+
+func TestS3Store_UploadResumable(t *testing.T) {
+	episodePath := filepath.Join(t.TempDir(), "ep.mp3")
+	content := writeEpisode(t, episodePath, 'a')
+
+	fake, s3 := newFakeS3(t)
+	// an upload of the same object to another bucket is left to the store of that bucket
+	records := map[string]*storage.MultipartUpload{"client-bucket:demo/ep.mp3": {
+		Object: "demo/ep.mp3", Bucket: "client-bucket", UploadID: "elsewhere", FilePath: episodePath,
+	}}
+	s3.Uploads, s3.PartSize = newUploadStore(records), 5<<20
+
+	// the run is stopped while the last part is uploaded
+	ctx, cancel := context.WithCancel(context.Background())
+	fake.fail = func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+		if r.URL.Query().Get("partNumber") == "3" && n == 3 {
+			cancel()
+			resetConnection(w)
+			return true
+		}
+		return false
+	}
+	_, err := s3.UploadEpisode(ctx, "demo/ep.mp3", episodePath)
+	require.Error(t, err)
+	require.Contains(t, records, "bucket:demo/ep.mp3", "interrupted upload is recorded")
+	assert.Len(t, records["bucket:demo/ep.mp3"].Parts, 2)
+	assert.Empty(t, fake.objects)
+
+	// the next run uploads the last part only
+	var reported []int64
+	result, err := s3.UploadEpisodeWithProgress(context.Background(), "demo/ep.mp3", episodePath, func(uploaded, total int64) {
+		reported = append(reported, uploaded)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, fake.count("POST demo/ep.mp3 uploads"))
+	assert.Equal(t, 4, fake.count("PUT demo/ep.mp3 part"), "parts 1 and 2 are not uploaded again")
+	assert.Equal(t, 1, fake.count("POST demo/ep.mp3 complete"))
+	assert.Equal(t, content, fake.objects["demo/ep.mp3"])
+	assert.Len(t, result.Checksum, 64)
+	assert.NotContains(t, records, "bucket:demo/ep.mp3", "completed upload is forgotten")
+	assert.Contains(t, records, "client-bucket:demo/ep.mp3")
+	assert.Equal(t, 0, fake.count("DELETE demo/ep.mp3 abort"))
+	require.NotEmpty(t, reported)
+	assert.Equal(t, int64(10<<20), reported[0], "progress starts from the uploaded parts")
+	assert.Equal(t, int64(12<<20), reported[len(reported)-1])
+}
+
+func TestS3Store_UploadResumable_StartsOver(t *testing.T) {
+	episodePath := filepath.Join(t.TempDir(), "ep.mp3")
+	content := writeEpisode(t, episodePath, 'a')
+	stat, err := os.Stat(episodePath)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		uploadID  string
+		sha256    string
+		wantAbort int
+	}{
+		{name: "file changed", uploadID: "upload-1", sha256: "other", wantAbort: 1},
+		{name: "upload gone from bucket", uploadID: "unknown", wantAbort: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, s3 := newFakeS3(t)
+			sums, err := proc.FileChecksums(episodePath)
+			require.NoError(t, err)
+			if tt.sha256 == "" {
+				tt.sha256 = sums.SHA256
+			}
+			// an earlier upload with a part of other content
+			fake.uploads["upload-1"] = &fakeUpload{key: "demo/ep.mp3", parts: map[int][]byte{1: []byte("old")}, initiated: time.Now()}
+			fake.nextID = 1
+			records := map[string]*storage.MultipartUpload{"bucket:demo/ep.mp3": {
+				Object: "demo/ep.mp3", Bucket: "bucket", UploadID: tt.uploadID, FilePath: episodePath,
+				Size: stat.Size(), ModTime: stat.ModTime(), SHA256: tt.sha256, PartSize: 5 << 20,
+				Parts: []storage.UploadPart{{Number: 1, ETag: strings.Trim(etag([]byte("old")), `"`), Size: 5 << 20}},
+			}}
+			s3.Uploads, s3.PartSize = newUploadStore(records), 5<<20
+
+			_, err = s3.UploadEpisode(context.Background(), "demo/ep.mp3", episodePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAbort, fake.count("DELETE demo/ep.mp3 abort"))
+			assert.Equal(t, 1, fake.count("POST demo/ep.mp3 uploads"), "a new upload is started")
+			assert.Equal(t, 3, fake.count("PUT demo/ep.mp3 part"))
+			assert.Equal(t, content, fake.objects["demo/ep.mp3"])
+			assert.Empty(t, records)
+		})
+	}
+}
+
+func TestS3Store_UploadResumable_AbortFails(t *testing.T) {
+	episodePath := filepath.Join(t.TempDir(), "ep.mp3")
+	writeEpisode(t, episodePath, 'a')
+	stat, err := os.Stat(episodePath)
+	require.NoError(t, err)
+
+	fake, s3 := newFakeS3(t)
+	fake.uploads["upload-1"] = &fakeUpload{key: "demo/ep.mp3", parts: map[int][]byte{}, initiated: time.Now()}
+	fake.fail = func(f *fakeS3, w http.ResponseWriter, r *http.Request, n int) bool {
+		if r.Method == http.MethodDelete && r.URL.Query().Has("uploadId") {
+			s3Error(w, http.StatusForbidden, "AccessDenied")
+			return true
+		}
+		return false
+	}
+	// the file changed since the upload started
+	records := map[string]*storage.MultipartUpload{"bucket:demo/ep.mp3": {
+		Object: "demo/ep.mp3", Bucket: "bucket", UploadID: "upload-1", FilePath: episodePath,
+		Size: stat.Size(), ModTime: stat.ModTime(), SHA256: "other", PartSize: 5 << 20,
+	}}
+	s3.Uploads, s3.PartSize = newUploadStore(records), 5<<20
+
+	_, err = s3.UploadEpisode(context.Background(), "demo/ep.mp3", episodePath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't abort upload of demo/ep.mp3")
+	assert.Equal(t, 0, fake.count("POST demo/ep.mp3 uploads"), "no upload starts while the old one is kept")
+	assert.Contains(t, records, "bucket:demo/ep.mp3", "the upload is recorded until it is aborted")
+}
+
+func TestS3Store_AbortStaleUploads(t *testing.T) {
+	dir := t.TempDir()
+	keptPath := filepath.Join(dir, "kept.mp3")
+	writeEpisode(t, keptPath, 'k')
+	stat, err := os.Stat(keptPath)
+	require.NoError(t, err)
+
+	fake, s3 := newFakeS3(t)
+	now := time.Now()
+	for id, upload := range map[string]*fakeUpload{
+		"kept":          {key: "demo/kept.mp3", initiated: now.Add(-time.Hour)},
+		"file-gone":     {key: "demo/gone.mp3", initiated: now.Add(-time.Hour)},
+		"too-old":       {key: "demo/old.mp3", initiated: now.Add(-48 * time.Hour)},
+		"unknown-old":   {key: "demo/crashed.mp3", initiated: now.Add(-48 * time.Hour)},
+		"unknown-fresh": {key: "demo/other-host.mp3", initiated: now.Add(-time.Minute)},
+	} {
+		upload.parts = map[int][]byte{}
+		fake.uploads[id] = upload
+	}
+	records := map[string]*storage.MultipartUpload{
+		"bucket:demo/kept.mp3": {Object: "demo/kept.mp3", Bucket: "bucket", UploadID: "kept", FilePath: keptPath,
+			Size: stat.Size(), ModTime: stat.ModTime(), StartedAt: now.Add(-time.Hour)},
+		"bucket:demo/gone.mp3": {Object: "demo/gone.mp3", Bucket: "bucket", UploadID: "file-gone", FilePath: filepath.Join(dir, "gone.mp3"),
+			StartedAt: now.Add(-time.Hour)},
+		"bucket:demo/old.mp3": {Object: "demo/old.mp3", Bucket: "bucket", UploadID: "too-old", FilePath: keptPath,
+			Size: stat.Size(), ModTime: stat.ModTime(), StartedAt: now.Add(-48 * time.Hour)},
+		"client-bucket:client/ep.mp3": {Object: "client/ep.mp3", Bucket: "client-bucket", UploadID: "elsewhere",
+			FilePath: filepath.Join(dir, "gone.mp3"), StartedAt: now.Add(-48 * time.Hour)},
+	}
+	s3.Uploads, s3.StaleAfter = newUploadStore(records), 24*time.Hour
+
+	aborted, err := s3.AbortStaleUploads(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, aborted)
+
+	remaining := []string{}
+	for id := range fake.uploads {
+		remaining = append(remaining, id)
+	}
+	assert.ElementsMatch(t, []string{"kept", "unknown-fresh"}, remaining)
+	assert.ElementsMatch(t, []string{"bucket:demo/kept.mp3", "client-bucket:client/ep.mp3"}, func() []string {
+		keys := []string{}
+		for k := range records {
+			keys = append(keys, k)
+		}
+		return keys
+	}(), "uploads to other buckets are left to their stores")
+}
+
+func TestS3Store_UploadLimited(t *testing.T) {
+	dir := t.TempDir()
+	_, s3 := newFakeS3(t)
+	s3.Limiter = ratelimit.New(2 << 20)
+
+	// two parallel uploads of 512KB share 2MB/s, so they take at least the time of 1MB less the burst
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range 2 {
+		path := filepath.Join(dir, fmt.Sprintf("ep%d.mp3", i))
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{'a'}, 512<<10), 0o600))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s3.UploadEpisode(context.Background(), "demo/"+filepath.Base(path), path)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 350*time.Millisecond)
+	assert.Less(t, elapsed, 3*time.Second)
+}
+
+func TestS3Store_PublicLocation(t *testing.T) {
+	_, s3 := newFakeS3(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("episode content"), 0o600))
+
+	result, err := s3.UploadEpisode(ctx, "demo/ep.mp3", path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimRight(s3.Client.EndpointURL().String(), "/")+"/bucket/demo/ep.mp3", result.Location)
+
+	s3.URLStyle = configs.URLStyleVirtualHost
+	info, err := s3.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "https://bucket."+s3.Client.EndpointURL().Host+"/demo/ep.mp3", info.Location)
+
+	s3.PublicBaseURL = "https://cdn.example.com"
+	result, err = s3.UploadEpisode(ctx, "demo/ep.mp3", path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/demo/ep.mp3", result.Location)
+}
+
+func TestS3Store_KeyPrefix(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	s3.KeyPrefix = "/podcasts/"
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("episode content"), 0o600))
+
+	result, err := s3.UploadEpisode(ctx, "demo/ep.mp3", path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimRight(s3.Client.EndpointURL().String(), "/")+"/bucket/podcasts/demo/ep.mp3", result.Location)
+	assert.Equal(t, []byte("episode content"), fake.objects["podcasts/demo/ep.mp3"])
+
+	info, err := s3.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "demo/ep.mp3", info.Key, "object names don't include the prefix")
+
+	require.NoError(t, s3.DeleteEpisode(ctx, "demo/ep.mp3"))
+	assert.Empty(t, fake.objects)
+}
+
+func TestS3Store_ObjectPolicies(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	s3.Policies = configs.ObjectPolicies{
+		Episode: configs.ObjectPolicy{CacheControl: "public, max-age=31536000", ACL: "public-read", StorageClass: "STANDARD_IA",
+			Encryption: configs.EncryptionKMS, KMSKeyID: "key-1", Metadata: map[string]string{"owner": "podgen"}},
+		Feed: configs.ObjectPolicy{CacheControl: "public, max-age=300", Encryption: configs.EncryptionS3},
+	}
+	s3.Uploads, s3.PartSize = newUploadStore(map[string]*storage.MultipartUpload{}), 5<<20
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	writeEpisode(t, filepath.Join(storagePath, "demo", "Выпуск 1.mp3"), 'a')
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "demo.rss"), []byte("<rss/>"), 0o600))
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	p := &proc.Processor{Storage: store, Podcasts: store, History: store, Sessions: store, S3Client: s3,
+		Files: &proc.Files{Storage: storagePath}, StoragePath: storagePath, ChunkSize: 1}
+	ctx := context.Background()
+	_, err = p.Update(ctx, "demo", "demo")
+	require.NoError(t, err)
+	require.NoError(t, p.UploadNewEpisodes(ctx, "sess", "demo", "demo", 0))
+	_, err = p.UploadFeed(ctx, "demo", "demo", "demo.rss")
+	require.NoError(t, err)
+
+	episode := fake.headers["demo/Выпуск 1.mp3"]
+	require.NotNil(t, episode, "the episode is uploaded in parts, headers are sent when the upload is created")
+	assert.Equal(t, "public, max-age=31536000", episode.Get("Cache-Control"))
+	assert.Equal(t, "public-read", episode.Get("X-Amz-Acl"))
+	assert.Equal(t, "STANDARD_IA", episode.Get("X-Amz-Storage-Class"))
+	assert.Equal(t, "aws:kms", episode.Get("X-Amz-Server-Side-Encryption"))
+	assert.Equal(t, "key-1", episode.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	assert.Equal(t, "podgen", episode.Get("X-Amz-Meta-Owner"))
+	assert.Equal(t, "demo", episode.Get("X-Amz-Meta-Podcast"))
+	assert.Equal(t, "=?utf-8?q?=D0=92=D1=8B=D0=BF=D1=83=D1=81=D0=BA_1.mp3?=", episode.Get("X-Amz-Meta-Episode"),
+		"non-ASCII metadata is encoded")
+	assert.NotEmpty(t, episode.Get("X-Amz-Meta-Sha256"))
+
+	feed := fake.headers["demo/demo.rss"]
+	require.NotNil(t, feed)
+	assert.Equal(t, "public, max-age=300", feed.Get("Cache-Control"))
+	assert.Equal(t, "AES256", feed.Get("X-Amz-Server-Side-Encryption"))
+	assert.Empty(t, feed.Get("X-Amz-Acl"))
+	assert.Equal(t, "demo", feed.Get("X-Amz-Meta-Podcast"))
+	assert.Empty(t, feed.Get("X-Amz-Meta-Episode"))
+	assert.Equal(t, "application/rss+xml", feed.Get("Content-Type"))
+}
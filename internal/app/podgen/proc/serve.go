@@ -0,0 +1,85 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/configs"
+	"podgen/internal/storage"
+)
+
+// LocalFeed returns the file name and the content of the feed of the podcast served from the storage folder,
+// see LocalFile. New episodes are included, so the feed can be previewed before they are uploaded, and enclosures
+// and artwork link to baseURL/<folder>/<file name>.
+func (p *Processor) LocalFeed(podcastID string, podcastEntity configs.Podcast, baseURL, imageFilename string) (name, feed string, err error) {
+	feedKey, err := p.getFeedKey(podcastID)
+	if err != nil {
+		return "", "", fmt.Errorf("can't generate feed key for %s, %w", podcastID, err)
+	}
+
+	var episodes []*podcast.Episode
+	for _, status := range []podcast.Status{podcast.New, podcast.Uploaded} {
+		found, err := p.Storage.FindEpisodesByStatus(podcastID, status)
+		if err != nil {
+			return "", "", fmt.Errorf("can't find episodes %s, %w", podcastID, err)
+		}
+		episodes = append(episodes, found...)
+	}
+	slices.SortFunc(episodes, func(a, b *podcast.Episode) int { return strings.Compare(a.Filename, b.Filename) })
+
+	var imageURL string
+	if _, err := p.LocalFile(podcastID, podcastEntity.Folder, imageFilename, imageFilename); err == nil {
+		imageURL = localURL(baseURL, podcastEntity.Folder, imageFilename)
+	}
+	feed, err = renderFeed(podcastEntity, imageURL, episodes, func(episode *podcast.Episode) (string, error) {
+		return localURL(baseURL, podcastEntity.Folder, episode.Filename), nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return feedKey + ".rss", feed, nil
+}
+
+// LocalFile returns the path of the file of the podcast served from the storage folder: an episode that isn't
+// deleted, or the artwork, found in the podcast folder or the storage folder like on upload.
+// Other files of the folder are not served, fs.ErrNotExist is returned for them.
+func (p *Processor) LocalFile(podcastID, podcastFolder, filename, imageFilename string) (string, error) {
+	if filename == "" || filename != filepath.Base(filename) || strings.HasPrefix(filename, ".") {
+		return "", fs.ErrNotExist
+	}
+
+	if filename == imageFilename {
+		for _, path := range []string{filepath.Join(p.StoragePath, podcastFolder, filename), filepath.Join(p.StoragePath, filename)} {
+			if CheckFileExists(path) {
+				return path, nil
+			}
+		}
+		return "", fs.ErrNotExist
+	}
+
+	episode, err := p.Storage.GetEpisodeByFilename(podcastID, filename)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return "", fs.ErrNotExist
+	case err != nil:
+		return "", fmt.Errorf("can't get episode %s of %s: %w", filename, podcastID, err)
+	case episode.Status == podcast.Deleted:
+		return "", fs.ErrNotExist
+	}
+	path := filepath.Join(p.StoragePath, podcastFolder, filename)
+	if !CheckFileExists(path) {
+		return "", fs.ErrNotExist
+	}
+	return path, nil
+}
+
+// localURL returns the URL of a file of the podcast folder served at baseURL.
+func localURL(baseURL, podcastFolder, filename string) string {
+	return publicLocation(baseURL, url.PathEscape(podcastFolder)+"/"+url.PathEscape(filename))
+}
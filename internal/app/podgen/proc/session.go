@@ -0,0 +1,242 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/storage"
+)
+
+const sessionDateLayout = "2006-01-02"
+
+// StartSession records the start of an upload, rollback or reconcile session and returns it.
+// target is the ID of the session a rollback reverts.
+// The returned session is usable even when sessions are not persisted.
+func (p *Processor) StartSession(id string, operation storage.Operation, target string, podcastIDs []string) *storage.Session {
+	session := &storage.Session{
+		ID:         id,
+		StartedAt:  time.Now(),
+		PodcastIDs: podcastIDs,
+		Operation:  operation,
+		Target:     target,
+		Outcome:    storage.SessionRunning,
+	}
+	if host, err := os.Hostname(); err == nil {
+		session.Host = host
+	}
+	p.saveSession(session)
+	return session
+}
+
+// FinishSession collects stats of the session and records its outcome.
+// runErr is the error the run ended with, nil on success.
+func (p *Processor) FinishSession(ctx context.Context, session *storage.Session, runErr error) {
+	session.FinishedAt = time.Now()
+	p.collectSessionStats(session)
+
+	switch {
+	case ctx.Err() != nil:
+		session.Outcome = storage.SessionCanceled
+	case runErr == nil:
+		session.Outcome = storage.SessionSucceeded
+	case session.Uploaded > 0 || session.Deleted > 0:
+		session.Outcome = storage.SessionPartial
+	default:
+		session.Outcome = storage.SessionFailed
+	}
+	if runErr != nil {
+		session.Error = runErr.Error()
+	}
+
+	p.saveSession(session)
+}
+
+// collectSessionStats counts episodes uploaded, deleted and failed in the session.
+// The counts come from history events recorded in the session;
+// without history only episodes uploaded in the session are counted.
+func (p *Processor) collectSessionStats(session *storage.Session) {
+	session.Uploaded, session.Deleted, session.Failed, session.Bytes = 0, 0, 0, 0
+
+	for _, podcastID := range session.PodcastIDs {
+		if p.History == nil {
+			episodes, err := p.Storage.FindEpisodesBySession(podcastID, session.ID)
+			if err != nil {
+				log.Printf("[WARN] can't find episodes of session %s - %s, %v", session.ID, podcastID, err)
+			}
+			session.Uploaded += len(episodes)
+			for _, episode := range episodes {
+				session.Bytes += episode.Size
+			}
+			continue
+		}
+
+		events, err := p.History.ListHistory(podcastID, storage.HistoryFilter{Since: session.StartedAt})
+		if err != nil {
+			log.Printf("[WARN] can't get history of session %s - %s, %v", session.ID, podcastID, err)
+			continue
+		}
+		for _, event := range events {
+			if event.Session != session.ID {
+				continue
+			}
+			switch {
+			case event.Error != "":
+				session.Failed++
+			case event.Operation == storage.OperationUpload,
+				event.NewStatus == podcast.Uploaded && event.OldStatus != podcast.Uploaded:
+				session.Uploaded++
+				episode, err := p.Storage.GetEpisodeByFilename(podcastID, event.Filename)
+				if err != nil {
+					log.Printf("[WARN] can't get episode by filename %s - %s, %v", podcastID, event.Filename, err)
+				}
+				if episode != nil {
+					session.Bytes += episode.Size
+				}
+			case event.Operation == storage.OperationDelete,
+				event.OldStatus == podcast.Uploaded && event.NewStatus != podcast.Uploaded:
+				session.Deleted++
+			}
+		}
+	}
+}
+
+// ListSessions returns recorded sessions of all operations, most recent first.
+// A positive limit caps the number of returned sessions.
+func (p *Processor) ListSessions(ctx context.Context, limit int) ([]*storage.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.Sessions == nil {
+		return nil, errors.New("upload sessions are not available")
+	}
+
+	sessions, err := p.Sessions.ListSessions(limit)
+	if err != nil {
+		log.Printf("[ERROR] can't list sessions, %v", err)
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindSessions resolves a session reference to recorded sessions.
+// The reference is "last" for the most recent upload session, "last-N" for the upload session N runs before it,
+// a date (YYYY-MM-DD) for all upload sessions started that day in local time, or a session ID.
+// An unknown ID resolves to a bare session, so episodes of sessions uploaded
+// before sessions were recorded can still be found.
+func (p *Processor) FindSessions(ctx context.Context, ref string) ([]*storage.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("empty session reference")
+	}
+
+	if back, ok, err := parseLastRef(ref); ok {
+		if err != nil {
+			return nil, err
+		}
+		uploads, err := p.listUploadSessions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(uploads) <= back {
+			return nil, fmt.Errorf("session %q not found: %d upload sessions recorded", ref, len(uploads))
+		}
+		return uploads[back : back+1], nil
+	}
+
+	if day, err := time.ParseInLocation(sessionDateLayout, ref, time.Local); err == nil {
+		uploads, err := p.listUploadSessions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		next := day.AddDate(0, 0, 1)
+		var result []*storage.Session
+		for _, session := range uploads {
+			if !session.StartedAt.Before(day) && session.StartedAt.Before(next) {
+				result = append(result, session)
+			}
+		}
+		if len(result) == 0 {
+			return nil, fmt.Errorf("no upload sessions started on %s", ref)
+		}
+		return result, nil
+	}
+
+	if p.Sessions != nil {
+		session, err := p.Sessions.GetSession(ref)
+		if err == nil {
+			return []*storage.Session{session}, nil
+		}
+		if !errors.Is(err, storage.ErrSessionNotFound) {
+			return nil, err
+		}
+	}
+	return []*storage.Session{{ID: ref}}, nil
+}
+
+// MarkSessionRolledBack records that episodes of the session were rolled back.
+func (p *Processor) MarkSessionRolledBack(id string) {
+	if p.Sessions == nil {
+		return
+	}
+	session, err := p.Sessions.GetSession(id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrSessionNotFound) {
+			log.Printf("[WARN] can't get session %s, %v", id, err)
+		}
+		return
+	}
+	session.Outcome = storage.SessionRolledBack
+	p.saveSession(session)
+}
+
+// listUploadSessions returns recorded upload sessions, most recent first, skipping rollbacks and reconcile fixes.
+func (p *Processor) listUploadSessions(ctx context.Context) ([]*storage.Session, error) {
+	sessions, err := p.ListSessions(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	uploads := make([]*storage.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsUpload() {
+			uploads = append(uploads, session)
+		}
+	}
+	return uploads, nil
+}
+
+// saveSession persists the session. Sessions are informational, so failures are only logged.
+func (p *Processor) saveSession(session *storage.Session) {
+	if p.Sessions == nil {
+		return
+	}
+	if err := p.Sessions.SaveSession(session); err != nil {
+		log.Printf("[WARN] can't save session %s, %v", session.ID, err)
+	}
+}
+
+// parseLastRef parses "last" and "last-N" session references into the number of sessions to skip.
+// ok is false if ref is not of that form.
+func parseLastRef(ref string) (back int, ok bool, err error) {
+	if ref == "last" {
+		return 0, true, nil
+	}
+	rest, found := strings.CutPrefix(ref, "last-")
+	if !found {
+		return 0, false, nil
+	}
+	back, err = strconv.Atoi(rest)
+	if err != nil || back < 0 {
+		return 0, true, fmt.Errorf("invalid session reference %q: expected last-N", ref)
+	}
+	return back, true, nil
+}
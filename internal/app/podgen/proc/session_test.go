@@ -0,0 +1,249 @@
+package proc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/app/podgen/proc/mocks"
+	"podgen/internal/storage"
+)
+
+func TestProcessor_FindSessions(t *testing.T) {
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.Local)
+	recorded := []*storage.Session{ // most recent first, as returned by the store
+		{ID: "r1", StartedAt: day.Add(40 * time.Hour), Operation: storage.OperationRollback, Target: "s2"},
+		{ID: "s3", StartedAt: day.Add(36 * time.Hour)},
+		{ID: "s2", StartedAt: day.Add(20 * time.Hour)},
+		{ID: "s1", StartedAt: day.Add(2 * time.Hour)},
+	}
+	sessions := &mocks.SessionStoreMock{
+		ListSessionsFunc: func(limit int) ([]*storage.Session, error) {
+			if limit > 0 && limit < len(recorded) {
+				return recorded[:limit], nil
+			}
+			return recorded, nil
+		},
+		GetSessionFunc: func(id string) (*storage.Session, error) {
+			for _, s := range recorded {
+				if s.ID == id {
+					return s, nil
+				}
+			}
+			return nil, storage.ErrSessionNotFound
+		},
+	}
+	p := &proc.Processor{Sessions: sessions}
+
+	tests := []struct {
+		ref     string
+		want    []string
+		wantErr bool
+	}{
+		{ref: "last", want: []string{"s3"}},
+		{ref: "last-0", want: []string{"s3"}},
+		{ref: "last-2", want: []string{"s1"}},
+		{ref: "last-3", wantErr: true},
+		{ref: "last-x", wantErr: true},
+		{ref: "2025-03-14", want: []string{"s2", "s1"}},
+		{ref: "2025-03-15", want: []string{"s3"}},
+		{ref: "2025-03-16", wantErr: true},
+		{ref: "s2", want: []string{"s2"}},
+		{ref: "r1", want: []string{"r1"}},
+		{ref: "abcdef", want: []string{"abcdef"}},
+		{ref: " ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			found, err := p.FindSessions(context.Background(), tt.ref)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]string, 0, len(found))
+			for _, s := range found {
+				ids = append(ids, s.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestProcessor_FindSessions_WithoutStore(t *testing.T) {
+	p := &proc.Processor{}
+
+	found, err := p.FindSessions(context.Background(), "abcdef")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "abcdef", found[0].ID)
+
+	_, err = p.FindSessions(context.Background(), "last")
+	require.Error(t, err)
+}
+
+func TestProcessor_FinishSession(t *testing.T) {
+	uploaded := storage.EpisodeEvent{Filename: "ep1.mp3", Operation: storage.OperationUpload,
+		OldStatus: podcast.New, NewStatus: podcast.Uploaded, Session: "sess"}
+	failed := storage.EpisodeEvent{Filename: "ep2.mp3", Operation: storage.OperationUpload,
+		OldStatus: podcast.New, NewStatus: podcast.New, Session: "sess", Error: "boom"}
+	deleted := storage.EpisodeEvent{Filename: "old.mp3", Operation: storage.OperationDelete,
+		OldStatus: podcast.Uploaded, NewStatus: podcast.Deleted, Session: "sess"}
+	other := storage.EpisodeEvent{Filename: "ep3.mp3", Operation: storage.OperationUpload,
+		OldStatus: podcast.New, NewStatus: podcast.Uploaded, Session: "other"}
+	reuploaded := storage.EpisodeEvent{Filename: "ep4.mp3", Operation: storage.OperationUpload,
+		OldStatus: podcast.Uploaded, NewStatus: podcast.Uploaded, Session: "sess"}
+	staleDeleted := storage.EpisodeEvent{Filename: "ep5.mp3", Operation: storage.OperationDelete,
+		OldStatus: podcast.Deleted, NewStatus: podcast.Deleted, Session: "sess"}
+	marked := storage.EpisodeEvent{Filename: "ep6.mp3", Operation: storage.OperationReconcile,
+		OldStatus: podcast.Uploaded, NewStatus: podcast.Deleted, Session: "sess"}
+
+	tests := []struct {
+		name         string
+		events       []storage.EpisodeEvent
+		runErr       error
+		canceled     bool
+		wantOutcome  storage.SessionOutcome
+		wantUploaded int
+		wantDeleted  int
+		wantFailed   int
+		wantBytes    int64
+	}{
+		{
+			name:         "success",
+			events:       []storage.EpisodeEvent{deleted, uploaded, other},
+			wantOutcome:  storage.SessionSucceeded,
+			wantUploaded: 1,
+			wantDeleted:  1,
+			wantBytes:    100,
+		},
+		{
+			name:         "reconcile fixes",
+			events:       []storage.EpisodeEvent{reuploaded, staleDeleted, marked},
+			wantOutcome:  storage.SessionSucceeded,
+			wantUploaded: 1,
+			wantDeleted:  2,
+			wantBytes:    100,
+		},
+		{
+			name:         "partial",
+			events:       []storage.EpisodeEvent{uploaded, failed},
+			runErr:       errors.New("upload failed"),
+			wantOutcome:  storage.SessionPartial,
+			wantUploaded: 1,
+			wantFailed:   1,
+			wantBytes:    100,
+		},
+		{
+			name:        "partial with deletes only",
+			events:      []storage.EpisodeEvent{deleted, failed},
+			runErr:      errors.New("upload failed"),
+			wantOutcome: storage.SessionPartial,
+			wantDeleted: 1,
+			wantFailed:  1,
+		},
+		{
+			name:        "failed",
+			events:      []storage.EpisodeEvent{failed, other},
+			runErr:      errors.New("upload failed"),
+			wantOutcome: storage.SessionFailed,
+			wantFailed:  1,
+		},
+		{
+			name:         "canceled",
+			events:       []storage.EpisodeEvent{uploaded},
+			runErr:       context.Canceled,
+			canceled:     true,
+			wantOutcome:  storage.SessionCanceled,
+			wantUploaded: 1,
+			wantBytes:    100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mocks.EpisodeStoreMock{
+				GetEpisodeByFilenameFunc: func(podcastID, fileName string) (*podcast.Episode, error) {
+					return &podcast.Episode{Filename: fileName, Size: 100}, nil
+				},
+			}
+			history := &mocks.HistoryStoreMock{
+				ListHistoryFunc: func(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+					return tt.events, nil
+				},
+			}
+			sessions := &mocks.SessionStoreMock{
+				SaveSessionFunc: func(session *storage.Session) error { return nil },
+			}
+			p := &proc.Processor{Storage: store, History: history, Sessions: sessions}
+
+			session := p.StartSession("sess", storage.OperationUpload, "", []string{"podcast1"})
+			require.Len(t, sessions.SaveSessionCalls(), 1)
+			assert.Equal(t, storage.SessionRunning, sessions.SaveSessionCalls()[0].Session.Outcome)
+			assert.Equal(t, storage.OperationUpload, sessions.SaveSessionCalls()[0].Session.Operation)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.canceled {
+				cancel()
+			}
+			defer cancel()
+			p.FinishSession(ctx, session, tt.runErr)
+
+			require.Len(t, sessions.SaveSessionCalls(), 2)
+			saved := sessions.SaveSessionCalls()[1].Session
+			assert.Equal(t, tt.wantOutcome, saved.Outcome)
+			assert.Equal(t, tt.wantUploaded, saved.Uploaded)
+			assert.Equal(t, tt.wantDeleted, saved.Deleted)
+			assert.Equal(t, tt.wantFailed, saved.Failed)
+			assert.Equal(t, tt.wantBytes, saved.Bytes)
+			assert.False(t, saved.FinishedAt.Before(saved.StartedAt))
+			require.Len(t, history.ListHistoryCalls(), 1)
+			assert.Equal(t, saved.StartedAt, history.ListHistoryCalls()[0].Filter.Since)
+			if tt.runErr != nil {
+				assert.Equal(t, tt.runErr.Error(), saved.Error)
+			}
+		})
+	}
+}
+
+func TestProcessor_FinishSession_WithoutHistory(t *testing.T) {
+	store := &mocks.EpisodeStoreMock{
+		FindEpisodesBySessionFunc: func(podcastID, session string) ([]*podcast.Episode, error) {
+			return []*podcast.Episode{{Filename: "ep1.mp3", Size: 100}, {Filename: "ep2.mp3", Size: 200}}, nil
+		},
+	}
+	p := &proc.Processor{Storage: store}
+
+	session := p.StartSession("sess", storage.OperationUpload, "", []string{"podcast1", "podcast2"})
+	p.FinishSession(context.Background(), session, nil)
+
+	assert.Equal(t, storage.SessionSucceeded, session.Outcome)
+	assert.Equal(t, 4, session.Uploaded)
+	assert.Equal(t, int64(600), session.Bytes)
+}
+
+func TestProcessor_MarkSessionRolledBack(t *testing.T) {
+	sessions := &mocks.SessionStoreMock{
+		GetSessionFunc: func(id string) (*storage.Session, error) {
+			if id == "sess" {
+				return &storage.Session{ID: "sess", Outcome: storage.SessionSucceeded}, nil
+			}
+			return nil, storage.ErrSessionNotFound
+		},
+		SaveSessionFunc: func(session *storage.Session) error { return nil },
+	}
+	p := &proc.Processor{Sessions: sessions}
+
+	p.MarkSessionRolledBack("unknown")
+	assert.Empty(t, sessions.SaveSessionCalls())
+
+	p.MarkSessionRolledBack("sess")
+	require.Len(t, sessions.SaveSessionCalls(), 1)
+	assert.Equal(t, storage.SessionRolledBack, sessions.SaveSessionCalls()[0].Session.Outcome)
+}
@@ -0,0 +1,266 @@
+package proc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	log "github.com/go-pkgz/lgr"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"podgen/internal/pkg/ratelimit"
+)
+
+// SFTPStore publishes objects to a directory on an SFTP server. Object names are paths relative to Root.
+// The connection is opened on first use and reopened after it is lost.
+type SFTPStore struct {
+	// Address is the host:port of the server.
+	Address string
+	// SSH holds the credentials and the host key check.
+	SSH *ssh.ClientConfig
+	// Root is the directory on the server objects are written to.
+	Root string
+	// BaseURL is the public URL Root is served at, object locations are built from it.
+	BaseURL string
+	// Limiter limits the combined bandwidth of all uploads. Nil doesn't limit.
+	Limiter *ratelimit.Bucket
+
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+// DeleteEpisode removes the file, missing files are ignored.
+func (s *SFTPStore) DeleteEpisode(ctx context.Context, objectName string) error {
+	target, err := s.path(objectName)
+	if err != nil {
+		return err
+	}
+	client, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := client.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("can't delete %s: %w", objectName, s.check(client, err))
+	}
+	return nil
+}
+
+// UploadEpisode to the SFTP server
+func (s *SFTPStore) UploadEpisode(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, nil)
+}
+
+// UploadEpisodeWithProgress uploads to the SFTP server with progress callback
+func (s *SFTPStore) UploadEpisodeWithProgress(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, progress)
+}
+
+// UploadImage to the SFTP server
+func (s *SFTPStore) UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, nil)
+}
+
+// UploadFeed to the SFTP server
+func (s *SFTPStore) UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, nil)
+}
+
+// put writes the file under a temporary name and renames it into place,
+// so the web server never serves a partial file.
+func (s *SFTPStore) put(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	target, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath) //nolint:gosec // filePath comes from internal code, not user input
+	if err != nil {
+		return nil, fmt.Errorf("can't open file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("can't stat file %s: %w", filePath, err)
+	}
+
+	client, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.MkdirAll(path.Dir(target)); err != nil {
+		return nil, fmt.Errorf("can't create directory for %s: %w", objectName, s.check(client, err))
+	}
+
+	tmp := path.Join(path.Dir(target), "."+path.Base(target)+tempSuffix)
+	remote, err := client.Create(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("can't create file for %s: %w", objectName, s.check(client, err))
+	}
+	sum := sha256.New()
+	reader := ratelimit.Reader(ctx, &progressReader{reader: file, total: stat.Size(), callback: progress}, s.Limiter)
+	if _, err := io.Copy(io.MultiWriter(remote, sum), &contextReader{ctx: ctx, reader: reader}); err != nil {
+		_ = remote.Close()
+		_ = client.Remove(tmp)
+		return nil, fmt.Errorf("can't write %s: %w", objectName, s.check(client, err))
+	}
+	if err := remote.Close(); err != nil {
+		return nil, fmt.Errorf("can't write %s: %w", objectName, s.check(client, err))
+	}
+	if err := client.Chmod(tmp, 0o644); err != nil {
+		return nil, fmt.Errorf("can't set mode of %s: %w", objectName, s.check(client, err))
+	}
+	if err := s.rename(client, tmp, target); err != nil {
+		return nil, fmt.Errorf("can't move %s into place: %w", objectName, s.check(client, err))
+	}
+
+	name, _ := cleanObjectName(objectName)
+	return &UploadResult{Location: publicLocation(s.BaseURL, name), Checksum: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// rename replaces newname atomically if the server supports it, plain SFTP rename fails on existing files.
+func (s *SFTPStore) rename(client *sftp.Client, oldname, newname string) error {
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		return client.PosixRename(oldname, newname)
+	}
+	if err := client.Remove(newname); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return client.Rename(oldname, newname)
+}
+
+// GetObjectInfo of the file. The server doesn't keep the SHA-256 of uploads, so it stays empty.
+func (s *SFTPStore) GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	target, err := s.path(objectName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := client.Stat(target)
+	if err != nil {
+		return nil, s.check(client, err)
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", objectName)
+	}
+	name, _ := cleanObjectName(objectName)
+	return &ObjectInfo{Key: name, Location: publicLocation(s.BaseURL, name), Size: stat.Size()}, nil
+}
+
+// List files whose names start with prefix, including nested ones
+func (s *SFTPStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	// walk the deepest directory covering the prefix only
+	dir := path.Dir(prefix)
+	if strings.HasSuffix(prefix, "/") {
+		dir = strings.TrimSuffix(prefix, "/")
+	}
+	start, err := s.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ObjectInfo
+	root := strings.TrimSuffix(s.Root, "/") + "/"
+	walker := client.Walk(start)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("can't list files %s in %s: %w", prefix, s.Root, s.check(client, err))
+		}
+		stat := walker.Stat()
+		if stat.IsDir() || strings.HasSuffix(stat.Name(), tempSuffix) {
+			continue
+		}
+		key := strings.TrimPrefix(walker.Path(), root)
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		result = append(result, ObjectInfo{Key: key, Location: publicLocation(s.BaseURL, key), Size: stat.Size()})
+	}
+	return result, nil
+}
+
+// Close closes the connection, the next request opens a new one.
+func (s *SFTPStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return nil
+	}
+	_ = s.client.Close()
+	err := s.conn.Close()
+	s.client, s.conn = nil, nil
+	return err
+}
+
+// connect returns the client of the open connection, opening it if there is none.
+func (s *SFTPStore) connect(ctx context.Context) (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", s.Address)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to %s: %w", s.Address, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, s.Address, s.SSH)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, fmt.Errorf("can't connect to %s: %w", s.Address, err)
+	}
+	conn := ssh.NewClient(sshConn, chans, reqs)
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("can't start sftp session on %s: %w", s.Address, err)
+	}
+	s.conn, s.client = conn, client
+	return client, nil
+}
+
+// check drops the connection if err tells it is lost, so the next request, usually a retry, reconnects.
+func (s *SFTPStore) check(client *sftp.Client, err error) error {
+	if !errors.Is(err, sftp.ErrSSHFxConnectionLost) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == client {
+		log.Printf("[WARN] lost connection to %s, %v", s.Address, err)
+		_ = s.client.Close()
+		_ = s.conn.Close()
+		s.client, s.conn = nil, nil
+	}
+	return err
+}
+
+// path returns the file of the object on the server, rejecting names that escape Root.
+func (s *SFTPStore) path(objectName string) (string, error) {
+	name, err := cleanObjectName(objectName)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(s.Root, name), nil
+}
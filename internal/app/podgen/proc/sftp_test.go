@@ -0,0 +1,221 @@
+package proc_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"podgen/internal/app/podgen/proc"
+)
+
+// sftpServer is an in-process SSH server with the sftp subsystem serving a local directory,
+// accepting the user "podgen" with password "secret".
+type sftpServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newSFTPServer(t *testing.T, root string) *sftpServer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "podgen" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &sftpServer{addr: listener.Addr().String(), hostKey: signer.PublicKey()}
+	t.Cleanup(func() {
+		_ = listener.Close()
+		srv.dropConnections()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			srv.mu.Lock()
+			srv.conns = append(srv.conns, conn)
+			srv.mu.Unlock()
+			go srv.serve(conn, config, root)
+		}
+	}()
+	return srv
+}
+
+func (s *sftpServer) serve(conn net.Conn, config *ssh.ServerConfig, root string) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(root))
+				if err != nil {
+					return
+				}
+				_ = server.Serve()
+				_ = server.Close()
+			}
+		}()
+	}
+}
+
+// dropConnections closes all open connections, as a server restart or network failure would.
+func (s *sftpServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+	s.conns = nil
+}
+
+// store returns a store publishing to dir, an absolute path on the server.
+func (s *sftpServer) store(password, dir string) *proc.SFTPStore {
+	return &proc.SFTPStore{
+		Address: s.addr,
+		SSH: &ssh.ClientConfig{
+			User:            "podgen",
+			Auth:            []ssh.AuthMethod{ssh.Password(password)},
+			HostKeyCallback: ssh.FixedHostKey(s.hostKey),
+		},
+		Root:    filepath.ToSlash(dir),
+		BaseURL: "https://podcasts.example.com",
+	}
+}
+
+func TestSFTPStore(t *testing.T) {
+	root := t.TempDir()
+	srv := newSFTPServer(t, root)
+	s := srv.store("secret", filepath.Join(root, "www"))
+	t.Cleanup(func() { _ = s.Close() })
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	content := []byte("episode content")
+	require.NoError(t, os.WriteFile(src, content, 0o600))
+
+	var reported []int64
+	result, err := s.UploadEpisodeWithProgress(ctx, "demo/ep.mp3", src, func(uploaded, total int64) {
+		reported = append(reported, uploaded)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://podcasts.example.com/demo/ep.mp3", result.Location)
+	assert.Len(t, result.Checksum, 64)
+	assert.Equal(t, int64(len(content)), reported[len(reported)-1])
+
+	published, err := os.ReadFile(filepath.Join(root, "www", "demo", "ep.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, content, published)
+
+	// uploading again replaces the file
+	require.NoError(t, os.WriteFile(src, []byte("new content"), 0o600))
+	_, err = s.UploadEpisode(ctx, "demo/ep.mp3", src)
+	require.NoError(t, err)
+	published, err = os.ReadFile(filepath.Join(root, "www", "demo", "ep.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, "new content", string(published))
+
+	info, err := s.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("new content")), info.Size)
+
+	_, err = s.UploadFeed(ctx, "demo/feed.rss", src)
+	require.NoError(t, err)
+	_, err = s.UploadImage(ctx, "other/podcast.png", src)
+	require.NoError(t, err)
+	objects, err := s.List(ctx, "demo/")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	assert.ElementsMatch(t, []string{"demo/ep.mp3", "demo/feed.rss"}, keys)
+
+	objects, err = s.List(ctx, "missing/")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	require.NoError(t, s.DeleteEpisode(ctx, "demo/ep.mp3"))
+	require.NoError(t, s.DeleteEpisode(ctx, "demo/ep.mp3"), "missing files are ignored")
+	_, err = s.GetObjectInfo(ctx, "demo/ep.mp3")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestSFTPStore_Reconnect(t *testing.T) {
+	root := t.TempDir()
+	srv := newSFTPServer(t, root)
+	s := srv.store("secret", root)
+	t.Cleanup(func() { _ = s.Close() })
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+	retrying := &proc.RetryStorage{Storage: s, Attempts: 3}
+
+	_, err := retrying.UploadEpisode(context.Background(), "demo/ep.mp3", src)
+	require.NoError(t, err)
+
+	// the lost connection fails one request, the retry opens a new one
+	srv.dropConnections()
+	info, err := retrying.GetObjectInfo(context.Background(), "demo/ep.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("episode content")), info.Size)
+}
+
+func TestSFTPStore_AuthFailure(t *testing.T) {
+	root := t.TempDir()
+	srv := newSFTPServer(t, root)
+
+	_, err := srv.store("wrong", root).GetObjectInfo(context.Background(), "demo/ep.mp3")
+	require.Error(t, err)
+	assert.False(t, proc.IsRetryable(err), "denied access is not retried")
+
+	s := srv.store("secret", root)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+	s.SSH.HostKeyCallback = ssh.FixedHostKey(otherSigner.PublicKey())
+	_, err = s.GetObjectInfo(context.Background(), "demo/ep.mp3")
+	require.ErrorContains(t, err, "host key mismatch")
+}
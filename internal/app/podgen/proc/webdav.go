@@ -0,0 +1,340 @@
+package proc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"podgen/internal/pkg/ratelimit"
+)
+
+// propfindBody asks for the properties WebDAVStore reads.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:getcontentlength/><d:getetag/><d:resourcetype/></d:prop></d:propfind>`
+
+// HTTPStatusError is an unexpected response of an HTTP based storage.
+// Responses with status 404 match fs.ErrNotExist.
+type HTTPStatusError struct {
+	Method     string
+	Key        string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Key, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is reports whether the response means the object doesn't exist.
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == fs.ErrNotExist && e.StatusCode == http.StatusNotFound
+}
+
+// WebDAVStore publishes objects to a WebDAV server. Object names are paths relative to URL.
+type WebDAVStore struct {
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// URL is the WebDAV collection objects are written to, such as https://dav.example.com/podcasts.
+	URL      string
+	Username string
+	Password string
+	// BaseURL is the public URL the collection is served at, object locations are built from it.
+	BaseURL string
+	// Limiter limits the combined bandwidth of all uploads. Nil doesn't limit.
+	Limiter *ratelimit.Bucket
+
+	collections sync.Map // collections known to exist, by path
+}
+
+// DeleteEpisode removes the file, missing files are ignored.
+func (s *WebDAVStore) DeleteEpisode(ctx context.Context, objectName string) error {
+	name, err := cleanObjectName(objectName)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, name, nil, -1, nil)
+	if err != nil {
+		return fmt.Errorf("can't delete %s: %w", objectName, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound && !success(resp.StatusCode) {
+		return fmt.Errorf("can't delete %s: %w", objectName, &HTTPStatusError{Method: http.MethodDelete, Key: name, StatusCode: resp.StatusCode})
+	}
+	return nil
+}
+
+// UploadEpisode to the WebDAV server
+func (s *WebDAVStore) UploadEpisode(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, nil)
+}
+
+// UploadEpisodeWithProgress uploads to the WebDAV server with progress callback
+func (s *WebDAVStore) UploadEpisodeWithProgress(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, progress)
+}
+
+// UploadImage to the WebDAV server
+func (s *WebDAVStore) UploadImage(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, nil)
+}
+
+// UploadFeed to the WebDAV server
+func (s *WebDAVStore) UploadFeed(ctx context.Context, objectName, filePath string) (*UploadResult, error) {
+	return s.put(ctx, objectName, filePath, nil)
+}
+
+// put uploads the file under a temporary name and moves it into place,
+// so the server never serves a partial file. Missing collections are created first.
+func (s *WebDAVStore) put(ctx context.Context, objectName, filePath string, progress ProgressFunc) (*UploadResult, error) {
+	name, err := cleanObjectName(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath) //nolint:gosec // filePath comes from internal code, not user input
+	if err != nil {
+		return nil, fmt.Errorf("can't open file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("can't stat file %s: %w", filePath, err)
+	}
+
+	if err := s.mkcol(ctx, path.Dir(name)); err != nil {
+		return nil, err
+	}
+
+	tmp := path.Join(path.Dir(name), "."+path.Base(name)+tempSuffix)
+	sum := sha256.New()
+	body := io.TeeReader(ratelimit.Reader(ctx, &progressReader{reader: file, total: stat.Size(), callback: progress}, s.Limiter), sum)
+	resp, err := s.do(ctx, http.MethodPut, tmp, body, stat.Size(), map[string]string{"Content-Type": DetectContentType(filePath)})
+	if err != nil {
+		return nil, fmt.Errorf("can't upload %s: %w", objectName, err)
+	}
+	_ = resp.Body.Close()
+	if !success(resp.StatusCode) {
+		return nil, fmt.Errorf("can't upload %s: %w", objectName, &HTTPStatusError{Method: http.MethodPut, Key: name, StatusCode: resp.StatusCode})
+	}
+
+	resp, err = s.do(ctx, "MOVE", tmp, nil, -1, map[string]string{"Destination": s.url(name), "Overwrite": "T"})
+	if err != nil {
+		return nil, fmt.Errorf("can't move %s into place: %w", objectName, err)
+	}
+	_ = resp.Body.Close()
+	if !success(resp.StatusCode) {
+		return nil, fmt.Errorf("can't move %s into place: %w", objectName, &HTTPStatusError{Method: "MOVE", Key: name, StatusCode: resp.StatusCode})
+	}
+
+	return &UploadResult{Location: publicLocation(s.BaseURL, name), Checksum: hex.EncodeToString(sum.Sum(nil))}, nil
+}
+
+// mkcol creates the collection and its parents unless they are known to exist.
+func (s *WebDAVStore) mkcol(ctx context.Context, dir string) error {
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	if _, ok := s.collections.Load(dir); ok {
+		return nil
+	}
+	if err := s.mkcol(ctx, path.Dir(dir)); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, "MKCOL", dir+"/", nil, -1, nil)
+	if err != nil {
+		return fmt.Errorf("can't create collection %s: %w", dir, err)
+	}
+	_ = resp.Body.Close()
+	// 405 Method Not Allowed is the answer for an existing collection
+	if !success(resp.StatusCode) && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("can't create collection %s: %w", dir, &HTTPStatusError{Method: "MKCOL", Key: dir, StatusCode: resp.StatusCode})
+	}
+	s.collections.Store(dir, true)
+	return nil
+}
+
+// GetObjectInfo of the file. The server doesn't keep the SHA-256 of uploads, so it stays empty.
+func (s *WebDAVStore) GetObjectInfo(ctx context.Context, objectName string) (*ObjectInfo, error) {
+	name, err := cleanObjectName(objectName)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.propfind(ctx, name, "0")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.collection {
+			return &ObjectInfo{Key: name, Location: publicLocation(s.BaseURL, name), Size: e.size, ETag: e.etag}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is a collection", objectName)
+}
+
+// List files whose names start with prefix, including nested ones
+func (s *WebDAVStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	// walk the deepest collection covering the prefix only
+	dir := path.Dir(prefix)
+	if strings.HasSuffix(prefix, "/") {
+		dir = strings.TrimSuffix(prefix, "/")
+	}
+	if _, err := cleanObjectName(dir); err != nil {
+		return nil, err
+	}
+
+	var result []ObjectInfo
+	pending := []string{dir}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		entries, err := s.propfind(ctx, current+"/", "1")
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("can't list %s: %w", prefix, err)
+		}
+		for _, e := range entries {
+			if e.key == current || e.key == "." || strings.HasSuffix(e.key, tempSuffix) {
+				continue
+			}
+			if e.collection {
+				pending = append(pending, e.key)
+				continue
+			}
+			if strings.HasPrefix(e.key, prefix) {
+				result = append(result, ObjectInfo{Key: e.key, Location: publicLocation(s.BaseURL, e.key), Size: e.size, ETag: e.etag})
+			}
+		}
+	}
+	return result, nil
+}
+
+// davEntry is a resource of a PROPFIND response.
+type davEntry struct {
+	key        string // path relative to URL
+	size       int64
+	etag       string
+	collection bool
+}
+
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ContentLength string `xml:"getcontentlength"`
+				ETag          string `xml:"getetag"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// propfind returns the resource and, with depth 1, its members.
+func (s *WebDAVStore) propfind(ctx context.Context, name, depth string) ([]davEntry, error) {
+	resp, err := s.do(ctx, "PROPFIND", name, strings.NewReader(propfindBody), int64(len(propfindBody)),
+		map[string]string{"Depth": depth, "Content-Type": "application/xml; charset=utf-8"})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, &HTTPStatusError{Method: "PROPFIND", Key: name, StatusCode: resp.StatusCode}
+	}
+
+	var ms davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("can't decode PROPFIND response of %s: %w", name, err)
+	}
+	root, err := url.Parse(s.url(""))
+	if err != nil {
+		return nil, err
+	}
+	rootPath := strings.TrimSuffix(root.Path, "/") + "/"
+
+	entries := make([]davEntry, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			return nil, fmt.Errorf("invalid href %q in PROPFIND response of %s: %w", r.Href, name, err)
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(href.Path, rootPath), "/")
+		if key == "" {
+			key = "."
+		}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			size, _ := strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+			entries = append(entries, davEntry{
+				key:        key,
+				size:       size,
+				etag:       strings.Trim(ps.Prop.ETag, `"`),
+				collection: ps.Prop.ResourceType.Collection != nil,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// do sends a request for the resource with the credentials of the store. size is the body length, -1 if unknown.
+func (s *WebDAVStore) do(ctx context.Context, method, name string, body io.Reader, size int64, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.url(name), body)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// url returns the URL of the resource, with every path segment escaped.
+func (s *WebDAVStore) url(name string) string {
+	base := strings.TrimRight(s.URL, "/")
+	trimmed := strings.TrimSuffix(name, "/")
+	if trimmed == "" || trimmed == "." {
+		return base + "/"
+	}
+	segments := strings.Split(trimmed, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	result := base + "/" + strings.Join(segments, "/")
+	if strings.HasSuffix(name, "/") {
+		result += "/"
+	}
+	return result
+}
+
+func success(status int) bool {
+	return status >= 200 && status < 300
+}
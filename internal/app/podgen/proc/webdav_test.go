@@ -0,0 +1,125 @@
+package proc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+	"podgen/internal/app/podgen/proc"
+)
+
+// newWebDAVServer serves root over WebDAV under /dav/, accepting the user "podgen" with password "secret".
+func newWebDAVServer(t *testing.T, root string) *httptest.Server {
+	handler := &webdav.Handler{Prefix: "/dav", FileSystem: webdav.Dir(root), LockSystem: webdav.NewMemLS()}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "podgen" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebDAVStore(t *testing.T) {
+	root := t.TempDir()
+	srv := newWebDAVServer(t, root)
+	s := &proc.WebDAVStore{URL: srv.URL + "/dav/", Username: "podgen", Password: "secret", BaseURL: "https://podcasts.example.com"}
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	content := []byte("episode content")
+	require.NoError(t, os.WriteFile(src, content, 0o600))
+	sum := sha256.Sum256(content)
+
+	var reported []int64
+	result, err := s.UploadEpisodeWithProgress(ctx, "demo/2024/ep one.mp3", src, func(uploaded, total int64) {
+		reported = append(reported, uploaded)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://podcasts.example.com/demo/2024/ep one.mp3", result.Location)
+	assert.Equal(t, hex.EncodeToString(sum[:]), result.Checksum)
+	assert.Equal(t, int64(len(content)), reported[len(reported)-1])
+
+	published, err := os.ReadFile(filepath.Join(root, "demo", "2024", "ep one.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, content, published)
+
+	// uploading again replaces the file
+	require.NoError(t, os.WriteFile(src, []byte("new content"), 0o600))
+	_, err = s.UploadEpisode(ctx, "demo/2024/ep one.mp3", src)
+	require.NoError(t, err)
+	published, err = os.ReadFile(filepath.Join(root, "demo", "2024", "ep one.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, "new content", string(published))
+
+	info, err := s.GetObjectInfo(ctx, "demo/2024/ep one.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "demo/2024/ep one.mp3", info.Key)
+	assert.Equal(t, int64(len("new content")), info.Size)
+	assert.NotEmpty(t, info.ETag)
+
+	_, err = s.UploadFeed(ctx, "demo/feed.rss", src)
+	require.NoError(t, err)
+	_, err = s.UploadImage(ctx, "other/podcast.png", src)
+	require.NoError(t, err)
+
+	objects, err := s.List(ctx, "demo/")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	assert.ElementsMatch(t, []string{"demo/2024/ep one.mp3", "demo/feed.rss"}, keys)
+
+	objects, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, objects, 3)
+
+	objects, err = s.List(ctx, "missing/")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	require.NoError(t, s.DeleteEpisode(ctx, "demo/2024/ep one.mp3"))
+	require.NoError(t, s.DeleteEpisode(ctx, "demo/2024/ep one.mp3"), "missing files are ignored")
+	_, err = s.GetObjectInfo(ctx, "demo/2024/ep one.mp3")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	assert.False(t, proc.IsRetryable(err))
+}
+
+func TestWebDAVStore_Unauthorized(t *testing.T) {
+	srv := newWebDAVServer(t, t.TempDir())
+	s := &proc.WebDAVStore{URL: srv.URL + "/dav", Username: "podgen", Password: "wrong", BaseURL: "https://podcasts.example.com"}
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("episode content"), 0o600))
+	_, err := s.UploadEpisode(context.Background(), "demo/ep.mp3", src)
+	require.Error(t, err)
+
+	var statusErr *proc.HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+	assert.False(t, proc.IsRetryable(err))
+}
+
+func TestWebDAVStore_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	s := &proc.WebDAVStore{URL: srv.URL, BaseURL: "https://podcasts.example.com"}
+
+	_, err := s.GetObjectInfo(context.Background(), "demo/ep.mp3")
+	require.Error(t, err)
+	assert.True(t, proc.IsRetryable(err))
+}
@@ -49,6 +49,8 @@ type UploadTaskResult struct {
 	Index    int
 	Episode  *podcast.Episode
 	Location string
+	Checksum string
+	Key      string
 	Err      error
 }
 
@@ -60,6 +62,7 @@ type UploadFn func(ctx context.Context, workerID int, task UploadTask) UploadTas
 // Each worker has a stable workerID (0..workers-1) for progress reporting.
 // Results are sent to the returned channel, which is closed when all tasks are done.
 // Context cancellation stops workers after their current task completes.
+// Workers share the bandwidth limit of the storage, see S3Store.Limiter, so it caps the pool as a whole.
 func RunWorkerPool(ctx context.Context, workers int, tasks <-chan UploadTask, uploadFn UploadFn) <-chan UploadTaskResult {
 	if workers <= 0 {
 		workers = 1
@@ -0,0 +1,108 @@
+// Package scheduler runs the scheduled jobs of podcasts in daemon mode
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/app/podgen"
+	"podgen/internal/configs"
+	"podgen/internal/pkg/schedule"
+)
+
+// entry is a scheduled job of a podcast.
+type entry struct {
+	podcastID string
+	job       string
+	schedule  schedule.Schedule
+	next      time.Time
+}
+
+// Scheduler runs the scan and publish jobs of podcasts at the times of their schedules, one job at a time.
+// Runs missed while another job was running, or while the daemon was stopped, are skipped rather than caught up.
+type Scheduler struct {
+	app     *podgen.App
+	entries []*entry
+}
+
+// New returns the scheduler of the jobs of podcasts, an error if a schedule is invalid or no podcast has one.
+func New(app *podgen.App, podcasts map[string]configs.Podcast) (*Scheduler, error) {
+	s := &Scheduler{app: app}
+	for _, id := range slices.Sorted(maps.Keys(podcasts)) {
+		jobs := podcasts[id].Schedule.Jobs()
+		for _, job := range []string{configs.JobScan, configs.JobPublish} { // scan first if both are due
+			spec, ok := jobs[job]
+			if !ok {
+				continue
+			}
+			sched, err := schedule.Parse(spec)
+			if err != nil {
+				return nil, fmt.Errorf("podcast %s: %s: %w", id, job, err)
+			}
+			s.entries = append(s.entries, &entry{podcastID: id, job: job, schedule: sched})
+		}
+	}
+	if len(s.entries) == 0 {
+		return nil, errors.New("no podcast has a schedule")
+	}
+	return s, nil
+}
+
+// Run runs due jobs until ctx is done. A running job is canceled with ctx and recorded as canceled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	now := time.Now()
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+		log.Printf("[INFO] scheduled %s of %s, next run at %s", e.job, e.podcastID, e.next.Format(time.DateTime))
+	}
+
+	for {
+		timer := time.NewTimer(time.Until(s.next()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("[INFO] scheduler stopped")
+			return nil
+		case <-timer.C:
+		}
+		s.runDue(ctx, time.Now())
+	}
+}
+
+// next returns the time of the earliest run.
+func (s *Scheduler) next() time.Time {
+	next := s.entries[0].next
+	for _, e := range s.entries[1:] {
+		if e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next
+}
+
+// runDue runs the jobs due at now, earliest first, and schedules their next runs after they finish.
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) {
+	var due []*entry
+	for _, e := range s.entries {
+		if !e.next.After(now) {
+			due = append(due, e)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *entry) int { return a.next.Compare(b.next) })
+
+	for _, e := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.app.RunScheduled(ctx, e.podcastID, e.job); err != nil {
+			log.Printf("[WARN] scheduled %s of %s failed, %v", e.job, e.podcastID, err)
+		}
+		e.next = e.schedule.Next(time.Now())
+		log.Printf("[INFO] next %s of %s at %s", e.job, e.podcastID, e.next.Format(time.DateTime))
+	}
+}
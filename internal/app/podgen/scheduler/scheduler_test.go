@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+	"podgen/internal/storage"
+	"podgen/internal/storage/factory"
+)
+
+// newTestApp returns the app of a podcast with an episode not scanned yet, published to a directory.
+func newTestApp(t *testing.T, podcasts map[string]configs.Podcast) *podgen.App {
+	storagePath, root := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	conf := &configs.Conf{Podcasts: podcasts}
+	conf.CloudStorage = configs.CloudStorageConfig{Type: configs.CloudStorageFilesystem, Path: root,
+		PublicBaseURL: "https://podcasts.example.com"}
+	p := &proc.Processor{
+		Storage:     store,
+		Podcasts:    store,
+		History:     store,
+		Sessions:    store,
+		Runs:        store,
+		S3Client:    &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"},
+		Files:       &proc.Files{Storage: storagePath},
+		StoragePath: storagePath,
+		ChunkSize:   2,
+	}
+	app, err := podgen.NewApplication(conf, p)
+	require.NoError(t, err)
+	return app
+}
+
+func TestNew(t *testing.T) {
+	podcasts := map[string]configs.Podcast{
+		"demo":  {Title: "Demo", Folder: "demo", Schedule: configs.Schedule{Scan: "@every 15m", Publish: "0 6 * * *"}},
+		"other": {Title: "Other", Folder: "other"},
+	}
+	s, err := New(newTestApp(t, podcasts), podcasts)
+	require.NoError(t, err)
+	require.Len(t, s.entries, 2, "podcasts without a schedule are skipped")
+	assert.Equal(t, configs.JobScan, s.entries[0].job)
+	assert.Equal(t, configs.JobPublish, s.entries[1].job)
+
+	_, err = New(newTestApp(t, podcasts), map[string]configs.Podcast{"other": podcasts["other"]})
+	require.EqualError(t, err, "no podcast has a schedule")
+	_, err = New(newTestApp(t, podcasts), map[string]configs.Podcast{"demo": {Schedule: configs.Schedule{Scan: "daily"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "podcast demo: scan")
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	podcasts := map[string]configs.Podcast{
+		"demo": {Title: "Demo", Folder: "demo", Schedule: configs.Schedule{Scan: "@every 15m", Publish: "0 6 * * *"}},
+	}
+	app := newTestApp(t, podcasts)
+	s, err := New(app, podcasts)
+	require.NoError(t, err)
+
+	now := time.Now()
+	scan, publish := s.entries[0], s.entries[1]
+	scan.next, publish.next = now.Add(-time.Minute), now.Add(time.Hour)
+	s.runDue(context.Background(), now)
+
+	runs, err := app.ListRuns(context.Background(), "demo", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1, "only the due job runs")
+	assert.Equal(t, configs.JobScan, runs[0].Job)
+	assert.Equal(t, storage.SessionSucceeded, runs[0].Outcome)
+	assert.True(t, scan.next.After(now), "the next run is scheduled")
+	assert.Equal(t, now.Add(time.Hour), publish.next)
+
+	episodes, err := app.ListEpisodes(context.Background(), "demo", storage.EpisodeQuery{})
+	require.NoError(t, err)
+	assert.Len(t, episodes["demo"], 1, "the scan found the episode")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scan.next = now
+	s.runDue(ctx, now)
+	runs, err = app.ListRuns(context.Background(), "", 0)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1, "no jobs start after shutdown")
+}
+
+func TestScheduler_Run(t *testing.T) {
+	podcasts := map[string]configs.Podcast{"demo": {Title: "Demo", Folder: "demo", Schedule: configs.Schedule{Publish: "@daily"}}}
+	s, err := New(newTestApp(t, podcasts), podcasts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler didn't stop")
+	}
+}
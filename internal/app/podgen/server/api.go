@@ -0,0 +1,588 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/app/podgen/artwork"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/storage"
+)
+
+// Operations of jobs, the steps of podgen run by flags.
+const (
+	OpScan             = "scan"
+	OpUpload           = "upload"
+	OpFeed             = "feed"
+	OpImage            = "image"
+	OpRollback         = "rollback"
+	OpReconcile        = "reconcile"
+	OpRewriteLocations = "rewrite_locations"
+)
+
+// maxRequestBody caps the size of API request bodies.
+const maxRequestBody = 1 << 20
+
+// routeAPI registers the handlers of the JSON API, every request needs an API token.
+func (s *Server) routeAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/podcasts", s.auth(s.listPodcasts))
+	mux.HandleFunc("GET /api/v1/podcasts/{id}", s.auth(s.getPodcast))
+	mux.HandleFunc("GET /api/v1/podcasts/{id}/episodes", s.auth(s.listEpisodes))
+	mux.HandleFunc("GET /api/v1/podcasts/{id}/episodes/{filename}", s.auth(s.getEpisode))
+	mux.HandleFunc("PATCH /api/v1/podcasts/{id}/episodes/{filename}", s.auth(s.editEpisode))
+	mux.HandleFunc("GET /api/v1/podcasts/{id}/history", s.auth(s.episodeHistory))
+	mux.HandleFunc("GET /api/v1/sessions", s.auth(s.listSessions))
+	mux.HandleFunc("GET /api/v1/sessions/{ref}", s.auth(s.findSessions))
+	mux.HandleFunc("POST /api/v1/jobs", s.auth(s.addJob))
+	mux.HandleFunc("GET /api/v1/jobs", s.auth(s.listJobs))
+	mux.HandleFunc("GET /api/v1/jobs/{id}", s.auth(s.getJob))
+}
+
+type clientKey struct{}
+
+// auth passes requests with a valid bearer token to next, the name of the token is available by clientFrom.
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			if name, ok := s.tokenName(token); ok {
+				next(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, name)))
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="podgen"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid API token")
+	}
+}
+
+// tokenName returns the name of the API token.
+func (s *Server) tokenName(token string) (string, bool) {
+	for _, t := range s.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+			return t.Name, true
+		}
+	}
+	return "", false
+}
+
+// clientFrom returns the name of the API token of the request.
+func clientFrom(ctx context.Context) string {
+	name, _ := ctx.Value(clientKey{}).(string)
+	return name
+}
+
+type podcastJSON struct {
+	ID                 string            `json:"id"`
+	Title              string            `json:"title"`
+	Folder             string            `json:"folder"`
+	Private            bool              `json:"private"`
+	FeedURL            string            `json:"feed_url,omitempty"`
+	SubscriberFeedURLs map[string]string `json:"subscriber_feed_urls,omitempty"`
+	// Episodes are the numbers of stored episodes by status.
+	Episodes map[string]int `json:"episodes"`
+}
+
+type episodeJSON struct {
+	Filename string `json:"filename"`
+	Title    string `json:"title"`
+	Artist   string `json:"artist"`
+	Album    string `json:"album"`
+	Year     string `json:"year"`
+	Comment  string `json:"comment"`
+	Duration string `json:"duration"`
+	PubDate  string `json:"pub_date"`
+	Size     int64  `json:"size"`
+	Status   string `json:"status"`
+	Location string `json:"location,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Session  string `json:"session,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+type eventJSON struct {
+	Filename  string    `json:"filename"`
+	At        time.Time `json:"at"`
+	Operation string    `json:"operation"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Session   string    `json:"session,omitempty"`
+	Location  string    `json:"location,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type sessionJSON struct {
+	ID         string     `json:"id"`
+	Operation  string     `json:"operation"`
+	Target     string     `json:"target,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Host       string     `json:"host"`
+	Podcasts   []string   `json:"podcasts"`
+	Uploaded   int        `json:"uploaded"`
+	Deleted    int        `json:"deleted"`
+	Failed     int        `json:"failed"`
+	Bytes      int64      `json:"bytes"`
+	Outcome    string     `json:"outcome"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type reportJSON struct {
+	Episodes int           `json:"episodes"`
+	Objects  int           `json:"objects"`
+	Findings []findingJSON `json:"findings"`
+}
+
+type findingJSON struct {
+	Issue      string `json:"issue"`
+	Filename   string `json:"filename"`
+	Key        string `json:"key"`
+	Status     string `json:"status,omitempty"`
+	StoredSize int64  `json:"stored_size"`
+	LocalSize  int64  `json:"local_size"`
+	RemoteSize int64  `json:"remote_size"`
+	Fix        string `json:"fix,omitempty"`
+}
+
+// episodeEditJSON is the body of episode edits, fields left out are not changed.
+type episodeEditJSON struct {
+	Title   *string `json:"title"`
+	Artist  *string `json:"artist"`
+	Album   *string `json:"album"`
+	Year    *string `json:"year"`
+	Comment *string `json:"comment"`
+}
+
+// jobRequest is the body of new jobs. Podcasts default to all podcasts, the other fields apply to some operations.
+type jobRequest struct {
+	Operation string   `json:"operation"`
+	Podcasts  []string `json:"podcasts"`
+	// ForceDelete deletes old episodes before uploading, like --clear.
+	ForceDelete bool `json:"force_delete"`
+	// Regenerate regenerates artwork before uploading it, like --generate-artwork, Style is its --artwork-style.
+	Regenerate bool   `json:"regenerate"`
+	Style      string `json:"style"`
+	// Session is the session rolled back, like --rollback-session, the last episode's session by default.
+	Session string `json:"session"`
+	// Fix fixes the findings of reconcile, like --fix.
+	Fix bool `json:"fix"`
+}
+
+func (s *Server) listPodcasts(w http.ResponseWriter, r *http.Request) {
+	podcasts := s.App.FindPodcasts()
+	ids := slices.Sorted(maps.Keys(podcasts))
+	result := make([]podcastJSON, 0, len(ids))
+	for _, id := range ids {
+		p, err := s.podcastJSON(r.Context(), id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		result = append(result, p)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) getPodcast(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.podcastID(w, r)
+	if !ok {
+		return
+	}
+	p, err := s.podcastJSON(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// podcastJSON returns the podcast with its feed URLs and the numbers of its episodes.
+func (s *Server) podcastJSON(ctx context.Context, id string) (podcastJSON, error) {
+	episodes, err := s.App.ListEpisodes(ctx, id, storage.EpisodeQuery{})
+	if err != nil {
+		return podcastJSON{}, err
+	}
+	return s.toPodcastJSON(id, episodes[id]), nil
+}
+
+// toPodcastJSON returns the podcast with its feed URLs and the numbers of the episodes by status.
+func (s *Server) toPodcastJSON(id string, episodes []*podcast.Episode) podcastJSON {
+	p := s.App.FindPodcasts()[id]
+	result := podcastJSON{ID: id, Title: p.Title, Folder: p.Folder, Private: p.Private.IsSet(), Episodes: map[string]int{}}
+	for key, url := range s.App.GetFeedURLs(id) {
+		if name, ok := strings.CutPrefix(key, id+"/"); ok {
+			if result.SubscriberFeedURLs == nil {
+				result.SubscriberFeedURLs = map[string]string{}
+			}
+			result.SubscriberFeedURLs[name] = url
+			continue
+		}
+		result.FeedURL = url
+	}
+
+	for _, status := range []podcast.Status{podcast.New, podcast.Uploaded, podcast.Deleted} {
+		result.Episodes[status.String()] = 0
+	}
+	for _, e := range episodes {
+		result.Episodes[e.Status.String()]++
+	}
+	return result
+}
+
+func (s *Server) listEpisodes(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.podcastID(w, r)
+	if !ok {
+		return
+	}
+	query, err := episodeQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	episodes, err := s.App.ListEpisodes(r.Context(), id, query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result := make([]episodeJSON, 0, len(episodes[id]))
+	for _, e := range episodes[id] {
+		result = append(result, toEpisodeJSON(e))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) getEpisode(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.podcastID(w, r)
+	if !ok {
+		return
+	}
+	episode, err := s.App.GetEpisode(id, r.PathValue("filename"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toEpisodeJSON(episode))
+}
+
+func (s *Server) editEpisode(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.podcastID(w, r)
+	if !ok {
+		return
+	}
+	var edit episodeEditJSON
+	if err := decodeJSON(w, r, &edit); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filename := r.PathValue("filename")
+	episode, err := s.App.EditEpisode(r.Context(), id, filename, proc.EpisodeEdit(edit))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	log.Printf("[INFO] episode %s of %s edited by %s", filename, id, clientFrom(r.Context()))
+	writeJSON(w, http.StatusOK, toEpisodeJSON(episode))
+}
+
+func (s *Server) episodeHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.podcastID(w, r)
+	if !ok {
+		return
+	}
+	params := r.URL.Query()
+	filter := storage.HistoryFilter{Filename: params.Get("episode")}
+	var err error
+	if filter.Since, err = dateParam(params, "since"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Limit, err = intParam(params, "limit"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	history, err := s.App.EpisodeHistory(r.Context(), id, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result := make([]eventJSON, 0, len(history[id]))
+	for _, e := range history[id] {
+		result = append(result, eventJSON{Filename: e.Filename, At: e.At, Operation: string(e.Operation),
+			OldStatus: e.OldStatus.String(), NewStatus: e.NewStatus.String(), Session: e.Session, Location: e.Location,
+			Error: e.Error})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r.URL.Query(), "limit")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sessions, err := s.App.ListSessions(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toSessionsJSON(sessions))
+}
+
+func (s *Server) findSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.App.FindSessions(r.Context(), r.PathValue("ref"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toSessionsJSON(sessions))
+}
+
+func (s *Server) addJob(w http.ResponseWriter, r *http.Request) {
+	var req jobRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	podcasts := s.App.FindPodcasts()
+	if len(req.Podcasts) == 0 {
+		for id := range podcasts {
+			req.Podcasts = append(req.Podcasts, id)
+		}
+	}
+	slices.Sort(req.Podcasts)
+	for _, id := range req.Podcasts {
+		if _, ok := podcasts[id]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown podcast %q", id))
+			return
+		}
+	}
+
+	run, err := s.jobFunc(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	job, err := s.jobs.add(req.Operation, req.Podcasts, clientFrom(r.Context()), run)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// jobFunc returns the function running the operation of the request, the same calls as the flags of podgen make.
+func (s *Server) jobFunc(req jobRequest) (jobFunc, error) {
+	ids := strings.Join(req.Podcasts, ",")
+	switch req.Operation {
+	case OpScan:
+		return func(ctx context.Context) (any, error) { return nil, s.App.Update(ctx, ids) }, nil
+	case OpUpload:
+		return func(ctx context.Context) (any, error) {
+			return s.App.GetFeedURLs(ids), s.App.Publish(ctx, ids, req.ForceDelete)
+		}, nil
+	case OpFeed:
+		return func(ctx context.Context) (any, error) {
+			err := s.App.GenerateFeed(ctx, ids, s.App.GetPodcastImages(ctx, ids))
+			return s.App.GetFeedURLs(ids), err
+		}, nil
+	case OpImage:
+		if req.Style != "" && !slices.Contains(artwork.AllStyles(), artwork.Style(req.Style)) {
+			return nil, fmt.Errorf("unknown artwork style %q", req.Style)
+		}
+		return func(ctx context.Context) (any, error) {
+			images := s.App.UploadPodcastImage(ctx, ids, req.Regenerate, req.Style)
+			if len(images) < len(req.Podcasts) {
+				return images, errors.New("can't upload some podcast images")
+			}
+			return images, nil
+		}, nil
+	case OpRollback:
+		return func(ctx context.Context) (any, error) {
+			if req.Session != "" {
+				return nil, s.App.RollbackEpisodesBySession(ctx, ids, req.Session)
+			}
+			return nil, s.App.RollbackEpisodes(ctx, ids)
+		}, nil
+	case OpReconcile:
+		return func(ctx context.Context) (any, error) {
+			reports, err := s.App.Reconcile(ctx, ids, req.Fix)
+			return toReportsJSON(reports), err
+		}, nil
+	case OpRewriteLocations:
+		return func(ctx context.Context) (any, error) { return s.App.RewriteLocations(ctx, ids) }, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q, expected one of %s", req.Operation, strings.Join(
+			[]string{OpScan, OpUpload, OpFeed, OpImage, OpRollback, OpReconcile, OpRewriteLocations}, ", "))
+	}
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.jobs.list())
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown job")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// podcastID returns the podcast of the path, writing 404 for unknown podcasts.
+func (s *Server) podcastID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if _, ok := s.App.FindPodcasts()[id]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown podcast %q", id))
+		return "", false
+	}
+	return id, true
+}
+
+// episodeQuery converts query parameters to a storage query, they are named after the listing flags of podgen.
+func episodeQuery(params url.Values) (storage.EpisodeQuery, error) {
+	query := storage.EpisodeQuery{Text: params.Get("search")}
+	query.Session = params.Get("session")
+
+	if statuses := params.Get("status"); statuses != "" {
+		for _, name := range strings.Split(statuses, ",") {
+			status, err := podcast.ParseStatus(name)
+			if err != nil {
+				return query, err
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	var err error
+	if query.SortBy, query.Desc, err = storage.ParseEpisodeSort(params.Get("sort")); err != nil {
+		return query, err
+	}
+	if query.PublishedFrom, err = dateParam(params, "since"); err != nil {
+		return query, err
+	}
+	if query.PublishedTo, err = dateParam(params, "until"); err != nil {
+		return query, err
+	}
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if *target, err = intParam(params, name); err != nil {
+			return query, err
+		}
+	}
+	for name, target := range map[string]*int64{"min_size": &query.MinSize, "max_size": &query.MaxSize} {
+		if value := params.Get(name); value != "" {
+			if *target, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return query, fmt.Errorf("invalid %s %q", name, value)
+			}
+		}
+	}
+	return query, nil
+}
+
+// dateParam parses the YYYY-MM-DD date of the parameter in local time, zero if it isn't set.
+func dateParam(params url.Values, name string) (time.Time, error) {
+	value := params.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s date %q, expected YYYY-MM-DD", name, value)
+	}
+	return date, nil
+}
+
+// intParam parses the non-negative number of the parameter, zero if it isn't set.
+func intParam(params url.Values, name string) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+func toEpisodeJSON(e *podcast.Episode) episodeJSON {
+	return episodeJSON{Filename: e.Filename, Title: e.Title, Artist: e.Artist, Album: e.Album, Year: e.Year,
+		Comment: e.Comment, Duration: e.Duration, PubDate: e.PubDate, Size: e.Size, Status: e.Status.String(),
+		Location: e.Location, Key: e.Key, Session: e.Session, Checksum: e.Checksum}
+}
+
+func toSessionsJSON(sessions []*storage.Session) []sessionJSON {
+	result := make([]sessionJSON, 0, len(sessions))
+	for _, s := range sessions {
+		session := sessionJSON{ID: s.ID, Operation: string(s.Operation), Target: s.Target, StartedAt: s.StartedAt,
+			Host: s.Host, Podcasts: s.PodcastIDs, Uploaded: s.Uploaded, Deleted: s.Deleted, Failed: s.Failed,
+			Bytes: s.Bytes, Outcome: string(s.Outcome), Error: s.Error}
+		if session.Operation == "" {
+			session.Operation = string(storage.OperationUpload)
+		}
+		if !s.FinishedAt.IsZero() {
+			session.FinishedAt = &s.FinishedAt
+		}
+		result = append(result, session)
+	}
+	return result
+}
+
+func toReportsJSON(reports map[string]*proc.ReconcileReport) map[string]reportJSON {
+	result := make(map[string]reportJSON, len(reports))
+	for id, report := range reports {
+		r := reportJSON{Episodes: report.Episodes, Objects: report.Objects, Findings: []findingJSON{}}
+		for _, f := range report.Findings {
+			finding := findingJSON{Issue: string(f.Issue), Filename: f.Filename, Key: f.Key, StoredSize: f.StoredSize,
+				LocalSize: f.LocalSize, RemoteSize: f.RemoteSize, Fix: f.Fix}
+			if f.Issue != proc.IssueOrphanObject {
+				finding.Status = f.Status.String()
+			}
+			r.Findings = append(r.Findings, finding)
+		}
+		result[id] = r
+	}
+	return result
+}
+
+// decodeJSON decodes the JSON body of the request into v, unknown fields are refused.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// writeStoreError writes 404 for missing episodes, 500 for other errors.
+func writeStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[WARN] can't write response, %v", err)
+	}
+}
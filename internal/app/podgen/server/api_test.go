@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+	"podgen/internal/storage/factory"
+)
+
+const testToken = "cms-secret-token-1"
+
+// newAPIServer serves the API of a podcast with a scanned episode, published to a directory.
+func newAPIServer(t *testing.T) *httptest.Server {
+	storagePath, root := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "podcast.png"), []byte("png"), 0o600))
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	conf := &configs.Conf{Podcasts: map[string]configs.Podcast{"demo": {Title: "Demo", Folder: "demo"}}}
+	conf.CloudStorage = configs.CloudStorageConfig{Type: configs.CloudStorageFilesystem, Path: root,
+		PublicBaseURL: "https://podcasts.example.com"}
+	p := &proc.Processor{
+		Storage:     store,
+		Podcasts:    store,
+		History:     store,
+		Sessions:    store,
+		Subscribers: store,
+		S3Client:    &proc.FilesystemStore{Root: root, BaseURL: "https://podcasts.example.com"},
+		Files:       &proc.Files{Storage: storagePath},
+		StoragePath: storagePath,
+		ChunkSize:   2,
+	}
+	_, err = p.Update(context.Background(), "demo", "demo")
+	require.NoError(t, err)
+
+	app, err := podgen.NewApplication(conf, p)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{App: app, Tokens: []configs.APIToken{{Name: "cms", Token: testToken}}, jobs: newJobs(ctx)}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		cancel()
+		s.jobs.wait()
+	})
+	return ts
+}
+
+// call sends the request with the test token and decodes the JSON response into result, if set.
+func call(t *testing.T, ts *httptest.Server, method, path string, body any, result any) int {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reader).Encode(body))
+	}
+	req, err := http.NewRequest(method, ts.URL+path, &reader)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+	if result != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(result))
+	}
+	return resp.StatusCode
+}
+
+// waitJob polls the job until it is finished.
+func waitJob(t *testing.T, ts *httptest.Server, id string) Job {
+	t.Helper()
+	var job Job
+	require.Eventually(t, func() bool {
+		require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/jobs/"+id, nil, &job))
+		return job.Status == JobSucceeded || job.Status == JobFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestAPI_Auth(t *testing.T) {
+	ts := newAPIServer(t)
+	for _, header := range []string{"", "Bearer wrong-token", testToken} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/podcasts", http.NoBody)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, header)
+		assert.Equal(t, `Bearer realm="podgen"`, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/jobs") //nolint:noctx // test request
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAPI_Episodes(t *testing.T) {
+	ts := newAPIServer(t)
+
+	var podcasts []podcastJSON
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts", nil, &podcasts))
+	require.Len(t, podcasts, 1)
+	assert.Equal(t, "demo", podcasts[0].ID)
+	assert.Equal(t, map[string]int{"new": 1, "uploaded": 0, "deleted": 0}, podcasts[0].Episodes)
+	assert.Contains(t, podcasts[0].FeedURL, "https://podcasts.example.com/demo/")
+	assert.Equal(t, http.StatusNotFound, call(t, ts, http.MethodGet, "/api/v1/podcasts/unknown", nil, nil))
+
+	var episodes []episodeJSON
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/episodes?status=new&sort=-date", nil, &episodes))
+	require.Len(t, episodes, 1)
+	assert.Equal(t, "2024-01-15-ep.mp3", episodes[0].Filename)
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/episodes?status=uploaded", nil, &episodes))
+	assert.Empty(t, episodes)
+	assert.Equal(t, http.StatusBadRequest, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/episodes?since=yesterday", nil, nil))
+
+	var episode episodeJSON
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodPatch, "/api/v1/podcasts/demo/episodes/2024-01-15-ep.mp3",
+		map[string]string{"title": "Pilot", "artist": "Host"}, &episode))
+	assert.Equal(t, "Pilot", episode.Title)
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/episodes/2024-01-15-ep.mp3", nil, &episode))
+	assert.Equal(t, "Pilot", episode.Title)
+	assert.Equal(t, "Host", episode.Artist)
+	assert.Equal(t, "new", episode.Status)
+
+	assert.Equal(t, http.StatusBadRequest, call(t, ts, http.MethodPatch, "/api/v1/podcasts/demo/episodes/2024-01-15-ep.mp3",
+		map[string]string{"status": "uploaded"}, nil), "only metadata can be edited")
+	assert.Equal(t, http.StatusNotFound, call(t, ts, http.MethodPatch, "/api/v1/podcasts/demo/episodes/missing.mp3",
+		map[string]string{"title": "Pilot"}, nil))
+}
+
+func TestAPI_Jobs(t *testing.T) {
+	ts := newAPIServer(t)
+
+	var job Job
+	require.Equal(t, http.StatusAccepted, call(t, ts, http.MethodPost, "/api/v1/jobs",
+		jobRequest{Operation: OpUpload}, &job))
+	assert.Equal(t, []string{"demo"}, job.Podcasts, "all podcasts by default")
+	assert.Equal(t, "cms", job.RequestedBy)
+	job = waitJob(t, ts, job.ID)
+	require.Equal(t, JobSucceeded, job.Status, job.Error)
+	assert.NotNil(t, job.StartedAt)
+	assert.Contains(t, job.Result, "demo", "feed URLs are returned")
+
+	var episode episodeJSON
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/episodes/2024-01-15-ep.mp3", nil, &episode))
+	assert.Equal(t, "uploaded", episode.Status)
+	assert.Equal(t, "https://podcasts.example.com/demo/2024-01-15-ep.mp3", episode.Location)
+
+	var sessions []sessionJSON
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/sessions?limit=5", nil, &sessions))
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "upload", sessions[0].Operation)
+	assert.Equal(t, 1, sessions[0].Uploaded)
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/sessions/last", nil, &sessions))
+	assert.Len(t, sessions, 1)
+
+	require.Equal(t, http.StatusAccepted, call(t, ts, http.MethodPost, "/api/v1/jobs",
+		jobRequest{Operation: OpRollback, Podcasts: []string{"demo"}, Session: sessions[0].ID}, &job))
+	job = waitJob(t, ts, job.ID)
+	require.Equal(t, JobSucceeded, job.Status, job.Error)
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/episodes/2024-01-15-ep.mp3", nil, &episode))
+	assert.Equal(t, "new", episode.Status)
+
+	var history []eventJSON
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/podcasts/demo/history?episode=2024-01-15-ep.mp3", nil, &history))
+	require.Len(t, history, 3)
+	assert.Equal(t, []string{"scan", "upload", "rollback"},
+		[]string{history[0].Operation, history[1].Operation, history[2].Operation})
+
+	var jobs []Job
+	require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/jobs", nil, &jobs))
+	require.Len(t, jobs, 2)
+	assert.Equal(t, OpRollback, jobs[0].Operation, "most recent first")
+
+	assert.Equal(t, http.StatusBadRequest, call(t, ts, http.MethodPost, "/api/v1/jobs", jobRequest{Operation: "deploy"}, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, ts, http.MethodPost, "/api/v1/jobs",
+		jobRequest{Operation: OpScan, Podcasts: []string{"unknown"}}, nil))
+	assert.Equal(t, http.StatusBadRequest, call(t, ts, http.MethodPost, "/api/v1/jobs",
+		jobRequest{Operation: OpImage, Style: "cubism"}, nil))
+	assert.Equal(t, http.StatusNotFound, call(t, ts, http.MethodGet, "/api/v1/jobs/unknown", nil, nil))
+}
+
+func TestJobs_Prune(t *testing.T) {
+	j := newJobs(context.Background())
+	for range maxFinishedJobs + 5 {
+		id, err := newJobID()
+		require.NoError(t, err)
+		j.byID[id] = &Job{ID: id, Status: JobSucceeded}
+		j.order = append(j.order, id)
+	}
+	j.byID[j.order[0]].Status = JobRunning
+	j.prune()
+	assert.Len(t, j.order, maxFinishedJobs+1)
+	assert.Equal(t, JobRunning, j.list()[len(j.order)-1].Status, "unfinished jobs are kept")
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/pkg/progress"
+	"podgen/internal/storage"
+)
+
+const (
+	// dashboardSessions is the number of recent sessions searched for the sessions of podcasts.
+	dashboardSessions = 100
+	// dashboardJobs is the number of recent jobs shown.
+	dashboardJobs = 10
+	// dashboardRefresh is how often pages with unfinished jobs reload, in seconds.
+	dashboardRefresh = 5
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templateFuncs = template.FuncMap{
+	"bytes": progress.FormatBytes,
+	"path":  url.PathEscape,
+	"list":  func(values ...string) []string { return values },
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Local().Format(time.DateTime)
+	},
+}
+
+// pages are the templates of the dashboard, each one rendered with the layout and the shared parts.
+var pages = map[string]*template.Template{}
+
+func init() {
+	for _, page := range []string{"index.html", "podcast.html", "episode.html"} {
+		pages[page] = template.Must(template.New("layout.html").Funcs(templateFuncs).
+			ParseFS(templateFS, "templates/layout.html", "templates/parts.html", "templates/"+page))
+	}
+}
+
+// dashboardPodcast is a podcast with the sizes of its episodes and its last session.
+type dashboardPodcast struct {
+	podcastJSON
+	// UploadedBytes and PendingBytes are the total sizes of uploaded and new episodes.
+	UploadedBytes int64
+	PendingBytes  int64
+	// MaxSize is the max_size of the podcast, zero if it has none.
+	MaxSize     int64
+	LastSession *storage.Session
+}
+
+// OverLimit reports whether new episodes don't fit within max_size, so some of them won't be uploaded.
+func (p dashboardPodcast) OverLimit() bool {
+	return p.MaxSize > 0 && p.UploadedBytes+p.PendingBytes > p.MaxSize
+}
+
+// routeDashboard registers the handlers of the dashboard, for users logged in by API tokens.
+// Forms are protected from cross-origin requests, as browsers send the credentials with them.
+func (s *Server) routeDashboard(mux *http.ServeMux) {
+	csrf := http.NewCrossOriginProtection()
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, csrf.Handler(s.basicAuth(h)))
+	}
+	handle("GET /dashboard", s.dashboardIndex)
+	handle("GET /dashboard/podcasts/{id}", s.dashboardPodcastPage)
+	handle("POST /dashboard/podcasts/{id}/jobs", s.dashboardAddJob)
+	handle("GET /dashboard/podcasts/{id}/episodes/{filename}", s.dashboardEpisodePage)
+	handle("POST /dashboard/podcasts/{id}/episodes/{filename}", s.dashboardEditEpisode)
+}
+
+// basicAuth passes requests logged in with the name of an API token as user and the token as password to next.
+func (s *Server) basicAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); ok {
+			if name, ok := s.tokenName(password); ok && name == user {
+				next(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, name)))
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="podgen", charset="UTF-8"`)
+		http.Error(w, "log in with the name and the token of an API token", http.StatusUnauthorized)
+	}
+}
+
+func (s *Server) dashboardIndex(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.App.ListSessions(r.Context(), dashboardSessions)
+	if err != nil {
+		log.Printf("[WARN] can't list sessions, %v", err)
+	}
+	var podcasts []dashboardPodcast
+	for _, id := range slices.Sorted(maps.Keys(s.App.FindPodcasts())) {
+		p, err := s.dashboardPodcast(r.Context(), id, sessions)
+		if err != nil {
+			dashboardError(w, "can't list episodes", err)
+			return
+		}
+		podcasts = append(podcasts, p)
+	}
+	jobs := s.jobs.list()
+	render(w, "index.html", map[string]any{
+		"Podcasts": podcasts,
+		"Jobs":     jobs[:min(len(jobs), dashboardJobs)],
+		"Refresh":  unfinished(jobs),
+	})
+}
+
+func (s *Server) dashboardPodcastPage(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.dashboardPodcastID(w, r)
+	if !ok {
+		return
+	}
+	params := r.URL.Query()
+	if !params.Has("sort") {
+		params.Set("sort", "-date")
+	}
+	query, err := episodeQuery(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	episodes, err := s.App.ListEpisodes(r.Context(), id, query)
+	if err != nil {
+		dashboardError(w, "can't list episodes", err)
+		return
+	}
+	sessions, err := s.App.ListSessions(r.Context(), dashboardSessions)
+	if err != nil {
+		log.Printf("[WARN] can't list sessions, %v", err)
+	}
+	p, err := s.dashboardPodcast(r.Context(), id, sessions)
+	if err != nil {
+		dashboardError(w, "can't list episodes", err)
+		return
+	}
+
+	// upload sessions of the podcast that can be rolled back
+	var rollbacks []*storage.Session
+	for _, session := range sessions {
+		if session.IsUpload() && session.Outcome != storage.SessionRolledBack && slices.Contains(session.PodcastIDs, id) {
+			rollbacks = append(rollbacks, session)
+		}
+	}
+	var jobs []Job
+	for _, job := range s.jobs.list() {
+		if slices.Contains(job.Podcasts, id) && len(jobs) < dashboardJobs {
+			jobs = append(jobs, job)
+		}
+	}
+	render(w, "podcast.html", map[string]any{
+		"Podcast":   p,
+		"Episodes":  episodes[id],
+		"Status":    params.Get("status"),
+		"Search":    params.Get("search"),
+		"Rollbacks": rollbacks,
+		"Jobs":      jobs,
+		"Refresh":   unfinished(jobs),
+	})
+}
+
+// dashboardAddJob queues the upload, feed or rollback job of the button pressed on the page of the podcast.
+func (s *Server) dashboardAddJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.dashboardPodcastID(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	req := jobRequest{Operation: r.PostFormValue("operation"), Podcasts: []string{id}, Session: r.PostFormValue("session")}
+	if !slices.Contains([]string{OpUpload, OpFeed, OpRollback}, req.Operation) {
+		http.Error(w, fmt.Sprintf("unknown operation %q", req.Operation), http.StatusBadRequest)
+		return
+	}
+	run, err := s.jobFunc(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := s.jobs.add(req.Operation, req.Podcasts, clientFrom(r.Context()), run); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	http.Redirect(w, r, "/dashboard/podcasts/"+url.PathEscape(id), http.StatusSeeOther)
+}
+
+func (s *Server) dashboardEpisodePage(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.dashboardPodcastID(w, r)
+	if !ok {
+		return
+	}
+	filename := r.PathValue("filename")
+	episode, err := s.App.GetEpisode(id, filename)
+	if err != nil {
+		dashboardStoreError(w, err)
+		return
+	}
+	history, err := s.App.EpisodeHistory(r.Context(), id, storage.HistoryFilter{Filename: filename})
+	if err != nil {
+		log.Printf("[WARN] can't get history of %s, %v", filename, err)
+	}
+	render(w, "episode.html", map[string]any{
+		"ID":      id,
+		"Title":   s.App.FindPodcasts()[id].Title,
+		"Episode": episode,
+		"History": history[id],
+		"Saved":   r.URL.Query().Has("saved"),
+	})
+}
+
+// dashboardEditEpisode saves the tags of the episode form.
+func (s *Server) dashboardEditEpisode(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.dashboardPodcastID(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var edit proc.EpisodeEdit
+	for name, target := range map[string]**string{"title": &edit.Title, "artist": &edit.Artist, "album": &edit.Album,
+		"year": &edit.Year, "comment": &edit.Comment} {
+		if r.PostForm.Has(name) {
+			value := r.PostForm.Get(name)
+			*target = &value
+		}
+	}
+	filename := r.PathValue("filename")
+	if _, err := s.App.EditEpisode(r.Context(), id, filename, edit); err != nil {
+		dashboardStoreError(w, err)
+		return
+	}
+	log.Printf("[INFO] episode %s of %s edited by %s", filename, id, clientFrom(r.Context()))
+	http.Redirect(w, r, "/dashboard/podcasts/"+url.PathEscape(id)+"/episodes/"+url.PathEscape(filename)+"?saved",
+		http.StatusSeeOther)
+}
+
+// dashboardPodcast returns the podcast with the sizes of its episodes and its last session among sessions.
+func (s *Server) dashboardPodcast(ctx context.Context, id string, sessions []*storage.Session) (dashboardPodcast, error) {
+	episodes, err := s.App.ListEpisodes(ctx, id, storage.EpisodeQuery{})
+	if err != nil {
+		return dashboardPodcast{}, err
+	}
+	result := dashboardPodcast{podcastJSON: s.toPodcastJSON(id, episodes[id]), MaxSize: s.App.FindPodcasts()[id].MaxSize}
+	for _, e := range episodes[id] {
+		switch e.Status {
+		case podcast.Uploaded:
+			result.UploadedBytes += e.Size
+		case podcast.New:
+			result.PendingBytes += e.Size
+		}
+	}
+	for _, session := range sessions {
+		if slices.Contains(session.PodcastIDs, id) {
+			result.LastSession = session
+			break
+		}
+	}
+	return result, nil
+}
+
+// dashboardPodcastID returns the podcast of the path, writing 404 for unknown podcasts.
+func (s *Server) dashboardPodcastID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if _, ok := s.App.FindPodcasts()[id]; !ok {
+		http.Error(w, fmt.Sprintf("unknown podcast %q", id), http.StatusNotFound)
+		return "", false
+	}
+	return id, true
+}
+
+// render writes the page with data, the refresh interval is added to it.
+func render(w http.ResponseWriter, page string, data map[string]any) {
+	data["RefreshSeconds"] = dashboardRefresh
+	var buf bytes.Buffer
+	if err := pages[page].Execute(&buf, data); err != nil {
+		dashboardError(w, "can't render page", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = buf.WriteTo(w)
+}
+
+// unfinished reports whether some of the jobs are queued or running.
+func unfinished(jobs []Job) bool {
+	return slices.ContainsFunc(jobs, func(job Job) bool { return job.Status == JobQueued || job.Status == JobRunning })
+}
+
+func dashboardError(w http.ResponseWriter, msg string, err error) {
+	log.Printf("[ERROR] %s, %v", msg, err)
+	http.Error(w, msg, http.StatusInternalServerError)
+}
+
+// dashboardStoreError writes 404 for missing episodes, 500 for other errors.
+func dashboardStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	dashboardError(w, "can't access episode", err)
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// browse sends the request logged in as the test token, redirects are not followed.
+func browse(t *testing.T, ts *httptest.Server, method, path string, form url.Values, header map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.SetBasicAuth("cms", testToken)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if resp.StatusCode == http.StatusSeeOther {
+		return resp.StatusCode, resp.Header.Get("Location")
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestDashboard_Auth(t *testing.T) {
+	ts := newAPIServer(t)
+	for _, auth := range [][2]string{{"", ""}, {"cms", "wrong-token"}, {"other", testToken}} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/dashboard", http.NoBody)
+		require.NoError(t, err)
+		if auth[0] != "" {
+			req.SetBasicAuth(auth[0], auth[1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, auth[0])
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `Basic realm="podgen"`)
+	}
+
+	status, _ := browse(t, ts, http.MethodPost, "/dashboard/podcasts/demo/jobs", url.Values{"operation": {OpUpload}},
+		map[string]string{"Sec-Fetch-Site": "cross-site"})
+	assert.Equal(t, http.StatusForbidden, status, "cross-origin forms are refused")
+}
+
+func TestDashboard(t *testing.T) {
+	ts := newAPIServer(t)
+
+	status, body := browse(t, ts, http.MethodGet, "/dashboard", nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `<a href="/dashboard/podcasts/demo">Demo</a>`)
+	assert.Contains(t, body, "0B uploaded, 15B pending")
+	assert.Contains(t, body, "https://podcasts.example.com/demo/")
+	assert.Contains(t, body, "No jobs since the server started.")
+
+	status, body = browse(t, ts, http.MethodGet, "/dashboard/podcasts/demo", nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `href="/dashboard/podcasts/demo/episodes/2024-01-15-ep.mp3"`)
+	status, body = browse(t, ts, http.MethodGet, "/dashboard/podcasts/demo?status=uploaded", nil, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "No episodes.")
+	status, _ = browse(t, ts, http.MethodGet, "/dashboard/podcasts/unknown", nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	t.Run("tag editor", func(t *testing.T) {
+		page := "/dashboard/podcasts/demo/episodes/2024-01-15-ep.mp3"
+		status, location := browse(t, ts, http.MethodPost, page,
+			url.Values{"title": {" Pilot <1> "}, "artist": {"Host"}, "album": {""}, "year": {"2024"}, "comment": {""}}, nil)
+		require.Equal(t, http.StatusSeeOther, status)
+		assert.Equal(t, page+"?saved", location)
+
+		status, body := browse(t, ts, http.MethodGet, location, nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `<input name="title" value="Pilot &lt;1&gt;">`, "values are trimmed and escaped")
+		assert.Contains(t, body, `<input name="year" value="2024">`)
+		assert.Contains(t, body, "Saved.")
+		assert.Contains(t, body, "scan")
+
+		status, _ = browse(t, ts, http.MethodPost, "/dashboard/podcasts/demo/episodes/missing.mp3", url.Values{"title": {"x"}}, nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("buttons", func(t *testing.T) {
+		status, location := browse(t, ts, http.MethodPost, "/dashboard/podcasts/demo/jobs", url.Values{"operation": {OpUpload}}, nil)
+		require.Equal(t, http.StatusSeeOther, status)
+		assert.Equal(t, "/dashboard/podcasts/demo", location)
+
+		var jobs []Job
+		require.Equal(t, http.StatusOK, call(t, ts, http.MethodGet, "/api/v1/jobs", nil, &jobs))
+		require.Len(t, jobs, 1)
+		assert.Equal(t, "cms", jobs[0].RequestedBy)
+		require.Equal(t, JobSucceeded, waitJob(t, ts, jobs[0].ID).Status)
+
+		status, body := browse(t, ts, http.MethodGet, "/dashboard/podcasts/demo", nil, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "15B uploaded, 0B pending")
+		assert.Contains(t, body, `class="succeeded">succeeded`)
+		assert.Contains(t, body, "upload success</span>, 1 uploaded", "last session")
+		assert.Contains(t, body, ", 1 uploaded</option>", "the session can be rolled back")
+		assert.NotContains(t, body, `http-equiv="refresh"`, "no unfinished jobs")
+
+		status, _ = browse(t, ts, http.MethodPost, "/dashboard/podcasts/demo/jobs", url.Values{"operation": {OpReconcile}}, nil)
+		assert.Equal(t, http.StatusBadRequest, status, "only the buttons of the page")
+	})
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+)
+
+// JobStatus is the state of a job.
+type JobStatus string
+
+// Job statuses, a job is queued until the jobs before it are done.
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+const (
+	// maxQueuedJobs is the number of jobs waiting to run, more are refused.
+	maxQueuedJobs = 32
+	// maxFinishedJobs is the number of finished jobs kept for polling, the oldest are dropped.
+	maxFinishedJobs = 100
+)
+
+// errQueueFull is returned when maxQueuedJobs jobs are waiting.
+var errQueueFull = errors.New("too many queued jobs")
+
+// Job is an operation run in the background, clients poll it by ID.
+type Job struct {
+	ID          string     `json:"id"`
+	Operation   string     `json:"operation"`
+	Podcasts    []string   `json:"podcasts"`
+	RequestedBy string     `json:"requested_by"`
+	Status      JobStatus  `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// Result is returned by the operation, such as reconcile reports, nil for operations without one.
+	Result any `json:"result,omitempty"`
+}
+
+// jobFunc runs the operation of a job and returns its result.
+type jobFunc func(ctx context.Context) (any, error)
+
+// jobs runs jobs one at a time in the order they were added, operations of the app are not safe to run concurrently.
+type jobs struct {
+	ctx   context.Context
+	queue chan queuedJob
+	done  chan struct{}
+	once  sync.Once
+
+	mu    sync.Mutex
+	byID  map[string]*Job
+	order []string // IDs by creation time
+}
+
+type queuedJob struct {
+	job *Job
+	run jobFunc
+}
+
+// newJobs returns jobs running until ctx is done.
+func newJobs(ctx context.Context) *jobs {
+	return &jobs{ctx: ctx, queue: make(chan queuedJob, maxQueuedJobs), done: make(chan struct{}), byID: map[string]*Job{}}
+}
+
+// add queues the operation and returns a copy of its job.
+func (j *jobs) add(operation string, podcasts []string, requestedBy string, run jobFunc) (Job, error) {
+	j.once.Do(func() { go j.worker() })
+
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	job := &Job{ID: id, Operation: operation, Podcasts: podcasts, RequestedBy: requestedBy, Status: JobQueued,
+		CreatedAt: time.Now()}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	select {
+	case j.queue <- queuedJob{job: job, run: run}:
+	default:
+		return Job{}, errQueueFull
+	}
+	j.byID[id] = job
+	j.order = append(j.order, id)
+	j.prune()
+	return *job, nil
+}
+
+// get returns a copy of the job.
+func (j *jobs) get(id string) (Job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.byID[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// list returns copies of the jobs, most recent first.
+func (j *jobs) list() []Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	result := make([]Job, 0, len(j.order))
+	for i := len(j.order) - 1; i >= 0; i-- {
+		result = append(result, *j.byID[j.order[i]])
+	}
+	return result
+}
+
+// wait returns once the running job is done after ctx is done, jobs still queued are not run.
+func (j *jobs) wait() {
+	j.once.Do(func() { close(j.done) })
+	<-j.done
+}
+
+// worker runs queued jobs until ctx is done.
+func (j *jobs) worker() {
+	defer close(j.done)
+	for {
+		select {
+		case <-j.ctx.Done():
+			return
+		case q := <-j.queue:
+			j.run(q)
+		}
+	}
+}
+
+// run runs the job and records its outcome.
+func (j *jobs) run(q queuedJob) {
+	j.update(q.job, func(job *Job) {
+		now := time.Now()
+		job.Status, job.StartedAt = JobRunning, &now
+	})
+	log.Printf("[INFO] job %s started: %s %v, requested by %s", q.job.ID, q.job.Operation, q.job.Podcasts, q.job.RequestedBy)
+
+	result, err := q.run(j.ctx)
+
+	j.update(q.job, func(job *Job) {
+		now := time.Now()
+		job.Status, job.FinishedAt, job.Result = JobSucceeded, &now, result
+		if err != nil {
+			job.Status, job.Error = JobFailed, err.Error()
+		}
+	})
+	log.Printf("[INFO] job %s finished: %s", q.job.ID, q.job.Status)
+}
+
+// update changes the job under the lock.
+func (j *jobs) update(job *Job, fn func(job *Job)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(job)
+}
+
+// prune drops the oldest finished jobs beyond maxFinishedJobs, called under the lock.
+func (j *jobs) prune() {
+	finished := 0
+	for _, id := range j.order {
+		if s := j.byID[id].Status; s == JobSucceeded || s == JobFailed {
+			finished++
+		}
+	}
+	kept := j.order[:0]
+	for _, id := range j.order {
+		if s := j.byID[id].Status; finished > maxFinishedJobs && (s == JobSucceeded || s == JobFailed) {
+			delete(j.byID, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	j.order = kept
+}
+
+// newJobID returns a random job ID.
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
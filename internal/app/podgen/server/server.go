@@ -0,0 +1,145 @@
+// Package server serves podcasts over HTTP
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/go-pkgz/lgr"
+	"podgen/internal/app/podgen"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+)
+
+// shutdownTimeout is how long running requests, such as episode downloads, may take to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+// Server serves the feeds, artwork and episodes of podcasts from the storage folder,
+// at <BaseURL>/<podcast folder>/<file name>, and the JSON API at /api/v1 and the dashboard at /dashboard
+// if Tokens are set.
+type Server struct {
+	App *podgen.App
+	// Listen is the address the server listens at.
+	Listen string
+	// BaseURL is the URL clients reach the server at, feeds link episodes and artwork to it.
+	BaseURL string
+	// Tokens authenticate API clients and dashboard users, both are disabled without them.
+	Tokens []configs.APIToken
+
+	jobs *jobs
+}
+
+// Run serves until ctx is done, then shuts the server down. Running requests get shutdownTimeout to finish,
+// a running job is canceled and waited for.
+func (s *Server) Run(ctx context.Context) error {
+	s.jobs = newJobs(ctx)
+	defer s.jobs.wait()
+	srv := &http.Server{Addr: s.Listen, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("can't serve at %s: %w", s.Listen, err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[WARN] can't finish running requests, %v", err)
+		return srv.Close()
+	}
+	return nil
+}
+
+// Handler returns the handler of the server. Jobs run until the context of Run is done,
+// or for as long as the process runs if the handler is used without Run.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{folder}/{file}", s.serveFile)
+	if len(s.Tokens) > 0 {
+		if s.jobs == nil {
+			s.jobs = newJobs(context.Background())
+		}
+		s.routeAPI(mux)
+		s.routeDashboard(mux)
+	}
+	return mux
+}
+
+// serveFile serves the feed, the artwork or an episode of the podcast stored in the folder.
+// Range and conditional requests are handled by http.ServeContent.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	podcastID, ok := s.podcastByFolder(r.PathValue("folder"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	filename := r.PathValue("file")
+
+	if strings.HasSuffix(filename, ".rss") {
+		name, feed, err := s.App.LocalFeed(podcastID, s.BaseURL)
+		if err != nil {
+			log.Printf("[ERROR] can't generate feed of %s, %v", podcastID, err)
+			http.Error(w, "can't generate feed", http.StatusInternalServerError)
+			return
+		}
+		if name != filename {
+			http.NotFound(w, r)
+			return
+		}
+		sum := sha256.Sum256([]byte(feed))
+		w.Header().Set("Content-Type", proc.DetectContentType(name))
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		http.ServeContent(w, r, name, time.Time{}, strings.NewReader(feed))
+		return
+	}
+
+	path, err := s.App.LocalFile(podcastID, filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		log.Printf("[ERROR] can't find %s of %s, %v", filename, podcastID, err)
+		http.Error(w, "can't find file", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := os.Open(path) //nolint:gosec // path is resolved by the app from the storage folder
+	if err != nil {
+		log.Printf("[ERROR] can't open %s, %v", path, err)
+		http.NotFound(w, r)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	stat, err := f.Stat()
+	if err != nil {
+		log.Printf("[ERROR] can't stat %s, %v", path, err)
+		http.Error(w, "can't read file", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", proc.DetectContentType(filename))
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, stat.ModTime().Unix(), stat.Size()))
+	http.ServeContent(w, r, filename, stat.ModTime(), f)
+}
+
+// podcastByFolder returns the ID of the served podcast stored in the folder.
+func (s *Server) podcastByFolder(folder string) (string, bool) {
+	for id, p := range s.App.ServedPodcasts() {
+		if p.Folder == folder {
+			return id, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"podgen/internal/app/podgen"
+	"podgen/internal/app/podgen/proc"
+	"podgen/internal/configs"
+	"podgen/internal/storage/factory"
+)
+
+// newTestServer serves a public podcast with a scanned episode and a private podcast from a temporary storage folder.
+func newTestServer(t *testing.T) (*httptest.Server, *podgen.App) {
+	storagePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(storagePath, "demo"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "2024-01-15-ep.mp3"), []byte("episode content"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "demo", "notes.txt"), []byte("not an episode"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(storagePath, "podcast.png"), []byte("png"), 0o600))
+
+	store, err := factory.NewFromStrings("sqlite", filepath.Join(t.TempDir(), "podgen.db"))
+	require.NoError(t, err)
+	require.NoError(t, store.Open())
+	t.Cleanup(func() { _ = store.Close() })
+
+	private := configs.PrivateFeed{Mode: configs.PrivateToken}
+	conf := &configs.Conf{Podcasts: map[string]configs.Podcast{
+		"demo":     {Title: "Demo", Folder: "demo"},
+		"internal": {Title: "Internal", Folder: "internal", Private: private},
+	}}
+	p := &proc.Processor{
+		Storage:      store,
+		Podcasts:     store,
+		History:      store,
+		Sessions:     store,
+		Subscribers:  store,
+		Files:        &proc.Files{Storage: storagePath},
+		StoragePath:  storagePath,
+		PrivateFeeds: map[string]configs.PrivateFeed{"internal": private},
+	}
+	_, err = p.Update(context.Background(), "demo", "demo")
+	require.NoError(t, err)
+
+	app, err := podgen.NewApplication(conf, p)
+	require.NoError(t, err)
+	s := &Server{App: app}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	s.BaseURL = ts.URL
+	return ts, app
+}
+
+func TestServer(t *testing.T) {
+	ts, app := newTestServer(t)
+	feeds := app.LocalFeedURLs(ts.URL)
+	require.Len(t, feeds, 1, "private podcasts are not served")
+	feedURL := feeds["demo"]
+
+	get := func(url string, header map[string]string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("feed", func(t *testing.T) {
+		resp := get(feedURL, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/rss+xml", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `<enclosure url="`+ts.URL+`/demo/2024-01-15-ep.mp3"`, "new episodes are linked to the server")
+		assert.Contains(t, string(body), `<url>`+ts.URL+`/demo/podcast.png</url>`)
+
+		etag := resp.Header.Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.Equal(t, http.StatusNotModified, get(feedURL, map[string]string{"If-None-Match": etag}).StatusCode)
+		assert.Equal(t, http.StatusNotFound, get(ts.URL+"/demo/other.rss", nil).StatusCode)
+	})
+
+	t.Run("episode", func(t *testing.T) {
+		resp := get(ts.URL+"/demo/2024-01-15-ep.mp3", map[string]string{"Range": "bytes=8-14"})
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Equal(t, "audio/mpeg", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(body))
+
+		modified := resp.Header.Get("Last-Modified")
+		require.NotEmpty(t, modified)
+		assert.Equal(t, http.StatusNotModified,
+			get(ts.URL+"/demo/2024-01-15-ep.mp3", map[string]string{"If-Modified-Since": modified}).StatusCode)
+	})
+
+	t.Run("artwork from the storage folder", func(t *testing.T) {
+		resp := get(ts.URL+"/demo/podcast.png", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("not served", func(t *testing.T) {
+		for _, path := range []string{"/demo/notes.txt", "/demo/..%2F..%2Fetc%2Fpasswd", "/internal/podcast.png", "/unknown/ep.mp3"} {
+			assert.Equal(t, http.StatusNotFound, get(ts.URL+path, nil).StatusCode, path)
+		}
+	})
+}
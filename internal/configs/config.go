@@ -2,9 +2,11 @@
 package configs
 
 import (
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -63,13 +65,79 @@ podcasts: {}
 
 # S3-compatible cloud storage
 cloud_storage:
+  # type: s3  # or: filesystem, webdav, sftp
+  # path: /var/www/podcasts                        # filesystem and sftp
+  # public_base_url: https://podcasts.example.com  # required by filesystem, webdav and sftp, a CDN for s3
+  # url_style: path                                # s3 URLs without public_base_url, or: virtual-host
+  # webdav:
+  #   url: https://dav.example.com/podcasts
+  #   username: ""
+  #   password: ""
+  # sftp:
+  #   address: host.example.com:22
+  #   username: ""
+  #   private_key: ~/.ssh/id_ed25519  # or password: ""
+  #   host_key: "SHA256:..."          # or known_hosts: ~/.ssh/known_hosts
   endpoint_url: ""
   bucket: ""
   region: ""
+  # key_prefix: podcasts/  # prepended to object keys
   secrets:
     aws_key: ""
     aws_secret: ""
 
+# Mirrors receive copies of the podcasts listing them under mirrors,
+# feeds switch to the first mirror that is up while cloud_storage is marked down
+# mirrors:
+#   backup:
+#     type: filesystem
+#     path: /mnt/backup/podcasts
+#     public_base_url: https://backup.example.com
+# podcasts:
+#   news:
+#     mirrors: [backup]
+#     public_base_url: https://news.example.com  # overrides cloud_storage.public_base_url
+#     cloud_storage:                             # overrides the s3 target of cloud_storage
+#       endpoint_url: s3.client.example.com
+#       bucket: client-podcasts
+#       region: ""
+#       key_prefix: podcasts/
+#       secrets:
+#         aws_key: ""
+#         aws_secret: ""
+
+# Private podcasts publish a feed per subscriber instead of the public feed,
+# enclosures are presigned s3 URLs or carry the token of the subscriber
+# podcasts:
+#   internal:
+#     private:
+#       mode: presigned     # or: token
+#       expiry: 168h        # presigned URLs, at most 7 days
+#       token_param: token  # query parameter of token URLs
+#       subscribers:
+#         - name: alice
+#           token: "long random secret"
+
+# Schedules of podgen --daemon, cron fields (minute hour day month weekday),
+# @hourly, @daily, @weekly, @monthly or @every <duration>
+# podcasts:
+#   news:
+#     schedule:
+#       scan: "@every 15m"
+#       publish: "0 6 * * *"   # scan, upload and regenerate the feed daily at 06:00
+
+# Headers of objects uploaded to s3, by type: episode, image or feed
+# objects:
+#   episode:
+#     cache_control: "public, max-age=31536000, immutable"  # default: public, max-age=86400
+#     acl: public-read
+#     storage_class: STANDARD_IA
+#     server_side_encryption: AES256  # or aws:kms with kms_key_id
+#     metadata:
+#       owner: podgen
+#   feed:
+#     cache_control: "public, max-age=300"  # default
+
 # Database (SQLite by default, stored in ~/.config/podgen/podgen.db)
 # database:
 #   type: sqlite  # or: bolt
@@ -78,10 +146,29 @@ cloud_storage:
 # Upload settings
 # upload:
 #   chunk_size: 3
+#   part_size_mb: 16          # larger files are uploaded in parts and resume after a restart
+#   abort_stale_after: 168h   # incomplete uploads older than this are aborted
+#   max_bandwidth: 2MB        # combined limit of all upload workers, e.g. 500KB or 10Mbit
+#   key_template: "{podcast}/{yyyy}/{mm}/{slug}-{hash8}.{ext}"  # object keys of new episodes, per podcast too
+# podcasts:
+#   news:
+#     feed_name: feed  # news/feed.rss, default: news/<sha256 of the podcast id>.rss
+#   retry:          # retries of failed object storage requests
+#     attempts: 3
+#     delay: 1s
+#     max_delay: 30s
 
 # Artwork auto-generation (enabled by default)
 # artwork:
 #   auto_generate: true
+
+# HTTP server of podgen --serve, publishing feeds, artwork and episodes from the storage folder
+# serve:
+#   listen: ":8080"
+#   base_url: "http://nas.local:8080"  # the URL feeds link to, default: http://localhost:<port>
+#   api_tokens:       # enable the JSON API at /api/v1, clients send Authorization: Bearer <token>
+#     - name: cms
+#       token: "a long random secret"  # 16 characters at least
 `
 
 	if err := os.WriteFile(configFile, []byte(template), 0o600); err != nil {
@@ -91,6 +178,106 @@ cloud_storage:
 	return true, nil
 }
 
+// Publishing backends of cloud_storage.type
+const (
+	CloudStorageS3         = "s3"
+	CloudStorageFilesystem = "filesystem"
+	CloudStorageWebDAV     = "webdav"
+	CloudStorageSFTP       = "sftp"
+)
+
+// URL styles of cloud_storage.url_style
+const (
+	// URLStylePath puts the bucket into the path, endpoint/bucket/key.
+	URLStylePath = "path"
+	// URLStyleVirtualHost puts the bucket into the host name, bucket.endpoint/key.
+	URLStyleVirtualHost = "virtual-host"
+)
+
+// DefaultKeyTemplate is the object key layout of episodes without a configured key template.
+const DefaultKeyTemplate = "{podcast}/{filename}"
+
+// KeyPlaceholders are the placeholders of key templates:
+// {podcast} is the podcast folder, {filename}, {name} and {ext} the episode file name, without extension
+// and the extension alone, {yyyy}, {mm} and {dd} the publication date, {slug} the slugified title
+// and {hash} or {hash8} the SHA-256 of the file, in full or its first 8 characters.
+var KeyPlaceholders = []string{"podcast", "filename", "name", "ext", "yyyy", "mm", "dd", "slug", "hash", "hash8"}
+
+// Modes of private feeds, podcasts.<id>.private.mode
+const (
+	// PrivatePresigned links enclosures with presigned URLs of the storage.
+	PrivatePresigned = "presigned"
+	// PrivateToken links enclosures with the token of the subscriber appended, to be checked by whatever serves them.
+	PrivateToken = "token"
+)
+
+// MaxPresignExpiry is the longest expiry of presigned URLs accepted by S3.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// minTokenLength is the minimal length of subscriber and API tokens, subscriber tokens name their feeds.
+const minTokenLength = 16
+
+// ServeReservedFolders are the first path segments of the API and the dashboard of the server,
+// podcast folders can't have these names while API tokens are set.
+var ServeReservedFolders = []string{"api", "dashboard"}
+
+// Server-side encryption of objects.server_side_encryption
+const (
+	// EncryptionS3 encrypts objects with keys managed by the storage.
+	EncryptionS3 = "AES256"
+	// EncryptionKMS encrypts objects with a KMS key, the default key of the account if kms_key_id is not set.
+	EncryptionKMS = "aws:kms"
+)
+
+// PrimaryDestination is the name of the cloud_storage destination among mirrors.
+const PrimaryDestination = "primary"
+
+// WebDAVConfig defines the server of the webdav publishing backend
+type WebDAVConfig struct {
+	// URL is the collection files are published to, such as https://dav.example.com/podcasts.
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// SFTPConfig defines the server of the sftp publishing backend
+type SFTPConfig struct {
+	// Address is host:port of the server, port 22 if omitted.
+	Address  string `yaml:"address"`
+	Username string `yaml:"username"`
+	// PrivateKey is a key file path, tried before Password.
+	Password   string `yaml:"password"`
+	PrivateKey string `yaml:"private_key"`
+	// Passphrase decrypts PrivateKey if it is encrypted.
+	Passphrase string `yaml:"passphrase"`
+	// HostKey is the SHA256 fingerprint of the server key, as printed by ssh-keygen -l.
+	// Without it the server is checked against KnownHosts.
+	HostKey string `yaml:"host_key"`
+	// KnownHosts is the known_hosts file, ~/.ssh/known_hosts if not set.
+	KnownHosts string `yaml:"known_hosts"`
+}
+
+// ObjectPolicy defines the headers of uploaded objects of one type
+type ObjectPolicy struct {
+	CacheControl string `yaml:"cache_control"`
+	// ACL is a canned ACL, such as public-read. Buckets with ACLs disabled reject objects with one.
+	ACL          string `yaml:"acl"`
+	StorageClass string `yaml:"storage_class"`
+	// Encryption is the server-side encryption, EncryptionS3 or EncryptionKMS.
+	Encryption string `yaml:"server_side_encryption"`
+	KMSKeyID   string `yaml:"kms_key_id"`
+	// Metadata is stored with every object as user metadata.
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+// ObjectPolicies defines the headers of episodes, images and feeds uploaded to S3.
+// Transcripts are not published yet, they get a policy once they are.
+type ObjectPolicies struct {
+	Episode ObjectPolicy `yaml:"episode"`
+	Image   ObjectPolicy `yaml:"image"`
+	Feed    ObjectPolicy `yaml:"feed"`
+}
+
 // StorageConfig defines database storage configuration
 type StorageConfig struct {
 	// Type specifies the storage backend: sqlite (default), bolt, or postgres
@@ -108,20 +295,168 @@ type ArtworkConfig struct {
 	AutoGenerate *bool `yaml:"auto_generate"`
 }
 
+// ServeConfig defines the HTTP server of --serve
+type ServeConfig struct {
+	// Listen is the address the server listens at. Defaults to DefaultServeListen.
+	Listen string `yaml:"listen"`
+	// BaseURL is the URL clients reach the server at, feeds link episodes and artwork to it.
+	// Defaults to http://localhost:<port of Listen>.
+	BaseURL string `yaml:"base_url"`
+	// APITokens authenticate clients of the JSON API at /api/v1, which is disabled without them.
+	APITokens []APIToken `yaml:"api_tokens"`
+}
+
+// APIToken authenticates a client of the JSON API, Name identifies the client in logs and jobs.
+type APIToken struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+}
+
+// DefaultServeListen is the address of the HTTP server of --serve if serve.listen isn't set.
+const DefaultServeListen = ":8080"
+
+// RetryConfig defines retries of failed object storage requests
+type RetryConfig struct {
+	// Attempts is the total number of tries of a request, 1 disables retries. Defaults to 3.
+	Attempts int `yaml:"attempts"`
+	// Delay is the backoff before the first retry, doubled for every next one. Defaults to 1s.
+	Delay time.Duration `yaml:"delay"`
+	// MaxDelay caps the backoff. Defaults to 30s.
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+// CloudStorageConfig defines a publishing destination
+type CloudStorageConfig struct {
+	// Type is the publishing backend: s3 (default), filesystem, webdav or sftp.
+	Type        string    `yaml:"type"`
+	EndPointURL string    `yaml:"endpoint_url"`
+	Bucket      string    `yaml:"bucket"`
+	Region      string    `yaml:"region"`
+	Secrets     S3Secrets `yaml:"secrets"`
+	// KeyPrefix is prepended to the keys of objects published to S3, such as podcasts/ in a shared bucket.
+	KeyPrefix string `yaml:"key_prefix"`
+	// Path is the directory files are published to by the filesystem and sftp backends.
+	Path string `yaml:"path"`
+	// PublicBaseURL is the URL the published files are served at, such as a CDN or custom domain in front of an S3 bucket.
+	PublicBaseURL string `yaml:"public_base_url"`
+	// URLStyle is how S3 URLs without PublicBaseURL are built: path (default), endpoint/bucket/key,
+	// or virtual-host, bucket.endpoint/key.
+	URLStyle string       `yaml:"url_style"`
+	WebDAV   WebDAVConfig `yaml:"webdav"`
+	SFTP     SFTPConfig   `yaml:"sftp"`
+}
+
+// S3Secrets are the credentials of S3 storage
+type S3Secrets struct {
+	Key    string `yaml:"aws_key"`
+	Secret string `yaml:"aws_secret"`
+}
+
+// StorageOverride is the S3 target of a podcast published elsewhere than cloud_storage, such as a client's own bucket.
+// Empty fields keep the value of cloud_storage.
+type StorageOverride struct {
+	EndPointURL string    `yaml:"endpoint_url"`
+	Bucket      string    `yaml:"bucket"`
+	Region      string    `yaml:"region"`
+	Secrets     S3Secrets `yaml:"secrets"`
+	KeyPrefix   string    `yaml:"key_prefix"`
+}
+
+// Schedule defines when the daemon runs jobs of a podcast, by cron-like specs such as "*/15 * * * *",
+// "0 6 * * *", @daily or "@every 15m", see schedule.Parse. Jobs without a spec don't run.
+type Schedule struct {
+	// Scan finds new episodes, like --scan.
+	Scan string `yaml:"scan"`
+	// Publish finds new episodes, uploads them and regenerates the feed, like --scan --upload --feed.
+	Publish string `yaml:"publish"`
+}
+
+// Jobs of schedules.
+const (
+	JobScan    = "scan"
+	JobPublish = "publish"
+)
+
+// Jobs returns the specs of the scheduled jobs by job name.
+func (s Schedule) Jobs() map[string]string {
+	jobs := map[string]string{}
+	if s.Scan != "" {
+		jobs[JobScan] = s.Scan
+	}
+	if s.Publish != "" {
+		jobs[JobPublish] = s.Publish
+	}
+	return jobs
+}
+
+// PrivateFeed defines the subscribers of a private podcast and how its enclosures are protected
+type PrivateFeed struct {
+	// Mode is PrivatePresigned or PrivateToken, the podcast is public if empty.
+	Mode string `yaml:"mode"`
+	// Expiry of presigned URLs, MaxPresignExpiry if not set. Feeds have to be regenerated before URLs expire.
+	Expiry time.Duration `yaml:"expiry"`
+	// TokenParam is the query parameter carrying the token of the subscriber, "token" if not set.
+	TokenParam  string       `yaml:"token_param"`
+	Subscribers []Subscriber `yaml:"subscribers"`
+}
+
+// Subscriber of a private podcast
+type Subscriber struct {
+	Name string `yaml:"name"`
+	// Token is the secret of the subscriber, the name of their feed is derived from it.
+	Token string `yaml:"token"`
+}
+
+// IsSet returns true if the podcast is private.
+func (p PrivateFeed) IsSet() bool {
+	return p.Mode != ""
+}
+
+// GetExpiry returns the expiry of presigned URLs.
+func (p PrivateFeed) GetExpiry() time.Duration {
+	if p.Expiry <= 0 {
+		return MaxPresignExpiry
+	}
+	return p.Expiry
+}
+
+// GetTokenParam returns the query parameter carrying tokens.
+func (p PrivateFeed) GetTokenParam() string {
+	if p.TokenParam == "" {
+		return "token"
+	}
+	return p.TokenParam
+}
+
+// IsSet returns true if the override changes anything.
+func (o StorageOverride) IsSet() bool {
+	return o != StorageOverride{}
+}
+
+// GetType returns the publishing backend, defaulting to "s3".
+func (c CloudStorageConfig) GetType() string {
+	if c.Type == "" {
+		return CloudStorageS3
+	}
+	return c.Type
+}
+
 // Conf for config yaml
 type Conf struct {
 	Podcasts     map[string]Podcast `yaml:"podcasts"`
-	CloudStorage struct {
-		EndPointURL string `yaml:"endpoint_url"`
-		Bucket      string `yaml:"bucket"`
-		Region      string `yaml:"region"`
-		Secrets     struct {
-			Key    string `yaml:"aws_key"`
-			Secret string `yaml:"aws_secret"`
-		} `yaml:"secrets"`
-	} `yaml:"cloud_storage"`
-	Upload struct {
-		ChunkSize int `yaml:"chunk_size"`
+	CloudStorage CloudStorageConfig `yaml:"cloud_storage"`
+	// Mirrors are named destinations podcasts are published to in addition to cloud_storage.
+	Mirrors map[string]CloudStorageConfig `yaml:"mirrors"`
+	// Objects are the headers of objects uploaded to S3, by object type.
+	Objects ObjectPolicies `yaml:"objects"`
+	Upload  struct {
+		ChunkSize       int           `yaml:"chunk_size"`
+		PartSizeMB      int           `yaml:"part_size_mb"`
+		AbortStaleAfter time.Duration `yaml:"abort_stale_after"`
+		MaxBandwidth    string        `yaml:"max_bandwidth"`
+		Retry           RetryConfig   `yaml:"retry"`
+		// KeyTemplate is the object key layout of episodes, see KeyPlaceholders.
+		KeyTemplate string `yaml:"key_template"`
 	} `yaml:"upload"`
 	DB      string `yaml:"db"` // Deprecated: use Database.Path instead
 	Storage struct {
@@ -129,6 +464,7 @@ type Conf struct {
 	} `yaml:"storage"`
 	Database StorageConfig `yaml:"database"`
 	Artwork  ArtworkConfig `yaml:"artwork"`
+	Serve    ServeConfig   `yaml:"serve"`
 }
 
 // Podcast defines podcast section
@@ -137,7 +473,21 @@ type Podcast struct {
 	Folder            string `yaml:"folder"`
 	MaxSize           int64  `yaml:"max_size"`
 	DeleteOldEpisodes bool   `yaml:"delete_old_episodes"`
-	Info              struct {
+	// Mirrors names the entries of Conf.Mirrors the podcast is also published to.
+	Mirrors []string `yaml:"mirrors"`
+	// PublicBaseURL overrides cloud_storage.public_base_url for the podcast.
+	PublicBaseURL string `yaml:"public_base_url"`
+	// CloudStorage overrides the S3 target of cloud_storage for the podcast.
+	CloudStorage StorageOverride `yaml:"cloud_storage"`
+	// KeyTemplate overrides upload.key_template for the podcast.
+	KeyTemplate string `yaml:"key_template"`
+	// FeedName is the file name of the public feed without .rss, the SHA-256 of the podcast ID if empty.
+	FeedName string `yaml:"feed_name"`
+	// Private publishes a feed per subscriber instead of the public feed.
+	Private PrivateFeed `yaml:"private"`
+	// Schedule is when podgen --daemon runs jobs of the podcast.
+	Schedule Schedule `yaml:"schedule"`
+	Info     struct {
 		Author   string `yaml:"author"`
 		Owner    string `yaml:"owner"`
 		Email    string `yaml:"email"`
@@ -226,6 +576,110 @@ func (c *Conf) IsArtworkAutoGenerateEnabled() bool {
 	return *c.Artwork.AutoGenerate
 }
 
+// GetUploadPartSize returns the part size of resumable uploads in bytes.
+// Defaults to 16MB, S3 doesn't accept parts smaller than 5MB.
+func (c *Conf) GetUploadPartSize() int64 {
+	if c.Upload.PartSizeMB <= 0 {
+		return 16 << 20
+	}
+	return int64(max(c.Upload.PartSizeMB, 5)) << 20
+}
+
+// GetUploadRetry returns retry settings of object storage requests with defaults applied.
+func (c *Conf) GetUploadRetry() RetryConfig {
+	retry := c.Upload.Retry
+	if retry.Attempts <= 0 {
+		retry.Attempts = 3
+	}
+	if retry.Delay <= 0 {
+		retry.Delay = time.Second
+	}
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = 30 * time.Second
+	}
+	return retry
+}
+
+// GetCloudStorageType returns the publishing backend, defaulting to "s3".
+func (c *Conf) GetCloudStorageType() string {
+	return c.CloudStorage.GetType()
+}
+
+// GetPodcastCloudStorage returns cloud_storage with the overrides of the podcast applied.
+// A podcast published to another endpoint or bucket doesn't inherit cloud_storage.public_base_url,
+// as the CDN of cloud_storage doesn't serve it.
+func (c *Conf) GetPodcastCloudStorage(p Podcast) CloudStorageConfig {
+	target := c.CloudStorage
+	o := p.CloudStorage
+	if o.EndPointURL != "" || o.Bucket != "" {
+		target.PublicBaseURL = ""
+	}
+	if o.EndPointURL != "" {
+		target.EndPointURL = o.EndPointURL
+	}
+	if o.Bucket != "" {
+		target.Bucket = o.Bucket
+	}
+	if o.Region != "" {
+		target.Region = o.Region
+	}
+	if o.Secrets != (S3Secrets{}) {
+		target.Secrets = o.Secrets
+	}
+	if o.KeyPrefix != "" {
+		target.KeyPrefix = o.KeyPrefix
+	}
+	if p.PublicBaseURL != "" {
+		target.PublicBaseURL = p.PublicBaseURL
+	}
+	return target
+}
+
+// GetObjectPolicies returns the headers of uploaded objects. Cache-Control defaults to a day for episodes and images,
+// which don't change once uploaded, and to five minutes for feeds, so new episodes show up soon.
+func (c *Conf) GetObjectPolicies() ObjectPolicies {
+	policies := c.Objects
+	if policies.Episode.CacheControl == "" {
+		policies.Episode.CacheControl = "public, max-age=86400"
+	}
+	if policies.Image.CacheControl == "" {
+		policies.Image.CacheControl = "public, max-age=86400"
+	}
+	if policies.Feed.CacheControl == "" {
+		policies.Feed.CacheControl = "public, max-age=300"
+	}
+	return policies
+}
+
+// GetKeyTemplate returns the object key layout of the episodes of the podcast, defaulting to DefaultKeyTemplate.
+func (c *Conf) GetKeyTemplate(p Podcast) string {
+	switch {
+	case p.KeyTemplate != "":
+		return p.KeyTemplate
+	case c.Upload.KeyTemplate != "":
+		return c.Upload.KeyTemplate
+	default:
+		return DefaultKeyTemplate
+	}
+}
+
+// GetServe returns the settings of the HTTP server of --serve with defaults applied.
+func (c *Conf) GetServe() ServeConfig {
+	serve := c.Serve
+	if serve.Listen == "" {
+		serve.Listen = DefaultServeListen
+	}
+	if serve.BaseURL == "" {
+		port := strings.TrimPrefix(serve.Listen, ":")
+		if _, p, err := net.SplitHostPort(serve.Listen); err == nil {
+			port = p
+		}
+		serve.BaseURL = "http://localhost:" + port
+	}
+	serve.BaseURL = strings.TrimRight(serve.BaseURL, "/")
+	return serve
+}
+
 // GetStorageFolder returns the storage folder path.
 // Defaults to current directory if not configured.
 func (c *Conf) GetStorageFolder() string {
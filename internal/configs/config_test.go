@@ -2,6 +2,7 @@ package configs
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,6 +47,193 @@ func TestConf_Validate(t *testing.T) {
 		require.NoError(t, c.Validate())
 	})
 
+	t.Run("filesystem storage", func(t *testing.T) {
+		c := validConf()
+		c.CloudStorage.Type = CloudStorageFilesystem
+		c.CloudStorage.EndPointURL, c.CloudStorage.Bucket = "", ""
+		assert.ErrorContains(t, c.Validate(), "cloud_storage.path")
+		c.CloudStorage.Path = "/var/www/podcasts"
+		assert.ErrorContains(t, c.Validate(), "public_base_url")
+		c.CloudStorage.PublicBaseURL = "https://podcasts.example.com"
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("webdav storage", func(t *testing.T) {
+		c := validConf()
+		c.CloudStorage.Type = CloudStorageWebDAV
+		assert.ErrorContains(t, c.Validate(), "cloud_storage.webdav.url")
+		c.CloudStorage.WebDAV.URL = "https://dav.example.com/podcasts"
+		assert.ErrorContains(t, c.Validate(), "public_base_url")
+		c.CloudStorage.PublicBaseURL = "https://podcasts.example.com"
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("sftp storage", func(t *testing.T) {
+		c := validConf()
+		c.CloudStorage.Type = CloudStorageSFTP
+		assert.ErrorContains(t, c.Validate(), "cloud_storage.sftp.address")
+		c.CloudStorage.SFTP.Address, c.CloudStorage.SFTP.Username = "host.example.com", "podgen"
+		assert.ErrorContains(t, c.Validate(), "password or private_key")
+		c.CloudStorage.SFTP.PrivateKey = "/home/podgen/.ssh/id_ed25519"
+		assert.ErrorContains(t, c.Validate(), "cloud_storage.path")
+		c.CloudStorage.Path, c.CloudStorage.PublicBaseURL = "/var/www/podcasts", "https://podcasts.example.com"
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("unknown storage type", func(t *testing.T) {
+		c := validConf()
+		c.CloudStorage.Type = "ftp"
+		assert.ErrorContains(t, c.Validate(), "unknown cloud_storage.type")
+	})
+
+	t.Run("mirrors", func(t *testing.T) {
+		c := validConf()
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1", Mirrors: []string{"backup"}}
+		assert.ErrorContains(t, c.Validate(), `unknown mirror "backup"`)
+		c.Mirrors = map[string]CloudStorageConfig{"backup": {Type: CloudStorageFilesystem}}
+		assert.ErrorContains(t, c.Validate(), "mirrors.backup.path")
+		c.Mirrors["backup"] = CloudStorageConfig{Type: CloudStorageFilesystem, Path: "/mnt/backup", PublicBaseURL: "https://backup.example.com"}
+		require.NoError(t, c.Validate())
+		c.Mirrors[PrimaryDestination] = c.Mirrors["backup"]
+		assert.ErrorContains(t, c.Validate(), "reserved")
+	})
+
+	t.Run("url style", func(t *testing.T) {
+		c := validConf()
+		c.CloudStorage.URLStyle = URLStyleVirtualHost
+		require.NoError(t, c.Validate())
+		c.CloudStorage.URLStyle = "subdomain"
+		assert.ErrorContains(t, c.Validate(), "unknown cloud_storage.url_style")
+	})
+
+	t.Run("podcast cloud storage", func(t *testing.T) {
+		c := validConf()
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1",
+			CloudStorage: StorageOverride{Bucket: "client-bucket", KeyPrefix: "podcasts/"}}
+		require.NoError(t, c.Validate())
+		c.CloudStorage.Type, c.CloudStorage.Path, c.CloudStorage.PublicBaseURL = CloudStorageFilesystem, "/var/www", "https://example.com"
+		assert.ErrorContains(t, c.Validate(), "need s3 cloud_storage")
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1"}
+		c.CloudStorage.KeyPrefix = "podcasts/"
+		assert.ErrorContains(t, c.Validate(), "cloud_storage.key_prefix is supported by s3 storage only")
+	})
+
+	t.Run("key template", func(t *testing.T) {
+		c := validConf()
+		c.Upload.KeyTemplate = "{podcast}/{yyyy}/{mm}/{slug}-{hash8}.{ext}"
+		require.NoError(t, c.Validate())
+		c.Upload.KeyTemplate = "{yyyy}/{slug}.{ext}"
+		assert.ErrorContains(t, c.Validate(), "must start with {podcast}/")
+		c.Upload.KeyTemplate = "{podcast}/{title}.{ext}"
+		assert.ErrorContains(t, c.Validate(), "unknown placeholder {title}")
+		c.Upload.KeyTemplate = "{podcast}/{yyyy}/{mm}.{ext}"
+		assert.ErrorContains(t, c.Validate(), "to tell episodes apart")
+		c.Upload.KeyTemplate = ""
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1", KeyTemplate: "{slug}"}
+		assert.ErrorContains(t, c.Validate(), `podcast "p1": key_template`)
+	})
+
+	t.Run("feed name", func(t *testing.T) {
+		c := validConf()
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1", FeedName: "feed"}
+		require.NoError(t, c.Validate())
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1", FeedName: "feed.rss"}
+		assert.ErrorContains(t, c.Validate(), "must not end with .rss")
+		for _, name := range []string{"../feed", "feeds/main", ".feed", "my feed"} {
+			c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1", FeedName: name}
+			assert.ErrorContains(t, c.Validate(), `podcast "p1": feed_name`, name)
+		}
+	})
+
+	t.Run("object policies", func(t *testing.T) {
+		c := validConf()
+		c.Objects.Episode = ObjectPolicy{ACL: "public-read", Encryption: EncryptionKMS, KMSKeyID: "key-1"}
+		require.NoError(t, c.Validate())
+		c.Objects.Feed.ACL = "public"
+		assert.ErrorContains(t, c.Validate(), `unknown objects.feed.acl "public"`)
+		c.Objects.Feed.ACL = ""
+		c.Objects.Image.Encryption = "aes"
+		assert.ErrorContains(t, c.Validate(), `unknown objects.image.server_side_encryption "aes"`)
+		c.Objects.Image = ObjectPolicy{Encryption: EncryptionS3, KMSKeyID: "key-1"}
+		assert.ErrorContains(t, c.Validate(), "objects.image.kms_key_id needs server_side_encryption aws:kms")
+	})
+
+	t.Run("private feeds", func(t *testing.T) {
+		c := validConf()
+		alice := Subscriber{Name: "alice", Token: "alice-secret-token"}
+		setPrivate := func(private PrivateFeed) {
+			c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "folder1", Private: private}
+		}
+		setPrivate(PrivateFeed{Mode: PrivatePresigned, Expiry: 24 * time.Hour, Subscribers: []Subscriber{alice}})
+		require.NoError(t, c.Validate())
+
+		setPrivate(PrivateFeed{Subscribers: []Subscriber{alice}})
+		assert.ErrorContains(t, c.Validate(), "private.mode is required")
+		setPrivate(PrivateFeed{Mode: "secret", Subscribers: []Subscriber{alice}})
+		assert.ErrorContains(t, c.Validate(), `unknown private.mode "secret"`)
+		setPrivate(PrivateFeed{Mode: PrivateToken})
+		require.NoError(t, c.Validate(), "subscribers can be added to the database")
+		setPrivate(PrivateFeed{Mode: PrivatePresigned, Expiry: 8 * 24 * time.Hour, Subscribers: []Subscriber{alice}})
+		assert.ErrorContains(t, c.Validate(), "private.expiry 192h0m0s is out of range")
+		setPrivate(PrivateFeed{Mode: PrivateToken, Subscribers: []Subscriber{alice, alice}})
+		assert.ErrorContains(t, c.Validate(), `duplicate subscriber "alice"`)
+		setPrivate(PrivateFeed{Mode: PrivateToken, Subscribers: []Subscriber{alice, {Name: "bob", Token: alice.Token}}})
+		assert.ErrorContains(t, c.Validate(), `subscriber "bob" shares the token`)
+		setPrivate(PrivateFeed{Mode: PrivateToken, Subscribers: []Subscriber{{Name: "bob", Token: "short"}}})
+		assert.ErrorContains(t, c.Validate(), `token of subscriber "bob" is shorter than 16 characters`)
+
+		c.CloudStorage = CloudStorageConfig{Type: CloudStorageFilesystem, Path: "/srv", PublicBaseURL: "https://example.com"}
+		setPrivate(PrivateFeed{Mode: PrivateToken, Subscribers: []Subscriber{alice}})
+		require.NoError(t, c.Validate())
+		setPrivate(PrivateFeed{Mode: PrivatePresigned, Subscribers: []Subscriber{alice}})
+		assert.ErrorContains(t, c.Validate(), "presigned private feeds need s3 cloud_storage")
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		c := validConf()
+		c.Serve = ServeConfig{Listen: "127.0.0.1:8080", BaseURL: "http://nas.local:8080"}
+		require.NoError(t, c.Validate())
+		c.Serve.Listen = "8080"
+		assert.ErrorContains(t, c.Validate(), `invalid serve.listen "8080"`)
+		c.Serve.Listen = ""
+		c.Serve.BaseURL = "nas.local:8080"
+		assert.ErrorContains(t, c.Validate(), `invalid serve.base_url "nas.local:8080"`)
+
+		c.Serve.BaseURL = ""
+		cms := APIToken{Name: "cms", Token: "cms-secret-token-1"}
+		c.Serve.APITokens = []APIToken{cms, {Name: "bot", Token: "bot-secret-token-1"}}
+		require.NoError(t, c.Validate())
+		c.Serve.APITokens = []APIToken{cms, cms}
+		assert.ErrorContains(t, c.Validate(), `duplicate API token "cms"`)
+		c.Serve.APITokens = []APIToken{cms, {Name: "bot", Token: cms.Token}}
+		assert.ErrorContains(t, c.Validate(), `API token "bot" is the same as another one`)
+		c.Serve.APITokens = []APIToken{{Name: "bot", Token: "short"}}
+		assert.ErrorContains(t, c.Validate(), `API token "bot" is shorter than 16 characters`)
+
+		// folders of the API and the dashboard are reserved while they are enabled
+		c.Serve.APITokens = []APIToken{cms}
+		for _, folder := range []string{"api", "dashboard", "/dashboard/"} {
+			c.Podcasts["p1"] = Podcast{Title: "Test", Folder: folder}
+			assert.ErrorContains(t, c.Validate(), `podcast "p1": folder "`+folder+`" is reserved`)
+		}
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "apis"}
+		require.NoError(t, c.Validate())
+		c.Serve.APITokens = nil
+		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: "api"}
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("schedule", func(t *testing.T) {
+		c := validConf()
+		p := c.Podcasts["p1"]
+		p.Schedule = Schedule{Scan: "@every 15m", Publish: "0 6 * * *"}
+		c.Podcasts["p1"] = p
+		require.NoError(t, c.Validate())
+		p.Schedule.Publish = "6:00"
+		c.Podcasts["p1"] = p
+		assert.ErrorContains(t, c.Validate(), `podcast "p1": schedule.publish: invalid schedule "6:00"`)
+	})
+
 	t.Run("podcast missing folder", func(t *testing.T) {
 		c := validConf()
 		c.Podcasts["p1"] = Podcast{Title: "Test", Folder: ""}
@@ -64,6 +252,8 @@ func TestLoad(t *testing.T) {
 	assert.Equal(t, conf.CloudStorage.Region, "region-us")
 	assert.Equal(t, conf.CloudStorage.Secrets.Key, "123123123")
 	assert.Equal(t, conf.CloudStorage.Secrets.Secret, "abc123123123xyz")
+	assert.Equal(t, 5, conf.Upload.Retry.Attempts)
+	assert.Equal(t, 500*time.Millisecond, conf.Upload.Retry.Delay)
 }
 
 func TestLoadConfigNotFound(t *testing.T) {
@@ -213,11 +403,28 @@ func TestIsArtworkAutoGenerateEnabled(t *testing.T) {
 	})
 }
 
+func TestGetUploadPartSize(t *testing.T) {
+	c := &Conf{}
+	assert.Equal(t, int64(16<<20), c.GetUploadPartSize())
+	c.Upload.PartSizeMB = 2
+	assert.Equal(t, int64(5<<20), c.GetUploadPartSize())
+	c.Upload.PartSizeMB = 64
+	assert.Equal(t, int64(64<<20), c.GetUploadPartSize())
+}
+
+func TestGetUploadRetry(t *testing.T) {
+	c := &Conf{}
+	assert.Equal(t, RetryConfig{Attempts: 3, Delay: time.Second, MaxDelay: 30 * time.Second}, c.GetUploadRetry())
+
+	c.Upload.Retry = RetryConfig{Attempts: 1, Delay: 10 * time.Millisecond}
+	assert.Equal(t, RetryConfig{Attempts: 1, Delay: 10 * time.Millisecond, MaxDelay: 30 * time.Second}, c.GetUploadRetry())
+}
+
 func TestGetStorageDSN(t *testing.T) {
 	tests := []struct {
-		name          string
-		setup         func(*Conf)
-		expected      string
+		name     string
+		setup    func(*Conf)
+		expected string
 	}{
 		{
 			name: "DSN takes priority",
@@ -244,8 +451,8 @@ func TestGetStorageDSN(t *testing.T) {
 			expected: "/legacy/podgen.bolt",
 		},
 		{
-			name: "default to config dir",
-			setup: func(c *Conf) {},
+			name:     "default to config dir",
+			setup:    func(c *Conf) {},
 			expected: DefaultDatabasePath(),
 		},
 	}
@@ -258,3 +465,69 @@ func TestGetStorageDSN(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPodcastCloudStorage(t *testing.T) {
+	c := validConf()
+	c.CloudStorage.PublicBaseURL = "https://cdn.example.com"
+
+	target := c.GetPodcastCloudStorage(Podcast{Folder: "news"})
+	assert.Equal(t, "https://cdn.example.com", target.PublicBaseURL)
+	assert.Equal(t, "my-bucket", target.Bucket)
+
+	target = c.GetPodcastCloudStorage(Podcast{Folder: "news", PublicBaseURL: "https://news.example.com"})
+	assert.Equal(t, "https://news.example.com", target.PublicBaseURL)
+	assert.Equal(t, "https://cdn.example.com", c.CloudStorage.PublicBaseURL, "config is not changed")
+
+	p := Podcast{Folder: "client", CloudStorage: StorageOverride{
+		EndPointURL: "s3.client.example.com", Bucket: "client-bucket", KeyPrefix: "podcasts/",
+		Secrets: S3Secrets{Key: "client-key", Secret: "client-secret"},
+	}}
+	target = c.GetPodcastCloudStorage(p)
+	assert.Equal(t, "s3.client.example.com", target.EndPointURL)
+	assert.Equal(t, "client-bucket", target.Bucket)
+	assert.Equal(t, "podcasts/", target.KeyPrefix)
+	assert.Equal(t, S3Secrets{Key: "client-key", Secret: "client-secret"}, target.Secrets)
+	assert.Empty(t, target.PublicBaseURL, "the cdn of cloud_storage doesn't serve another bucket")
+
+	p.PublicBaseURL = "https://client-cdn.example.com"
+	assert.Equal(t, "https://client-cdn.example.com", c.GetPodcastCloudStorage(p).PublicBaseURL)
+	assert.Equal(t, c.CloudStorage, c.GetPodcastCloudStorage(Podcast{}), "podcasts without overrides share cloud_storage")
+}
+
+func TestGetKeyTemplate(t *testing.T) {
+	c := &Conf{}
+	assert.Equal(t, DefaultKeyTemplate, c.GetKeyTemplate(Podcast{}))
+	c.Upload.KeyTemplate = "{podcast}/{hash}.{ext}"
+	assert.Equal(t, "{podcast}/{hash}.{ext}", c.GetKeyTemplate(Podcast{}))
+	assert.Equal(t, "{podcast}/{slug}.{ext}", c.GetKeyTemplate(Podcast{KeyTemplate: "{podcast}/{slug}.{ext}"}))
+}
+
+func TestGetObjectPolicies(t *testing.T) {
+	c := &Conf{}
+	policies := c.GetObjectPolicies()
+	assert.Equal(t, "public, max-age=86400", policies.Episode.CacheControl)
+	assert.Equal(t, "public, max-age=86400", policies.Image.CacheControl)
+	assert.Equal(t, "public, max-age=300", policies.Feed.CacheControl)
+
+	c.Objects.Episode = ObjectPolicy{CacheControl: "public, max-age=31536000, immutable", StorageClass: "STANDARD_IA"}
+	policies = c.GetObjectPolicies()
+	assert.Equal(t, "public, max-age=31536000, immutable", policies.Episode.CacheControl)
+	assert.Equal(t, "STANDARD_IA", policies.Episode.StorageClass)
+}
+
+func TestPrivateFeed(t *testing.T) {
+	assert.False(t, PrivateFeed{}.IsSet())
+	assert.Equal(t, MaxPresignExpiry, PrivateFeed{}.GetExpiry())
+	assert.Equal(t, time.Hour, PrivateFeed{Expiry: time.Hour}.GetExpiry())
+	assert.Equal(t, "token", PrivateFeed{}.GetTokenParam())
+	assert.Equal(t, "key", PrivateFeed{TokenParam: "key"}.GetTokenParam())
+}
+
+func TestGetServe(t *testing.T) {
+	c := &Conf{}
+	assert.Equal(t, ServeConfig{Listen: ":8080", BaseURL: "http://localhost:8080"}, c.GetServe())
+	c.Serve.Listen = "0.0.0.0:9000"
+	assert.Equal(t, "http://localhost:9000", c.GetServe().BaseURL)
+	c.Serve.BaseURL = "https://podcasts.home.lan/"
+	assert.Equal(t, "https://podcasts.home.lan", c.GetServe().BaseURL)
+}
@@ -3,28 +3,293 @@ package configs
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"podgen/internal/pkg/schedule"
 )
 
+var keyPlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// feedName matches names of feeds: letters, digits, dots, dashes and underscores, not starting with a dot.
+var feedName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
 // Validate checks the configuration for required fields.
 // Empty config is valid - podcasts can be added later via --add.
 func (c *Conf) Validate() error {
 	// Only validate cloud storage if podcasts exist
 	if len(c.Podcasts) > 0 {
-		if c.CloudStorage.EndPointURL == "" {
-			return errors.New("cloud_storage.endpoint_url is required")
+		if err := c.CloudStorage.validate("cloud_storage"); err != nil {
+			return err
+		}
+
+		for name, mirror := range c.Mirrors {
+			if name == PrimaryDestination {
+				return fmt.Errorf("mirror name %q is reserved for cloud_storage", name)
+			}
+			if err := mirror.validate("mirrors." + name); err != nil {
+				return err
+			}
 		}
 
-		if c.CloudStorage.Bucket == "" {
-			return errors.New("cloud_storage.bucket is required")
+		if err := c.Objects.Episode.validate("objects.episode"); err != nil {
+			return err
+		}
+		if err := c.Objects.Image.validate("objects.image"); err != nil {
+			return err
+		}
+		if err := c.Objects.Feed.validate("objects.feed"); err != nil {
+			return err
 		}
 
 		for id, p := range c.Podcasts {
 			if p.Folder == "" {
 				return fmt.Errorf("podcast %q: folder is required", id)
 			}
+			if err := validateKeyTemplate(c.GetKeyTemplate(p)); err != nil {
+				return fmt.Errorf("podcast %q: %w", id, err)
+			}
+			if err := validateFeedName(p.FeedName); err != nil {
+				return fmt.Errorf("podcast %q: %w", id, err)
+			}
+			if p.CloudStorage.IsSet() {
+				if c.GetCloudStorageType() != CloudStorageS3 {
+					return fmt.Errorf("podcast %q: cloud_storage overrides need s3 cloud_storage", id)
+				}
+				if err := c.GetPodcastCloudStorage(p).validate(fmt.Sprintf("podcasts.%s.cloud_storage", id)); err != nil {
+					return err
+				}
+			}
+			for _, name := range p.Mirrors {
+				if _, ok := c.Mirrors[name]; !ok {
+					return fmt.Errorf("podcast %q: unknown mirror %q", id, name)
+				}
+			}
+			if err := c.validatePrivate(p); err != nil {
+				return fmt.Errorf("podcast %q: %w", id, err)
+			}
+			if err := p.Schedule.validate(); err != nil {
+				return fmt.Errorf("podcast %q: %w", id, err)
+			}
+		}
+	}
+
+	if err := c.Serve.validate(); err != nil {
+		return err
+	}
+	return c.validateServedFolders()
+}
+
+// validateServedFolders checks no podcast folder is shadowed by the API or the dashboard of the server.
+func (c *Conf) validateServedFolders() error {
+	if len(c.Serve.APITokens) == 0 {
+		return nil
+	}
+	for id, p := range c.Podcasts {
+		first, _, _ := strings.Cut(strings.Trim(p.Folder, "/"), "/")
+		if slices.Contains(ServeReservedFolders, first) {
+			return fmt.Errorf("podcast %q: folder %q is reserved by the API and the dashboard of serve.api_tokens", id, p.Folder)
+		}
+	}
+	return nil
+}
+
+// validate checks the address and the base URL of the HTTP server, if set, and the API tokens.
+func (c ServeConfig) validate() error {
+	if c.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+			return fmt.Errorf("invalid serve.listen %q, expected host:port or :port", c.Listen)
+		}
+	}
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid serve.base_url %q, expected an http or https URL", c.BaseURL)
+		}
+	}
+
+	names, tokens := map[string]bool{}, map[string]bool{}
+	for _, t := range c.APITokens {
+		if t.Name == "" {
+			return errors.New("serve.api_tokens need a name")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate API token %q", t.Name)
 		}
+		if len(t.Token) < minTokenLength {
+			return fmt.Errorf("API token %q is shorter than %d characters", t.Name, minTokenLength)
+		}
+		if tokens[t.Token] {
+			return fmt.Errorf("API token %q is the same as another one", t.Name)
+		}
+		names[t.Name], tokens[t.Token] = true, true
 	}
+	return nil
+}
+
+// validate checks the settings required by the publishing backend, name is the config key used in errors.
+func (c CloudStorageConfig) validate(name string) error {
+	switch c.GetType() {
+	case CloudStorageS3:
+		if c.EndPointURL == "" {
+			return fmt.Errorf("%s.endpoint_url is required", name)
+		}
+
+		if c.Bucket == "" {
+			return fmt.Errorf("%s.bucket is required", name)
+		}
+	case CloudStorageFilesystem:
+		if c.Path == "" {
+			return fmt.Errorf("%s.path is required for filesystem storage", name)
+		}
+
+		if c.PublicBaseURL == "" {
+			return fmt.Errorf("%s.public_base_url is required for filesystem storage", name)
+		}
+	case CloudStorageWebDAV:
+		if c.WebDAV.URL == "" {
+			return fmt.Errorf("%s.webdav.url is required for webdav storage", name)
+		}
+
+		if c.PublicBaseURL == "" {
+			return fmt.Errorf("%s.public_base_url is required for webdav storage", name)
+		}
+	case CloudStorageSFTP:
+		if c.SFTP.Address == "" || c.SFTP.Username == "" {
+			return fmt.Errorf("%s.sftp.address and username are required for sftp storage", name)
+		}
 
+		if c.SFTP.Password == "" && c.SFTP.PrivateKey == "" {
+			return fmt.Errorf("%s.sftp.password or private_key is required for sftp storage", name)
+		}
+
+		if c.Path == "" || c.PublicBaseURL == "" {
+			return fmt.Errorf("%s.path and public_base_url are required for sftp storage", name)
+		}
+	default:
+		return fmt.Errorf("unknown %s.type %q, expected s3, filesystem, webdav or sftp", name, c.Type)
+	}
+
+	if c.KeyPrefix != "" && c.GetType() != CloudStorageS3 {
+		return fmt.Errorf("%s.key_prefix is supported by s3 storage only", name)
+	}
+
+	if c.URLStyle != "" && c.URLStyle != URLStylePath && c.URLStyle != URLStyleVirtualHost {
+		return fmt.Errorf("unknown %s.url_style %q, expected %s or %s", name, c.URLStyle, URLStylePath, URLStyleVirtualHost)
+	}
+	return nil
+}
+
+// validate checks the specs of the schedule.
+func (s Schedule) validate() error {
+	for _, job := range []struct{ name, spec string }{{JobScan, s.Scan}, {JobPublish, s.Publish}} {
+		if job.spec == "" {
+			continue
+		}
+		if _, err := schedule.Parse(job.spec); err != nil {
+			return fmt.Errorf("schedule.%s: %w", job.name, err)
+		}
+	}
+	return nil
+}
+
+// validatePrivate checks the private feed settings of the podcast.
+func (c *Conf) validatePrivate(p Podcast) error {
+	private := p.Private
+	switch private.Mode {
+	case "":
+		if len(private.Subscribers) > 0 {
+			return errors.New("private.mode is required with subscribers")
+		}
+		return nil
+	case PrivatePresigned:
+		if c.GetCloudStorageType() != CloudStorageS3 {
+			return errors.New("presigned private feeds need s3 cloud_storage")
+		}
+		for _, name := range p.Mirrors {
+			if c.Mirrors[name].GetType() != CloudStorageS3 {
+				return fmt.Errorf("presigned private feeds need s3 mirrors, %q is not", name)
+			}
+		}
+		if private.Expiry < 0 || private.Expiry > MaxPresignExpiry {
+			return fmt.Errorf("private.expiry %s is out of range, at most %s", private.Expiry, MaxPresignExpiry)
+		}
+	case PrivateToken:
+	default:
+		return fmt.Errorf("unknown private.mode %q, expected %s or %s", private.Mode, PrivatePresigned, PrivateToken)
+	}
+
+	names, tokens := map[string]bool{}, map[string]bool{}
+	for _, sub := range private.Subscribers {
+		if sub.Name == "" {
+			return errors.New("private.subscribers need a name")
+		}
+		if names[sub.Name] {
+			return fmt.Errorf("duplicate subscriber %q", sub.Name)
+		}
+		if len(sub.Token) < minTokenLength {
+			return fmt.Errorf("token of subscriber %q is shorter than %d characters", sub.Name, minTokenLength)
+		}
+		if tokens[sub.Token] {
+			return fmt.Errorf("subscriber %q shares the token of another subscriber", sub.Name)
+		}
+		names[sub.Name], tokens[sub.Token] = true, true
+	}
+	return nil
+}
+
+// cannedACLs are the canned ACLs of S3
+var cannedACLs = []string{"private", "public-read", "public-read-write", "authenticated-read",
+	"aws-exec-read", "bucket-owner-read", "bucket-owner-full-control"}
+
+// validate checks the ACL and encryption of the policy, name is the config key used in errors.
+func (p ObjectPolicy) validate(name string) error {
+	if p.ACL != "" && !slices.Contains(cannedACLs, p.ACL) {
+		return fmt.Errorf("unknown %s.acl %q, expected one of %s", name, p.ACL, strings.Join(cannedACLs, ", "))
+	}
+	switch p.Encryption {
+	case "", EncryptionS3:
+		if p.KMSKeyID != "" {
+			return fmt.Errorf("%s.kms_key_id needs server_side_encryption %s", name, EncryptionKMS)
+		}
+	case EncryptionKMS:
+	default:
+		return fmt.Errorf("unknown %s.server_side_encryption %q, expected %s or %s", name, p.Encryption, EncryptionS3, EncryptionKMS)
+	}
+	return nil
+}
+
+// validateKeyTemplate checks the template keeps keys under the podcast folder and uses known placeholders only.
+func validateKeyTemplate(template string) error {
+	if !strings.HasPrefix(template, "{podcast}/") {
+		return fmt.Errorf("key_template %q must start with {podcast}/", template)
+	}
+	unique := false
+	for _, m := range keyPlaceholder.FindAllStringSubmatch(template, -1) {
+		if !slices.Contains(KeyPlaceholders, m[1]) {
+			return fmt.Errorf("key_template %q has unknown placeholder {%s}", template, m[1])
+		}
+		unique = unique || slices.Contains([]string{"filename", "name", "slug", "hash", "hash8"}, m[1])
+	}
+	if !unique {
+		return fmt.Errorf("key_template %q needs {filename}, {name}, {slug} or {hash} to tell episodes apart", template)
+	}
+	return nil
+}
+
+// validateFeedName checks the feed name is a plain file name without the .rss extension, empty for the default.
+func validateFeedName(name string) error {
+	switch {
+	case name == "":
+		return nil
+	case !feedName.MatchString(name):
+		return fmt.Errorf("feed_name %q must be a file name of letters, digits, dots, dashes and underscores", name)
+	case strings.HasSuffix(strings.ToLower(name), ".rss"):
+		return fmt.Errorf("feed_name %q must not end with .rss, it is added", name)
+	}
 	return nil
 }
 
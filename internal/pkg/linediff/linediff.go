@@ -0,0 +1,194 @@
+// Package linediff computes line-based differences between two texts.
+package linediff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxCells limits the size of the LCS table. Larger changed regions are reported
+// as a removal of all old lines followed by an insertion of all new ones.
+const maxCells = 4_000_000
+
+// Op is the kind of change of a diff line.
+type Op int
+
+const (
+	// Equal marks a line present in both texts.
+	Equal Op = iota
+	// Delete marks a line present only in the old text.
+	Delete
+	// Insert marks a line present only in the new text.
+	Insert
+)
+
+// Line is a line of the diff.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Diff returns the lines of a and b as a sequence of equal, deleted and inserted lines.
+func Diff(a, b []string) []Line {
+	// common prefix and suffix are trimmed first, so appends to long texts stay cheap
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for _, s := range a[:prefix] {
+		result = append(result, Line{Op: Equal, Text: s})
+	}
+	result = append(result, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		result = append(result, Line{Op: Equal, Text: s})
+	}
+	return result
+}
+
+// middle diffs the changed region using the longest common subsequence.
+func middle(a, b []string) []Line {
+	result := make([]Line, 0, len(a)+len(b))
+	if len(a)*len(b) > maxCells || len(a) == 0 || len(b) == 0 {
+		for _, s := range a {
+			result = append(result, Line{Op: Delete, Text: s})
+		}
+		for _, s := range b {
+			result = append(result, Line{Op: Insert, Text: s})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, Line{Op: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Op: Delete, Text: a[i]})
+			i++
+		default:
+			result = append(result, Line{Op: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, Line{Op: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, Line{Op: Insert, Text: b[j]})
+	}
+	return result
+}
+
+// Unified formats the difference between old and new texts in unified diff format
+// with the given number of context lines. It returns an empty string if the texts are equal.
+func Unified(oldText, newText string, context int) string {
+	lines := Diff(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	oldLine, newLine := 1, 1 // line numbers of lines[i] in both texts
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// hunk starts context lines before the change and extends while changes are close enough
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != Equal {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Op == Equal {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = next
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		for _, l := range lines[start:end] {
+			if l.Op != Insert {
+				oldCount++
+			}
+			if l.Op != Delete {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		for _, l := range lines[start:end] {
+			switch l.Op {
+			case Equal:
+				sb.WriteString(" ")
+			case Delete:
+				sb.WriteString("-")
+			case Insert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(l.Text)
+			sb.WriteString("\n")
+		}
+
+		for _, l := range lines[i:end] {
+			if l.Op != Insert {
+				oldLine++
+			}
+			if l.Op != Delete {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the start and length of a hunk side, using line 0 for empty sides as diff does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines without trailing newlines. An empty text has no lines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,76 @@
+package linediff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []Line
+	}{
+		{
+			name: "equal",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: []Line{{Equal, "a"}, {Equal, "b"}},
+		},
+		{
+			name: "insert in the middle",
+			a:    []string{"a", "c"},
+			b:    []string{"a", "b", "c"},
+			want: []Line{{Equal, "a"}, {Insert, "b"}, {Equal, "c"}},
+		},
+		{
+			name: "replace",
+			a:    []string{"a", "x", "c"},
+			b:    []string{"a", "y", "c"},
+			want: []Line{{Equal, "a"}, {Delete, "x"}, {Insert, "y"}, {Equal, "c"}},
+		},
+		{
+			name: "from empty",
+			b:    []string{"a"},
+			want: []Line{{Insert, "a"}},
+		},
+		{
+			name: "common lines inside changed region",
+			a:    []string{"1", "a", "x", "b", "2"},
+			b:    []string{"1", "y", "a", "b", "z", "2"},
+			want: []Line{{Equal, "1"}, {Insert, "y"}, {Equal, "a"}, {Delete, "x"}, {Equal, "b"}, {Insert, "z"}, {Equal, "2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Diff(tt.a, tt.b))
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	assert.Empty(t, Unified("a\nb\n", "a\nb\n", 3))
+
+	assert.Equal(t, "@@ -0,0 +1,2 @@\n+a\n+b\n", Unified("", "a\nb\n", 3))
+
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		oldLines = append(oldLines, line)
+		switch i {
+		case 3:
+			newLines = append(newLines, "changed")
+		case 18:
+			newLines = append(newLines, line, "added")
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+	got := Unified(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"), 1)
+	want := "@@ -2,3 +2,3 @@\n xx\n-xxx\n+changed\n xxxx\n" +
+		"@@ -18,2 +18,3 @@\n " + strings.Repeat("x", 18) + "\n+added\n " + strings.Repeat("x", 19) + "\n"
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,52 @@
+// Package lockfile makes sure a single process runs, by an exclusive lock of a file held while it runs.
+// The lock is released by the operating system when the process exits, so a crash doesn't leave it behind.
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned when another process holds the lock.
+var ErrLocked = errors.New("locked by another process")
+
+// Lock is an acquired lock of a file.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire locks the file at path, creating it if needed, and writes the process ID into it.
+// It returns an error wrapping ErrLocked, with the process ID of the holder if known, when the file is locked.
+func Acquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) //nolint:gosec // path of the lock file is configured
+	if err != nil {
+		return nil, fmt.Errorf("can't open lock file %s: %w", path, err)
+	}
+	if err := lock(f); err != nil {
+		_ = f.Close()
+		if errors.Is(err, ErrLocked) {
+			if pid, readErr := os.ReadFile(path); readErr == nil && len(strings.TrimSpace(string(pid))) > 0 { //nolint:gosec // same path
+				return nil, fmt.Errorf("%s is %w, pid %s", path, ErrLocked, strings.TrimSpace(string(pid)))
+			}
+			return nil, fmt.Errorf("%s is %w", path, ErrLocked)
+		}
+		return nil, fmt.Errorf("can't lock %s: %w", path, err)
+	}
+
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release unlocks and closes the file, which is kept so the process ID of the last holder can be read.
+func (l *Lock) Release() error {
+	if err := unlock(l.f); err != nil {
+		_ = l.f.Close()
+		return fmt.Errorf("can't unlock %s: %w", l.f.Name(), err)
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,36 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podgen.lock")
+
+	l, err := Acquire(path)
+	require.NoError(t, err)
+	pid, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(pid))
+
+	_, err = Acquire(path)
+	require.ErrorIs(t, err, ErrLocked, "the lock is held by an open file")
+	assert.Contains(t, err.Error(), "pid "+strconv.Itoa(os.Getpid()))
+
+	require.NoError(t, l.Release())
+	l, err = Acquire(path)
+	require.NoError(t, err, "released locks can be acquired again")
+	require.NoError(t, l.Release())
+}
+
+func TestAcquire_MissingDir(t *testing.T) {
+	_, err := Acquire(filepath.Join(t.TempDir(), "missing", "podgen.lock"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrLocked)
+}
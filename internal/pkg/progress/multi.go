@@ -24,6 +24,7 @@ type WorkerState struct {
 	Uploaded  int64
 	Total     int64
 	StartTime time.Time
+	Retries   int
 }
 
 // Multi manages multi-line progress display for concurrent uploads.
@@ -35,6 +36,7 @@ type Multi struct {
 	total        int
 	totalBytes   int64 // bytes uploaded so far
 	errors       int
+	retries      int
 	startTime    time.Time
 	lastRender   time.Time
 	linesWritten int
@@ -102,6 +104,20 @@ func (m *Multi) CompleteFile(workerID int, fileSize int64, err error) {
 	m.render()
 }
 
+// RetryFile marks the file of a worker as failed and tried again, its progress starts over.
+func (m *Multi) RetryFile(workerID, attempt int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if workerID >= 0 && workerID < len(m.workers) {
+		m.workers[workerID].Retries = attempt
+		m.workers[workerID].Uploaded = 0
+		m.workers[workerID].StartTime = time.Now()
+	}
+	m.retries++
+	m.render()
+}
+
 // Finish clears the progress display.
 func (m *Multi) Finish() {
 	m.mu.Lock()
@@ -120,6 +136,7 @@ func (m *Multi) Reset(totalTasks int) {
 	m.total = totalTasks
 	m.completed = 0
 	m.errors = 0
+	m.retries = 0
 	m.totalBytes = 0
 	m.startTime = time.Now()
 	m.linesWritten = 0
@@ -144,12 +161,15 @@ func (m *Multi) render() {
 	bar := renderBar(pct, 30)
 	statusParts := []string{
 		fmt.Sprintf("%d/%d", m.completed, m.total),
-		formatBytes(m.totalBytes),
+		FormatBytes(m.totalBytes),
 		elapsed.String(),
 	}
 	if m.errors > 0 {
 		statusParts = append(statusParts, fmt.Sprintf("%d errors", m.errors))
 	}
+	if m.retries > 0 {
+		statusParts = append(statusParts, fmt.Sprintf("%d retries", m.retries))
+	}
 
 	lines = append(lines,
 		fmt.Sprintf("\033[1mTotal:\033[0m %s %3d%% │ %s",
@@ -187,8 +207,13 @@ func (m *Multi) formatWorker(id int, w WorkerState) string {
 			sizeWidth, "-", speedWidth, "-")
 	}
 
-	// Truncate and pad filename
-	name := truncateString(w.Filename, filenameWidth)
+	// Truncate and pad filename, leaving room for the retry marker
+	name := w.Filename
+	if w.Retries > 0 {
+		retry := fmt.Sprintf(" (retry %d)", w.Retries)
+		name = truncateString(name, filenameWidth-utf8.RuneCountInString(retry)) + retry
+	}
+	name = truncateString(name, filenameWidth)
 	name = padRight(name, filenameWidth)
 
 	// Calculate progress percentage
@@ -209,7 +234,7 @@ func (m *Multi) formatWorker(id int, w WorkerState) string {
 	bar := renderBar(pct, barWidth)
 
 	// Size info
-	sizeInfo := fmt.Sprintf("%s/%s", formatBytes(w.Uploaded), formatBytes(w.Total))
+	sizeInfo := fmt.Sprintf("%s/%s", FormatBytes(w.Uploaded), FormatBytes(w.Total))
 
 	return fmt.Sprintf("[%d] %s %s %3d%% │ %-*s │ %*s",
 		id+1, name, bar, pct, sizeWidth, sizeInfo, speedWidth, speed)
@@ -251,8 +276,8 @@ func padRight(s string, width int) string {
 	return s + strings.Repeat(" ", width-runeCount)
 }
 
-// formatBytes formats bytes as human-readable string.
-func formatBytes(b int64) string {
+// FormatBytes formats bytes as human-readable string, e.g. "1.5MB".
+func FormatBytes(b int64) string {
 	const unit = 1024
 	if b < unit {
 		return fmt.Sprintf("%dB", b)
@@ -41,7 +41,7 @@ func TestFormatBytes(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		got := formatBytes(tt.bytes)
+		got := FormatBytes(tt.bytes)
 		assert.Equal(t, tt.expected, got, "bytes=%d", tt.bytes)
 	}
 }
@@ -166,6 +166,32 @@ func TestMultiUpdateProgress(t *testing.T) {
 	assert.Equal(t, int64(1024), w.Total)
 }
 
+func TestMultiRetryFile(t *testing.T) {
+	var buf strings.Builder
+	m := NewMulti(&buf, 2, 3)
+
+	m.StartFile(0, "episode.mp3", 1024)
+	m.UpdateProgress(0, 512, 1024)
+	m.RetryFile(0, 1, assert.AnError)
+	m.RetryFile(99, 1, assert.AnError) // should not panic on invalid worker IDs
+
+	m.mu.Lock()
+	w := m.workers[0]
+	retries := m.retries
+	m.mu.Unlock()
+
+	assert.Equal(t, int64(0), w.Uploaded, "progress starts over on retry")
+	assert.Equal(t, 1, w.Retries)
+	assert.Equal(t, 2, retries)
+	assert.Contains(t, buf.String(), "episode.mp3 (retry 1)")
+	assert.Contains(t, buf.String(), "2 retries")
+
+	m.StartFile(0, "next.mp3", 1024)
+	m.mu.Lock()
+	assert.Equal(t, 0, m.workers[0].Retries, "retries are counted per file")
+	m.mu.Unlock()
+}
+
 func TestMultiFinish(t *testing.T) {
 	var buf strings.Builder
 	m := NewMulti(&buf, 1, 1)
@@ -0,0 +1,138 @@
+// Package ratelimit limits the throughput of readers with a shared token bucket.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChunkSize is the largest read passed through a bucket at once, so throughput stays even.
+const ChunkSize = 32 << 10
+
+// Bucket is a token bucket allowing a number of bytes per second.
+// It is safe for concurrent use, so a single bucket limits the combined rate of all its users.
+// A nil bucket doesn't limit.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// New returns a bucket allowing bytesPerSec on average, with bursts of a tenth of a second.
+// Returns nil, an unlimited bucket, if bytesPerSec is not positive.
+func New(bytesPerSec int64) *Bucket {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := max(float64(bytesPerSec)/10, ChunkSize)
+	return &Bucket{rate: float64(bytesPerSec), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Rate returns the allowed bytes per second, 0 for a nil bucket.
+func (b *Bucket) Rate() int64 {
+	if b == nil {
+		return 0
+	}
+	return int64(b.rate)
+}
+
+// Wait blocks until n bytes may pass or ctx is done.
+// Callers take their bytes from the bucket as they arrive and wait for the debt to be paid off,
+// so concurrent callers are served in order.
+func (b *Bucket) Wait(ctx context.Context, n int) error {
+	if b == nil || n <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens += float64(n) // give back what wasn't sent
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Reader returns r with reads limited by the bucket. Reads fail with the context error once ctx is done.
+func Reader(ctx context.Context, r io.Reader, b *Bucket) io.Reader {
+	if b == nil {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, b: b}
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+	b   *Bucket
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > ChunkSize {
+		p = p[:ChunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.b.Wait(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// ParseRate parses a rate in bytes per second, such as "500KB", "2MB" or "1.5GB" with units of 1024,
+// or in bits per second, such as "800kbit" or "10Mbit" with units of 1000. A plain number is bytes per second,
+// an optional "/s" suffix is ignored. An empty string or zero means no limit and returns 0.
+func ParseRate(s string) (int64, error) {
+	value := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "/s")
+	if value == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"gbit", 1e9 / 8}, {"mbit", 1e6 / 8}, {"kbit", 1e3 / 8}, {"bit", 1.0 / 8},
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}, {"b", 1},
+	}
+	multiplier := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, u.suffix)), u.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid rate %q, expected a value like 2MB or 10Mbit", s)
+	}
+	return int64(number * multiplier), nil
+}
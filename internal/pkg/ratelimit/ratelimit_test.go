@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"1024", 1024, false},
+		{"500KB", 500 << 10, false},
+		{"2MB", 2 << 20, false},
+		{"2mb/s", 2 << 20, false},
+		{"1.5 MB", 3 << 19, false},
+		{"1G", 1 << 30, false},
+		{"10Mbit", 1_250_000, false},
+		{"800kbit/s", 100_000, false},
+		{"fast", 0, true},
+		{"-1MB", 0, true},
+		{"MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.input)
+		if tt.wantErr {
+			assert.Error(t, err, "input=%q", tt.input)
+			continue
+		}
+		require.NoError(t, err, "input=%q", tt.input)
+		assert.Equal(t, tt.want, got, "input=%q", tt.input)
+	}
+}
+
+func TestNewUnlimited(t *testing.T) {
+	b := New(0)
+	assert.Nil(t, b)
+	assert.Equal(t, int64(0), b.Rate())
+	require.NoError(t, b.Wait(context.Background(), 1<<30))
+
+	r := bytes.NewReader([]byte("data"))
+	assert.Same(t, r, Reader(context.Background(), r, nil))
+}
+
+func TestReader(t *testing.T) {
+	b := New(512 << 10)
+	data := make([]byte, 256<<10)
+
+	start := time.Now()
+	got, err := io.ReadAll(Reader(context.Background(), bytes.NewReader(data), b))
+	require.NoError(t, err)
+	assert.Len(t, got, len(data))
+
+	// a tenth of a second is the burst, the rest is paid at 512KB/s
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 350*time.Millisecond)
+	assert.Less(t, elapsed, 2*time.Second)
+}
+
+func TestBucketShared(t *testing.T) {
+	b := New(512 << 10)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := io.Copy(io.Discard, Reader(context.Background(), bytes.NewReader(make([]byte, 64<<10)), b))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// four readers of 64KB share 512KB/s, the same as a single reader of 256KB
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 350*time.Millisecond)
+	assert.Less(t, elapsed, 2*time.Second)
+}
+
+func TestReaderCancel(t *testing.T) {
+	b := New(64 << 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := io.Copy(io.Discard, Reader(ctx, bytes.NewReader(make([]byte, 10<<20)), b))
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,176 @@
+// Package schedule parses cron-like schedules and finds their next activation time.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the activation times of a job.
+type Schedule interface {
+	// Next returns the first activation time after t, in the location of t.
+	// Cron fields are matched in the location of t.
+	Next(t time.Time) time.Time
+}
+
+// shorthands are the predefined schedules, as in crontab.
+var shorthands = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// field is the range of values of a cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{{"minute", 0, 59}, {"hour", 0, 23}, {"day of month", 1, 31}, {"month", 1, 12}, {"day of week", 0, 7}}
+
+// Parse parses a schedule: five cron fields (minute, hour, day of month, month and day of week, Sunday is 0 or 7)
+// with *, lists, ranges and steps, such as "*/15 * * * *" or "0 6 * * 1-5", a shorthand such as @daily,
+// or "@every <duration>" of a minute to a day, such as "@every 15m", activated at multiples of the duration
+// since midnight UTC and at midnight UTC, so intervals that don't divide a day restart every day.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if interval < time.Minute || interval > 24*time.Hour {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be from a minute to a day, use cron fields for longer ones", spec)
+		}
+		return every(interval), nil
+	}
+	if expanded, ok := shorthands[spec]; ok {
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields or a shorthand such as @daily or @every 15m", spec)
+	}
+	var c cron
+	sets := []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		*sets[i] = set
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday as well
+	}
+	c.domAny, c.dowAny = parts[2] == "*", parts[4] == "*"
+	if c.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: no such date", spec)
+	}
+	return c, nil
+}
+
+// parseField parses a comma-separated list of *, values and ranges, each with an optional step, into a bit set.
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for item := range strings.SplitSeq(s, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %s", stepText, f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rng != "*" {
+			lowText, highText, isRange := strings.Cut(rng, "-")
+			var err error
+			if low, err = parseValue(lowText, f); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseValue(highText, f); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = f.max // "5/15" is "5-59/15"
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q of %s", rng, f.name)
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// every is activated at multiples of the interval since midnight UTC of each day.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	u := t.UTC()
+	midnight := time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+	next := midnight.Add((u.Sub(midnight)/d + 1) * d)
+	if tomorrow := midnight.AddDate(0, 0, 1); next.After(tomorrow) {
+		next = tomorrow
+	}
+	return next.In(t.Location())
+}
+
+// cron is activated at the minutes matching all fields. As in crontab, a day matches if either the day of month
+// or the day of week matches, unless one of them is *.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// maxSearch bounds the search of the next activation, it covers a leap day.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+func (c cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.Add(maxSearch)
+	for next.Before(limit) {
+		switch {
+		case c.month&(1<<int(next.Month())) == 0:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<next.Hour()) == 0:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<next.Minute()) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (c cron) dayMatches(t time.Time) bool {
+	dom, dow := c.dom&(1<<t.Day()) != 0, c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{"*/15 * * * *", []time.Time{
+			time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC),
+		}},
+		{"0 6 * * *", []time.Time{
+			time.Date(2024, 5, 16, 6, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 17, 6, 0, 0, 0, time.UTC),
+		}},
+		{"30 9,17 * * 1-5", []time.Time{
+			time.Date(2024, 5, 15, 17, 30, 0, 0, time.UTC),
+			time.Date(2024, 5, 16, 9, 30, 0, 0, time.UTC),
+		}},
+		{"0 0 * * 7", []time.Time{time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)}},
+		{"0 12 1,15 * 1", []time.Time{
+			time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 2 *", []time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)}},
+		{"5/20 10 * * *", []time.Time{
+			time.Date(2024, 5, 15, 10, 25, 0, 0, time.UTC),
+			time.Date(2024, 5, 15, 10, 45, 0, 0, time.UTC),
+		}},
+		{"@daily", []time.Time{time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)}},
+		{"@hourly", []time.Time{time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)}},
+		{"@every 15m", []time.Time{
+			time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC),
+			time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			require.NoError(t, err)
+			next := from
+			for _, want := range tt.want {
+				next = s.Next(next)
+				assert.Equal(t, want, next)
+			}
+		})
+	}
+}
+
+func TestParse_Every(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		{"@every 7h", time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC), []time.Time{
+			time.Date(2024, 5, 15, 14, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 15, 21, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC), // restarts at midnight
+			time.Date(2024, 5, 16, 7, 0, 0, 0, time.UTC),
+		}},
+		{"@every 25m", time.Date(2024, 5, 15, 23, 40, 0, 0, time.UTC), []time.Time{
+			time.Date(2024, 5, 15, 23, 45, 0, 0, time.UTC),
+			time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 16, 0, 25, 0, 0, time.UTC),
+		}},
+		{"@every 24h", time.Date(2024, 5, 15, 23, 59, 0, 0, time.UTC), []time.Time{
+			time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			require.NoError(t, err)
+			next := tt.from
+			for _, want := range tt.want {
+				next = s.Next(next)
+				assert.Equal(t, want, next)
+			}
+		})
+	}
+
+	loc := time.FixedZone("IST", 5*3600+1800)
+	s, err := Parse("@every 7h")
+	require.NoError(t, err)
+	next := s.Next(time.Date(2024, 5, 15, 19, 0, 0, 0, loc)) // 13:30 UTC
+	assert.Equal(t, time.Date(2024, 5, 15, 14, 0, 0, 0, time.UTC), next.UTC(), "multiples are counted in UTC")
+	assert.Equal(t, loc, next.Location())
+}
+
+func TestParse_Location(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	s, err := Parse("0 6 * * *")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 16, 6, 0, 0, 0, loc), s.Next(time.Date(2024, 5, 15, 6, 0, 0, 0, loc)),
+		"fields match local time")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "10-5 * * * *", "a * * * *", "0 0 30 2 *", "@every 10s", "@every 25h", "@every soon", "@yearly"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -3,7 +3,10 @@ package storage_test
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/storage"
@@ -137,6 +140,8 @@ func TestAcceptance_BoltToSQLiteMigration(t *testing.T) {
 			Location: "https://old.example.com/ep1.mp3",
 			Title:    "Old Episode 1",
 			Size:     1000,
+			Checksum: "d2a84f4b8b650937ec8f73cd8be2c74add5a911ba64df27458ed8229da804a26",
+			Key:      "old/2024/01/old-episode-1-d2a84f4b.mp3",
 		},
 		{
 			Filename: "old-episode2.mp3",
@@ -145,6 +150,8 @@ func TestAcceptance_BoltToSQLiteMigration(t *testing.T) {
 			Location: "https://old.example.com/ep2.mp3",
 			Title:    "Old Episode 2",
 			Size:     2000,
+			Checksum: "7b3d979ca8330a94fa7e9e1b466d8b99e0bcdea1ec90596c0dcc8d7ef6b4300c",
+			Key:      "old/2024/02/old-episode-2-7b3d979c.mp3",
 		},
 	}
 
@@ -222,11 +229,108 @@ func TestAcceptance_BoltToSQLiteMigration(t *testing.T) {
 		if ep.Location == "" {
 			t.Errorf("Episode %s is missing Location", ep.Filename)
 		}
+		if len(ep.Checksum) != 64 {
+			t.Errorf("Episode %s is missing Checksum", ep.Filename)
+		}
+		if !strings.HasSuffix(ep.Key, ep.Checksum[:8]+".mp3") {
+			t.Errorf("Episode %s has wrong Key: %q", ep.Filename, ep.Key)
+		}
 	}
 
 	t.Logf("BoltDB to SQLite migration test PASSED")
 }
 
+// TestAcceptance_MigrationKeepsReplicas verifies that replicas and destination statuses survive a migration
+// in both directions, so mirrored episodes aren't uploaded again and a destination marked down stays down.
+func TestAcceptance_MigrationKeepsReplicas(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		fromType string
+		toType   string
+	}{
+		{"BoltDB to SQLite", "bolt", "sqlite"},
+		{"SQLite to BoltDB", "sqlite", "bolt"},
+	}
+
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	replicas := []*storage.Replica{
+		{Object: "news/ep1.mp3", Destination: "backup", Status: storage.ReplicaFailed, Error: "connection refused", UpdatedAt: at},
+		{Object: "news/ep1.mp3", Destination: "primary", Status: storage.ReplicaUploaded,
+			Location: "https://cdn.example.com/news/ep1.mp3", Checksum: "abc", UpdatedAt: at},
+		{Object: "news/ep2.mp3", Destination: "primary", Status: storage.ReplicaDeleted,
+			Location: "https://cdn.example.com/news/ep2.mp3", UpdatedAt: at.Add(time.Hour)},
+	}
+	down := &storage.DestinationStatus{Name: "primary", Down: true, Reason: "503 Service Unavailable", UpdatedAt: at}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := factory.NewFromStrings(tt.fromType, filepath.Join(tmpDir, tt.fromType+"-to-"+tt.toType+"-src.db"))
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.fromType, err)
+			}
+			if err := src.Open(); err != nil {
+				t.Fatalf("Failed to open source store: %v", err)
+			}
+			defer func() { _ = src.Close() }()
+			dst, err := factory.NewFromStrings(tt.toType, filepath.Join(tmpDir, tt.fromType+"-to-"+tt.toType+"-dst.db"))
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.toType, err)
+			}
+			if err := dst.Open(); err != nil {
+				t.Fatalf("Failed to open destination store: %v", err)
+			}
+			defer func() { _ = dst.Close() }()
+
+			if err := src.SaveEpisode("news", &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded}); err != nil {
+				t.Fatalf("SaveEpisode failed: %v", err)
+			}
+			for _, r := range replicas {
+				if err := src.SaveReplica(r); err != nil {
+					t.Fatalf("SaveReplica failed: %v", err)
+				}
+			}
+			if err := src.SaveDestinationStatus(down); err != nil {
+				t.Fatalf("SaveDestinationStatus failed: %v", err)
+			}
+
+			stats, err := storage.Migrate(src, dst)
+			if err != nil {
+				t.Fatalf("Migration failed: %v", err)
+			}
+			if stats.ReplicasMigrated != len(replicas) {
+				t.Errorf("Expected %d replicas migrated, got %d", len(replicas), stats.ReplicasMigrated)
+			}
+
+			got, err := dst.ListReplicas()
+			if err != nil {
+				t.Fatalf("ListReplicas failed: %v", err)
+			}
+			if len(got) != len(replicas) {
+				t.Fatalf("ListReplicas = %v, want %d replicas", got, len(replicas))
+			}
+			for i, want := range replicas {
+				r := got[i]
+				if r.Object != want.Object || r.Destination != want.Destination || r.Status != want.Status ||
+					r.Location != want.Location || r.Checksum != want.Checksum || r.Error != want.Error ||
+					!r.UpdatedAt.Equal(want.UpdatedAt) {
+					t.Errorf("ListReplicas[%d] = %+v, want %+v", i, r, want)
+				}
+			}
+
+			statuses, err := dst.ListDestinationStatuses()
+			if err != nil {
+				t.Fatalf("ListDestinationStatuses failed: %v", err)
+			}
+			if len(statuses) != 1 || statuses[0].Name != down.Name || !statuses[0].Down ||
+				statuses[0].Reason != down.Reason || !statuses[0].UpdatedAt.Equal(down.UpdatedAt) {
+				t.Errorf("ListDestinationStatuses = %+v, want primary down", statuses)
+			}
+		})
+	}
+}
+
 // TestAcceptance_SQLiteWALMode verifies that SQLite uses WAL mode
 func TestAcceptance_SQLiteWALMode(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -351,3 +455,847 @@ func TestAcceptance_StorageFactory(t *testing.T) {
 		})
 	}
 }
+
+// TestAcceptance_BulkOperations verifies that bulk status changes, bulk saves and
+// episode deletion behave identically on every backend.
+func TestAcceptance_BulkOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "bulk-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "bulk-bolt.db")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			episodes := []*podcast.Episode{
+				{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "session-a", Size: 100},
+				{Filename: "ep2.mp3", Status: podcast.Deleted, Session: "session-a", Size: 200},
+				{Filename: "ep3.mp3", Status: podcast.Uploaded, Session: "session-b", Size: 300},
+				{Filename: "ep4.mp3", Status: podcast.New, Size: 400},
+			}
+			if err := store.SaveEpisodes("bulk", episodes); err != nil {
+				t.Fatalf("SaveEpisodes failed: %v", err)
+			}
+
+			// Roll back one session in a single call
+			changed, err := store.ChangeStatusEpisodesByFilter("bulk", storage.EpisodeFilter{Session: "session-a"}, podcast.New)
+			if err != nil {
+				t.Fatalf("ChangeStatusEpisodesByFilter failed: %v", err)
+			}
+			if changed != 2 {
+				t.Errorf("Expected 2 episodes changed, got %d", changed)
+			}
+
+			newEpisodes, err := store.FindEpisodesByStatus("bulk", podcast.New)
+			if err != nil {
+				t.Fatalf("FindEpisodesByStatus failed: %v", err)
+			}
+			if len(newEpisodes) != 3 {
+				t.Errorf("Expected 3 new episodes, got %d", len(newEpisodes))
+			}
+
+			// Move every remaining uploaded episode to deleted
+			if err := store.ChangeStatusEpisodes("bulk", podcast.Uploaded, podcast.Deleted); err != nil {
+				t.Fatalf("ChangeStatusEpisodes failed: %v", err)
+			}
+			ep3, err := store.GetEpisodeByFilename("bulk", "ep3.mp3")
+			if err != nil {
+				t.Fatalf("GetEpisodeByFilename failed: %v", err)
+			}
+			if ep3.Status != podcast.Deleted || ep3.Session != "session-b" || ep3.Size != 300 {
+				t.Errorf("ep3 after status change = %+v, want Deleted with other fields kept", ep3)
+			}
+
+			// Remove rows
+			deleted, err := store.DeleteEpisodes("bulk", []string{"ep1.mp3", "ep4.mp3", "unknown.mp3"})
+			if err != nil {
+				t.Fatalf("DeleteEpisodes failed: %v", err)
+			}
+			if deleted != 2 {
+				t.Errorf("Expected 2 episodes deleted, got %d", deleted)
+			}
+			if _, err := store.GetEpisodeByFilename("bulk", "ep1.mp3"); err != storage.ErrNotFound {
+				t.Errorf("Expected ErrNotFound for deleted episode, got %v", err)
+			}
+
+			// Operations on unknown podcasts are no-ops
+			changed, err = store.ChangeStatusEpisodesByFilter("missing", storage.EpisodeFilter{}, podcast.New)
+			if err != nil || changed != 0 {
+				t.Errorf("ChangeStatusEpisodesByFilter on missing podcast = (%d, %v), want (0, nil)", changed, err)
+			}
+			deleted, err = store.DeleteEpisodes("missing", []string{"ep1.mp3"})
+			if err != nil || deleted != 0 {
+				t.Errorf("DeleteEpisodes on missing podcast = (%d, %v), want (0, nil)", deleted, err)
+			}
+		})
+	}
+}
+
+// TestAcceptance_QueryEpisodes verifies that every backend returns the same
+// filtered, sorted and paginated results as the in-memory EpisodeQuery reference.
+func TestAcceptance_QueryEpisodes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	backends := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "query-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "query-bolt.db")},
+	}
+
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "s1", Title: "Go generics", Artist: "Alice", Size: 100, PubDate: pubDate("2024-01-05")},
+		{Filename: "ep2.mp3", Status: podcast.Uploaded, Session: "s1", Title: "Rust borrow checker", Artist: "Bob", Size: 300, PubDate: pubDate("2024-02-10")},
+		{Filename: "ep3.mp3", Status: podcast.Deleted, Session: "s2", Title: "Интервью", Artist: "Alice", Size: 200, PubDate: pubDate("2024-03-15")},
+		{Filename: "ep4.mp3", Status: podcast.New, Title: "Go testing", Artist: "Carol", Size: 400},
+		{Filename: "ep5.mp3", Status: podcast.New, Session: "s2", Title: "Zig", Artist: "Dave", Size: 200, PubDate: pubDate("2024-02-10")},
+	}
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	queries := []struct {
+		name  string
+		query storage.EpisodeQuery
+		want  []string
+	}{
+		{"all", storage.EpisodeQuery{}, []string{"ep1.mp3", "ep2.mp3", "ep3.mp3", "ep4.mp3", "ep5.mp3"}},
+		{"status set", storage.EpisodeQuery{EpisodeFilter: storage.EpisodeFilter{Statuses: []podcast.Status{podcast.New, podcast.Deleted}}},
+			[]string{"ep3.mp3", "ep4.mp3", "ep5.mp3"}},
+		{"session", storage.EpisodeQuery{EpisodeFilter: storage.EpisodeFilter{Session: "s2"}}, []string{"ep3.mp3", "ep5.mp3"}},
+		{"text", storage.EpisodeQuery{Text: "go"}, []string{"ep1.mp3", "ep4.mp3"}},
+		{"text unicode", storage.EpisodeQuery{Text: "интервью"}, []string{"ep3.mp3"}},
+		{"text artist", storage.EpisodeQuery{Text: "ALICE"}, []string{"ep1.mp3", "ep3.mp3"}},
+		{"date range", storage.EpisodeQuery{PublishedFrom: day("2024-02-01"), PublishedTo: day("2024-03-15")}, []string{"ep2.mp3", "ep5.mp3"}},
+		{"size range", storage.EpisodeQuery{MinSize: 200, MaxSize: 300}, []string{"ep2.mp3", "ep3.mp3", "ep5.mp3"}},
+		{"filename desc", storage.EpisodeQuery{Desc: true, Limit: 2}, []string{"ep5.mp3", "ep4.mp3"}},
+		{"date asc", storage.EpisodeQuery{SortBy: storage.SortByPubDate}, []string{"ep4.mp3", "ep1.mp3", "ep2.mp3", "ep5.mp3", "ep3.mp3"}},
+		{"date desc paged", storage.EpisodeQuery{SortBy: storage.SortByPubDate, Desc: true, Offset: 1, Limit: 2}, []string{"ep5.mp3", "ep2.mp3"}},
+		{"date with filter", storage.EpisodeQuery{SortBy: storage.SortByPubDate, Text: "alice"}, []string{"ep1.mp3", "ep3.mp3"}},
+		{"size desc", storage.EpisodeQuery{SortBy: storage.SortBySize, Desc: true}, []string{"ep4.mp3", "ep2.mp3", "ep5.mp3", "ep3.mp3", "ep1.mp3"}},
+		{"title", storage.EpisodeQuery{SortBy: storage.SortByTitle, Limit: 3}, []string{"ep1.mp3", "ep4.mp3", "ep2.mp3"}},
+		{"offset past end", storage.EpisodeQuery{Offset: 10}, []string{}},
+	}
+
+	for _, tt := range backends {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			if err := store.SaveEpisodes("query", episodes); err != nil {
+				t.Fatalf("SaveEpisodes failed: %v", err)
+			}
+
+			for _, qt := range queries {
+				got, err := store.QueryEpisodes("query", qt.query)
+				if err != nil {
+					t.Fatalf("%s: QueryEpisodes failed: %v", qt.name, err)
+				}
+				names := make([]string, 0, len(got))
+				for _, ep := range got {
+					names = append(names, ep.Filename)
+				}
+				if !slices.Equal(names, qt.want) {
+					t.Errorf("%s: QueryEpisodes = %v, want %v", qt.name, names, qt.want)
+				}
+			}
+
+			got, err := store.QueryEpisodes("missing", storage.EpisodeQuery{})
+			if err != nil || len(got) != 0 {
+				t.Errorf("QueryEpisodes on missing podcast = (%v, %v), want empty result", got, err)
+			}
+
+			podcasts, err := store.ListPodcasts()
+			if err != nil {
+				t.Fatalf("ListPodcasts failed: %v", err)
+			}
+			if !slices.Equal(podcasts, []string{"query"}) {
+				t.Errorf("ListPodcasts = %v, want [query]", podcasts)
+			}
+		})
+	}
+}
+
+// TestAcceptance_PodcastRecords verifies that podcast records round-trip on every
+// backend and that podcasts known only by their record are listed.
+func TestAcceptance_PodcastRecords(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "podcasts-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "podcasts-bolt.db")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+
+			if _, err := store.GetPodcast("news"); err != storage.ErrPodcastNotFound {
+				t.Errorf("GetPodcast before save error = %v, want ErrPodcastNotFound", err)
+			}
+
+			record := &podcast.Podcast{
+				ID:         "news",
+				LastScanAt: time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC),
+				ImageURL:   "https://cdn.example.com/news/podcast.png",
+			}
+			if err := store.SavePodcast(record); err != nil {
+				t.Fatalf("SavePodcast failed: %v", err)
+			}
+
+			// Saving again replaces the whole record
+			record.LastPublishAt = time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+			record.FeedURL = "https://cdn.example.com/news/feed.rss"
+			record.FeedHash = "deadbeef"
+			if err := store.SavePodcast(record); err != nil {
+				t.Fatalf("SavePodcast failed: %v", err)
+			}
+			if err := store.SaveEpisode("tech", &podcast.Episode{Filename: "ep1.mp3"}); err != nil {
+				t.Fatalf("SaveEpisode failed: %v", err)
+			}
+
+			// Records survive reopening
+			if err := store.Close(); err != nil {
+				t.Fatalf("Failed to close store: %v", err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to reopen store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			got, err := store.GetPodcast("news")
+			if err != nil {
+				t.Fatalf("GetPodcast failed: %v", err)
+			}
+			if got.ID != record.ID || !got.LastScanAt.Equal(record.LastScanAt) || !got.LastPublishAt.Equal(record.LastPublishAt) ||
+				got.ImageURL != record.ImageURL || got.FeedURL != record.FeedURL || got.FeedHash != record.FeedHash {
+				t.Errorf("GetPodcast = %+v, want %+v", got, record)
+			}
+
+			podcasts, err := store.ListPodcasts()
+			if err != nil {
+				t.Fatalf("ListPodcasts failed: %v", err)
+			}
+			if !slices.Equal(podcasts, []string{"news", "tech"}) {
+				t.Errorf("ListPodcasts = %v, want [news tech]", podcasts)
+			}
+		})
+	}
+}
+
+// TestAcceptance_EpisodeHistory verifies that the episode history is append-only,
+// filtered the same way and kept after the episode itself is removed on every backend.
+func TestAcceptance_EpisodeHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "history-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "history-bolt.db")},
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	events := []storage.EpisodeEvent{
+		{Filename: "ep1.mp3", At: start, Operation: storage.OperationScan},
+		{Filename: "ep1.mp3", At: start.Add(time.Minute), Operation: storage.OperationUpload, Session: "s1", Error: "connection reset"},
+		{Filename: "ep2.mp3", At: start.Add(2 * time.Minute), Operation: storage.OperationScan},
+		{Filename: "ep1.mp3", At: start.Add(3 * time.Minute), Operation: storage.OperationUpload,
+			OldStatus: podcast.New, NewStatus: podcast.Uploaded, Session: "s2", Location: "https://cdn.example.com/ep1.mp3"},
+		{Filename: "ep1.mp3", At: start.Add(4 * time.Minute), Operation: storage.OperationDelete,
+			OldStatus: podcast.Uploaded, NewStatus: podcast.Deleted, Session: "s2", Location: "https://cdn.example.com/ep1.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			if err := store.SaveEpisode("show", &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Deleted}); err != nil {
+				t.Fatalf("SaveEpisode failed: %v", err)
+			}
+			if err := store.AppendHistory("show", events[:2]); err != nil {
+				t.Fatalf("AppendHistory failed: %v", err)
+			}
+			if err := store.AppendHistory("show", events[2:]); err != nil {
+				t.Fatalf("AppendHistory failed: %v", err)
+			}
+			if err := store.AppendHistory("other", events[:1]); err != nil {
+				t.Fatalf("AppendHistory failed: %v", err)
+			}
+
+			// History outlives the episode record
+			if _, err := store.DeleteEpisodes("show", []string{"ep1.mp3"}); err != nil {
+				t.Fatalf("DeleteEpisodes failed: %v", err)
+			}
+
+			got, err := store.ListHistory("show", storage.HistoryFilter{})
+			if err != nil {
+				t.Fatalf("ListHistory failed: %v", err)
+			}
+			if len(got) != len(events) {
+				t.Fatalf("ListHistory returned %d events, want %d", len(got), len(events))
+			}
+			for i := range events {
+				want := events[i]
+				if !got[i].At.Equal(want.At) {
+					t.Errorf("event %d At = %v, want %v", i, got[i].At, want.At)
+				}
+				got[i].At = want.At
+				if got[i] != want {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+
+			got, err = store.ListHistory("show", storage.HistoryFilter{Filename: "ep1.mp3", Limit: 2})
+			if err != nil {
+				t.Fatalf("ListHistory failed: %v", err)
+			}
+			if len(got) != 2 || got[0].Operation != storage.OperationUpload || got[1].Operation != storage.OperationDelete {
+				t.Errorf("ListHistory(ep1, limit 2) = %+v, want last upload and delete", got)
+			}
+
+			got, err = store.ListHistory("show", storage.HistoryFilter{Since: start.Add(2 * time.Minute)})
+			if err != nil {
+				t.Fatalf("ListHistory failed: %v", err)
+			}
+			if len(got) != 3 || got[0].Filename != "ep2.mp3" {
+				t.Errorf("ListHistory(since) = %+v, want 3 events starting with ep2.mp3", got)
+			}
+
+			got, err = store.ListHistory("missing", storage.HistoryFilter{})
+			if err != nil || len(got) != 0 {
+				t.Errorf("ListHistory on missing podcast = (%v, %v), want empty result", got, err)
+			}
+		})
+	}
+}
+
+// TestAcceptance_Sessions verifies that upload sessions round-trip and are listed
+// newest first on every backend.
+func TestAcceptance_Sessions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "sessions-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "sessions-bolt.db")},
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			if _, err := store.GetSession("missing"); err != storage.ErrSessionNotFound {
+				t.Errorf("GetSession error = %v, want ErrSessionNotFound", err)
+			}
+
+			running := &storage.Session{
+				ID:         "b2",
+				StartedAt:  start.Add(time.Hour),
+				Host:       "nas",
+				PodcastIDs: []string{"news"},
+				Operation:  storage.OperationRollback,
+				Target:     "a1",
+				Outcome:    storage.SessionRunning,
+			}
+			finished := &storage.Session{
+				ID:         "a1",
+				StartedAt:  start,
+				FinishedAt: start.Add(5 * time.Minute),
+				Host:       "laptop",
+				PodcastIDs: []string{"news", "tech"},
+				Operation:  storage.OperationUpload,
+				Uploaded:   3,
+				Deleted:    2,
+				Failed:     1,
+				Bytes:      3 << 20,
+				Outcome:    storage.SessionPartial,
+				Error:      "upload ep4.mp3: timeout",
+			}
+			for _, session := range []*storage.Session{finished, running} {
+				if err := store.SaveSession(session); err != nil {
+					t.Fatalf("SaveSession failed: %v", err)
+				}
+			}
+
+			// Finishing a session replaces the record
+			running.FinishedAt = start.Add(time.Hour + time.Minute)
+			running.Outcome = storage.SessionSucceeded
+			running.Deleted = 3
+			if err := store.SaveSession(running); err != nil {
+				t.Fatalf("SaveSession failed: %v", err)
+			}
+
+			got, err := store.GetSession("a1")
+			if err != nil {
+				t.Fatalf("GetSession failed: %v", err)
+			}
+			if !got.StartedAt.Equal(finished.StartedAt) || !got.FinishedAt.Equal(finished.FinishedAt) || got.Host != finished.Host ||
+				!slices.Equal(got.PodcastIDs, finished.PodcastIDs) || got.Operation != storage.OperationUpload || got.Target != "" ||
+				got.Uploaded != 3 || got.Deleted != 2 || got.Failed != 1 || got.Bytes != 3<<20 ||
+				got.Outcome != storage.SessionPartial || got.Error != finished.Error {
+				t.Errorf("GetSession = %+v, want %+v", got, finished)
+			}
+
+			sessions, err := store.ListSessions(0)
+			if err != nil {
+				t.Fatalf("ListSessions failed: %v", err)
+			}
+			if len(sessions) != 2 || sessions[0].ID != "b2" || sessions[1].ID != "a1" {
+				t.Fatalf("ListSessions = %+v, want [b2 a1]", sessions)
+			}
+			if sessions[0].Outcome != storage.SessionSucceeded || !sessions[0].FinishedAt.Equal(running.FinishedAt) ||
+				sessions[0].Operation != storage.OperationRollback || sessions[0].Target != "a1" || sessions[0].Deleted != 3 {
+				t.Errorf("ListSessions()[0] = %+v, want finished session", sessions[0])
+			}
+
+			sessions, err = store.ListSessions(1)
+			if err != nil {
+				t.Fatalf("ListSessions failed: %v", err)
+			}
+			if len(sessions) != 1 || sessions[0].ID != "b2" {
+				t.Errorf("ListSessions(1) = %+v, want [b2]", sessions)
+			}
+		})
+	}
+}
+
+func TestAcceptance_MultipartUploads(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "uploads-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "uploads-bolt.db")},
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			if _, err := store.GetMultipartUpload("podcasts", "missing"); err != storage.ErrUploadNotFound {
+				t.Errorf("GetMultipartUpload error = %v, want ErrUploadNotFound", err)
+			}
+
+			newer := &storage.MultipartUpload{
+				Object:    "news/ep2.mp3",
+				Bucket:    "podcasts",
+				UploadID:  "upload-2",
+				StartedAt: start.Add(time.Hour),
+			}
+			upload := &storage.MultipartUpload{
+				Object:    "news/ep1.mp3",
+				Bucket:    "podcasts",
+				UploadID:  "upload-1",
+				FilePath:  "/data/news/ep1.mp3",
+				Size:      12 << 20,
+				ModTime:   start.Add(-time.Hour),
+				SHA256:    "abc",
+				PartSize:  5 << 20,
+				StartedAt: start,
+			}
+			// the same object uploaded to another bucket has a record of its own
+			archived := &storage.MultipartUpload{
+				Object:    "news/ep1.mp3",
+				Bucket:    "archive",
+				UploadID:  "upload-3",
+				StartedAt: start.Add(2 * time.Hour),
+			}
+			for _, u := range []*storage.MultipartUpload{newer, upload, archived} {
+				if err := store.SaveMultipartUpload(u); err != nil {
+					t.Fatalf("SaveMultipartUpload failed: %v", err)
+				}
+			}
+
+			// Every uploaded part replaces the record
+			upload.Parts = []storage.UploadPart{{Number: 1, ETag: "e1", Size: 5 << 20}, {Number: 2, ETag: "e2", Size: 5 << 20}}
+			upload.UpdatedAt = start.Add(time.Minute)
+			if err := store.SaveMultipartUpload(upload); err != nil {
+				t.Fatalf("SaveMultipartUpload failed: %v", err)
+			}
+
+			got, err := store.GetMultipartUpload("podcasts", "news/ep1.mp3")
+			if err != nil {
+				t.Fatalf("GetMultipartUpload failed: %v", err)
+			}
+			if got.Bucket != upload.Bucket || got.UploadID != upload.UploadID || got.FilePath != upload.FilePath ||
+				got.Size != upload.Size || !got.ModTime.Equal(upload.ModTime) || got.SHA256 != upload.SHA256 ||
+				got.PartSize != upload.PartSize || !slices.Equal(got.Parts, upload.Parts) ||
+				!got.StartedAt.Equal(upload.StartedAt) || !got.UpdatedAt.Equal(upload.UpdatedAt) {
+				t.Errorf("GetMultipartUpload = %+v, want %+v", got, upload)
+			}
+
+			uploads, err := store.ListMultipartUploads()
+			if err != nil {
+				t.Fatalf("ListMultipartUploads failed: %v", err)
+			}
+			if len(uploads) != 3 || uploads[0].Object != "news/ep1.mp3" || uploads[1].Object != "news/ep2.mp3" ||
+				uploads[2].Bucket != "archive" {
+				t.Errorf("ListMultipartUploads = %v, want oldest first", uploads)
+			}
+
+			if err := store.DeleteMultipartUpload("podcasts", "news/ep1.mp3"); err != nil {
+				t.Fatalf("DeleteMultipartUpload failed: %v", err)
+			}
+			if err := store.DeleteMultipartUpload("podcasts", "news/unknown.mp3"); err != nil {
+				t.Errorf("DeleteMultipartUpload of unknown object failed: %v", err)
+			}
+			if _, err := store.GetMultipartUpload("podcasts", "news/ep1.mp3"); err != storage.ErrUploadNotFound {
+				t.Errorf("GetMultipartUpload after delete error = %v, want ErrUploadNotFound", err)
+			}
+			if got, err := store.GetMultipartUpload("archive", "news/ep1.mp3"); err != nil || got.UploadID != archived.UploadID {
+				t.Errorf("GetMultipartUpload of the other bucket = %+v, %v, want it kept", got, err)
+			}
+		})
+	}
+}
+
+func TestAcceptance_Replicas(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "replicas-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "replicas-bolt.db")},
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			replicas, err := store.GetReplicas("news/ep1.mp3")
+			if err != nil {
+				t.Fatalf("GetReplicas failed: %v", err)
+			}
+			if len(replicas) != 0 {
+				t.Errorf("GetReplicas of unknown object = %v, want none", replicas)
+			}
+
+			backup := &storage.Replica{
+				Object:      "news/ep1.mp3",
+				Destination: "backup",
+				Status:      storage.ReplicaFailed,
+				Error:       "connection refused",
+				UpdatedAt:   start,
+			}
+			primary := &storage.Replica{
+				Object:      "news/ep1.mp3",
+				Destination: "primary",
+				Status:      storage.ReplicaUploaded,
+				Location:    "https://cdn.example.com/news/ep1.mp3",
+				Checksum:    "abc",
+				UpdatedAt:   start,
+			}
+			// the name of another object starting with the same name isn't mixed in
+			other := &storage.Replica{Object: "news/ep1.mp3.bak", Destination: "backup", Status: storage.ReplicaUploaded}
+			for _, r := range []*storage.Replica{primary, backup, other} {
+				if err := store.SaveReplica(r); err != nil {
+					t.Fatalf("SaveReplica failed: %v", err)
+				}
+			}
+
+			// a successful retry replaces the failed replica
+			backup.Status = storage.ReplicaUploaded
+			backup.Location = "https://backup.example.com/news/ep1.mp3"
+			backup.Error = ""
+			backup.UpdatedAt = start.Add(time.Minute)
+			if err := store.SaveReplica(backup); err != nil {
+				t.Fatalf("SaveReplica failed: %v", err)
+			}
+
+			replicas, err = store.GetReplicas("news/ep1.mp3")
+			if err != nil {
+				t.Fatalf("GetReplicas failed: %v", err)
+			}
+			if len(replicas) != 2 {
+				t.Fatalf("GetReplicas = %v, want 2 replicas", replicas)
+			}
+			for i, want := range []*storage.Replica{backup, primary} {
+				got := replicas[i]
+				if got.Object != want.Object || got.Destination != want.Destination || got.Status != want.Status ||
+					got.Location != want.Location || got.Checksum != want.Checksum || got.Error != want.Error ||
+					!got.UpdatedAt.Equal(want.UpdatedAt) {
+					t.Errorf("GetReplicas[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+
+			all, err := store.ListReplicas()
+			if err != nil {
+				t.Fatalf("ListReplicas failed: %v", err)
+			}
+			if len(all) != 3 || all[0].Destination != "backup" || all[1].Destination != "primary" ||
+				all[2].Object != other.Object {
+				t.Errorf("ListReplicas = %+v, want replicas of news/ep1.mp3 then news/ep1.mp3.bak", all)
+			}
+
+			statuses, err := store.ListDestinationStatuses()
+			if err != nil {
+				t.Fatalf("ListDestinationStatuses failed: %v", err)
+			}
+			if len(statuses) != 0 {
+				t.Errorf("ListDestinationStatuses = %v, want none", statuses)
+			}
+
+			down := &storage.DestinationStatus{Name: "primary", Down: true, Reason: "503 Service Unavailable", UpdatedAt: start}
+			up := &storage.DestinationStatus{Name: "backup", UpdatedAt: start}
+			for _, s := range []*storage.DestinationStatus{down, up} {
+				if err := store.SaveDestinationStatus(s); err != nil {
+					t.Fatalf("SaveDestinationStatus failed: %v", err)
+				}
+			}
+			statuses, err = store.ListDestinationStatuses()
+			if err != nil {
+				t.Fatalf("ListDestinationStatuses failed: %v", err)
+			}
+			if len(statuses) != 2 || statuses[0].Name != "backup" || statuses[0].Down ||
+				statuses[1].Name != "primary" || !statuses[1].Down || statuses[1].Reason != down.Reason ||
+				!statuses[1].UpdatedAt.Equal(start) {
+				t.Errorf("ListDestinationStatuses = %+v, want backup up and primary down", statuses)
+			}
+
+			// marking up replaces the status
+			if err := store.SaveDestinationStatus(&storage.DestinationStatus{Name: "primary", UpdatedAt: start.Add(time.Hour)}); err != nil {
+				t.Fatalf("SaveDestinationStatus failed: %v", err)
+			}
+			statuses, err = store.ListDestinationStatuses()
+			if err != nil {
+				t.Fatalf("ListDestinationStatuses failed: %v", err)
+			}
+			if len(statuses) != 2 || statuses[1].Down || statuses[1].Reason != "" {
+				t.Errorf("ListDestinationStatuses = %+v, want primary up", statuses)
+			}
+		})
+	}
+}
+
+func TestAcceptance_Subscribers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "subscribers-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "subscribers-bolt.db")},
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			if _, err := store.GetSubscriber("news", "alice"); err != storage.ErrSubscriberNotFound {
+				t.Errorf("GetSubscriber of unknown subscriber error = %v, want ErrSubscriberNotFound", err)
+			}
+
+			bob := &storage.Subscriber{PodcastID: "news", Name: "bob", Token: "bob-token", CreatedAt: start}
+			alice := &storage.Subscriber{PodcastID: "news", Name: "alice", Token: "alice-token", CreatedAt: start}
+			// a subscriber of another podcast whose ID starts with the same name isn't mixed in
+			other := &storage.Subscriber{PodcastID: "newsletter", Name: "carol", Token: "carol-token", CreatedAt: start}
+			for _, sub := range []*storage.Subscriber{bob, alice, other} {
+				if err := store.SaveSubscriber(sub); err != nil {
+					t.Fatalf("SaveSubscriber failed: %v", err)
+				}
+			}
+
+			// revoking replaces the subscriber
+			bob.Token = ""
+			bob.RevokedAt = start.Add(time.Hour)
+			if err := store.SaveSubscriber(bob); err != nil {
+				t.Fatalf("SaveSubscriber failed: %v", err)
+			}
+
+			subs, err := store.ListSubscribers("news")
+			if err != nil {
+				t.Fatalf("ListSubscribers failed: %v", err)
+			}
+			if len(subs) != 2 {
+				t.Fatalf("ListSubscribers = %v, want 2 subscribers", subs)
+			}
+			for i, want := range []*storage.Subscriber{alice, bob} {
+				got := subs[i]
+				if got.PodcastID != want.PodcastID || got.Name != want.Name || got.Token != want.Token ||
+					!got.CreatedAt.Equal(want.CreatedAt) || !got.RotatedAt.Equal(want.RotatedAt) || !got.RevokedAt.Equal(want.RevokedAt) {
+					t.Errorf("ListSubscribers[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+			if !subs[0].Active() || subs[1].Active() {
+				t.Errorf("Active = %v, %v, want alice active and bob revoked", subs[0].Active(), subs[1].Active())
+			}
+
+			got, err := store.GetSubscriber("news", "alice")
+			if err != nil {
+				t.Fatalf("GetSubscriber failed: %v", err)
+			}
+			if got.Token != "alice-token" || !got.RotatedAt.IsZero() {
+				t.Errorf("GetSubscriber = %+v, want alice", got)
+			}
+		})
+	}
+}
+
+func TestAcceptance_Runs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		storeType string
+		path      string
+	}{
+		{"SQLite backend", "sqlite", filepath.Join(tmpDir, "runs-sqlite.db")},
+		{"BoltDB backend", "bolt", filepath.Join(tmpDir, "runs-bolt.db")},
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := factory.NewFromStrings(tt.storeType, tt.path)
+			if err != nil {
+				t.Fatalf("Factory failed to create %s: %v", tt.storeType, err)
+			}
+			if err := store.Open(); err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+
+			runs, err := store.ListRuns("", 0)
+			if err != nil {
+				t.Fatalf("ListRuns failed: %v", err)
+			}
+			if len(runs) != 0 {
+				t.Errorf("ListRuns of empty store = %v, want none", runs)
+			}
+
+			scan := &storage.Run{ID: "a1", PodcastID: "news", Job: "scan", StartedAt: start, Outcome: storage.SessionRunning}
+			publish := &storage.Run{ID: "b2", PodcastID: "news", Job: "publish", StartedAt: start.Add(time.Hour),
+				FinishedAt: start.Add(time.Hour + time.Minute), Outcome: storage.SessionFailed, Error: "upload failed"}
+			// a run of another podcast whose ID starts with the same name isn't mixed in
+			other := &storage.Run{ID: "c3", PodcastID: "newsletter", Job: "scan", StartedAt: start.Add(2 * time.Hour),
+				Outcome: storage.SessionSucceeded}
+			for _, run := range []*storage.Run{scan, publish, other} {
+				if err := store.SaveRun(run); err != nil {
+					t.Fatalf("SaveRun failed: %v", err)
+				}
+			}
+
+			// finishing replaces the run
+			scan.FinishedAt = start.Add(time.Minute)
+			scan.Outcome = storage.SessionSucceeded
+			if err := store.SaveRun(scan); err != nil {
+				t.Fatalf("SaveRun failed: %v", err)
+			}
+
+			runs, err = store.ListRuns("news", 0)
+			if err != nil {
+				t.Fatalf("ListRuns failed: %v", err)
+			}
+			if len(runs) != 2 {
+				t.Fatalf("ListRuns(news) = %v, want 2 runs", runs)
+			}
+			for i, want := range []*storage.Run{publish, scan} {
+				got := runs[i]
+				if got.ID != want.ID || got.PodcastID != want.PodcastID || got.Job != want.Job || got.Outcome != want.Outcome ||
+					got.Error != want.Error || !got.StartedAt.Equal(want.StartedAt) || !got.FinishedAt.Equal(want.FinishedAt) {
+					t.Errorf("ListRuns[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+
+			runs, err = store.ListRuns("", 1)
+			if err != nil {
+				t.Fatalf("ListRuns failed: %v", err)
+			}
+			if len(runs) != 1 || runs[0].ID != "c3" {
+				t.Errorf("ListRuns(\"\", 1) = %+v, want [c3]", runs)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@
 package bolt
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +21,39 @@ import (
 	"podgen/internal/storage"
 )
 
+// Internal buckets live next to podcast buckets at the top level and share this prefix.
+// ListPodcasts skips them so they never show up as podcasts.
+const internalBucketPrefix = "__podgen_"
+
+// pubDateIndexBucket holds one nested bucket per podcast with keys ordered by
+// publication time and filename, used for date range queries and date sorting.
+var pubDateIndexBucket = []byte(internalBucketPrefix + "idx_pubdate")
+
+// podcastsBucket holds JSON-encoded podcast records keyed by podcast ID.
+var podcastsBucket = []byte(internalBucketPrefix + "podcasts")
+
+// historyBucket holds one nested bucket per podcast with JSON-encoded episode events
+// keyed by a big-endian sequence number, so cursor order is the order of appends.
+var historyBucket = []byte(internalBucketPrefix + "history")
+
+// sessionsBucket holds JSON-encoded upload sessions keyed by session ID.
+var sessionsBucket = []byte(internalBucketPrefix + "sessions")
+
+// uploadsBucket holds JSON-encoded multipart uploads keyed by bucket and object name, separated by a zero byte.
+var uploadsBucket = []byte(internalBucketPrefix + "uploads")
+
+// replicasBucket holds JSON-encoded replicas keyed by object name and destination, separated by a zero byte.
+var replicasBucket = []byte(internalBucketPrefix + "replicas")
+
+// destinationsBucket holds JSON-encoded destination statuses keyed by name.
+var destinationsBucket = []byte(internalBucketPrefix + "destinations")
+
+// subscribersBucket holds JSON-encoded subscribers keyed by podcast ID and name, separated by a zero byte.
+var subscribersBucket = []byte(internalBucketPrefix + "subscribers")
+
+// runsBucket holds JSON-encoded runs of scheduled jobs keyed by podcast ID and run ID, separated by a zero byte.
+var runsBucket = []byte(internalBucketPrefix + "runs")
+
 // Store implements storage.Store using BoltDB.
 type Store struct {
 	db     *bolt.DB
@@ -50,10 +87,86 @@ func (s *Store) Open() error {
 	}
 
 	s.db = db
+
+	if err := s.ensureIndexes(); err != nil {
+		_ = db.Close()
+		s.db = nil
+		return fmt.Errorf("failed to build indexes: %w", err)
+	}
+
+	if err := s.rekeyUploads(); err != nil {
+		_ = db.Close()
+		s.db = nil
+		return fmt.Errorf("failed to rekey multipart uploads: %w", err)
+	}
+
 	log.Printf("[INFO] BoltDB store opened: %s", s.dsn)
 	return nil
 }
 
+// rekeyUploads moves multipart uploads recorded by object name alone, before uploads were keyed by bucket too.
+func (s *Store) rekeyUploads() error {
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(uploadsBucket)
+		if bucket == nil {
+			return nil
+		}
+		legacy := map[string][]byte{}
+		err := bucket.ForEach(func(k, v []byte) error {
+			if !bytes.Contains(k, []byte{0}) {
+				legacy[string(k)] = v
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for key, value := range legacy {
+			upload := &storage.MultipartUpload{}
+			if err := json.Unmarshal(value, upload); err != nil {
+				return fmt.Errorf("failed to unmarshal multipart upload %s: %w", key, err)
+			}
+			if err := bucket.Put(replicaKey(upload.Bucket, upload.Object), value); err != nil {
+				return err
+			}
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ensureIndexes builds secondary indexes for databases created before they existed.
+func (s *Store) ensureIndexes() error {
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		if tx.Bucket(pubDateIndexBucket) != nil {
+			return nil
+		}
+		idx, err := tx.CreateBucket(pubDateIndexBucket)
+		if err != nil {
+			return err
+		}
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if isInternalBucket(name) {
+				return nil
+			}
+			podcastIdx, err := idx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+			return b.ForEach(func(k, v []byte) error {
+				item := podcast.Episode{}
+				if err := json.Unmarshal(v, &item); err != nil {
+					log.Printf("[WARN] failed to unmarshal, %v", err)
+					return nil
+				}
+				return podcastIdx.Put(pubDateIndexKey(&item), nil)
+			})
+		})
+	})
+}
+
 // Close releases all database resources.
 func (s *Store) Close() error {
 	if s.db == nil {
@@ -99,12 +212,70 @@ func (s *Store) saveEpisode(tx *bolt.Tx, podcastID string, episode *podcast.Epis
 		return err
 	}
 
+	if err := s.removeFromIndex(tx, podcastID, bucket.Get(key)); err != nil {
+		return err
+	}
+
 	jdata, err := json.Marshal(episode)
 	if err != nil {
 		return err
 	}
 
-	return bucket.Put(key, jdata)
+	if err := bucket.Put(key, jdata); err != nil {
+		return err
+	}
+
+	idx, err := s.podcastIndex(tx, podcastID)
+	if err != nil {
+		return err
+	}
+	return idx.Put(pubDateIndexKey(episode), nil)
+}
+
+// podcastIndex returns the publication date index bucket of a podcast, creating it if needed.
+func (s *Store) podcastIndex(tx *bolt.Tx, podcastID string) (*bolt.Bucket, error) {
+	idx, err := tx.CreateBucketIfNotExists(pubDateIndexBucket)
+	if err != nil {
+		return nil, err
+	}
+	return idx.CreateBucketIfNotExists([]byte(podcastID))
+}
+
+// removeFromIndex deletes the index entry of a stored episode record, if any.
+func (s *Store) removeFromIndex(tx *bolt.Tx, podcastID string, record []byte) error {
+	if record == nil {
+		return nil
+	}
+	idx := tx.Bucket(pubDateIndexBucket)
+	if idx == nil {
+		return nil
+	}
+	podcastIdx := idx.Bucket([]byte(podcastID))
+	if podcastIdx == nil {
+		return nil
+	}
+	old := podcast.Episode{}
+	if err := json.Unmarshal(record, &old); err != nil {
+		log.Printf("[WARN] failed to unmarshal, %v", err)
+		return nil
+	}
+	return podcastIdx.Delete(pubDateIndexKey(&old))
+}
+
+// pubDateIndexKey builds an index key that sorts by publication time, then filename.
+// Episodes without a parseable date sort first.
+func pubDateIndexKey(episode *podcast.Episode) []byte {
+	key := make([]byte, 8, 8+len(episode.Filename))
+	if published := episode.PublishedAt(); !published.IsZero() {
+		// flip the sign bit so negative timestamps sort before positive ones
+		binary.BigEndian.PutUint64(key, uint64(published.Unix())^(1<<63)) //nolint:gosec // intentional bit reinterpretation
+	}
+	return append(key, episode.Filename...)
+}
+
+// isInternalBucket reports whether a top-level bucket is used internally rather than by a podcast.
+func isInternalBucket(name []byte) bool {
+	return bytes.HasPrefix(name, []byte(internalBucketPrefix))
 }
 
 // FindEpisodesByStatus retrieves all episodes with the given status.
@@ -185,41 +356,188 @@ func (s *Store) findEpisodesBySession(tx *bolt.Tx, podcastID, session string) ([
 	return result, nil
 }
 
+// QueryEpisodes retrieves episodes matching the query, sorted and paginated.
+// Filename order walks the podcast bucket and date order walks the publication date
+// index, so both stop as soon as the requested page is filled. Other orders scan
+// all episodes and sort them in memory.
+func (s *Store) QueryEpisodes(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	result := []*podcast.Episode{}
+	err := s.WithReadTx(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(podcastID))
+		if bucket == nil {
+			return nil
+		}
+
+		var podcastIdx *bolt.Bucket
+		if idx := tx.Bucket(pubDateIndexBucket); idx != nil {
+			podcastIdx = idx.Bucket([]byte(podcastID))
+		}
+
+		episodeRecord := func(_, v []byte) []byte { return v }
+
+		switch {
+		case q.SortBy == "" || q.SortBy == storage.SortByFilename:
+			result = s.scanOrdered(bucket.Cursor(), q, episodeRecord)
+		case q.SortBy == storage.SortByPubDate && podcastIdx != nil:
+			result = s.scanOrdered(podcastIdx.Cursor(), q, func(k, _ []byte) []byte {
+				return bucket.Get(k[8:])
+			})
+		default:
+			unpaged := q
+			unpaged.Desc, unpaged.Limit, unpaged.Offset = false, 0, 0
+			matched := s.scanOrdered(bucket.Cursor(), unpaged, episodeRecord)
+			q.Sort(matched)
+			result = q.Page(matched)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// scanOrdered walks the cursor in key order (reversed if q.Desc), loads each episode
+// through record and collects matches until the page defined by Offset and Limit is full.
+func (s *Store) scanOrdered(c *bolt.Cursor, q storage.EpisodeQuery, record func(k, v []byte) []byte) []*podcast.Episode {
+	first, next := c.First, c.Next
+	if q.Desc {
+		first, next = c.Last, c.Prev
+	}
+
+	result := []*podcast.Episode{}
+	skipped := 0
+	for k, v := first(); k != nil; k, v = next() {
+		data := record(k, v)
+		if data == nil {
+			continue
+		}
+		item := podcast.Episode{}
+		if err := json.Unmarshal(data, &item); err != nil {
+			log.Printf("[WARN] failed to unmarshal, %v", err)
+			continue
+		}
+		if !q.Match(&item) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		result = append(result, &item)
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+	}
+	return result
+}
+
 // ChangeStatusEpisodes changes the status of all episodes matching fromStatus to toStatus.
 func (s *Store) ChangeStatusEpisodes(podcastID string, fromStatus, toStatus podcast.Status) error {
+	_, err := s.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{Statuses: []podcast.Status{fromStatus}}, toStatus)
+	return err
+}
+
+// ChangeStatusEpisodesByFilter sets toStatus on all episodes matching the filter.
+// Returns the number of episodes changed.
+func (s *Store) ChangeStatusEpisodesByFilter(podcastID string, filter storage.EpisodeFilter, toStatus podcast.Status) (int, error) {
 	if s.db == nil {
-		return storage.ErrClosed
+		return 0, storage.ErrClosed
 	}
 
-	return s.db.Batch(func(tx *bolt.Tx) error {
+	var changed int
+	err := s.WithWriteTx(func(tx *bolt.Tx) error {
+		changed = 0
 		bucket := tx.Bucket([]byte(podcastID))
 		if bucket == nil {
-			return &apperrors.EpisodeError{PodcastID: podcastID, Op: "ChangeStatusEpisodes", Err: apperrors.ErrNoBucket}
+			// Nothing to change for new podcasts (no bucket yet), matching SQLite behavior
+			return nil
 		}
 
 		c := bucket.Cursor()
-		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+		for k, v := c.First(); k != nil; k, v = c.Next() {
 			item := podcast.Episode{}
 			if err := json.Unmarshal(v, &item); err != nil {
 				log.Printf("[WARN] failed to unmarshal, %v", err)
 				continue
 			}
-			if item.Status != fromStatus {
+			if !filter.Match(&item) {
 				continue
 			}
 
 			item.Status = toStatus
-			jdata, jerr := json.Marshal(&item)
-			if jerr != nil {
-				return jerr
+			jdata, err := json.Marshal(&item)
+			if err != nil {
+				return err
 			}
-
 			if err := bucket.Put(k, jdata); err != nil {
 				return err
 			}
+			changed++
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// SaveEpisodes persists several episodes in a single transaction.
+func (s *Store) SaveEpisodes(podcastID string, episodes []*podcast.Episode) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+	if len(episodes) == 0 {
+		return nil
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		for _, episode := range episodes {
+			if err := s.saveEpisode(tx, podcastID, episode); err != nil {
+				return fmt.Errorf("failed to save episode %s: %w", episode.Filename, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteEpisodes removes episode records by filename.
+// Returns the number of episodes removed; unknown filenames are ignored.
+func (s *Store) DeleteEpisodes(podcastID string, filenames []string) (int, error) {
+	if s.db == nil {
+		return 0, storage.ErrClosed
+	}
+	if len(filenames) == 0 {
+		return 0, nil
+	}
+
+	var deleted int
+	err := s.WithWriteTx(func(tx *bolt.Tx) error {
+		deleted = 0
+		bucket := tx.Bucket([]byte(podcastID))
+		if bucket == nil {
+			return nil
+		}
+
+		for _, filename := range filenames {
+			key, err := s.getEpisodeKeyByFilename(filename)
+			if err != nil {
+				return err
+			}
+			record := bucket.Get(key)
+			if record == nil {
+				continue
+			}
+			if err := s.removeFromIndex(tx, podcastID, record); err != nil {
+				return err
+			}
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+			deleted++
 		}
 		return nil
 	})
+	return deleted, err
 }
 
 // FindEpisodesBySizeLimit retrieves episodes up to a total size limit.
@@ -387,61 +705,657 @@ func (s *Store) getLastEpisodeByNotStatusInTx(tx *bolt.Tx, podcastID string, sta
 	return nil, nil
 }
 
-// ListPodcasts returns all podcast IDs in the store.
-func (s *Store) ListPodcasts() ([]string, error) {
+// GetPodcast retrieves the podcast record.
+func (s *Store) GetPodcast(podcastID string) (*podcast.Podcast, error) {
 	if s.db == nil {
 		return nil, storage.ErrClosed
 	}
 
-	var podcasts []string
+	var record *podcast.Podcast
 	err := s.db.View(func(tx *bolt.Tx) error {
-		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
-			podcasts = append(podcasts, string(name))
-			return nil
-		})
+		bucket := tx.Bucket(podcastsBucket)
+		if bucket == nil {
+			return storage.ErrPodcastNotFound
+		}
+		value := bucket.Get([]byte(podcastID))
+		if value == nil {
+			return storage.ErrPodcastNotFound
+		}
+		record = &podcast.Podcast{}
+		if err := json.Unmarshal(value, record); err != nil {
+			return fmt.Errorf("failed to unmarshal podcast %s: %w", podcastID, err)
+		}
+		return nil
 	})
-	return podcasts, err
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
 }
 
-// ListEpisodes returns all episodes for a podcast.
-func (s *Store) ListEpisodes(podcastID string) ([]*podcast.Episode, error) {
+// SavePodcast creates or replaces the podcast record.
+func (s *Store) SavePodcast(p *podcast.Podcast) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal podcast %s: %w", p.ID, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(podcastsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(p.ID), value)
+	})
+}
+
+// AppendHistory records events of podcast episodes in the given order.
+func (s *Store) AppendHistory(podcastID string, events []storage.EpisodeEvent) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		history, err := tx.CreateBucketIfNotExists(historyBucket)
+		if err != nil {
+			return err
+		}
+		bucket, err := history.CreateBucketIfNotExists([]byte(podcastID))
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(&events[i])
+			if err != nil {
+				return fmt.Errorf("failed to marshal history of %s: %w", events[i].Filename, err)
+			}
+			if err := bucket.Put(binary.BigEndian.AppendUint64(nil, seq), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListHistory returns recorded events of podcast matching the filter, oldest first.
+func (s *Store) ListHistory(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
 	if s.db == nil {
 		return nil, storage.ErrClosed
 	}
 
-	var episodes []*podcast.Episode
+	events := []storage.EpisodeEvent{}
 	err := s.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(podcastID))
+		history := tx.Bucket(historyBucket)
+		if history == nil {
+			return nil
+		}
+		bucket := history.Bucket([]byte(podcastID))
 		if bucket == nil {
 			return nil
 		}
 
+		// walk from the latest entry so Limit can stop the scan early
 		c := bucket.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			item := podcast.Episode{}
-			if err := json.Unmarshal(v, &item); err != nil {
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e storage.EpisodeEvent
+			if err := json.Unmarshal(v, &e); err != nil {
 				log.Printf("[WARN] failed to unmarshal, %v", err)
 				continue
 			}
-			episodes = append(episodes, &item)
+			if !filter.Match(&e) {
+				continue
+			}
+			events = append(events, e)
+			if filter.Limit > 0 && len(events) == filter.Limit {
+				break
+			}
 		}
 		return nil
 	})
-	return episodes, err
+	slices.Reverse(events)
+	return events, err
 }
 
-// DB returns the underlying BoltDB instance for advanced operations.
-// This is provided for backward compatibility and migration purposes.
-func (s *Store) DB() *bolt.DB {
-	return s.db
+// SaveSession creates or replaces the session record.
+func (s *Store) SaveSession(session *storage.Session) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session %s: %w", session.ID, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(session.ID), value)
+	})
 }
 
-func (s *Store) getEpisodeKey(episode *podcast.Episode) ([]byte, error) {
-	return s.getEpisodeKeyByFilename(episode.Filename)
+// GetSession retrieves a session by ID.
+func (s *Store) GetSession(id string) (*storage.Session, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	var session *storage.Session
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		if bucket == nil {
+			return storage.ErrSessionNotFound
+		}
+		value := bucket.Get([]byte(id))
+		if value == nil {
+			return storage.ErrSessionNotFound
+		}
+		session = &storage.Session{}
+		if err := json.Unmarshal(value, session); err != nil {
+			return fmt.Errorf("failed to unmarshal session %s: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
-func (s *Store) getEpisodeKeyByFilename(filename string) ([]byte, error) {
-	return []byte(filename), nil
+// ListSessions returns recorded sessions, most recently started first.
+// Sessions are keyed by ID, so all of them are loaded and sorted in memory.
+func (s *Store) ListSessions(limit int) ([]*storage.Session, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	sessions := []*storage.Session{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			session := &storage.Session{}
+			if err := json.Unmarshal(v, session); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				return nil
+			}
+			sessions = append(sessions, session)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(sessions, func(a, b *storage.Session) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+// SaveMultipartUpload creates or replaces the upload record.
+func (s *Store) SaveMultipartUpload(upload *storage.MultipartUpload) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal multipart upload %s: %w", upload.Object, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(uploadsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(replicaKey(upload.Bucket, upload.Object), value)
+	})
+}
+
+// GetMultipartUpload retrieves the upload of the object in the bucket.
+func (s *Store) GetMultipartUpload(bucketName, object string) (*storage.MultipartUpload, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	var upload *storage.MultipartUpload
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(uploadsBucket)
+		if bucket == nil {
+			return storage.ErrUploadNotFound
+		}
+		value := bucket.Get(replicaKey(bucketName, object))
+		if value == nil {
+			return storage.ErrUploadNotFound
+		}
+		upload = &storage.MultipartUpload{}
+		if err := json.Unmarshal(value, upload); err != nil {
+			return fmt.Errorf("failed to unmarshal multipart upload %s: %w", object, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// DeleteMultipartUpload removes the upload record.
+func (s *Store) DeleteMultipartUpload(bucketName, object string) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(uploadsBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete(replicaKey(bucketName, object))
+	})
+}
+
+// ListMultipartUploads returns all recorded uploads, oldest first.
+func (s *Store) ListMultipartUploads() ([]*storage.MultipartUpload, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	uploads := []*storage.MultipartUpload{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(uploadsBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			upload := &storage.MultipartUpload{}
+			if err := json.Unmarshal(v, upload); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				return nil
+			}
+			uploads = append(uploads, upload)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(uploads, func(a, b *storage.MultipartUpload) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Object, b.Object)
+	})
+	return uploads, nil
+}
+
+// SaveReplica creates or replaces the replica of the object at its destination.
+func (s *Store) SaveReplica(replica *storage.Replica) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(replica)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replica %s: %w", replica.Object, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(replicasBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(replicaKey(replica.Object, replica.Destination), value)
+	})
+}
+
+// GetReplicas returns the replicas of the object, ordered by destination.
+func (s *Store) GetReplicas(object string) ([]*storage.Replica, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	replicas := []*storage.Replica{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(replicasBucket)
+		if bucket == nil {
+			return nil
+		}
+		prefix := replicaKey(object, "")
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			replica := &storage.Replica{}
+			if err := json.Unmarshal(v, replica); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				continue
+			}
+			replicas = append(replicas, replica)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return replicas, nil
+}
+
+// ListReplicas returns the replicas of all objects, ordered by object and destination.
+func (s *Store) ListReplicas() ([]*storage.Replica, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	replicas := []*storage.Replica{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(replicasBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			replica := &storage.Replica{}
+			if err := json.Unmarshal(v, replica); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				return nil
+			}
+			replicas = append(replicas, replica)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return replicas, nil
+}
+
+// replicaKey separates the object name from the destination with a zero byte,
+// so the replicas of an object are adjacent and ordered by destination.
+func replicaKey(object, destination string) []byte {
+	return []byte(object + "\x00" + destination)
+}
+
+// SaveSubscriber creates or replaces the subscriber of the podcast with the same name.
+func (s *Store) SaveSubscriber(sub *storage.Subscriber) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscriber %s: %w", sub.Name, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(subscribersBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(replicaKey(sub.PodcastID, sub.Name), value)
+	})
+}
+
+// GetSubscriber returns the subscriber of the podcast.
+func (s *Store) GetSubscriber(podcastID, name string) (*storage.Subscriber, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	var sub *storage.Subscriber
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscribersBucket)
+		if bucket == nil {
+			return storage.ErrSubscriberNotFound
+		}
+		value := bucket.Get(replicaKey(podcastID, name))
+		if value == nil {
+			return storage.ErrSubscriberNotFound
+		}
+		sub = &storage.Subscriber{}
+		return json.Unmarshal(value, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListSubscribers returns the subscribers of the podcast ordered by name, revoked ones included.
+func (s *Store) ListSubscribers(podcastID string) ([]*storage.Subscriber, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	subs := []*storage.Subscriber{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(subscribersBucket)
+		if bucket == nil {
+			return nil
+		}
+		prefix := replicaKey(podcastID, "")
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			sub := &storage.Subscriber{}
+			if err := json.Unmarshal(v, sub); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				continue
+			}
+			subs = append(subs, sub)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// SaveDestinationStatus creates or replaces the status of the destination.
+func (s *Store) SaveDestinationStatus(status *storage.DestinationStatus) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("failed to marshal destination status %s: %w", status.Name, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(destinationsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(status.Name), value)
+	})
+}
+
+// ListDestinationStatuses returns the recorded statuses of all destinations, ordered by name.
+func (s *Store) ListDestinationStatuses() ([]*storage.DestinationStatus, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	statuses := []*storage.DestinationStatus{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(destinationsBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			status := &storage.DestinationStatus{}
+			if err := json.Unmarshal(v, status); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				return nil
+			}
+			statuses = append(statuses, status)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// ListPodcasts returns IDs of all podcasts having episodes or a podcast record.
+func (s *Store) ListPodcasts() ([]string, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	var podcasts []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if isInternalBucket(name) {
+				return nil
+			}
+			podcasts = append(podcasts, string(name))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		records := tx.Bucket(podcastsBucket)
+		if records == nil {
+			return nil
+		}
+		return records.ForEach(func(k, _ []byte) error {
+			if tx.Bucket(k) == nil {
+				podcasts = append(podcasts, string(k))
+			}
+			return nil
+		})
+	})
+	slices.Sort(podcasts)
+	return podcasts, err
+}
+
+// ListEpisodes returns all episodes for a podcast.
+func (s *Store) ListEpisodes(podcastID string) ([]*podcast.Episode, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	var episodes []*podcast.Episode
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(podcastID))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			item := podcast.Episode{}
+			if err := json.Unmarshal(v, &item); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				continue
+			}
+			episodes = append(episodes, &item)
+		}
+		return nil
+	})
+	return episodes, err
+}
+
+// DB returns the underlying BoltDB instance for advanced operations.
+// This is provided for backward compatibility and migration purposes.
+func (s *Store) DB() *bolt.DB {
+	return s.db
+}
+
+func (s *Store) getEpisodeKey(episode *podcast.Episode) ([]byte, error) {
+	return s.getEpisodeKeyByFilename(episode.Filename)
+}
+
+func (s *Store) getEpisodeKeyByFilename(filename string) ([]byte, error) {
+	return []byte(filename), nil
+}
+
+// SaveRun creates or replaces the run record.
+func (s *Store) SaveRun(run *storage.Run) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	value, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run %s: %w", run.ID, err)
+	}
+
+	return s.WithWriteTx(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(replicaKey(run.PodcastID, run.ID), value)
+	})
+}
+
+// ListRuns returns recorded runs, most recently started first.
+// Runs are keyed by podcast and ID, so the selected ones are loaded and sorted in memory.
+func (s *Store) ListRuns(podcastID string, limit int) ([]*storage.Run, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	runs := []*storage.Run{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		if bucket == nil {
+			return nil
+		}
+		var prefix []byte
+		if podcastID != "" {
+			prefix = replicaKey(podcastID, "")
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			run := &storage.Run{}
+			if err := json.Unmarshal(v, run); err != nil {
+				log.Printf("[WARN] failed to unmarshal, %v", err)
+				continue
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(runs, func(a, b *storage.Run) int {
+		if c := b.StartedAt.Compare(a.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
 }
 
 // Verify Store implements storage.Store interface.
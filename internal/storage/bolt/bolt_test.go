@@ -3,9 +3,11 @@ package bolt_test
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
 
 	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/storage"
@@ -275,6 +277,194 @@ func TestChangeStatusEpisodes(t *testing.T) {
 	assert.Len(t, newEps, 0)
 }
 
+func TestChangeStatusEpisodesByFilter(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "s1"},
+		{Filename: "ep2.mp3", Status: podcast.Deleted, Session: "s1"},
+		{Filename: "ep3.mp3", Status: podcast.Uploaded, Session: "s2"},
+	}
+	require.NoError(t, store.SaveEpisodes(podcastID, episodes))
+
+	changed, err := store.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{Session: "s1"}, podcast.New)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	newEps, err := store.FindEpisodesByStatus(podcastID, podcast.New)
+	require.NoError(t, err)
+	assert.Len(t, newEps, 2)
+
+	changed, err = store.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{
+		Statuses:  []podcast.Status{podcast.Uploaded},
+		Filenames: []string{"ep1.mp3", "ep3.mp3"},
+	}, podcast.Deleted)
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+
+	ep3, err := store.GetEpisodeByFilename(podcastID, "ep3.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, podcast.Deleted, ep3.Status)
+	assert.Equal(t, "s2", ep3.Session)
+}
+
+func TestChangeStatusEpisodesByFilterNoBucket(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	changed, err := store.ChangeStatusEpisodesByFilter("nonexistent", storage.EpisodeFilter{}, podcast.New)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+}
+
+func TestSaveEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	require.NoError(t, store.SaveEpisodes(podcastID, nil))
+
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.New, Size: 100},
+		{Filename: "ep2.mp3", Status: podcast.Uploaded, Size: 200},
+	}
+	require.NoError(t, store.SaveEpisodes(podcastID, episodes))
+
+	list, err := store.ListEpisodes(podcastID)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+func TestDeleteEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	require.NoError(t, store.SaveEpisodes(podcastID, []*podcast.Episode{
+		{Filename: "ep1.mp3"},
+		{Filename: "ep2.mp3"},
+		{Filename: "ep3.mp3"},
+	}))
+
+	deleted, err := store.DeleteEpisodes(podcastID, []string{"ep1.mp3", "ep3.mp3", "missing.mp3"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	list, err := store.ListEpisodes(podcastID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "ep2.mp3", list[0].Filename)
+
+	deleted, err = store.DeleteEpisodes("nonexistent", []string{"ep1.mp3"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
+
+func TestQueryEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Size: 100, PubDate: "Mon, 01 Jan 2024 10:00:00 +0000"},
+		{Filename: "ep2.mp3", Status: podcast.New, Size: 200, PubDate: "Tue, 02 Jan 2024 10:00:00 +0000"},
+		{Filename: "ep3.mp3", Status: podcast.Uploaded, Size: 300},
+	}
+	require.NoError(t, store.SaveEpisodes(podcastID, episodes))
+
+	byDateDesc := storage.EpisodeQuery{SortBy: storage.SortByPubDate, Desc: true}
+
+	got, err := store.QueryEpisodes(podcastID, byDateDesc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ep2.mp3", "ep1.mp3", "ep3.mp3"}, filenames(got))
+
+	// Changing the publication date moves the episode in the index
+	episodes[0].PubDate = "Wed, 03 Jan 2024 10:00:00 +0000"
+	require.NoError(t, store.SaveEpisode(podcastID, episodes[0]))
+	got, err = store.QueryEpisodes(podcastID, byDateDesc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ep1.mp3", "ep2.mp3", "ep3.mp3"}, filenames(got))
+
+	// Deleted episodes disappear from the index
+	_, err = store.DeleteEpisodes(podcastID, []string{"ep1.mp3"})
+	require.NoError(t, err)
+	got, err = store.QueryEpisodes(podcastID, byDateDesc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ep2.mp3", "ep3.mp3"}, filenames(got))
+
+	// The index bucket is not reported as a podcast
+	podcasts, err := store.ListPodcasts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{podcastID}, podcasts)
+}
+
+func TestQueryEpisodesRebuildsIndex(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	cfg := storage.Config{Type: storage.TypeBolt, DSN: dbPath}
+
+	store := boltstore.New(cfg)
+	require.NoError(t, store.Open())
+	require.NoError(t, store.SaveEpisodes("legacy", []*podcast.Episode{
+		{Filename: "a.mp3", PubDate: "Mon, 01 Jul 2024 10:00:00 +0000"},
+		{Filename: "b.mp3", PubDate: "Mon, 01 Jan 2024 10:00:00 +0000"},
+	}))
+
+	// Simulate a database written before the index existed
+	require.NoError(t, store.DB().Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte("__podgen_idx_pubdate"))
+	}))
+	require.NoError(t, store.Close())
+
+	store = boltstore.New(cfg)
+	require.NoError(t, store.Open())
+	defer func() { _ = store.Close() }()
+
+	got, err := store.QueryEpisodes("legacy", storage.EpisodeQuery{SortBy: storage.SortByPubDate, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.mp3"}, filenames(got))
+}
+
+func TestMultipartUploadsRekeyedByBucket(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	cfg := storage.Config{Type: storage.TypeBolt, DSN: dbPath}
+
+	store := boltstore.New(cfg)
+	require.NoError(t, store.Open())
+
+	// Simulate a database with multipart uploads keyed by object alone
+	require.NoError(t, store.DB().Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte("__podgen_uploads"))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte("news/ep1.mp3"), []byte(`{"Bucket":"podcasts","Object":"news/ep1.mp3","UploadID":"upload-1"}`))
+	}))
+	require.NoError(t, store.Close())
+
+	store = boltstore.New(cfg)
+	require.NoError(t, store.Open())
+	defer func() { _ = store.Close() }()
+
+	got, err := store.GetMultipartUpload("podcasts", "news/ep1.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "upload-1", got.UploadID)
+
+	require.NoError(t, store.DeleteMultipartUpload("podcasts", "news/ep1.mp3"))
+	uploads, err := store.ListMultipartUploads()
+	require.NoError(t, err)
+	assert.Empty(t, uploads, "the legacy record is gone")
+}
+
+func filenames(episodes []*podcast.Episode) []string {
+	names := make([]string, 0, len(episodes))
+	for _, ep := range episodes {
+		names = append(names, ep.Filename)
+	}
+	return names
+}
+
 func TestFindEpisodesBySizeLimit(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -465,6 +655,28 @@ func TestListPodcasts(t *testing.T) {
 	assert.Len(t, podcasts, 2)
 }
 
+func TestSavePodcast(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	_, err := store.GetPodcast("podcast-a")
+	assert.Equal(t, storage.ErrPodcastNotFound, err)
+
+	record := &podcast.Podcast{ID: "podcast-a", LastScanAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), FeedHash: "abc"}
+	require.NoError(t, store.SavePodcast(record))
+	require.NoError(t, store.SaveEpisode("podcast-a", &podcast.Episode{Filename: "ep1.mp3"}))
+	require.NoError(t, store.SavePodcast(&podcast.Podcast{ID: "podcast-b"}))
+
+	got, err := store.GetPodcast("podcast-a")
+	require.NoError(t, err)
+	assert.Equal(t, record, got)
+
+	// Each podcast is listed once, internal buckets are hidden
+	podcasts, err := store.ListPodcasts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"podcast-a", "podcast-b"}, podcasts)
+}
+
 func TestListEpisodes(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -534,6 +746,30 @@ func TestOperationsOnClosedStore(t *testing.T) {
 	assert.Equal(t, storage.ErrClosed, err)
 
 	assert.Equal(t, storage.ErrClosed, store.ChangeStatusEpisodes(podcastID, podcast.New, podcast.Uploaded))
+	assert.Equal(t, storage.ErrClosed, store.SaveEpisodes(podcastID, []*podcast.Episode{{Filename: "ep1.mp3"}}))
+
+	_, err = store.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{}, podcast.New)
+	assert.Equal(t, storage.ErrClosed, err)
+
+	_, err = store.DeleteEpisodes(podcastID, []string{"ep1.mp3"})
+	assert.Equal(t, storage.ErrClosed, err)
+
+	_, err = store.QueryEpisodes(podcastID, storage.EpisodeQuery{})
+	assert.Equal(t, storage.ErrClosed, err)
+
+	_, err = store.GetPodcast(podcastID)
+	assert.Equal(t, storage.ErrClosed, err)
+	assert.Equal(t, storage.ErrClosed, store.SavePodcast(&podcast.Podcast{ID: podcastID}))
+
+	assert.Equal(t, storage.ErrClosed, store.AppendHistory(podcastID, []storage.EpisodeEvent{{Filename: "ep1.mp3"}}))
+	_, err = store.ListHistory(podcastID, storage.HistoryFilter{})
+	assert.Equal(t, storage.ErrClosed, err)
+
+	assert.Equal(t, storage.ErrClosed, store.SaveSession(&storage.Session{ID: "s1"}))
+	_, err = store.GetSession("s1")
+	assert.Equal(t, storage.ErrClosed, err)
+	_, err = store.ListSessions(0)
+	assert.Equal(t, storage.ErrClosed, err)
 }
 
 func TestStoreImplementsInterface(t *testing.T) {
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"time"
+
+	"podgen/internal/app/podgen/podcast"
+)
+
+// Operation names the action that caused an episode state transition.
+type Operation string
+
+const (
+	// OperationScan records an episode found in the podcast folder.
+	// The episode did not exist before, so both statuses are New.
+	OperationScan Operation = "scan"
+	// OperationUpload records an upload of the episode to object storage.
+	OperationUpload Operation = "upload"
+	// OperationDelete records a removal of the episode from object storage.
+	OperationDelete Operation = "delete"
+	// OperationRollback records a reset of the episode status to New.
+	OperationRollback Operation = "rollback"
+	// OperationReconcile records a status correction made to match object storage.
+	OperationReconcile Operation = "reconcile"
+)
+
+// EpisodeEvent is an entry of the append-only episode history.
+// Failed operations are recorded too, with Error set and the status unchanged.
+type EpisodeEvent struct {
+	Filename  string
+	At        time.Time
+	Operation Operation
+	OldStatus podcast.Status
+	NewStatus podcast.Status
+	// Session is the ID of the upload, rollback or reconcile run that recorded the event, empty for scans.
+	Session  string
+	Location string
+	Error    string
+}
+
+// HistoryFilter selects entries of the episode history.
+// Empty fields are not applied.
+type HistoryFilter struct {
+	// Filename matches entries of a single episode.
+	Filename string
+	// Since matches entries recorded at or after this time.
+	Since time.Time
+	// Limit keeps only the latest matching entries.
+	Limit int
+}
+
+// Match reports whether the event satisfies the filter. Limit is not considered.
+func (f HistoryFilter) Match(event *EpisodeEvent) bool {
+	if f.Filename != "" && event.Filename != f.Filename {
+		return false
+	}
+	if !f.Since.IsZero() && event.At.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// HistoryStore defines the interface for the episode history log.
+type HistoryStore interface {
+	// AppendHistory records events of podcast episodes in the given order.
+	AppendHistory(podcastID string, events []EpisodeEvent) error
+
+	// ListHistory returns recorded events of podcast matching the filter, oldest first.
+	ListHistory(podcastID string, filter HistoryFilter) ([]EpisodeEvent, error)
+}
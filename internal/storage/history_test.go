@@ -0,0 +1,34 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"podgen/internal/storage"
+)
+
+func TestHistoryFilterMatch(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	event := &storage.EpisodeEvent{Filename: "ep1.mp3", At: at}
+
+	tests := []struct {
+		name   string
+		filter storage.HistoryFilter
+		want   bool
+	}{
+		{"empty filter matches", storage.HistoryFilter{}, true},
+		{"filename matches", storage.HistoryFilter{Filename: "ep1.mp3"}, true},
+		{"filename mismatch", storage.HistoryFilter{Filename: "ep2.mp3"}, false},
+		{"since is inclusive", storage.HistoryFilter{Since: at}, true},
+		{"since after event", storage.HistoryFilter{Since: at.Add(time.Second)}, false},
+		{"limit is ignored", storage.HistoryFilter{Limit: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
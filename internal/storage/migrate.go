@@ -2,6 +2,7 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 
 	log "github.com/go-pkgz/lgr"
@@ -13,6 +14,8 @@ type MigrateStats struct {
 	PodcastsFailed    int
 	EpisodesMigrated  int
 	EpisodesFailed    int
+	SessionsMigrated  int
+	ReplicasMigrated  int
 }
 
 // Migrate transfers all data from source store to destination store.
@@ -53,6 +56,18 @@ func Migrate(from, to Store) (*MigrateStats, error) {
 		log.Printf("[INFO] Migrated podcast %s: %d episodes", podcastID, episodeCount)
 	}
 
+	sessions, err := migrateSessions(from, to)
+	if err != nil {
+		return stats, err
+	}
+	stats.SessionsMigrated = sessions
+
+	replicas, err := migrateReplicas(from, to)
+	if err != nil {
+		return stats, err
+	}
+	stats.ReplicasMigrated = replicas
+
 	log.Printf("[INFO] Migration completed: %d podcasts (%d failed), %d episodes migrated, %d failed",
 		stats.PodcastsProcessed, stats.PodcastsFailed, stats.EpisodesMigrated, stats.EpisodesFailed)
 
@@ -64,9 +79,48 @@ func Migrate(from, to Store) (*MigrateStats, error) {
 	return stats, nil
 }
 
-// migratePodcast migrates all episodes for a single podcast.
+// migratePodcast migrates the podcast record, subscribers, runs of scheduled jobs, episode history and all episodes
+// for a single podcast.
 // Returns the number of episodes migrated, failed count, and any error.
 func migratePodcast(from, to Store, podcastID string) (migrated, failed int, err error) {
+	record, getErr := from.GetPodcast(podcastID)
+	switch {
+	case getErr == nil:
+		if err := to.SavePodcast(record); err != nil {
+			return 0, 0, fmt.Errorf("failed to save podcast record: %w", err)
+		}
+	case !errors.Is(getErr, ErrPodcastNotFound):
+		return 0, 0, fmt.Errorf("failed to get podcast record: %w", getErr)
+	}
+
+	subscribers, subErr := from.ListSubscribers(podcastID)
+	if subErr != nil {
+		return 0, 0, fmt.Errorf("failed to list subscribers: %w", subErr)
+	}
+	for _, sub := range subscribers {
+		if err := to.SaveSubscriber(sub); err != nil {
+			return 0, 0, fmt.Errorf("failed to save subscriber %s: %w", sub.Name, err)
+		}
+	}
+
+	runs, runErr := from.ListRuns(podcastID, 0)
+	if runErr != nil {
+		return 0, 0, fmt.Errorf("failed to list runs: %w", runErr)
+	}
+	for _, run := range runs {
+		if err := to.SaveRun(run); err != nil {
+			return 0, 0, fmt.Errorf("failed to save run %s: %w", run.ID, err)
+		}
+	}
+
+	history, historyErr := from.ListHistory(podcastID, HistoryFilter{})
+	if historyErr != nil {
+		return 0, 0, fmt.Errorf("failed to list history: %w", historyErr)
+	}
+	if err := to.AppendHistory(podcastID, history); err != nil {
+		return 0, 0, fmt.Errorf("failed to append history: %w", err)
+	}
+
 	episodes, listErr := from.ListEpisodes(podcastID)
 	if listErr != nil {
 		return 0, 0, fmt.Errorf("failed to list episodes: %w", listErr)
@@ -84,6 +138,46 @@ func migratePodcast(from, to Store, podcastID string) (migrated, failed int, err
 	return migrated, failed, nil
 }
 
+// migrateSessions copies all upload session records.
+// Returns the number of sessions migrated.
+func migrateSessions(from, to Store) (int, error) {
+	sessions, err := from.ListSessions(0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions from source: %w", err)
+	}
+	for _, session := range sessions {
+		if err := to.SaveSession(session); err != nil {
+			return 0, fmt.Errorf("failed to save session %s: %w", session.ID, err)
+		}
+	}
+	return len(sessions), nil
+}
+
+// migrateReplicas copies the replicas of objects published to several destinations and the statuses of the destinations.
+// Returns the number of replicas migrated.
+func migrateReplicas(from, to Store) (int, error) {
+	replicas, err := from.ListReplicas()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list replicas from source: %w", err)
+	}
+	for _, replica := range replicas {
+		if err := to.SaveReplica(replica); err != nil {
+			return 0, fmt.Errorf("failed to save replica %s at %s: %w", replica.Object, replica.Destination, err)
+		}
+	}
+
+	statuses, err := from.ListDestinationStatuses()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list destination statuses from source: %w", err)
+	}
+	for _, status := range statuses {
+		if err := to.SaveDestinationStatus(status); err != nil {
+			return 0, fmt.Errorf("failed to save status of destination %s: %w", status.Name, err)
+		}
+	}
+	return len(replicas), nil
+}
+
 // MigrateWithProgress transfers data with progress callback.
 // The callback is called after each podcast is processed.
 type MigrateProgressCallback func(podcastID string, podcastNum, totalPodcasts int, episodesMigrated int)
@@ -129,6 +223,18 @@ func MigrateWithProgressCallback(from, to Store, callback MigrateProgressCallbac
 		log.Printf("[INFO] Migrated podcast %s: %d episodes (%d/%d)", podcastID, episodeCount, i+1, totalPodcasts)
 	}
 
+	sessions, err := migrateSessions(from, to)
+	if err != nil {
+		return stats, err
+	}
+	stats.SessionsMigrated = sessions
+
+	replicas, err := migrateReplicas(from, to)
+	if err != nil {
+		return stats, err
+	}
+	stats.ReplicasMigrated = replicas
+
 	log.Printf("[INFO] Migration completed: %d podcasts (%d failed), %d episodes migrated, %d failed",
 		stats.PodcastsProcessed, stats.PodcastsFailed, stats.EpisodesMigrated, stats.EpisodesFailed)
 
@@ -3,6 +3,7 @@ package storage_test
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -164,6 +165,81 @@ func TestMigrateSQLiteToBolt(t *testing.T) {
 	}
 }
 
+func TestMigratePodcastRecordsHistoryAndSessions(t *testing.T) {
+	src, srcCleanup := newBoltStore(t)
+	defer srcCleanup()
+
+	dst, dstCleanup := newSQLiteStore(t)
+	defer dstCleanup()
+
+	record := &podcast.Podcast{
+		ID:            "podcast1",
+		LastScanAt:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		LastPublishAt: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC),
+		ImageURL:      "https://cdn.example.com/podcast1/podcast.png",
+		FeedURL:       "https://cdn.example.com/podcast1/feed.rss",
+		FeedHash:      "abc123",
+	}
+	require.NoError(t, src.SavePodcast(record))
+	require.NoError(t, src.SaveEpisode("podcast1", &podcast.Episode{Filename: "ep1.mp3"}))
+
+	events := []storage.EpisodeEvent{
+		{Filename: "ep1.mp3", At: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Operation: storage.OperationScan},
+		{Filename: "ep1.mp3", At: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC), Operation: storage.OperationUpload,
+			NewStatus: podcast.Uploaded, Session: "s1"},
+	}
+	require.NoError(t, src.AppendHistory("podcast1", events))
+
+	session := &storage.Session{ID: "s1", StartedAt: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC), Uploaded: 1, Outcome: storage.SessionSucceeded}
+	require.NoError(t, src.SaveSession(session))
+
+	subscriber := &storage.Subscriber{PodcastID: "podcast1", Name: "alice", Token: "alice-secret-token",
+		CreatedAt: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+	require.NoError(t, src.SaveSubscriber(subscriber))
+
+	run := &storage.Run{ID: "r1", PodcastID: "podcast1", Job: "scan", StartedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		FinishedAt: time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC), Outcome: storage.SessionSucceeded}
+	require.NoError(t, src.SaveRun(run))
+
+	// A podcast without episodes is migrated too
+	require.NoError(t, src.SavePodcast(&podcast.Podcast{ID: "podcast2", ImageURL: "https://cdn.example.com/podcast2.png"}))
+
+	stats, err := storage.Migrate(src, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.PodcastsProcessed)
+	assert.Equal(t, 1, stats.EpisodesMigrated)
+	assert.Equal(t, 1, stats.SessionsMigrated)
+
+	got, err := dst.GetPodcast("podcast1")
+	require.NoError(t, err)
+	assert.Equal(t, record, got)
+
+	history, err := dst.ListHistory("podcast1", storage.HistoryFilter{})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, storage.OperationUpload, history[1].Operation)
+	assert.True(t, events[1].At.Equal(history[1].At))
+
+	migratedSubscriber, err := dst.GetSubscriber("podcast1", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, subscriber, migratedSubscriber)
+
+	runs, err := dst.ListRuns("podcast1", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "scan", runs[0].Job)
+	assert.True(t, run.FinishedAt.Equal(runs[0].FinishedAt))
+
+	migratedSession, err := dst.GetSession("s1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, migratedSession.Uploaded)
+	assert.Equal(t, storage.SessionSucceeded, migratedSession.Outcome)
+
+	got, err = dst.GetPodcast("podcast2")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/podcast2.png", got.ImageURL)
+}
+
 func TestMigrateEmptyStore(t *testing.T) {
 	// Setup source (empty BoltDB)
 	src, srcCleanup := newBoltStore(t)
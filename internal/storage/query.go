@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"podgen/internal/app/podgen/podcast"
+)
+
+// EpisodeSort names the field used to order query results.
+type EpisodeSort string
+
+const (
+	// SortByFilename orders episodes by filename (default).
+	SortByFilename EpisodeSort = "filename"
+	// SortByPubDate orders episodes by publication date.
+	SortByPubDate EpisodeSort = "date"
+	// SortBySize orders episodes by file size.
+	SortBySize EpisodeSort = "size"
+	// SortByTitle orders episodes by title.
+	SortByTitle EpisodeSort = "title"
+)
+
+// ParseEpisodeSort converts a string to EpisodeSort.
+// A leading "-" requests descending order, e.g. "-date".
+func ParseEpisodeSort(s string) (sortBy EpisodeSort, desc bool, err error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.HasPrefix(s, "-") {
+		desc = true
+		s = s[1:]
+	}
+	switch s {
+	case "", "filename", "name":
+		return SortByFilename, desc, nil
+	case "date", "pub_date", "pubdate":
+		return SortByPubDate, desc, nil
+	case "size":
+		return SortBySize, desc, nil
+	case "title":
+		return SortByTitle, desc, nil
+	default:
+		return "", false, fmt.Errorf("unknown sort field %q: valid fields are filename, date, size, title", s)
+	}
+}
+
+// EpisodeQuery describes a filtered, sorted and paginated episode lookup.
+// Zero-value fields are not applied, so an empty query lists all episodes by filename.
+type EpisodeQuery struct {
+	EpisodeFilter
+
+	// Text matches episodes whose title or artist contains it (case-insensitive).
+	Text string
+	// PublishedFrom matches episodes published at or after this time.
+	PublishedFrom time.Time
+	// PublishedTo matches episodes published before this time.
+	PublishedTo time.Time
+	// MinSize matches episodes of at least this many bytes.
+	MinSize int64
+	// MaxSize matches episodes of at most this many bytes.
+	MaxSize int64
+
+	// SortBy selects the ordering field; empty means SortByFilename.
+	SortBy EpisodeSort
+	// Desc reverses the ordering.
+	Desc bool
+	// Limit caps the number of returned episodes; zero means no limit.
+	Limit int
+	// Offset skips this many matching episodes before returning results.
+	Offset int
+}
+
+// Match reports whether the episode satisfies all filters of the query.
+// Sorting and pagination are not considered.
+func (q EpisodeQuery) Match(episode *podcast.Episode) bool {
+	if !q.EpisodeFilter.Match(episode) {
+		return false
+	}
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(episode.Title), text) && !strings.Contains(strings.ToLower(episode.Artist), text) {
+			return false
+		}
+	}
+	if !q.PublishedFrom.IsZero() || !q.PublishedTo.IsZero() {
+		published := episode.PublishedAt()
+		if published.IsZero() {
+			return false
+		}
+		if !q.PublishedFrom.IsZero() && published.Before(q.PublishedFrom) {
+			return false
+		}
+		if !q.PublishedTo.IsZero() && !published.Before(q.PublishedTo) {
+			return false
+		}
+	}
+	if q.MinSize > 0 && episode.Size < q.MinSize {
+		return false
+	}
+	if q.MaxSize > 0 && episode.Size > q.MaxSize {
+		return false
+	}
+	return true
+}
+
+// Sort orders episodes in place according to SortBy and Desc.
+// Ties are broken by filename so results are stable across backends.
+func (q EpisodeQuery) Sort(episodes []*podcast.Episode) {
+	slices.SortStableFunc(episodes, func(a, b *podcast.Episode) int {
+		var c int
+		switch q.SortBy {
+		case SortByPubDate:
+			c = a.PublishedAt().Compare(b.PublishedAt())
+		case SortBySize:
+			c = cmp.Compare(a.Size, b.Size)
+		case SortByTitle:
+			c = strings.Compare(a.Title, b.Title)
+		}
+		if c == 0 {
+			c = strings.Compare(a.Filename, b.Filename)
+		}
+		if q.Desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// Page applies Offset and Limit to already sorted episodes.
+func (q EpisodeQuery) Page(episodes []*podcast.Episode) []*podcast.Episode {
+	if q.Offset > 0 {
+		if q.Offset >= len(episodes) {
+			return []*podcast.Episode{}
+		}
+		episodes = episodes[q.Offset:]
+	}
+	if q.Limit > 0 && len(episodes) > q.Limit {
+		episodes = episodes[:q.Limit]
+	}
+	return episodes
+}
@@ -0,0 +1,134 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"podgen/internal/app/podgen/podcast"
+	"podgen/internal/storage"
+)
+
+func pubDate(s string) string {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+func TestParseEpisodeSort(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantSort storage.EpisodeSort
+		wantDesc bool
+		wantErr  bool
+	}{
+		{"", storage.SortByFilename, false, false},
+		{"filename", storage.SortByFilename, false, false},
+		{"-date", storage.SortByPubDate, true, false},
+		{"Size", storage.SortBySize, false, false},
+		{"-title", storage.SortByTitle, true, false},
+		{"duration", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, desc, err := storage.ParseEpisodeSort(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEpisodeSort(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.wantSort || desc != tt.wantDesc {
+				t.Errorf("ParseEpisodeSort(%q) = (%q, %v), want (%q, %v)", tt.input, got, desc, tt.wantSort, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestEpisodeQueryMatch(t *testing.T) {
+	episode := &podcast.Episode{
+		Filename: "ep1.mp3",
+		Status:   podcast.Uploaded,
+		Title:    "Интервью с автором",
+		Artist:   "Some Host",
+		Size:     1000,
+		PubDate:  pubDate("2024-03-10"),
+	}
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	tests := []struct {
+		name  string
+		query storage.EpisodeQuery
+		want  bool
+	}{
+		{"empty query", storage.EpisodeQuery{}, true},
+		{"text in title, unicode case-insensitive", storage.EpisodeQuery{Text: "ИНТЕРВЬЮ"}, true},
+		{"text in artist", storage.EpisodeQuery{Text: "host"}, true},
+		{"text mismatch", storage.EpisodeQuery{Text: "guest"}, false},
+		{"date within range", storage.EpisodeQuery{PublishedFrom: day("2024-03-01"), PublishedTo: day("2024-04-01")}, true},
+		{"date before range", storage.EpisodeQuery{PublishedFrom: day("2024-03-11")}, false},
+		{"range end is exclusive", storage.EpisodeQuery{PublishedTo: day("2024-03-10")}, false},
+		{"size within bounds", storage.EpisodeQuery{MinSize: 500, MaxSize: 1000}, true},
+		{"size too small", storage.EpisodeQuery{MinSize: 1001}, false},
+		{"size too big", storage.EpisodeQuery{MaxSize: 999}, false},
+		{"filter applies", storage.EpisodeQuery{EpisodeFilter: storage.EpisodeFilter{Statuses: []podcast.Status{podcast.New}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Match(episode); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	undated := &podcast.Episode{Filename: "undated.mp3"}
+	if (storage.EpisodeQuery{PublishedFrom: day("2000-01-01")}).Match(undated) {
+		t.Error("Match() with date range accepted an episode without a date")
+	}
+}
+
+func TestEpisodeQuerySortAndPage(t *testing.T) {
+	episodes := func() []*podcast.Episode {
+		return []*podcast.Episode{
+			{Filename: "b.mp3", Size: 200, Title: "Beta", PubDate: pubDate("2024-01-02")},
+			{Filename: "a.mp3", Size: 300, Title: "Gamma", PubDate: pubDate("2024-01-03")},
+			{Filename: "c.mp3", Size: 100, Title: "Alpha", PubDate: pubDate("2024-01-01")},
+		}
+	}
+	names := func(eps []*podcast.Episode) string {
+		var s string
+		for _, ep := range eps {
+			s += ep.Filename[:1]
+		}
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		query storage.EpisodeQuery
+		want  string
+	}{
+		{"default by filename", storage.EpisodeQuery{}, "abc"},
+		{"filename desc", storage.EpisodeQuery{Desc: true}, "cba"},
+		{"by date", storage.EpisodeQuery{SortBy: storage.SortByPubDate}, "cba"},
+		{"by size desc", storage.EpisodeQuery{SortBy: storage.SortBySize, Desc: true}, "abc"},
+		{"by title", storage.EpisodeQuery{SortBy: storage.SortByTitle}, "cba"},
+		{"limit", storage.EpisodeQuery{Limit: 2}, "ab"},
+		{"offset and limit", storage.EpisodeQuery{Offset: 1, Limit: 1}, "b"},
+		{"offset past end", storage.EpisodeQuery{Offset: 5}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eps := episodes()
+			tt.query.Sort(eps)
+			if got := names(tt.query.Page(eps)); got != tt.want {
+				t.Errorf("Sort+Page = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
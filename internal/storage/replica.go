@@ -0,0 +1,60 @@
+package storage
+
+import "time"
+
+// ReplicaStatus is the state of an object at a publishing destination.
+type ReplicaStatus string
+
+// Replica statuses
+const (
+	// ReplicaUploaded means the destination holds the current object.
+	ReplicaUploaded ReplicaStatus = "uploaded"
+	// ReplicaDeleted means the object was removed from the destination.
+	ReplicaDeleted ReplicaStatus = "deleted"
+	// ReplicaFailed means the last write of the object to the destination failed.
+	ReplicaFailed ReplicaStatus = "failed"
+)
+
+// Replica is the state of an object, such as an episode, at one of the destinations it is published to.
+type Replica struct {
+	// Object and Destination identify the replica.
+	Object      string
+	Destination string
+	Status      ReplicaStatus
+	// Location is the public URL of the object at the destination, kept after it is deleted.
+	Location string
+	// Checksum is the hex SHA-256 of the uploaded content, if the destination computed it.
+	Checksum string
+	// Error is the error of the last failed write.
+	Error     string
+	UpdatedAt time.Time
+}
+
+// DestinationStatus tells whether a publishing destination is usable.
+// Destinations without a recorded status are up.
+type DestinationStatus struct {
+	Name string
+	// Down marks the destination as failing, feeds then link to the objects at another destination.
+	Down bool
+	// Reason is why the destination was marked down, such as the error of the failed request.
+	Reason    string
+	UpdatedAt time.Time
+}
+
+// ReplicaStore defines the interface for persistence of objects published to several destinations.
+type ReplicaStore interface {
+	// SaveReplica creates or replaces the replica of the object at its destination.
+	SaveReplica(replica *Replica) error
+
+	// GetReplicas returns the replicas of the object, ordered by destination.
+	GetReplicas(object string) ([]*Replica, error)
+
+	// ListReplicas returns the replicas of all objects, ordered by object and destination.
+	ListReplicas() ([]*Replica, error)
+
+	// SaveDestinationStatus creates or replaces the status of the destination.
+	SaveDestinationStatus(status *DestinationStatus) error
+
+	// ListDestinationStatuses returns the recorded statuses of all destinations, ordered by name.
+	ListDestinationStatuses() ([]*DestinationStatus, error)
+}
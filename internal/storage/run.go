@@ -0,0 +1,26 @@
+package storage
+
+import "time"
+
+// Run is a recorded run of a scheduled job of the daemon.
+type Run struct {
+	ID        string
+	PodcastID string
+	// Job is the scheduled operation, such as "scan" or "publish".
+	Job        string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Outcome is SessionRunning until the run finishes, then SessionSucceeded, SessionFailed or SessionCanceled.
+	Outcome SessionOutcome
+	Error   string
+}
+
+// RunStore defines the interface for persistence of the run history of scheduled jobs.
+type RunStore interface {
+	// SaveRun creates or replaces the run record.
+	SaveRun(run *Run) error
+
+	// ListRuns returns recorded runs, most recently started first.
+	// A non-empty podcastID selects runs of that podcast, a positive limit caps the number of returned runs.
+	ListRuns(podcastID string, limit int) ([]*Run, error)
+}
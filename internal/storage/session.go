@@ -0,0 +1,66 @@
+package storage
+
+import "time"
+
+// SessionOutcome describes how an upload session ended.
+type SessionOutcome string
+
+const (
+	// SessionRunning marks a session that has started and not finished yet.
+	// A session left in this state was interrupted.
+	SessionRunning SessionOutcome = "running"
+	// SessionSucceeded marks a session finished without errors.
+	SessionSucceeded SessionOutcome = "success"
+	// SessionPartial marks a session that uploaded some episodes and failed others.
+	SessionPartial SessionOutcome = "partial"
+	// SessionFailed marks a session that failed without uploading anything.
+	SessionFailed SessionOutcome = "failed"
+	// SessionCanceled marks a session stopped by a signal.
+	SessionCanceled SessionOutcome = "canceled"
+	// SessionRolledBack marks a session whose episodes were rolled back.
+	SessionRolledBack SessionOutcome = "rolled_back"
+)
+
+// Session is a recorded run that changed remote state: an upload, a rollback or a reconcile fix.
+// Episodes uploaded in an upload run carry its ID, and so do history events of any run.
+type Session struct {
+	ID         string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Host       string
+	PodcastIDs []string
+	// Operation is OperationUpload, OperationRollback or OperationReconcile.
+	Operation Operation
+	// Target is the ID of the session reverted by a rollback.
+	Target string
+	// Uploaded is the number of episodes uploaded in the session.
+	Uploaded int
+	// Deleted is the number of episodes removed from object storage in the session.
+	Deleted int
+	// Failed is the number of episode operations that failed.
+	Failed int
+	// Bytes is the total size of uploaded episodes.
+	Bytes   int64
+	Outcome SessionOutcome
+	Error   string
+}
+
+// IsUpload reports whether the session is an upload run.
+// Sessions recorded before operations were tracked have no operation and are uploads.
+func (s *Session) IsUpload() bool {
+	return s.Operation == OperationUpload || s.Operation == ""
+}
+
+// SessionStore defines the interface for upload session persistence.
+type SessionStore interface {
+	// SaveSession creates or replaces the session record.
+	SaveSession(session *Session) error
+
+	// GetSession retrieves a session by ID.
+	// Returns ErrSessionNotFound if the session was not recorded.
+	GetSession(id string) (*Session, error)
+
+	// ListSessions returns recorded sessions, most recently started first.
+	// A positive limit caps the number of returned sessions.
+	ListSessions(limit int) ([]*Session, error)
+}
@@ -3,18 +3,38 @@ package sqlite
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	log "github.com/go-pkgz/lgr"
-	_ "modernc.org/sqlite"
+	sqlitedrv "modernc.org/sqlite"
 
 	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/storage"
 )
 
+func init() {
+	// unicode_lower lowercases text with Go rules, since SQLite's LOWER only handles ASCII.
+	// It keeps case-insensitive episode search consistent with the other backends.
+	sqlitedrv.MustRegisterDeterministicScalarFunction("unicode_lower", 1,
+		func(_ *sqlitedrv.FunctionContext, args []driver.Value) (driver.Value, error) {
+			switch v := args[0].(type) {
+			case string:
+				return strings.ToLower(v), nil
+			case []byte:
+				return strings.ToLower(string(v)), nil
+			default:
+				return v, nil
+			}
+		})
+}
+
 // Store implements storage.Store using SQLite with WAL mode.
 type Store struct {
 	db     *sql.DB
@@ -95,6 +115,24 @@ func (s *Store) Open() error {
 	return nil
 }
 
+// multipartUploadsTable creates the multipart_uploads table, it is also used to rebuild the table keyed by object alone.
+const multipartUploadsTable = `
+		CREATE TABLE IF NOT EXISTS multipart_uploads (
+			bucket TEXT NOT NULL DEFAULT '',
+			object TEXT NOT NULL,
+			upload_id TEXT NOT NULL,
+			file_path TEXT,
+			size INTEGER DEFAULT 0,
+			mod_time INTEGER,
+			sha256 TEXT,
+			part_size INTEGER DEFAULT 0,
+			parts TEXT,
+			started_at INTEGER NOT NULL,
+			updated_at INTEGER,
+			PRIMARY KEY (bucket, object)
+		);
+	`
+
 // createSchema creates the necessary tables and indexes.
 func (s *Store) createSchema() error {
 	schema := `
@@ -112,16 +150,248 @@ func (s *Store) createSchema() error {
 			year TEXT,
 			comment TEXT,
 			duration TEXT,
+			pub_ts INTEGER,
+			checksum TEXT DEFAULT '',
+			object_key TEXT DEFAULT '',
 			PRIMARY KEY (podcast_id, filename)
 		);
 
 		CREATE INDEX IF NOT EXISTS idx_episodes_status ON episodes(podcast_id, status);
 		CREATE INDEX IF NOT EXISTS idx_episodes_session ON episodes(podcast_id, session);
+
+		CREATE TABLE IF NOT EXISTS podcasts (
+			id TEXT PRIMARY KEY,
+			last_scan_at TEXT,
+			last_publish_at TEXT,
+			image_url TEXT,
+			feed_url TEXT,
+			feed_hash TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS episode_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			podcast_id TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			at INTEGER NOT NULL,
+			operation TEXT,
+			old_status INTEGER,
+			new_status INTEGER,
+			session TEXT,
+			location TEXT,
+			error TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_episode_history_filename ON episode_history(podcast_id, filename);
+
+		CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			started_at INTEGER NOT NULL,
+			finished_at INTEGER,
+			host TEXT,
+			podcast_ids TEXT,
+			operation TEXT,
+			target TEXT,
+			uploaded INTEGER DEFAULT 0,
+			deleted INTEGER DEFAULT 0,
+			failed INTEGER DEFAULT 0,
+			bytes INTEGER DEFAULT 0,
+			outcome TEXT,
+			error TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_sessions_started_at ON sessions(started_at);
+
+	` + multipartUploadsTable + `
+
+		CREATE TABLE IF NOT EXISTS replicas (
+			object TEXT NOT NULL,
+			destination TEXT NOT NULL,
+			status TEXT NOT NULL,
+			location TEXT,
+			checksum TEXT,
+			error TEXT,
+			updated_at INTEGER,
+			PRIMARY KEY (object, destination)
+		);
+
+		CREATE TABLE IF NOT EXISTS destinations (
+			name TEXT PRIMARY KEY,
+			down INTEGER DEFAULT 0,
+			reason TEXT,
+			updated_at INTEGER
+		);
+
+		CREATE TABLE IF NOT EXISTS subscribers (
+			podcast_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			token TEXT,
+			created_at TEXT,
+			rotated_at TEXT,
+			revoked_at TEXT,
+			PRIMARY KEY (podcast_id, name)
+		);
+
+		CREATE TABLE IF NOT EXISTS runs (
+			id TEXT PRIMARY KEY,
+			podcast_id TEXT NOT NULL,
+			job TEXT NOT NULL,
+			started_at INTEGER NOT NULL,
+			finished_at INTEGER,
+			outcome TEXT,
+			error TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_runs_started_at ON runs(podcast_id, started_at);
 	`
-	_, err := s.db.Exec(schema)
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	if err := s.migrateSchema(); err != nil {
+		return err
+	}
+
+	_, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_episodes_pub_ts ON episodes(podcast_id, pub_ts)`)
 	return err
 }
 
+// migrateSchema adds columns introduced after the initial schema to existing databases.
+func (s *Store) migrateSchema() error {
+	added, err := s.addColumnIfMissing("episodes", "pub_ts", "INTEGER")
+	if err != nil {
+		return err
+	}
+	if added {
+		if err := s.backfillPubTimestamps(); err != nil {
+			return fmt.Errorf("failed to backfill pub_ts: %w", err)
+		}
+	}
+	if _, err := s.addColumnIfMissing("episodes", "checksum", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := s.addColumnIfMissing("episodes", "object_key", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return s.rekeyMultipartUploads()
+}
+
+// rekeyMultipartUploads rebuilds multipart_uploads created with object as the primary key,
+// before uploads were keyed by bucket too.
+func (s *Store) rekeyMultipartUploads() error {
+	var keyed int
+	err := s.db.QueryRow(`SELECT pk FROM pragma_table_info('multipart_uploads') WHERE name = 'bucket'`).Scan(&keyed)
+	if err != nil {
+		return fmt.Errorf("failed to read multipart_uploads columns: %w", err)
+	}
+	if keyed > 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, stmt := range []string{
+		`ALTER TABLE multipart_uploads RENAME TO multipart_uploads_legacy`,
+		multipartUploadsTable,
+		`INSERT INTO multipart_uploads (` + uploadColumns + `)
+			SELECT object, COALESCE(bucket, ''), upload_id, file_path, size, mod_time, sha256, part_size, parts,
+				started_at, updated_at
+			FROM multipart_uploads_legacy`,
+		`DROP TABLE multipart_uploads_legacy`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to rekey multipart_uploads: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("[INFO] SQLite schema: multipart_uploads keyed by bucket and object")
+	return nil
+}
+
+// addColumnIfMissing adds a column to the table unless it already exists.
+// Returns true if the column was added.
+func (s *Store) addColumnIfMissing(table, column, definition string) (bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		if name == column {
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	_ = rows.Close()
+
+	if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return false, fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	log.Printf("[INFO] SQLite schema: added column %s.%s", table, column)
+	return true, nil
+}
+
+// backfillPubTimestamps fills pub_ts from pub_date for rows created before the column existed.
+func (s *Store) backfillPubTimestamps() error {
+	rows, err := s.db.Query(`SELECT podcast_id, filename, pub_date FROM episodes WHERE pub_ts IS NULL AND pub_date != ''`)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		podcastID, filename string
+		ts                  any
+	}
+	var updates []row
+	for rows.Next() {
+		var podcastID, filename string
+		var pubDate sql.NullString
+		if err := rows.Scan(&podcastID, &filename, &pubDate); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ep := podcast.Episode{PubDate: pubDate.String}
+		updates = append(updates, row{podcastID: podcastID, filename: filename, ts: pubTimestamp(&ep)})
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		if _, err := s.db.Exec(`UPDATE episodes SET pub_ts = ? WHERE podcast_id = ? AND filename = ?`, u.ts, u.podcastID, u.filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pubTimestamp returns the episode publication time as unix seconds, or nil if PubDate can't be parsed.
+func pubTimestamp(episode *podcast.Episode) any {
+	published := episode.PublishedAt()
+	if published.IsZero() {
+		return nil
+	}
+	return published.Unix()
+}
+
 // Close releases all database resources.
 func (s *Store) Close() error {
 	if s.db == nil {
@@ -133,30 +403,34 @@ func (s *Store) Close() error {
 	return err
 }
 
+// upsertEpisodeQuery inserts an episode or updates all of its fields if it already exists.
+const upsertEpisodeQuery = `
+	INSERT INTO episodes (podcast_id, filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, pub_ts, checksum, object_key)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(podcast_id, filename) DO UPDATE SET
+		pub_date = excluded.pub_date,
+		pub_ts = excluded.pub_ts,
+		size = excluded.size,
+		status = excluded.status,
+		location = excluded.location,
+		session = excluded.session,
+		title = excluded.title,
+		artist = excluded.artist,
+		album = excluded.album,
+		year = excluded.year,
+		comment = excluded.comment,
+		duration = excluded.duration,
+		checksum = excluded.checksum,
+		object_key = excluded.object_key
+`
+
 // SaveEpisode persists an episode to the store.
 func (s *Store) SaveEpisode(podcastID string, episode *podcast.Episode) error {
 	if s.db == nil {
 		return storage.ErrClosed
 	}
 
-	query := `
-		INSERT INTO episodes (podcast_id, filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(podcast_id, filename) DO UPDATE SET
-			pub_date = excluded.pub_date,
-			size = excluded.size,
-			status = excluded.status,
-			location = excluded.location,
-			session = excluded.session,
-			title = excluded.title,
-			artist = excluded.artist,
-			album = excluded.album,
-			year = excluded.year,
-			comment = excluded.comment,
-			duration = excluded.duration
-	`
-
-	_, err := s.db.Exec(query,
+	_, err := s.db.Exec(upsertEpisodeQuery,
 		podcastID,
 		episode.Filename,
 		episode.PubDate,
@@ -170,6 +444,9 @@ func (s *Store) SaveEpisode(podcastID string, episode *podcast.Episode) error {
 		episode.Year,
 		episode.Comment,
 		episode.Duration,
+		pubTimestamp(episode),
+		episode.Checksum,
+		episode.Key,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save episode: %w", err)
@@ -186,7 +463,7 @@ func (s *Store) FindEpisodesByStatus(podcastID string, status podcast.Status) ([
 	}
 
 	query := `
-		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration
+		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, checksum, object_key
 		FROM episodes
 		WHERE podcast_id = ? AND status = ?
 		ORDER BY filename
@@ -209,7 +486,7 @@ func (s *Store) FindEpisodesBySession(podcastID, session string) ([]*podcast.Epi
 	}
 
 	query := `
-		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration
+		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, checksum, object_key
 		FROM episodes
 		WHERE podcast_id = ? AND session = ?
 		ORDER BY filename
@@ -280,7 +557,7 @@ func (s *Store) GetEpisodeByFilename(podcastID, fileName string) (*podcast.Episo
 	}
 
 	query := `
-		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration
+		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, checksum, object_key
 		FROM episodes
 		WHERE podcast_id = ? AND filename = ?
 	`
@@ -309,7 +586,7 @@ func (s *Store) GetLastEpisodeByNotStatus(podcastID string, status podcast.Statu
 	}
 
 	query := `
-		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration
+		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, checksum, object_key
 		FROM episodes
 		WHERE podcast_id = ? AND status != ?
 		ORDER BY filename DESC
@@ -328,13 +605,622 @@ func (s *Store) GetLastEpisodeByNotStatus(podcastID string, status podcast.Statu
 	return episode, nil
 }
 
-// ListPodcasts returns all podcast IDs in the store.
+// QueryEpisodes retrieves episodes matching the query, sorted and paginated in SQL.
+// Returns an empty slice if nothing matches.
+func (s *Store) QueryEpisodes(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	where, args := filterClause(podcastID, q.EpisodeFilter)
+	conds := []string{where}
+
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		conds = append(conds, "(instr(unicode_lower(COALESCE(title, '')), ?) > 0 OR instr(unicode_lower(COALESCE(artist, '')), ?) > 0)")
+		args = append(args, text, text)
+	}
+	if !q.PublishedFrom.IsZero() {
+		from := q.PublishedFrom.Unix()
+		if q.PublishedFrom.Nanosecond() > 0 {
+			from++ // pub_ts has second precision, round the bound up
+		}
+		conds = append(conds, "pub_ts >= ?")
+		args = append(args, from)
+	}
+	if !q.PublishedTo.IsZero() {
+		to := q.PublishedTo.Unix()
+		if q.PublishedTo.Nanosecond() > 0 {
+			to++
+		}
+		conds = append(conds, "pub_ts < ?")
+		args = append(args, to)
+	}
+	if q.MinSize > 0 {
+		conds = append(conds, "size >= ?")
+		args = append(args, q.MinSize)
+	}
+	if q.MaxSize > 0 {
+		conds = append(conds, "size <= ?")
+		args = append(args, q.MaxSize)
+	}
+
+	query := `
+		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, checksum, object_key
+		FROM episodes
+		WHERE ` + strings.Join(conds, " AND ") + `
+		ORDER BY ` + orderClause(q)
+
+	if q.Limit > 0 || q.Offset > 0 {
+		limit := q.Limit
+		if limit <= 0 {
+			limit = -1 // no limit in SQLite
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, q.Offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query episodes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	episodes, err := s.scanEpisodes(rows)
+	if err != nil {
+		return nil, err
+	}
+	if episodes == nil {
+		episodes = []*podcast.Episode{}
+	}
+	return episodes, nil
+}
+
+// orderClause returns the ORDER BY expression for the query; ties are broken by filename.
+func orderClause(q storage.EpisodeQuery) string {
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	switch q.SortBy {
+	case storage.SortByPubDate:
+		return "pub_ts " + dir + ", filename " + dir
+	case storage.SortBySize:
+		return "size " + dir + ", filename " + dir
+	case storage.SortByTitle:
+		return "COALESCE(title, '') " + dir + ", filename " + dir
+	default:
+		return "filename " + dir
+	}
+}
+
+// ChangeStatusEpisodes changes the status of all episodes matching fromStatus to toStatus.
+func (s *Store) ChangeStatusEpisodes(podcastID string, fromStatus, toStatus podcast.Status) error {
+	_, err := s.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{Statuses: []podcast.Status{fromStatus}}, toStatus)
+	return err
+}
+
+// ChangeStatusEpisodesByFilter sets toStatus on all episodes matching the filter.
+// Returns the number of episodes changed.
+func (s *Store) ChangeStatusEpisodesByFilter(podcastID string, filter storage.EpisodeFilter, toStatus podcast.Status) (int, error) {
+	if s.db == nil {
+		return 0, storage.ErrClosed
+	}
+
+	where, args := filterClause(podcastID, filter)
+	query := `UPDATE episodes SET status = ? WHERE ` + where
+
+	res, err := s.db.Exec(query, append([]any{toStatus}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to change episodes status: %w", err)
+	}
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count changed episodes: %w", err)
+	}
+	return int(changed), nil
+}
+
+// SaveEpisodes persists several episodes in a single transaction.
+func (s *Store) SaveEpisodes(podcastID string, episodes []*podcast.Episode) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+	if len(episodes) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(upsertEpisodeQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, episode := range episodes {
+		_, err = stmt.Exec(
+			podcastID,
+			episode.Filename,
+			episode.PubDate,
+			episode.Size,
+			episode.Status,
+			episode.Location,
+			episode.Session,
+			episode.Title,
+			episode.Artist,
+			episode.Album,
+			episode.Year,
+			episode.Comment,
+			episode.Duration,
+			pubTimestamp(episode),
+			episode.Checksum,
+			episode.Key,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save episode %s: %w", episode.Filename, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit episodes: %w", err)
+	}
+	return nil
+}
+
+// DeleteEpisodes removes episode records by filename.
+// Returns the number of episodes removed; unknown filenames are ignored.
+func (s *Store) DeleteEpisodes(podcastID string, filenames []string) (int, error) {
+	if s.db == nil {
+		return 0, storage.ErrClosed
+	}
+	if len(filenames) == 0 {
+		return 0, nil
+	}
+
+	where, args := filterClause(podcastID, storage.EpisodeFilter{Filenames: filenames})
+	res, err := s.db.Exec(`DELETE FROM episodes WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete episodes: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted episodes: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// filterClause builds a WHERE clause (without the keyword) and its arguments for the filter.
+func filterClause(podcastID string, filter storage.EpisodeFilter) (string, []any) {
+	conds := []string{"podcast_id = ?"}
+	args := []any{podcastID}
+
+	if len(filter.Statuses) > 0 {
+		conds = append(conds, "status IN ("+placeholders(len(filter.Statuses))+")")
+		for _, st := range filter.Statuses {
+			args = append(args, st)
+		}
+	}
+	if filter.Session != "" {
+		conds = append(conds, "session = ?")
+		args = append(args, filter.Session)
+	}
+	if len(filter.Filenames) > 0 {
+		conds = append(conds, "filename IN ("+placeholders(len(filter.Filenames))+")")
+		for _, name := range filter.Filenames {
+			args = append(args, name)
+		}
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// placeholders returns n comma-separated SQL placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// GetPodcast retrieves the podcast record.
+func (s *Store) GetPodcast(podcastID string) (*podcast.Podcast, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	query := `
+		SELECT id, last_scan_at, last_publish_at, image_url, feed_url, feed_hash
+		FROM podcasts
+		WHERE id = ?
+	`
+
+	var p podcast.Podcast
+	var lastScanAt, lastPublishAt string
+	err := s.db.QueryRow(query, podcastID).Scan(&p.ID, &lastScanAt, &lastPublishAt, &p.ImageURL, &p.FeedURL, &p.FeedHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrPodcastNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get podcast: %w", err)
+	}
+
+	if p.LastScanAt, err = parseTime(lastScanAt); err != nil {
+		return nil, fmt.Errorf("invalid last_scan_at of podcast %s: %w", podcastID, err)
+	}
+	if p.LastPublishAt, err = parseTime(lastPublishAt); err != nil {
+		return nil, fmt.Errorf("invalid last_publish_at of podcast %s: %w", podcastID, err)
+	}
+	return &p, nil
+}
+
+// SavePodcast creates or replaces the podcast record.
+func (s *Store) SavePodcast(p *podcast.Podcast) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	query := `
+		INSERT INTO podcasts (id, last_scan_at, last_publish_at, image_url, feed_url, feed_hash)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			last_scan_at = excluded.last_scan_at,
+			last_publish_at = excluded.last_publish_at,
+			image_url = excluded.image_url,
+			feed_url = excluded.feed_url,
+			feed_hash = excluded.feed_hash
+	`
+
+	_, err := s.db.Exec(query, p.ID, formatTime(p.LastScanAt), formatTime(p.LastPublishAt), p.ImageURL, p.FeedURL, p.FeedHash)
+	if err != nil {
+		return fmt.Errorf("failed to save podcast: %w", err)
+	}
+	return nil
+}
+
+// AppendHistory records events of podcast episodes in the given order.
+func (s *Store) AppendHistory(podcastID string, events []storage.EpisodeEvent) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO episode_history (podcast_id, filename, at, operation, old_status, new_status, session, location, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, e := range events {
+		_, err := stmt.Exec(podcastID, e.Filename, e.At.UnixNano(), string(e.Operation),
+			e.OldStatus, e.NewStatus, e.Session, e.Location, e.Error)
+		if err != nil {
+			return fmt.Errorf("failed to append history of %s: %w", e.Filename, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListHistory returns recorded events of podcast matching the filter, oldest first.
+func (s *Store) ListHistory(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	conds := []string{"podcast_id = ?"}
+	args := []any{podcastID}
+	if filter.Filename != "" {
+		conds = append(conds, "filename = ?")
+		args = append(args, filter.Filename)
+	}
+	if !filter.Since.IsZero() {
+		conds = append(conds, "at >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	limit := -1
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	args = append(args, limit)
+
+	// select the latest entries, then return them in chronological order
+	query := `
+		SELECT filename, at, operation, old_status, new_status, session, location, error FROM (
+			SELECT id, filename, at, operation, old_status, new_status, session, location, error
+			FROM episode_history
+			WHERE ` + strings.Join(conds, " AND ") + `
+			ORDER BY id DESC
+			LIMIT ?
+		) ORDER BY id
+	`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	events := []storage.EpisodeEvent{}
+	for rows.Next() {
+		var e storage.EpisodeEvent
+		var at int64
+		var operation string
+		if err := rows.Scan(&e.Filename, &at, &operation, &e.OldStatus, &e.NewStatus, &e.Session, &e.Location, &e.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan history: %w", err)
+		}
+		e.At = time.Unix(0, at)
+		e.Operation = storage.Operation(operation)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// SaveSession creates or replaces the session record.
+func (s *Store) SaveSession(session *storage.Session) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	query := `
+		INSERT INTO sessions (id, started_at, finished_at, host, podcast_ids, operation, target, uploaded, deleted, failed, bytes, outcome, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			started_at = excluded.started_at,
+			finished_at = excluded.finished_at,
+			host = excluded.host,
+			podcast_ids = excluded.podcast_ids,
+			operation = excluded.operation,
+			target = excluded.target,
+			uploaded = excluded.uploaded,
+			deleted = excluded.deleted,
+			failed = excluded.failed,
+			bytes = excluded.bytes,
+			outcome = excluded.outcome,
+			error = excluded.error
+	`
+
+	var finishedAt any
+	if !session.FinishedAt.IsZero() {
+		finishedAt = session.FinishedAt.UnixNano()
+	}
+
+	_, err := s.db.Exec(query, session.ID, session.StartedAt.UnixNano(), finishedAt, session.Host,
+		strings.Join(session.PodcastIDs, ","), string(session.Operation), session.Target,
+		session.Uploaded, session.Deleted, session.Failed, session.Bytes, string(session.Outcome), session.Error)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// sessionColumns lists the columns read by scanSession.
+const sessionColumns = `id, started_at, finished_at, host, podcast_ids, operation, target, uploaded, deleted, failed, bytes, outcome, error`
+
+// GetSession retrieves a session by ID.
+func (s *Store) GetSession(id string) (*storage.Session, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	row := s.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// ListSessions returns recorded sessions, most recently started first.
+func (s *Store) ListSessions(limit int) ([]*storage.Session, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.Query(`SELECT `+sessionColumns+` FROM sessions ORDER BY started_at DESC, id LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	sessions := []*storage.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// scanSession reads a session from a row selected with sessionColumns.
+func scanSession(row interface{ Scan(dest ...any) error }) (*storage.Session, error) {
+	var session storage.Session
+	var startedAt int64
+	var finishedAt sql.NullInt64
+	var host, podcastIDs, operation, target, outcome, errText sql.NullString
+	err := row.Scan(&session.ID, &startedAt, &finishedAt, &host, &podcastIDs, &operation, &target,
+		&session.Uploaded, &session.Deleted, &session.Failed, &session.Bytes, &outcome, &errText)
+	if err != nil {
+		return nil, err
+	}
+
+	session.StartedAt = time.Unix(0, startedAt)
+	if finishedAt.Valid {
+		session.FinishedAt = time.Unix(0, finishedAt.Int64)
+	}
+	session.Host = host.String
+	if podcastIDs.String != "" {
+		session.PodcastIDs = strings.Split(podcastIDs.String, ",")
+	}
+	session.Operation = storage.Operation(operation.String)
+	session.Target = target.String
+	session.Outcome = storage.SessionOutcome(outcome.String)
+	session.Error = errText.String
+	return &session, nil
+}
+
+// SaveMultipartUpload creates or replaces the upload record.
+// Parts are stored as JSON, the record is replaced after every uploaded part.
+func (s *Store) SaveMultipartUpload(upload *storage.MultipartUpload) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	parts, err := json.Marshal(upload.Parts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parts of %s: %w", upload.Object, err)
+	}
+
+	query := `
+		INSERT INTO multipart_uploads (object, bucket, upload_id, file_path, size, mod_time, sha256, part_size, parts, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bucket, object) DO UPDATE SET
+			upload_id = excluded.upload_id,
+			file_path = excluded.file_path,
+			size = excluded.size,
+			mod_time = excluded.mod_time,
+			sha256 = excluded.sha256,
+			part_size = excluded.part_size,
+			parts = excluded.parts,
+			started_at = excluded.started_at,
+			updated_at = excluded.updated_at
+	`
+	_, err = s.db.Exec(query, upload.Object, upload.Bucket, upload.UploadID, upload.FilePath, upload.Size,
+		upload.ModTime.UnixNano(), upload.SHA256, upload.PartSize, string(parts),
+		upload.StartedAt.UnixNano(), upload.UpdatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to save multipart upload: %w", err)
+	}
+	return nil
+}
+
+// uploadColumns lists the columns read by scanUpload.
+const uploadColumns = `object, bucket, upload_id, file_path, size, mod_time, sha256, part_size, parts, started_at, updated_at`
+
+// GetMultipartUpload retrieves the upload of the object in the bucket.
+func (s *Store) GetMultipartUpload(bucket, object string) (*storage.MultipartUpload, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	row := s.db.QueryRow(`SELECT `+uploadColumns+` FROM multipart_uploads WHERE bucket = ? AND object = ?`, bucket, object)
+	upload, err := scanUpload(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multipart upload: %w", err)
+	}
+	return upload, nil
+}
+
+// DeleteMultipartUpload removes the upload record.
+func (s *Store) DeleteMultipartUpload(bucket, object string) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM multipart_uploads WHERE bucket = ? AND object = ?`, bucket, object); err != nil {
+		return fmt.Errorf("failed to delete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// ListMultipartUploads returns all recorded uploads, oldest first.
+func (s *Store) ListMultipartUploads() ([]*storage.MultipartUpload, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	rows, err := s.db.Query(`SELECT ` + uploadColumns + ` FROM multipart_uploads ORDER BY started_at, bucket, object`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list multipart uploads: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	uploads := []*storage.MultipartUpload{}
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan multipart upload: %w", err)
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
+
+// scanUpload reads an upload from a row selected with uploadColumns.
+func scanUpload(row interface{ Scan(dest ...any) error }) (*storage.MultipartUpload, error) {
+	var upload storage.MultipartUpload
+	var modTime, startedAt int64
+	var updatedAt sql.NullInt64
+	var bucket, filePath, sha, parts sql.NullString
+	err := row.Scan(&upload.Object, &bucket, &upload.UploadID, &filePath, &upload.Size, &modTime, &sha,
+		&upload.PartSize, &parts, &startedAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.Bucket = bucket.String
+	upload.FilePath = filePath.String
+	upload.ModTime = time.Unix(0, modTime)
+	upload.SHA256 = sha.String
+	upload.StartedAt = time.Unix(0, startedAt)
+	upload.UpdatedAt = time.Unix(0, updatedAt.Int64)
+	if parts.String != "" {
+		if err := json.Unmarshal([]byte(parts.String), &upload.Parts); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parts of %s: %w", upload.Object, err)
+		}
+	}
+	return &upload, nil
+}
+
+// formatTime stores times as RFC 3339 text, keeping the zero time as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime is the inverse of formatTime.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// ListPodcasts returns IDs of all podcasts having episodes or a podcast record.
 func (s *Store) ListPodcasts() ([]string, error) {
 	if s.db == nil {
 		return nil, storage.ErrClosed
 	}
 
-	query := `SELECT DISTINCT podcast_id FROM episodes ORDER BY podcast_id`
+	query := `SELECT podcast_id FROM episodes UNION SELECT id FROM podcasts ORDER BY 1`
 
 	rows, err := s.db.Query(query)
 	if err != nil {
@@ -361,7 +1247,7 @@ func (s *Store) ListEpisodes(podcastID string) ([]*podcast.Episode, error) {
 	}
 
 	query := `
-		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration
+		SELECT filename, pub_date, size, status, location, session, title, artist, album, year, comment, duration, checksum, object_key
 		FROM episodes
 		WHERE podcast_id = ?
 		ORDER BY filename
@@ -392,6 +1278,8 @@ func (s *Store) scanEpisode(row *sql.Row) (*podcast.Episode, error) {
 		&ep.Year,
 		&ep.Comment,
 		&ep.Duration,
+		&ep.Checksum,
+		&ep.Key,
 	)
 	if err != nil {
 		return nil, err
@@ -417,6 +1305,8 @@ func (s *Store) scanEpisodes(rows *sql.Rows) ([]*podcast.Episode, error) {
 			&ep.Year,
 			&ep.Comment,
 			&ep.Duration,
+			&ep.Checksum,
+			&ep.Key,
 		)
 		if err != nil {
 			log.Printf("[WARN] failed to scan episode: %v", err)
@@ -429,3 +1319,301 @@ func (s *Store) scanEpisodes(rows *sql.Rows) ([]*podcast.Episode, error) {
 
 // Verify Store implements storage.Store interface.
 var _ storage.Store = (*Store)(nil)
+
+// SaveReplica creates or replaces the replica of the object at its destination.
+func (s *Store) SaveReplica(replica *storage.Replica) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	query := `
+		INSERT INTO replicas (object, destination, status, location, checksum, error, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(object, destination) DO UPDATE SET
+			status = excluded.status,
+			location = excluded.location,
+			checksum = excluded.checksum,
+			error = excluded.error,
+			updated_at = excluded.updated_at
+	`
+	_, err := s.db.Exec(query, replica.Object, replica.Destination, string(replica.Status), replica.Location,
+		replica.Checksum, replica.Error, replica.UpdatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to save replica: %w", err)
+	}
+	return nil
+}
+
+// GetReplicas returns the replicas of the object, ordered by destination.
+func (s *Store) GetReplicas(object string) ([]*storage.Replica, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	rows, err := s.db.Query(`SELECT `+replicaColumns+` FROM replicas WHERE object = ? ORDER BY destination`, object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replicas: %w", err)
+	}
+	return scanReplicas(rows)
+}
+
+// ListReplicas returns the replicas of all objects, ordered by object and destination.
+func (s *Store) ListReplicas() ([]*storage.Replica, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	rows, err := s.db.Query(`SELECT ` + replicaColumns + ` FROM replicas ORDER BY object, destination`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicas: %w", err)
+	}
+	return scanReplicas(rows)
+}
+
+// replicaColumns lists the columns read by scanReplicas.
+const replicaColumns = `object, destination, status, location, checksum, error, updated_at`
+
+// scanReplicas reads rows of replicaColumns and closes them.
+func scanReplicas(rows *sql.Rows) ([]*storage.Replica, error) {
+	defer func() { _ = rows.Close() }()
+
+	replicas := []*storage.Replica{}
+	for rows.Next() {
+		var replica storage.Replica
+		var status string
+		var location, checksum, errText sql.NullString
+		var updatedAt sql.NullInt64
+		if err := rows.Scan(&replica.Object, &replica.Destination, &status, &location, &checksum, &errText, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan replica: %w", err)
+		}
+		replica.Status = storage.ReplicaStatus(status)
+		replica.Location = location.String
+		replica.Checksum = checksum.String
+		replica.Error = errText.String
+		replica.UpdatedAt = time.Unix(0, updatedAt.Int64)
+		replicas = append(replicas, &replica)
+	}
+	return replicas, rows.Err()
+}
+
+// SaveSubscriber creates or replaces the subscriber of the podcast with the same name.
+func (s *Store) SaveSubscriber(sub *storage.Subscriber) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	query := `
+		INSERT INTO subscribers (podcast_id, name, token, created_at, rotated_at, revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(podcast_id, name) DO UPDATE SET
+			token = excluded.token,
+			created_at = excluded.created_at,
+			rotated_at = excluded.rotated_at,
+			revoked_at = excluded.revoked_at
+	`
+	_, err := s.db.Exec(query, sub.PodcastID, sub.Name, sub.Token,
+		formatTime(sub.CreatedAt), formatTime(sub.RotatedAt), formatTime(sub.RevokedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save subscriber: %w", err)
+	}
+	return nil
+}
+
+// subscriberColumns lists the columns read by scanSubscriber.
+const subscriberColumns = `podcast_id, name, token, created_at, rotated_at, revoked_at`
+
+// GetSubscriber returns the subscriber of the podcast.
+func (s *Store) GetSubscriber(podcastID, name string) (*storage.Subscriber, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	row := s.db.QueryRow(`SELECT `+subscriberColumns+` FROM subscribers WHERE podcast_id = ? AND name = ?`, podcastID, name)
+	sub, err := scanSubscriber(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrSubscriberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriber: %w", err)
+	}
+	return sub, nil
+}
+
+// ListSubscribers returns the subscribers of the podcast ordered by name, revoked ones included.
+func (s *Store) ListSubscribers(podcastID string) ([]*storage.Subscriber, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	rows, err := s.db.Query(`SELECT `+subscriberColumns+` FROM subscribers WHERE podcast_id = ? ORDER BY name`, podcastID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	subs := []*storage.Subscriber{}
+	for rows.Next() {
+		sub, err := scanSubscriber(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscriber: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// scanSubscriber reads a row of subscriberColumns.
+func scanSubscriber(row interface{ Scan(dest ...any) error }) (*storage.Subscriber, error) {
+	var sub storage.Subscriber
+	var token, createdAt, rotatedAt, revokedAt sql.NullString
+	if err := row.Scan(&sub.PodcastID, &sub.Name, &token, &createdAt, &rotatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	sub.Token = token.String
+	var err error
+	if sub.CreatedAt, err = parseTime(createdAt.String); err != nil {
+		return nil, fmt.Errorf("invalid created_at of subscriber %s: %w", sub.Name, err)
+	}
+	if sub.RotatedAt, err = parseTime(rotatedAt.String); err != nil {
+		return nil, fmt.Errorf("invalid rotated_at of subscriber %s: %w", sub.Name, err)
+	}
+	if sub.RevokedAt, err = parseTime(revokedAt.String); err != nil {
+		return nil, fmt.Errorf("invalid revoked_at of subscriber %s: %w", sub.Name, err)
+	}
+	return &sub, nil
+}
+
+// SaveDestinationStatus creates or replaces the status of the destination.
+func (s *Store) SaveDestinationStatus(status *storage.DestinationStatus) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	query := `
+		INSERT INTO destinations (name, down, reason, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			down = excluded.down,
+			reason = excluded.reason,
+			updated_at = excluded.updated_at
+	`
+	if _, err := s.db.Exec(query, status.Name, status.Down, status.Reason, status.UpdatedAt.UnixNano()); err != nil {
+		return fmt.Errorf("failed to save destination status: %w", err)
+	}
+	return nil
+}
+
+// ListDestinationStatuses returns the recorded statuses of all destinations, ordered by name.
+func (s *Store) ListDestinationStatuses() ([]*storage.DestinationStatus, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+
+	rows, err := s.db.Query(`SELECT name, down, reason, updated_at FROM destinations ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination statuses: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	statuses := []*storage.DestinationStatus{}
+	for rows.Next() {
+		var status storage.DestinationStatus
+		var reason sql.NullString
+		var updatedAt sql.NullInt64
+		if err := rows.Scan(&status.Name, &status.Down, &reason, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan destination status: %w", err)
+		}
+		status.Reason = reason.String
+		status.UpdatedAt = time.Unix(0, updatedAt.Int64)
+		statuses = append(statuses, &status)
+	}
+	return statuses, rows.Err()
+}
+
+// SaveRun creates or replaces the run record.
+func (s *Store) SaveRun(run *storage.Run) error {
+	if s.db == nil {
+		return storage.ErrClosed
+	}
+
+	query := `
+		INSERT INTO runs (id, podcast_id, job, started_at, finished_at, outcome, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			podcast_id = excluded.podcast_id,
+			job = excluded.job,
+			started_at = excluded.started_at,
+			finished_at = excluded.finished_at,
+			outcome = excluded.outcome,
+			error = excluded.error
+	`
+
+	var finishedAt any
+	if !run.FinishedAt.IsZero() {
+		finishedAt = run.FinishedAt.UnixNano()
+	}
+
+	_, err := s.db.Exec(query, run.ID, run.PodcastID, run.Job, run.StartedAt.UnixNano(), finishedAt,
+		string(run.Outcome), run.Error)
+	if err != nil {
+		return fmt.Errorf("failed to save run: %w", err)
+	}
+	return nil
+}
+
+// runColumns lists the columns read by scanRun.
+const runColumns = `id, podcast_id, job, started_at, finished_at, outcome, error`
+
+// ListRuns returns recorded runs, most recently started first.
+func (s *Store) ListRuns(podcastID string, limit int) ([]*storage.Run, error) {
+	if s.db == nil {
+		return nil, storage.ErrClosed
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	query := `SELECT ` + runColumns + ` FROM runs`
+	var args []any
+	if podcastID != "" {
+		query += ` WHERE podcast_id = ?`
+		args = append(args, podcastID)
+	}
+	query += ` ORDER BY started_at DESC, id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	runs := []*storage.Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// scanRun reads a run from a row selected with runColumns.
+func scanRun(row interface{ Scan(dest ...any) error }) (*storage.Run, error) {
+	var run storage.Run
+	var startedAt int64
+	var finishedAt sql.NullInt64
+	var outcome, errText sql.NullString
+	if err := row.Scan(&run.ID, &run.PodcastID, &run.Job, &startedAt, &finishedAt, &outcome, &errText); err != nil {
+		return nil, err
+	}
+
+	run.StartedAt = time.Unix(0, startedAt)
+	if finishedAt.Valid {
+		run.FinishedAt = time.Unix(0, finishedAt.Int64)
+	}
+	run.Outcome = storage.SessionOutcome(outcome.String)
+	run.Error = errText.String
+	return &run, nil
+}
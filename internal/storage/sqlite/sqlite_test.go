@@ -1,10 +1,12 @@
 package sqlite_test
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"podgen/internal/app/podgen/podcast"
 	"podgen/internal/storage"
@@ -515,6 +517,278 @@ func TestGetLastEpisodeByNotStatusNoBucket(t *testing.T) {
 	}
 }
 
+func TestChangeStatusEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.New},
+		{Filename: "ep2.mp3", Status: podcast.New},
+		{Filename: "ep3.mp3", Status: podcast.Deleted},
+	}
+	if err := store.SaveEpisodes(podcastID, episodes); err != nil {
+		t.Fatalf("SaveEpisodes() failed: %v", err)
+	}
+
+	if err := store.ChangeStatusEpisodes(podcastID, podcast.New, podcast.Uploaded); err != nil {
+		t.Fatalf("ChangeStatusEpisodes() failed: %v", err)
+	}
+
+	uploaded, err := store.FindEpisodesByStatus(podcastID, podcast.Uploaded)
+	if err != nil {
+		t.Fatalf("FindEpisodesByStatus() failed: %v", err)
+	}
+	if len(uploaded) != 2 {
+		t.Errorf("FindEpisodesByStatus(Uploaded) count = %d, want 2", len(uploaded))
+	}
+}
+
+func TestChangeStatusEpisodesByFilter(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "s1"},
+		{Filename: "ep2.mp3", Status: podcast.Deleted, Session: "s1"},
+		{Filename: "ep3.mp3", Status: podcast.Uploaded, Session: "s2"},
+	}
+	if err := store.SaveEpisodes(podcastID, episodes); err != nil {
+		t.Fatalf("SaveEpisodes() failed: %v", err)
+	}
+
+	changed, err := store.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{Session: "s1"}, podcast.New)
+	if err != nil {
+		t.Fatalf("ChangeStatusEpisodesByFilter() failed: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("ChangeStatusEpisodesByFilter() changed = %d, want 2", changed)
+	}
+
+	changed, err = store.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{
+		Statuses:  []podcast.Status{podcast.Uploaded},
+		Filenames: []string{"ep1.mp3", "ep3.mp3"},
+	}, podcast.Deleted)
+	if err != nil {
+		t.Fatalf("ChangeStatusEpisodesByFilter() failed: %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("ChangeStatusEpisodesByFilter() changed = %d, want 1", changed)
+	}
+
+	changed, err = store.ChangeStatusEpisodesByFilter("nonexistent", storage.EpisodeFilter{}, podcast.New)
+	if err != nil {
+		t.Fatalf("ChangeStatusEpisodesByFilter() on missing podcast failed: %v", err)
+	}
+	if changed != 0 {
+		t.Errorf("ChangeStatusEpisodesByFilter() on missing podcast changed = %d, want 0", changed)
+	}
+}
+
+func TestSaveEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	if err := store.SaveEpisodes(podcastID, nil); err != nil {
+		t.Fatalf("SaveEpisodes(nil) failed: %v", err)
+	}
+
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.New, Size: 100, Title: "One"},
+		{Filename: "ep2.mp3", Status: podcast.Uploaded, Size: 200, Title: "Two"},
+	}
+	if err := store.SaveEpisodes(podcastID, episodes); err != nil {
+		t.Fatalf("SaveEpisodes() failed: %v", err)
+	}
+
+	// Saving again updates existing rows instead of duplicating them
+	episodes[0].Title = "One (edited)"
+	if err := store.SaveEpisodes(podcastID, episodes); err != nil {
+		t.Fatalf("SaveEpisodes() second call failed: %v", err)
+	}
+
+	list, err := store.ListEpisodes(podcastID)
+	if err != nil {
+		t.Fatalf("ListEpisodes() failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("ListEpisodes() count = %d, want 2", len(list))
+	}
+	if list[0].Title != "One (edited)" {
+		t.Errorf("episode title = %q, want %q", list[0].Title, "One (edited)")
+	}
+}
+
+func TestDeleteEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	if err := store.SaveEpisodes(podcastID, []*podcast.Episode{
+		{Filename: "ep1.mp3"},
+		{Filename: "ep2.mp3"},
+		{Filename: "ep3.mp3"},
+	}); err != nil {
+		t.Fatalf("SaveEpisodes() failed: %v", err)
+	}
+
+	deleted, err := store.DeleteEpisodes(podcastID, []string{"ep1.mp3", "ep3.mp3", "missing.mp3"})
+	if err != nil {
+		t.Fatalf("DeleteEpisodes() failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteEpisodes() deleted = %d, want 2", deleted)
+	}
+
+	list, err := store.ListEpisodes(podcastID)
+	if err != nil {
+		t.Fatalf("ListEpisodes() failed: %v", err)
+	}
+	if len(list) != 1 || list[0].Filename != "ep2.mp3" {
+		t.Errorf("ListEpisodes() after delete = %v, want only ep2.mp3", list)
+	}
+}
+
+func TestQueryEpisodes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	podcastID := "test-podcast"
+	episodes := []*podcast.Episode{
+		{Filename: "ep1.mp3", Status: podcast.Uploaded, Title: "First", Size: 100, PubDate: "Mon, 01 Jan 2024 10:00:00 +0000"},
+		{Filename: "ep2.mp3", Status: podcast.New, Title: "Second", Size: 200, PubDate: "Tue, 02 Jan 2024 10:00:00 +0000"},
+		{Filename: "ep3.mp3", Status: podcast.Uploaded, Title: "Third", Size: 300, PubDate: "invalid"},
+	}
+	if err := store.SaveEpisodes(podcastID, episodes); err != nil {
+		t.Fatalf("SaveEpisodes() failed: %v", err)
+	}
+
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	got, err := store.QueryEpisodes(podcastID, storage.EpisodeQuery{PublishedFrom: from})
+	if err != nil {
+		t.Fatalf("QueryEpisodes() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Filename != "ep2.mp3" {
+		t.Errorf("QueryEpisodes(from) = %v, want [ep2.mp3]", got)
+	}
+	if got[0].Title != "Second" || got[0].Size != 200 || got[0].Status != podcast.New {
+		t.Errorf("QueryEpisodes() returned incomplete episode: %+v", got[0])
+	}
+
+	got, err = store.QueryEpisodes(podcastID, storage.EpisodeQuery{SortBy: storage.SortByPubDate, Desc: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("QueryEpisodes() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Filename != "ep2.mp3" {
+		t.Errorf("QueryEpisodes(-date, limit 1) = %v, want [ep2.mp3]", got)
+	}
+
+	// Updating the publication date must update the sort key
+	episodes[0].PubDate = "Wed, 03 Jan 2024 10:00:00 +0000"
+	if err := store.SaveEpisode(podcastID, episodes[0]); err != nil {
+		t.Fatalf("SaveEpisode() failed: %v", err)
+	}
+	got, err = store.QueryEpisodes(podcastID, storage.EpisodeQuery{SortBy: storage.SortByPubDate, Desc: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("QueryEpisodes() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Filename != "ep1.mp3" {
+		t.Errorf("QueryEpisodes(-date, limit 1) after update = %v, want [ep1.mp3]", got)
+	}
+}
+
+func TestQueryEpisodesBackfillsPubTimestamp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Create a database with the schema used before pub_ts was introduced
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open() failed: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE episodes (
+			podcast_id TEXT NOT NULL, filename TEXT NOT NULL, pub_date TEXT, size INTEGER DEFAULT 0,
+			status INTEGER DEFAULT 0, location TEXT, session TEXT, title TEXT, artist TEXT,
+			album TEXT, year TEXT, comment TEXT, duration TEXT,
+			PRIMARY KEY (podcast_id, filename)
+		);
+		INSERT INTO episodes VALUES
+			('legacy', 'old.mp3', 'Mon, 01 Jan 2024 10:00:00 +0000', 0, 0, '', '', '', '', '', '', '', ''),
+			('legacy', 'new.mp3', 'Mon, 01 Jul 2024 10:00:00 +0000', 0, 0, '', '', '', '', '', '', '', ''),
+			('legacy', 'undated.mp3', '', 0, 0, '', '', '', '', '', '', '', '');
+	`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	store := sqlite.New(storage.Config{Type: storage.TypeSQLite, DSN: dbPath})
+	if err := store.Open(); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	got, err := store.QueryEpisodes("legacy", storage.EpisodeQuery{PublishedFrom: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("QueryEpisodes() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Filename != "new.mp3" {
+		t.Errorf("QueryEpisodes() after migration = %v, want [new.mp3]", got)
+	}
+}
+
+func TestMultipartUploadsRekeyedByBucket(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Create a database with multipart uploads keyed by object alone
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open() failed: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE multipart_uploads (
+			object TEXT PRIMARY KEY, bucket TEXT, upload_id TEXT NOT NULL, file_path TEXT, size INTEGER DEFAULT 0,
+			mod_time INTEGER, sha256 TEXT, part_size INTEGER DEFAULT 0, parts TEXT, started_at INTEGER NOT NULL,
+			updated_at INTEGER
+		);
+		INSERT INTO multipart_uploads VALUES ('news/ep1.mp3', 'podcasts', 'upload-1', '/data/news/ep1.mp3', 100, 0, '',
+			5242880, '[]', 1, 1);
+	`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	store := sqlite.New(storage.Config{Type: storage.TypeSQLite, DSN: dbPath})
+	if err := store.Open(); err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	got, err := store.GetMultipartUpload("podcasts", "news/ep1.mp3")
+	if err != nil {
+		t.Fatalf("GetMultipartUpload() of a legacy upload failed: %v", err)
+	}
+	if got.UploadID != "upload-1" {
+		t.Errorf("GetMultipartUpload() = %+v, want upload-1", got)
+	}
+
+	// the same object in another bucket is another upload
+	other := &storage.MultipartUpload{Bucket: "archive", Object: "news/ep1.mp3", UploadID: "upload-2", StartedAt: time.Now()}
+	if err := store.SaveMultipartUpload(other); err != nil {
+		t.Fatalf("SaveMultipartUpload() failed: %v", err)
+	}
+	uploads, err := store.ListMultipartUploads()
+	if err != nil {
+		t.Fatalf("ListMultipartUploads() failed: %v", err)
+	}
+	if len(uploads) != 2 {
+		t.Errorf("ListMultipartUploads() = %v, want uploads of both buckets", uploads)
+	}
+}
+
 func TestListPodcasts(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -553,6 +827,58 @@ func TestListPodcasts(t *testing.T) {
 	}
 }
 
+func TestSavePodcast(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if _, err := store.GetPodcast("podcast-a"); err != storage.ErrPodcastNotFound {
+		t.Errorf("GetPodcast() error = %v, want ErrPodcastNotFound", err)
+	}
+
+	record := &podcast.Podcast{
+		ID:            "podcast-a",
+		LastScanAt:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		LastPublishAt: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC),
+		ImageURL:      "https://cdn.example.com/podcast.png",
+		FeedURL:       "https://cdn.example.com/feed.rss",
+		FeedHash:      "abc",
+	}
+	if err := store.SavePodcast(record); err != nil {
+		t.Fatalf("SavePodcast() failed: %v", err)
+	}
+	if err := store.SaveEpisode("podcast-a", &podcast.Episode{Filename: "ep1.mp3"}); err != nil {
+		t.Fatalf("SaveEpisode() failed: %v", err)
+	}
+	if err := store.SavePodcast(&podcast.Podcast{ID: "podcast-b"}); err != nil {
+		t.Fatalf("SavePodcast() failed: %v", err)
+	}
+
+	got, err := store.GetPodcast("podcast-a")
+	if err != nil {
+		t.Fatalf("GetPodcast() failed: %v", err)
+	}
+	if !got.LastScanAt.Equal(record.LastScanAt) || !got.LastPublishAt.Equal(record.LastPublishAt) ||
+		got.ImageURL != record.ImageURL || got.FeedURL != record.FeedURL || got.FeedHash != record.FeedHash {
+		t.Errorf("GetPodcast() = %+v, want %+v", got, record)
+	}
+
+	empty, err := store.GetPodcast("podcast-b")
+	if err != nil {
+		t.Fatalf("GetPodcast() failed: %v", err)
+	}
+	if !empty.LastScanAt.IsZero() || !empty.LastPublishAt.IsZero() {
+		t.Errorf("GetPodcast() times = %v, %v, want zero", empty.LastScanAt, empty.LastPublishAt)
+	}
+
+	podcasts, err := store.ListPodcasts()
+	if err != nil {
+		t.Fatalf("ListPodcasts() failed: %v", err)
+	}
+	if len(podcasts) != 2 || podcasts[0] != "podcast-a" || podcasts[1] != "podcast-b" {
+		t.Errorf("ListPodcasts() = %v, want [podcast-a podcast-b]", podcasts)
+	}
+}
+
 func TestListEpisodes(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -636,6 +962,50 @@ func TestOperationsOnClosedStore(t *testing.T) {
 	if _, err := store.ListEpisodes(podcastID); err != storage.ErrClosed {
 		t.Errorf("ListEpisodes() error = %v, want ErrClosed", err)
 	}
+
+	if err := store.SaveEpisodes(podcastID, []*podcast.Episode{episode}); err != storage.ErrClosed {
+		t.Errorf("SaveEpisodes() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{}, podcast.New); err != storage.ErrClosed {
+		t.Errorf("ChangeStatusEpisodesByFilter() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.DeleteEpisodes(podcastID, []string{"file.mp3"}); err != storage.ErrClosed {
+		t.Errorf("DeleteEpisodes() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.QueryEpisodes(podcastID, storage.EpisodeQuery{}); err != storage.ErrClosed {
+		t.Errorf("QueryEpisodes() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.GetPodcast(podcastID); err != storage.ErrClosed {
+		t.Errorf("GetPodcast() error = %v, want ErrClosed", err)
+	}
+
+	if err := store.SavePodcast(&podcast.Podcast{ID: podcastID}); err != storage.ErrClosed {
+		t.Errorf("SavePodcast() error = %v, want ErrClosed", err)
+	}
+
+	if err := store.AppendHistory(podcastID, []storage.EpisodeEvent{{Filename: "ep1.mp3"}}); err != storage.ErrClosed {
+		t.Errorf("AppendHistory() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.ListHistory(podcastID, storage.HistoryFilter{}); err != storage.ErrClosed {
+		t.Errorf("ListHistory() error = %v, want ErrClosed", err)
+	}
+
+	if err := store.SaveSession(&storage.Session{ID: "s1"}); err != storage.ErrClosed {
+		t.Errorf("SaveSession() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.GetSession("s1"); err != storage.ErrClosed {
+		t.Errorf("GetSession() error = %v, want ErrClosed", err)
+	}
+
+	if _, err := store.ListSessions(0); err != storage.ErrClosed {
+		t.Errorf("ListSessions() error = %v, want ErrClosed", err)
+	}
 }
 
 func TestStoreImplementsInterface(t *testing.T) {
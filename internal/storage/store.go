@@ -3,6 +3,7 @@ package storage
 
 import (
 	"errors"
+	"slices"
 
 	"podgen/internal/app/podgen/podcast"
 )
@@ -43,10 +44,13 @@ func DefaultConfig(storagePath string) Config {
 
 // Common errors for storage operations.
 var (
-	ErrNoBucket      = errors.New("no bucket/table found")
-	ErrNotFound      = errors.New("episode not found")
-	ErrInvalidConfig = errors.New("invalid storage configuration")
-	ErrClosed        = errors.New("storage is closed")
+	ErrNoBucket        = errors.New("no bucket/table found")
+	ErrNotFound        = errors.New("episode not found")
+	ErrPodcastNotFound = errors.New("podcast not found")
+	ErrSessionNotFound = errors.New("session not found")
+	ErrUploadNotFound  = errors.New("multipart upload not found")
+	ErrInvalidConfig   = errors.New("invalid storage configuration")
+	ErrClosed          = errors.New("storage is closed")
 )
 
 // EpisodeStore defines the interface for episode persistence operations.
@@ -69,11 +73,73 @@ type EpisodeStore interface {
 
 	// GetLastEpisodeByNotStatus retrieves the last episode that doesn't have the given status.
 	GetLastEpisodeByNotStatus(podcastID string, status podcast.Status) (*podcast.Episode, error)
+
+	// QueryEpisodes retrieves episodes matching the query, sorted and paginated.
+	QueryEpisodes(podcastID string, q EpisodeQuery) ([]*podcast.Episode, error)
+
+	// ChangeStatusEpisodes changes the status of all episodes matching fromStatus to toStatus.
+	ChangeStatusEpisodes(podcastID string, fromStatus, toStatus podcast.Status) error
+
+	// ChangeStatusEpisodesByFilter sets toStatus on all episodes matching the filter.
+	// Returns the number of episodes changed.
+	ChangeStatusEpisodesByFilter(podcastID string, filter EpisodeFilter, toStatus podcast.Status) (int, error)
+
+	// SaveEpisodes persists several episodes in a single transaction.
+	SaveEpisodes(podcastID string, episodes []*podcast.Episode) error
+
+	// DeleteEpisodes removes episode records by filename.
+	// Returns the number of episodes removed; unknown filenames are ignored.
+	DeleteEpisodes(podcastID string, filenames []string) (int, error)
+}
+
+// PodcastStore defines the interface for podcast record persistence.
+type PodcastStore interface {
+	// GetPodcast retrieves the podcast record.
+	// Returns ErrPodcastNotFound if nothing was recorded for the podcast yet.
+	GetPodcast(podcastID string) (*podcast.Podcast, error)
+
+	// SavePodcast creates or replaces the podcast record.
+	SavePodcast(p *podcast.Podcast) error
+}
+
+// EpisodeFilter selects episodes for bulk operations.
+// Empty fields are not applied, so a zero filter matches every episode of a podcast.
+type EpisodeFilter struct {
+	// Statuses matches episodes having any of the listed statuses.
+	Statuses []podcast.Status
+	// Session matches episodes uploaded in the given session.
+	Session string
+	// Filenames matches episodes with any of the listed filenames.
+	Filenames []string
+}
+
+// Match reports whether the episode satisfies the filter.
+func (f EpisodeFilter) Match(episode *podcast.Episode) bool {
+	if episode == nil {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, episode.Status) {
+		return false
+	}
+	if f.Session != "" && episode.Session != f.Session {
+		return false
+	}
+	if len(f.Filenames) > 0 && !slices.Contains(f.Filenames, episode.Filename) {
+		return false
+	}
+	return true
 }
 
 // Store is the main storage interface that wraps EpisodeStore with lifecycle methods.
 type Store interface {
 	EpisodeStore
+	PodcastStore
+	HistoryStore
+	SessionStore
+	UploadStore
+	ReplicaStore
+	SubscriberStore
+	RunStore
 
 	// Open initializes the storage connection.
 	Open() error
@@ -81,7 +147,7 @@ type Store interface {
 	// Close releases all storage resources.
 	Close() error
 
-	// ListPodcasts returns all podcast IDs in the store.
+	// ListPodcasts returns IDs of all podcasts having episodes or a podcast record.
 	ListPodcasts() ([]string, error)
 
 	// ListEpisodes returns all episodes for a podcast.
@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"sort"
 	"testing"
 
 	"podgen/internal/app/podgen/podcast"
@@ -10,6 +11,14 @@ import (
 // MockStore is a test implementation of the Store interface.
 type MockStore struct {
 	episodes  map[string]map[string]*podcast.Episode // podcastID -> filename -> episode
+	records   map[string]*podcast.Podcast
+	history   map[string][]storage.EpisodeEvent
+	sessions  map[string]*storage.Session
+	uploads   map[string]*storage.MultipartUpload
+	replicas  map[string]map[string]*storage.Replica // object -> destination -> replica
+	statuses  map[string]*storage.DestinationStatus
+	subs      map[string]map[string]*storage.Subscriber // podcast -> name -> subscriber
+	runs      map[string]*storage.Run
 	podcasts  []string
 	openCalls int
 	closed    bool
@@ -18,6 +27,14 @@ type MockStore struct {
 func NewMockStore() *MockStore {
 	return &MockStore{
 		episodes: make(map[string]map[string]*podcast.Episode),
+		records:  make(map[string]*podcast.Podcast),
+		history:  make(map[string][]storage.EpisodeEvent),
+		sessions: make(map[string]*storage.Session),
+		uploads:  make(map[string]*storage.MultipartUpload),
+		replicas: make(map[string]map[string]*storage.Replica),
+		statuses: make(map[string]*storage.DestinationStatus),
+		subs:     make(map[string]map[string]*storage.Subscriber),
+		runs:     make(map[string]*storage.Run),
 		podcasts: []string{},
 	}
 }
@@ -155,6 +172,299 @@ func (m *MockStore) ListEpisodes(podcastID string) ([]*podcast.Episode, error) {
 	return result, nil
 }
 
+func (m *MockStore) ChangeStatusEpisodes(podcastID string, fromStatus, toStatus podcast.Status) error {
+	_, err := m.ChangeStatusEpisodesByFilter(podcastID, storage.EpisodeFilter{Statuses: []podcast.Status{fromStatus}}, toStatus)
+	return err
+}
+
+func (m *MockStore) ChangeStatusEpisodesByFilter(podcastID string, filter storage.EpisodeFilter, toStatus podcast.Status) (int, error) {
+	if m.closed {
+		return 0, storage.ErrClosed
+	}
+	var changed int
+	for _, ep := range m.episodes[podcastID] {
+		if filter.Match(ep) {
+			ep.Status = toStatus
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (m *MockStore) SaveEpisodes(podcastID string, episodes []*podcast.Episode) error {
+	for _, ep := range episodes {
+		if err := m.SaveEpisode(podcastID, ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockStore) DeleteEpisodes(podcastID string, filenames []string) (int, error) {
+	if m.closed {
+		return 0, storage.ErrClosed
+	}
+	var deleted int
+	for _, name := range filenames {
+		if _, ok := m.episodes[podcastID][name]; ok {
+			delete(m.episodes[podcastID], name)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *MockStore) QueryEpisodes(podcastID string, q storage.EpisodeQuery) ([]*podcast.Episode, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := []*podcast.Episode{}
+	for _, ep := range m.episodes[podcastID] {
+		if q.Match(ep) {
+			result = append(result, ep)
+		}
+	}
+	q.Sort(result)
+	return q.Page(result), nil
+}
+
+func (m *MockStore) GetPodcast(podcastID string) (*podcast.Podcast, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	record, ok := m.records[podcastID]
+	if !ok {
+		return nil, storage.ErrPodcastNotFound
+	}
+	return record, nil
+}
+
+func (m *MockStore) SavePodcast(p *podcast.Podcast) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	m.records[p.ID] = p
+	return nil
+}
+
+func (m *MockStore) AppendHistory(podcastID string, events []storage.EpisodeEvent) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	m.history[podcastID] = append(m.history[podcastID], events...)
+	return nil
+}
+
+func (m *MockStore) ListHistory(podcastID string, filter storage.HistoryFilter) ([]storage.EpisodeEvent, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := []storage.EpisodeEvent{}
+	for _, e := range m.history[podcastID] {
+		if filter.Match(&e) {
+			result = append(result, e)
+		}
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result, nil
+}
+
+func (m *MockStore) SaveSession(session *storage.Session) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	m.sessions[session.ID] = session
+	return nil
+}
+
+func (m *MockStore) GetSession(id string) (*storage.Session, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, storage.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (m *MockStore) ListSessions(limit int) ([]*storage.Session, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := make([]*storage.Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		result = append(result, session)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.After(result[j].StartedAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *MockStore) SaveMultipartUpload(upload *storage.MultipartUpload) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	m.uploads[upload.Bucket+"\x00"+upload.Object] = upload
+	return nil
+}
+
+func (m *MockStore) GetMultipartUpload(bucket, object string) (*storage.MultipartUpload, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	upload, ok := m.uploads[bucket+"\x00"+object]
+	if !ok {
+		return nil, storage.ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+func (m *MockStore) DeleteMultipartUpload(bucket, object string) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	delete(m.uploads, bucket+"\x00"+object)
+	return nil
+}
+
+func (m *MockStore) ListMultipartUploads() ([]*storage.MultipartUpload, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := make([]*storage.MultipartUpload, 0, len(m.uploads))
+	for _, upload := range m.uploads {
+		result = append(result, upload)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.Before(result[j].StartedAt) })
+	return result, nil
+}
+
+func (m *MockStore) SaveReplica(replica *storage.Replica) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	if m.replicas[replica.Object] == nil {
+		m.replicas[replica.Object] = make(map[string]*storage.Replica)
+	}
+	m.replicas[replica.Object][replica.Destination] = replica
+	return nil
+}
+
+func (m *MockStore) GetReplicas(object string) ([]*storage.Replica, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := make([]*storage.Replica, 0, len(m.replicas[object]))
+	for _, replica := range m.replicas[object] {
+		result = append(result, replica)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Destination < result[j].Destination })
+	return result, nil
+}
+
+func (m *MockStore) ListReplicas() ([]*storage.Replica, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	var result []*storage.Replica
+	for _, replicas := range m.replicas {
+		for _, replica := range replicas {
+			result = append(result, replica)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Object != result[j].Object {
+			return result[i].Object < result[j].Object
+		}
+		return result[i].Destination < result[j].Destination
+	})
+	return result, nil
+}
+
+func (m *MockStore) SaveDestinationStatus(status *storage.DestinationStatus) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	m.statuses[status.Name] = status
+	return nil
+}
+
+func (m *MockStore) ListDestinationStatuses() ([]*storage.DestinationStatus, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := make([]*storage.DestinationStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *MockStore) SaveSubscriber(sub *storage.Subscriber) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	if m.subs[sub.PodcastID] == nil {
+		m.subs[sub.PodcastID] = make(map[string]*storage.Subscriber)
+	}
+	m.subs[sub.PodcastID][sub.Name] = sub
+	return nil
+}
+
+func (m *MockStore) GetSubscriber(podcastID, name string) (*storage.Subscriber, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	sub, ok := m.subs[podcastID][name]
+	if !ok {
+		return nil, storage.ErrSubscriberNotFound
+	}
+	return sub, nil
+}
+
+func (m *MockStore) ListSubscribers(podcastID string) ([]*storage.Subscriber, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := make([]*storage.Subscriber, 0, len(m.subs[podcastID]))
+	for _, sub := range m.subs[podcastID] {
+		result = append(result, sub)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *MockStore) SaveRun(run *storage.Run) error {
+	if m.closed {
+		return storage.ErrClosed
+	}
+	m.runs[run.ID] = run
+	return nil
+}
+
+func (m *MockStore) ListRuns(podcastID string, limit int) ([]*storage.Run, error) {
+	if m.closed {
+		return nil, storage.ErrClosed
+	}
+	result := make([]*storage.Run, 0, len(m.runs))
+	for _, run := range m.runs {
+		if podcastID == "" || run.PodcastID == podcastID {
+			result = append(result, run)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.After(result[j].StartedAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
 // Compile-time check that MockStore implements Store interface.
 var _ storage.Store = (*MockStore)(nil)
 
@@ -397,3 +707,34 @@ func TestNoBucketErrors(t *testing.T) {
 		t.Errorf("FindEpisodesBySizeLimit() error = %v, want ErrNoBucket", err)
 	}
 }
+
+func TestEpisodeFilterMatch(t *testing.T) {
+	episode := &podcast.Episode{Filename: "ep1.mp3", Status: podcast.Uploaded, Session: "s1"}
+
+	tests := []struct {
+		name   string
+		filter storage.EpisodeFilter
+		want   bool
+	}{
+		{"empty filter matches", storage.EpisodeFilter{}, true},
+		{"status matches", storage.EpisodeFilter{Statuses: []podcast.Status{podcast.New, podcast.Uploaded}}, true},
+		{"status mismatch", storage.EpisodeFilter{Statuses: []podcast.Status{podcast.New}}, false},
+		{"session matches", storage.EpisodeFilter{Session: "s1"}, true},
+		{"session mismatch", storage.EpisodeFilter{Session: "s2"}, false},
+		{"filename matches", storage.EpisodeFilter{Filenames: []string{"ep0.mp3", "ep1.mp3"}}, true},
+		{"filename mismatch", storage.EpisodeFilter{Filenames: []string{"ep2.mp3"}}, false},
+		{"all fields match", storage.EpisodeFilter{Statuses: []podcast.Status{podcast.Uploaded}, Session: "s1", Filenames: []string{"ep1.mp3"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(episode); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (storage.EpisodeFilter{}).Match(nil) {
+		t.Error("Match(nil) = true, want false")
+	}
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSubscriberNotFound is returned for subscribers never added to the podcast.
+var ErrSubscriberNotFound = errors.New("subscriber not found")
+
+// Subscriber is a subscriber of a private podcast, with a feed of their own.
+type Subscriber struct {
+	// PodcastID and Name identify the subscriber.
+	PodcastID string
+	Name      string
+	// Token is the secret of the subscriber, the name of their feed and the token of token URLs are derived from it.
+	// It is cleared when the subscriber is revoked.
+	Token     string
+	CreatedAt time.Time
+	// RotatedAt is when the token was last replaced, zero if never.
+	RotatedAt time.Time
+	// RevokedAt is when the subscriber was revoked, zero while active.
+	RevokedAt time.Time
+}
+
+// Active returns true if the subscriber is not revoked.
+func (s *Subscriber) Active() bool {
+	return s.RevokedAt.IsZero()
+}
+
+// SubscriberStore defines the interface for persistence of subscribers of private podcasts.
+type SubscriberStore interface {
+	// SaveSubscriber creates or replaces the subscriber of the podcast with the same name.
+	SaveSubscriber(sub *Subscriber) error
+
+	// GetSubscriber returns the subscriber of the podcast, ErrSubscriberNotFound if there is none.
+	GetSubscriber(podcastID, name string) (*Subscriber, error)
+
+	// ListSubscribers returns the subscribers of the podcast ordered by name, revoked ones included.
+	ListSubscribers(podcastID string) ([]*Subscriber, error)
+}
@@ -0,0 +1,47 @@
+package storage
+
+import "time"
+
+// MultipartUpload is an incomplete multipart upload of a local file to object storage.
+// It is recorded while parts are uploaded, so an interrupted upload resumes on the next run.
+type MultipartUpload struct {
+	// Bucket and Object identify the upload, an object name is unique per bucket.
+	Bucket   string
+	Object   string
+	UploadID string
+	// FilePath, Size, ModTime and SHA256 describe the uploaded file.
+	// An upload is resumed only if the file is still the same.
+	FilePath string
+	Size     int64
+	ModTime  time.Time
+	SHA256   string
+	// PartSize is the size of every part but the last.
+	PartSize int64
+	// Parts are the parts uploaded so far.
+	Parts     []UploadPart
+	StartedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UploadPart is an uploaded part of a multipart upload.
+type UploadPart struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// UploadStore defines the interface for multipart upload persistence.
+type UploadStore interface {
+	// SaveMultipartUpload creates or replaces the upload record.
+	SaveMultipartUpload(upload *MultipartUpload) error
+
+	// GetMultipartUpload retrieves the upload of the object in the bucket.
+	// Returns ErrUploadNotFound if there is none.
+	GetMultipartUpload(bucket, object string) (*MultipartUpload, error)
+
+	// DeleteMultipartUpload removes the upload record, unknown objects are ignored.
+	DeleteMultipartUpload(bucket, object string) error
+
+	// ListMultipartUploads returns all recorded uploads, oldest first.
+	ListMultipartUploads() ([]*MultipartUpload, error)
+}